@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail/smtp"
+	"github.com/github.com/VadimOcLock/vauth/pkg/pg"
+	"github.com/matchsystems/werr"
+)
+
+var errNoSMTP = errors.New("smtp.host is not configured")
+
+// newClient connects to Postgres and returns a client for the commands with
+// a function releasing its connections.
+func newClient(ctx context.Context, cfg Config) (*authclient.Client, func(), error) {
+	pool, err := pg.New(ctx, pg.Config{DSN: cfg.Database.DSN})
+	if err != nil {
+		return nil, nil, werr.Wrap(err)
+	}
+	closers := []func(){pool.Close}
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	// Without SMTP, only the commands that email the user fail.
+	var sender authclient.EmailSender = authclient.EmailSenderHook(func(context.Context, string, string) error {
+		return errNoSMTP
+	})
+	if cfg.SMTP.Host != "" {
+		transport, err := smtp.New(smtp.Config{
+			Host:        cfg.SMTP.Host,
+			Port:        cfg.SMTP.Port,
+			Username:    cfg.SMTP.Username,
+			Password:    cfg.SMTP.Password,
+			Auth:        smtpAuth(cfg.SMTP),
+			Security:    cfg.SMTP.Security,
+			TLSConfig:   nil,
+			From:        cfg.SMTP.From,
+			LocalName:   "",
+			Timeout:     0,
+			IdleTimeout: 0,
+		})
+		if err != nil {
+			closeAll()
+
+			return nil, nil, werr.Wrap(err)
+		}
+		closers = append(closers, func() { _ = transport.Close() })
+		renderer, err := mail.NewRenderer()
+		if err != nil {
+			closeAll()
+
+			return nil, nil, werr.Wrap(err)
+		}
+		sender = mail.NewSender(renderer, transport)
+	}
+
+	// vauthctl never issues tokens, but the client needs a signing key.
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	client, err := authclient.New(authclient.Config{
+		PgClient:         pool,
+		JWTConfig:        jwtgen.CreatorConfig{SecretKey: secret},
+		EmailSender:      sender,
+		EmailLinkBuilder: linkBuilder(cfg.Email.LinkBaseURL),
+	}, authclient.WithLockout(authclient.LockoutConfig{}))
+	if err != nil {
+		closeAll()
+
+		return nil, nil, werr.Wrap(err)
+	}
+
+	return client, closeAll, nil
+}
+
+func linkBuilder(baseURL string) authclient.LinkBuilder {
+	if baseURL == "" {
+		return nil
+	}
+	base := strings.TrimSuffix(baseURL, "/")
+
+	return func(purpose authclient.EmailPurpose, code string) string {
+		return base + "/" + string(purpose) + "?code=" + code
+	}
+}
+
+func smtpAuth(cfg SMTPConfig) smtp.AuthMechanism {
+	if cfg.Username == "" {
+		return smtp.AuthNone
+	}
+
+	return smtp.AuthPlain
+}
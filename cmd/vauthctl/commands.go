@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+// indefiniteLock is how long lock locks without -for: until unlocked, in practice.
+const indefiniteLock = 100 * 365 * 24 * time.Hour
+
+var errUsage = errors.New("usage")
+
+// admin is the part of *authclient.Client used by the commands.
+type admin interface {
+	CreateUser(ctx context.Context, dto authclient.CreateUserParams) (entity.User, error)
+	ListUsers(ctx context.Context, dto authclient.ListUsersParams) ([]entity.User, error)
+	FindUser(ctx context.Context, idOrEmail string) (entity.User, error)
+	MarkUserVerified(ctx context.Context, userID uuid.UUID) error
+	ForcePasswordReset(ctx context.Context, userID uuid.UUID) error
+	LockAccount(ctx context.Context, userID uuid.UUID, until time.Time) error
+	UnlockAccount(ctx context.Context, userID uuid.UUID) error
+	RevokeAllTokens(ctx context.Context, userID uuid.UUID) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type env struct {
+	admin  admin
+	in     io.Reader
+	out    printer
+	stderr io.Writer
+}
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, env env, flags *flag.FlagSet) error
+	// flags declares the command flags; run reads them back by name.
+	flags func(flags *flag.FlagSet)
+}
+
+var commands = []command{
+	{
+		name:    "create",
+		args:    "<email>",
+		summary: "create a user, passwordless unless -password-stdin",
+		flags: func(flags *flag.FlagSet) {
+			flags.Bool("password-stdin", false, "read the password from the first line of stdin")
+			flags.Bool("verified", false, "mark the email as verified")
+		},
+		run: createUser,
+	},
+	{
+		name:    "list",
+		summary: "list users",
+		flags: func(flags *flag.FlagSet) {
+			flags.String("search", "", "only list emails containing this text")
+			flags.Int("limit", 50, "maximum number of users, up to 1000")
+			flags.Int("offset", 0, "number of users to skip")
+		},
+		run: listUsers,
+	},
+	{name: "find", args: "<id|email>", summary: "show a user", flags: nil, run: findUser},
+	{name: "verify", args: "<id|email>", summary: "mark a user's email as verified", flags: nil, run: verifyUser},
+	{
+		name:    "reset-password",
+		args:    "<id|email>",
+		summary: "clear a user's password, revoke their sessions and email a reset code",
+		flags:   nil,
+		run:     userAction("password reset", admin.ForcePasswordReset),
+	},
+	{
+		name:    "lock",
+		args:    "<id|email>",
+		summary: "lock a user out of logging in",
+		flags: func(flags *flag.FlagSet) {
+			flags.Duration("for", 0, "lock duration; without it the lock lasts until unlock")
+		},
+		run: lockUser,
+	},
+	{
+		name:    "unlock",
+		args:    "<id|email>",
+		summary: "lift a user's lock and reset their failed logins",
+		flags:   nil,
+		run:     userAction("unlocked", admin.UnlockAccount),
+	},
+	{
+		name:    "revoke",
+		args:    "<id|email>",
+		summary: "revoke all of a user's sessions",
+		flags:   nil,
+		run:     userAction("sessions revoked", admin.RevokeAllTokens),
+	},
+	{
+		name:    "delete",
+		args:    "<id|email>",
+		summary: "delete a user with their tokens and factors",
+		flags:   nil,
+		run:     userAction("deleted", admin.DeleteUser),
+	},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// runCommand runs args[0] with the rest of args as its flags and arguments.
+func runCommand(ctx context.Context, env env, args []string) error {
+	if len(args) == 0 {
+		return werr.Wrapf(errUsage, "missing command")
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		return werr.Wrapf(errUsage, "unknown command %q", args[0])
+	}
+
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: vauthctl %s [flags] %s\n", cmd.name, cmd.args)
+		flags.PrintDefaults()
+	}
+	if cmd.flags != nil {
+		cmd.flags(flags)
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return werr.Wrapf(errUsage, "%s: %s", cmd.name, err)
+	}
+	if want := len(strings.Fields(cmd.args)); flags.NArg() != want {
+		flags.Usage()
+
+		return werr.Wrapf(errUsage, "%s takes %d argument(s)", cmd.name, want)
+	}
+
+	return werr.Wrap(cmd.run(ctx, env, flags))
+}
+
+func createUser(ctx context.Context, env env, flags *flag.FlagSet) error {
+	var password string
+	if flagValue[bool](flags, "password-stdin") {
+		line, err := bufio.NewReader(env.in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return werr.Wrap(err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	user, err := env.admin.CreateUser(ctx, authclient.CreateUserParams{
+		Email:    flags.Arg(0),
+		Password: password,
+		Verified: flagValue[bool](flags, "verified"),
+	})
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(env.out.user(user))
+}
+
+func listUsers(ctx context.Context, env env, flags *flag.FlagSet) error {
+	users, err := env.admin.ListUsers(ctx, authclient.ListUsersParams{
+		Search: flagValue[string](flags, "search"),
+		Limit:  int32(min(flagValue[int](flags, "limit"), math.MaxInt32)),
+		Offset: int32(min(flagValue[int](flags, "offset"), math.MaxInt32)),
+	})
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(env.out.users(users))
+}
+
+func findUser(ctx context.Context, env env, flags *flag.FlagSet) error {
+	user, err := env.admin.FindUser(ctx, flags.Arg(0))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(env.out.user(user))
+}
+
+func verifyUser(ctx context.Context, env env, flags *flag.FlagSet) error {
+	user, err := env.admin.FindUser(ctx, flags.Arg(0))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if err = env.admin.MarkUserVerified(ctx, user.ID); err != nil {
+		return werr.Wrap(err)
+	}
+	user.IsVerified = true
+
+	return werr.Wrap(env.out.user(user))
+}
+
+func lockUser(ctx context.Context, env env, flags *flag.FlagSet) error {
+	user, err := env.admin.FindUser(ctx, flags.Arg(0))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	duration := flagValue[time.Duration](flags, "for")
+	if duration <= 0 {
+		duration = indefiniteLock
+	}
+	until := time.Now().Add(duration).UTC().Truncate(time.Second)
+	if err = env.admin.LockAccount(ctx, user.ID, until); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(env.out.result(newResult(user, "locked", &until)))
+}
+
+// userAction makes a command that finds the user and applies action, a
+// method of admin, to them.
+func userAction(
+	done string,
+	action func(admin, context.Context, uuid.UUID) error,
+) func(ctx context.Context, env env, flags *flag.FlagSet) error {
+	return func(ctx context.Context, env env, flags *flag.FlagSet) error {
+		user, err := env.admin.FindUser(ctx, flags.Arg(0))
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		if err = action(env.admin, ctx, user.ID); err != nil {
+			return werr.Wrap(err)
+		}
+
+		return werr.Wrap(env.out.result(newResult(user, done, nil)))
+	}
+}
+
+// flagValue returns the value of a flag declared by command.flags.
+func flagValue[T any](flags *flag.FlagSet, name string) T {
+	value, _ := flags.Lookup(name).Value.(flag.Getter).Get().(T)
+
+	return value
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdmin keeps users in memory and records the calls of the actions.
+type fakeAdmin struct {
+	users   []entity.User
+	created authclient.CreateUserParams
+	calls   []string
+	until   time.Time
+}
+
+func (f *fakeAdmin) CreateUser(_ context.Context, dto authclient.CreateUserParams) (entity.User, error) {
+	f.created = dto
+	user := entity.User{ID: uuid.New(), Email: dto.Email, IsVerified: dto.Verified}
+	f.users = append(f.users, user)
+
+	return user, nil
+}
+
+func (f *fakeAdmin) ListUsers(_ context.Context, dto authclient.ListUsersParams) ([]entity.User, error) {
+	var users []entity.User
+	for _, user := range f.users {
+		if strings.Contains(user.Email, dto.Search) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (f *fakeAdmin) FindUser(_ context.Context, idOrEmail string) (entity.User, error) {
+	for _, user := range f.users {
+		if user.Email == idOrEmail || user.ID.String() == idOrEmail {
+			return user, nil
+		}
+	}
+
+	return entity.User{}, errorz.ErrUserNotFound
+}
+
+func (f *fakeAdmin) record(name string, userID uuid.UUID) error {
+	f.calls = append(f.calls, name+" "+userID.String())
+
+	return nil
+}
+
+func (f *fakeAdmin) MarkUserVerified(_ context.Context, userID uuid.UUID) error {
+	return f.record("verify", userID)
+}
+
+func (f *fakeAdmin) ForcePasswordReset(_ context.Context, userID uuid.UUID) error {
+	return f.record("reset", userID)
+}
+
+func (f *fakeAdmin) LockAccount(_ context.Context, userID uuid.UUID, until time.Time) error {
+	f.until = until
+
+	return f.record("lock", userID)
+}
+
+func (f *fakeAdmin) UnlockAccount(_ context.Context, userID uuid.UUID) error {
+	return f.record("unlock", userID)
+}
+
+func (f *fakeAdmin) RevokeAllTokens(_ context.Context, userID uuid.UUID) error {
+	return f.record("revoke", userID)
+}
+
+func (f *fakeAdmin) DeleteUser(_ context.Context, userID uuid.UUID) error {
+	return f.record("delete", userID)
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	alice := entity.User{
+		ID:         uuid.New(),
+		Email:      "alice@example.com",
+		IsVerified: true,
+		CreatedAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	bob := entity.User{
+		ID:                 uuid.New(),
+		Email:              "bob@example.org",
+		PreferredMFAFactor: entity.MFAFactorType("totp"),
+	}
+	run := func(t *testing.T, jsonOutput bool, stdin string, args ...string) (*fakeAdmin, string, error) {
+		t.Helper()
+
+		admin := &fakeAdmin{users: []entity.User{alice, bob}}
+		var out, stderr bytes.Buffer
+		err := runCommand(ctx, env{
+			admin:  admin,
+			in:     strings.NewReader(stdin),
+			out:    printer{w: &out, json: jsonOutput},
+			stderr: &stderr,
+		}, args)
+
+		return admin, out.String(), err
+	}
+
+	t.Run("list prints a table", func(t *testing.T) {
+		t.Parallel()
+
+		_, out, err := run(t, false, "", "list")
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 3)
+		assert.Regexp(t, `^ID\s+EMAIL\s+VERIFIED\s+MFA\s+CREATED$`, lines[0])
+		assert.Contains(t, lines[1], "alice@example.com")
+		assert.Contains(t, lines[1], "2024-05-01 12:00:00")
+		assert.Regexp(t, `bob@example\.org\s+false\s+totp`, lines[2])
+	})
+
+	t.Run("list prints JSON", func(t *testing.T) {
+		t.Parallel()
+
+		_, out, err := run(t, true, "", "list", "-search", "nobody")
+
+		require.NoError(t, err)
+		assert.JSONEq(t, "[]", out)
+	})
+
+	t.Run("create reads the password from stdin", func(t *testing.T) {
+		t.Parallel()
+
+		admin, out, err := run(t, true, "s3cret-pass\n", "create", "-password-stdin", "-verified", "carol@example.com")
+
+		require.NoError(t, err)
+		assert.Equal(t, authclient.CreateUserParams{
+			Email:    "carol@example.com",
+			Password: "s3cret-pass",
+			Verified: true,
+		}, admin.created)
+		var view userView
+		require.NoError(t, json.Unmarshal([]byte(out), &view))
+		assert.Equal(t, "carol@example.com", view.Email)
+		assert.True(t, view.Verified)
+	})
+
+	t.Run("actions resolve the user by email or id", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			command string
+			call    string
+			output  string
+		}{
+			{"reset-password", "reset", "password reset: bob@example.org"},
+			{"unlock", "unlock", "unlocked: bob@example.org"},
+			{"revoke", "revoke", "sessions revoked: bob@example.org"},
+			{"delete", "delete", "deleted: bob@example.org"},
+		} {
+			admin, out, err := run(t, false, "", tc.command, bob.ID.String())
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.call + " " + bob.ID.String()}, admin.calls)
+			assert.True(t, strings.HasPrefix(out, tc.output), out)
+		}
+
+		admin, _, err := run(t, false, "", "verify", "bob@example.org")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"verify " + bob.ID.String()}, admin.calls)
+	})
+
+	t.Run("lock defaults to indefinitely", func(t *testing.T) {
+		t.Parallel()
+
+		admin, _, err := run(t, false, "", "lock", "alice@example.com")
+		require.NoError(t, err)
+		assert.Greater(t, time.Until(admin.until), 50*365*24*time.Hour)
+
+		admin, out, err := run(t, true, "", "lock", "-for", "2h", "alice@example.com")
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(2*time.Hour), admin.until, time.Minute)
+		var res result
+		require.NoError(t, json.Unmarshal([]byte(out), &res))
+		assert.Equal(t, "locked", res.Action)
+		require.NotNil(t, res.Until)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		admin, _, err := run(t, false, "", "delete", "nobody@example.com")
+
+		require.ErrorIs(t, err, errorz.ErrUserNotFound)
+		assert.Empty(t, admin.calls)
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		t.Parallel()
+
+		for _, args := range [][]string{
+			{},
+			{"frobnicate"},
+			{"find"},
+			{"find", "a", "b"},
+			{"list", "-limit", "many"},
+		} {
+			_, _, err := run(t, false, "", args...)
+			require.ErrorIs(t, err, errUsage, args)
+		}
+	})
+}
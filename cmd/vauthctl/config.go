@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail/smtp"
+	"github.com/matchsystems/werr"
+	"gopkg.in/yaml.v3"
+)
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+// Config is the part of the vauthd configuration vauthctl needs; other keys
+// of the file are ignored.
+type Config struct {
+	Database DatabaseConfig `yaml:"database"`
+	// SMTP is only needed by reset-password.
+	SMTP  SMTPConfig  `yaml:"smtp"`
+	Email EmailConfig `yaml:"email"`
+}
+
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn"`
+}
+
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	From     string        `yaml:"from"`
+	Security smtp.Security `yaml:"security"`
+}
+
+type EmailConfig struct {
+	LinkBaseURL string `yaml:"link_base_url"`
+}
+
+func loadConfig(path string, lookupEnv func(string) (string, bool)) (Config, error) {
+	var cfg Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, werr.Wrap(err)
+		}
+		if err = yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, werr.Wrap(err)
+		}
+	}
+
+	vars := []struct {
+		name string
+		dst  *string
+	}{
+		{"VAUTH_DATABASE_DSN", &cfg.Database.DSN},
+		{"VAUTH_SMTP_HOST", &cfg.SMTP.Host},
+		{"VAUTH_SMTP_USERNAME", &cfg.SMTP.Username},
+		{"VAUTH_SMTP_PASSWORD", &cfg.SMTP.Password},
+		{"VAUTH_SMTP_FROM", &cfg.SMTP.From},
+		{"VAUTH_SMTP_SECURITY", (*string)(&cfg.SMTP.Security)},
+		{"VAUTH_EMAIL_LINK_BASE_URL", &cfg.Email.LinkBaseURL},
+	}
+	for _, v := range vars {
+		if value, ok := lookupEnv(v.name); ok {
+			*v.dst = value
+		}
+	}
+	if value, ok := lookupEnv("VAUTH_SMTP_PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return Config{}, werr.Wrapf(errInvalidConfig, "VAUTH_SMTP_PORT: %s", err)
+		}
+		cfg.SMTP.Port = port
+	}
+
+	return cfg, nil
+}
+
+func (cfg Config) Validate() error {
+	var errs []error
+	if cfg.Database.DSN == "" {
+		errs = append(errs, fmt.Errorf("%w: database.dsn is required", errInvalidConfig))
+	}
+	if cfg.SMTP.Host != "" && cfg.SMTP.From == "" {
+		errs = append(errs, fmt.Errorf("%w: smtp.from is required with smtp.host", errInvalidConfig))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("vauthd file is read", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := loadConfig("../vauthd/vauthd.example.yaml", func(string) (string, bool) { return "", false })
+
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.NotEmpty(t, cfg.Database.DSN)
+		assert.NotEmpty(t, cfg.SMTP.Host)
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		t.Parallel()
+
+		env := map[string]string{
+			"VAUTH_DATABASE_DSN": "postgres://localhost/other",
+			"VAUTH_SMTP_PORT":    "2525",
+		}
+		cfg, err := loadConfig("../vauthd/vauthd.example.yaml", func(name string) (string, bool) {
+			value, ok := env[name]
+
+			return value, ok
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://localhost/other", cfg.Database.DSN)
+		assert.Equal(t, 2525, cfg.SMTP.Port)
+	})
+
+	t.Run("missing dsn", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := loadConfig("", func(string) (string, bool) { return "", false })
+
+		require.NoError(t, err)
+		require.ErrorIs(t, cfg.Validate(), errInvalidConfig)
+	})
+}
@@ -0,0 +1,78 @@
+// Command vauthctl manages vauth users directly in the Postgres database.
+//
+// It reads database.dsn, and smtp and email for reset-password, from the
+// vauthd configuration file given by -config or VAUTH_CONFIG, then applies
+// the same VAUTH_* environment variables as vauthd.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	os.Exit(vauthctl(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func vauthctl(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("vauthctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", os.Getenv("VAUTH_CONFIG"), "path to the vauthd YAML configuration file")
+	jsonOutput := flags.Bool("json", false, "print JSON instead of tables")
+	flags.Usage = func() { printUsage(stderr, flags) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if _, ok := findCommand(flags.Arg(0)); !ok {
+		flags.Usage()
+
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, os.LookupEnv)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "vauthctl: %s\n", err)
+
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	client, closeClient, err := newClient(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "vauthctl: %s\n", err)
+
+		return 1
+	}
+	defer closeClient()
+
+	env := env{admin: client, in: stdin, out: printer{w: stdout, json: *jsonOutput}, stderr: stderr}
+	if err = runCommand(ctx, env, flags.Args()); err != nil {
+		fmt.Fprintf(stderr, "vauthctl: %s\n", err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+func printUsage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "usage: vauthctl [flags] <command> [command flags] [args]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nflags:\n")
+	flags.PrintDefaults()
+}
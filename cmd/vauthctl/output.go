@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+const timeLayout = "2006-01-02 15:04:05"
+
+// printer writes users and results as aligned tables or, with json, as one
+// JSON document per command.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+type userView struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Verified  bool      `json:"verified"`
+	MFAFactor string    `json:"mfa_factor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newUserView(user entity.User) userView {
+	return userView{
+		ID:        user.ID,
+		Email:     user.Email,
+		Verified:  user.IsVerified,
+		MFAFactor: string(user.PreferredMFAFactor),
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+type result struct {
+	ID     uuid.UUID  `json:"id"`
+	Email  string     `json:"email"`
+	Action string     `json:"action"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+func newResult(user entity.User, action string, until *time.Time) result {
+	return result{ID: user.ID, Email: user.Email, Action: action, Until: until}
+}
+
+func (p printer) user(user entity.User) error {
+	if p.json {
+		return p.encode(newUserView(user))
+	}
+
+	return p.table([]entity.User{user})
+}
+
+func (p printer) users(users []entity.User) error {
+	if p.json {
+		views := make([]userView, 0, len(users))
+		for _, user := range users {
+			views = append(views, newUserView(user))
+		}
+
+		return p.encode(views)
+	}
+
+	return p.table(users)
+}
+
+func (p printer) result(res result) error {
+	if p.json {
+		return p.encode(res)
+	}
+
+	line := fmt.Sprintf("%s: %s (%s)", res.Action, res.Email, res.ID)
+	if res.Until != nil {
+		line += " until " + res.Until.Format(timeLayout)
+	}
+	_, err := fmt.Fprintln(p.w, line)
+
+	return werr.Wrap(err)
+}
+
+func (p printer) table(users []entity.User) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMAIL\tVERIFIED\tMFA\tCREATED")
+	for _, user := range users {
+		mfa := string(user.PreferredMFAFactor)
+		if mfa == "" {
+			mfa = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n",
+			user.ID, user.Email, user.IsVerified, mfa, user.CreatedAt.Format(timeLayout))
+	}
+
+	return werr.Wrap(tw.Flush())
+}
+
+func (p printer) encode(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+
+	return werr.Wrap(enc.Encode(v))
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail/smtp"
+	"github.com/github.com/VadimOcLock/vauth/pkg/secretbox"
+	"github.com/matchsystems/werr"
+	"gopkg.in/yaml.v3"
+)
+
+const minSecretLength = 32
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+type Config struct {
+	Listen          string        `yaml:"listen"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ClientIPHeader, such as X-Forwarded-For, is trusted for the client IP
+	// when vauthd runs behind a proxy.
+	ClientIPHeader string           `yaml:"client_ip_header"`
+	TLS            TLSConfig        `yaml:"tls"`
+	Database       DatabaseConfig   `yaml:"database"`
+	JWT            JWTConfig        `yaml:"jwt"`
+	SMTP           SMTPConfig       `yaml:"smtp"`
+	Email          EmailConfig      `yaml:"email"`
+	RateLimits     RateLimitsConfig `yaml:"rate_limits"`
+	Janitor        JanitorConfig    `yaml:"janitor"`
+	Lockout        LockoutConfig    `yaml:"lockout"`
+	// EnumerationProtection answers the same way for registered and unknown emails.
+	EnumerationProtection EnumerationProtectionConfig `yaml:"enumeration_protection"`
+	MFA                   MFAConfig                   `yaml:"mfa"`
+	// SessionCookies, when enabled, answers /login and /refresh with
+	// HttpOnly cookies instead of tokens in JSON, for browser apps.
+	SessionCookies SessionCookiesConfig `yaml:"session_cookies"`
+}
+
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn"`
+	// AutoMigrate applies pending schema migrations on startup.
+	AutoMigrate bool `yaml:"auto_migrate"`
+	// BaselineVersion, for a schema created by hand from the migration files,
+	// is the last version applied that way; it is recorded without running.
+	BaselineVersion int64 `yaml:"baseline_version"`
+}
+
+// JWTConfig takes either an HMAC Secret or a PrivateKeyFile; only the latter
+// is published at /.well-known/jwks.json.
+type JWTConfig struct {
+	Secret          string        `yaml:"secret"`
+	PrivateKeyFile  string        `yaml:"private_key_file"`
+	KeyID           string        `yaml:"key_id"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	From     string        `yaml:"from"`
+	Security smtp.Security `yaml:"security"`
+}
+
+type EmailConfig struct {
+	// LinkBaseURL turns codes into links: <LinkBaseURL>/<purpose>?code=<code>.
+	LinkBaseURL string `yaml:"link_base_url"`
+}
+
+type SessionCookiesConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	AccessName  string `yaml:"access_name"`
+	RefreshName string `yaml:"refresh_name"`
+	Domain      string `yaml:"domain"`
+	Path        string `yaml:"path"`
+	// SameSite is lax, strict or none.
+	SameSite string `yaml:"same_site"`
+	Insecure bool   `yaml:"insecure"`
+	// CSRFKey signs the CSRF tokens required on every POST in cookie mode.
+	CSRFKey string `yaml:"csrf_key"`
+	// TrustedOrigins may send requests in addition to the vauthd host, e.g.
+	// "https://app.example.com".
+	TrustedOrigins []string `yaml:"trusted_origins"`
+}
+
+// JanitorConfig deletes expired tokens and email confirmation codes. Zero
+// values use the authclient defaults.
+type JanitorConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`
+	BatchSize int           `yaml:"batch_size"`
+	// Retention keeps rows for this long after they expire.
+	Retention time.Duration `yaml:"retention"`
+}
+
+// LockoutConfig locks users and client IPs after repeated failed logins. Zero
+// values use the authclient defaults.
+type LockoutConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	MaxUserFailures int32         `yaml:"max_user_failures"`
+	MaxIPFailures   int32         `yaml:"max_ip_failures"`
+	Window          time.Duration `yaml:"window"`
+	LockDuration    time.Duration `yaml:"lock_duration"`
+	MaxLockDuration time.Duration `yaml:"max_lock_duration"`
+}
+
+type EnumerationProtectionConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	MinDuration time.Duration `yaml:"min_duration"`
+}
+
+type MFAConfig struct {
+	// EncryptionKey is a base64-encoded 32-byte key for TOTP secrets at rest.
+	// TOTP and recovery codes are off without it; email codes work either way.
+	EncryptionKey string `yaml:"encryption_key"`
+}
+
+type RateLimitRule struct {
+	Limit  int64         `yaml:"limit"`
+	Period time.Duration `yaml:"period"`
+}
+
+type RateLimitsConfig struct {
+	Default RateLimitRule `yaml:"default"`
+	// Rules are keyed by register, forgot_password, confirmation_email,
+	// account_unlock, login_link and mfa_email_code.
+	Rules map[string]RateLimitRule `yaml:"rules"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Listen:          ":8080",
+		ShutdownTimeout: 15 * time.Second,
+		ClientIPHeader:  "",
+		TLS:             TLSConfig{CertFile: "", KeyFile: ""},
+		Database:        DatabaseConfig{DSN: "", AutoMigrate: false, BaselineVersion: 0},
+		JWT: JWTConfig{
+			Secret:          "",
+			PrivateKeyFile:  "",
+			KeyID:           "",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+		},
+		SMTP: SMTPConfig{
+			Host:     "",
+			Port:     0,
+			Username: "",
+			Password: "",
+			From:     "",
+			Security: "",
+		},
+		Email: EmailConfig{LinkBaseURL: ""},
+		RateLimits: RateLimitsConfig{
+			Default: RateLimitRule{Limit: 0, Period: 0},
+			Rules:   nil,
+		},
+		Janitor: JanitorConfig{Enabled: false, Interval: 0, BatchSize: 0, Retention: 0},
+		Lockout: LockoutConfig{
+			Enabled:         true,
+			MaxUserFailures: 0,
+			MaxIPFailures:   0,
+			Window:          0,
+			LockDuration:    0,
+			MaxLockDuration: 0,
+		},
+		EnumerationProtection: EnumerationProtectionConfig{Enabled: false, MinDuration: 0},
+		MFA:                   MFAConfig{EncryptionKey: ""},
+		SessionCookies: SessionCookiesConfig{
+			Enabled:        false,
+			AccessName:     "",
+			RefreshName:    "",
+			Domain:         "",
+			Path:           "",
+			SameSite:       "",
+			Insecure:       false,
+			CSRFKey:        "",
+			TrustedOrigins: nil,
+		},
+	}
+}
+
+// loadConfig reads the YAML file at path, if any, over the defaults and then
+// applies VAUTH_* environment variables on top.
+func loadConfig(path string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := defaultConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, werr.Wrap(err)
+		}
+		if err = yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, werr.Wrap(err)
+		}
+	}
+	if err := cfg.applyEnv(lookupEnv); err != nil {
+		return Config{}, werr.Wrap(err)
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) applyEnv(lookupEnv func(string) (string, bool)) error {
+	vars := []struct {
+		name string
+		dst  any
+	}{
+		{"VAUTH_LISTEN", &cfg.Listen},
+		{"VAUTH_SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout},
+		{"VAUTH_CLIENT_IP_HEADER", &cfg.ClientIPHeader},
+		{"VAUTH_TLS_CERT_FILE", &cfg.TLS.CertFile},
+		{"VAUTH_TLS_KEY_FILE", &cfg.TLS.KeyFile},
+		{"VAUTH_DATABASE_DSN", &cfg.Database.DSN},
+		{"VAUTH_DATABASE_AUTO_MIGRATE", &cfg.Database.AutoMigrate},
+		{"VAUTH_DATABASE_BASELINE_VERSION", &cfg.Database.BaselineVersion},
+		{"VAUTH_JWT_SECRET", &cfg.JWT.Secret},
+		{"VAUTH_JWT_PRIVATE_KEY_FILE", &cfg.JWT.PrivateKeyFile},
+		{"VAUTH_JWT_KEY_ID", &cfg.JWT.KeyID},
+		{"VAUTH_JWT_ACCESS_TOKEN_TTL", &cfg.JWT.AccessTokenTTL},
+		{"VAUTH_JWT_REFRESH_TOKEN_TTL", &cfg.JWT.RefreshTokenTTL},
+		{"VAUTH_SMTP_HOST", &cfg.SMTP.Host},
+		{"VAUTH_SMTP_PORT", &cfg.SMTP.Port},
+		{"VAUTH_SMTP_USERNAME", &cfg.SMTP.Username},
+		{"VAUTH_SMTP_PASSWORD", &cfg.SMTP.Password},
+		{"VAUTH_SMTP_FROM", &cfg.SMTP.From},
+		{"VAUTH_SMTP_SECURITY", (*string)(&cfg.SMTP.Security)},
+		{"VAUTH_EMAIL_LINK_BASE_URL", &cfg.Email.LinkBaseURL},
+		{"VAUTH_RATE_LIMIT_DEFAULT_LIMIT", &cfg.RateLimits.Default.Limit},
+		{"VAUTH_RATE_LIMIT_DEFAULT_PERIOD", &cfg.RateLimits.Default.Period},
+		{"VAUTH_JANITOR_ENABLED", &cfg.Janitor.Enabled},
+		{"VAUTH_JANITOR_INTERVAL", &cfg.Janitor.Interval},
+		{"VAUTH_LOCKOUT_ENABLED", &cfg.Lockout.Enabled},
+		{"VAUTH_LOCKOUT_MAX_USER_FAILURES", &cfg.Lockout.MaxUserFailures},
+		{"VAUTH_LOCKOUT_MAX_IP_FAILURES", &cfg.Lockout.MaxIPFailures},
+		{"VAUTH_LOCKOUT_WINDOW", &cfg.Lockout.Window},
+		{"VAUTH_LOCKOUT_LOCK_DURATION", &cfg.Lockout.LockDuration},
+		{"VAUTH_LOCKOUT_MAX_LOCK_DURATION", &cfg.Lockout.MaxLockDuration},
+		{"VAUTH_ENUMERATION_PROTECTION_ENABLED", &cfg.EnumerationProtection.Enabled},
+		{"VAUTH_ENUMERATION_PROTECTION_MIN_DURATION", &cfg.EnumerationProtection.MinDuration},
+		{"VAUTH_MFA_ENCRYPTION_KEY", &cfg.MFA.EncryptionKey},
+		{"VAUTH_SESSION_COOKIES_ENABLED", &cfg.SessionCookies.Enabled},
+		{"VAUTH_SESSION_COOKIES_DOMAIN", &cfg.SessionCookies.Domain},
+		{"VAUTH_SESSION_COOKIES_CSRF_KEY", &cfg.SessionCookies.CSRFKey},
+	}
+	for _, v := range vars {
+		value, ok := lookupEnv(v.name)
+		if !ok {
+			continue
+		}
+		var err error
+		switch dst := v.dst.(type) {
+		case *string:
+			*dst = value
+		case *bool:
+			*dst, err = strconv.ParseBool(value)
+		case *int:
+			*dst, err = strconv.Atoi(value)
+		case *int32:
+			var n int64
+			n, err = strconv.ParseInt(value, 10, 32)
+			*dst = int32(n)
+		case *int64:
+			*dst, err = strconv.ParseInt(value, 10, 64)
+		case *time.Duration:
+			*dst, err = time.ParseDuration(value)
+		}
+		if err != nil {
+			return werr.Wrapf(errInvalidConfig, "%s: %s", v.name, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate reports every problem at once.
+func (cfg Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{errInvalidConfig}, args...)...))
+	}
+
+	if cfg.Listen == "" {
+		invalid("listen is required")
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		invalid("shutdown_timeout must be positive")
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		invalid("tls.cert_file and tls.key_file are set together")
+	}
+	if cfg.Database.DSN == "" {
+		invalid("database.dsn is required")
+	}
+	if cfg.Database.BaselineVersion < 0 {
+		invalid("database.baseline_version must not be negative")
+	}
+	switch {
+	case cfg.JWT.Secret == "" && cfg.JWT.PrivateKeyFile == "":
+		invalid("jwt.secret or jwt.private_key_file is required")
+	case cfg.JWT.Secret != "" && cfg.JWT.PrivateKeyFile != "":
+		invalid("jwt.secret and jwt.private_key_file are mutually exclusive")
+	case cfg.JWT.PrivateKeyFile == "" && len(cfg.JWT.Secret) < minSecretLength:
+		invalid("jwt.secret must be at least %d bytes", minSecretLength)
+	}
+	if cfg.JWT.AccessTokenTTL <= 0 || cfg.JWT.RefreshTokenTTL <= 0 {
+		invalid("jwt token TTLs must be positive")
+	}
+	if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+		invalid("smtp.host and smtp.from are required")
+	}
+	switch cfg.SMTP.Security {
+	case "", smtp.SecurityStartTLS, smtp.SecurityImplicitTLS, smtp.SecurityNone:
+	default:
+		invalid("smtp.security must be starttls, tls or none")
+	}
+	for name, rule := range cfg.RateLimits.Rules {
+		switch name {
+		case authclient.RateLimitRegister, authclient.RateLimitForgotPassword, authclient.RateLimitConfirmationEmail,
+			authclient.RateLimitAccountUnlock, authclient.RateLimitLoginLink, authclient.RateLimitMFAEmailCode:
+		default:
+			invalid("rate_limits.rules: unknown operation %q", name)
+		}
+		if rule.Limit < 0 || rule.Period < 0 {
+			invalid("rate_limits.rules.%s must not be negative", name)
+		}
+	}
+	if cfg.Janitor.Interval < 0 || cfg.Janitor.Retention < 0 {
+		invalid("janitor.interval and janitor.retention must not be negative")
+	}
+	if cfg.Janitor.BatchSize < 0 || cfg.Janitor.BatchSize > math.MaxInt32 {
+		invalid("janitor.batch_size must be between 0 and %d", math.MaxInt32)
+	}
+
+	if cfg.Lockout.Window < 0 || cfg.Lockout.LockDuration < 0 || cfg.Lockout.MaxLockDuration < 0 {
+		invalid("lockout.window, lockout.lock_duration and lockout.max_lock_duration must not be negative")
+	}
+	if cfg.EnumerationProtection.MinDuration < 0 {
+		invalid("enumeration_protection.min_duration must not be negative")
+	}
+	if cfg.MFA.EncryptionKey != "" {
+		if _, err := cfg.mfaEncryptionKey(); err != nil {
+			invalid("mfa.encryption_key must be %d base64-encoded bytes", secretbox.KeySize)
+		}
+	}
+
+	if _, ok := sameSiteModes[cfg.SessionCookies.SameSite]; !ok {
+		invalid("session_cookies.same_site must be lax, strict or none")
+	}
+	if cfg.SessionCookies.Enabled && len(cfg.SessionCookies.CSRFKey) < minSecretLength {
+		invalid("session_cookies.csrf_key must be at least %d bytes", minSecretLength)
+	}
+	if cfg.SessionCookies.SameSite == "none" && cfg.SessionCookies.Insecure {
+		invalid("session_cookies.same_site none requires secure cookies")
+	}
+
+	return errors.Join(errs...)
+}
+
+var sameSiteModes = map[string]http.SameSite{
+	"":       http.SameSiteDefaultMode,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+func (cfg Config) janitorConfig() authclient.JanitorConfig {
+	return authclient.JanitorConfig{
+		Interval:  cfg.Janitor.Interval,
+		BatchSize: int32(min(cfg.Janitor.BatchSize, math.MaxInt32)),
+		Retention: cfg.Janitor.Retention,
+	}
+}
+
+func (cfg Config) lockoutConfig() authclient.LockoutConfig {
+	return authclient.LockoutConfig{
+		MaxUserFailures: cfg.Lockout.MaxUserFailures,
+		MaxIPFailures:   cfg.Lockout.MaxIPFailures,
+		Window:          cfg.Lockout.Window,
+		LockDuration:    cfg.Lockout.LockDuration,
+		MaxLockDuration: cfg.Lockout.MaxLockDuration,
+	}
+}
+
+func (cfg Config) mfaEncryptionKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(cfg.MFA.EncryptionKey)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if len(key) != secretbox.KeySize {
+		return nil, werr.Wrapf(errInvalidConfig, "mfa.encryption_key is %d bytes", len(key))
+	}
+
+	return key, nil
+}
+
+func (cfg Config) sessionCookies() httpauth.SessionCookies {
+	return httpauth.SessionCookies{
+		AccessName:  cfg.SessionCookies.AccessName,
+		RefreshName: cfg.SessionCookies.RefreshName,
+		Domain:      cfg.SessionCookies.Domain,
+		Path:        cfg.SessionCookies.Path,
+		SameSite:    sameSiteModes[cfg.SessionCookies.SameSite],
+		Insecure:    cfg.SessionCookies.Insecure,
+	}
+}
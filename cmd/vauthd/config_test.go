@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("example file is valid", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := loadConfig("vauthd.example.yaml", func(string) (string, bool) { return "", false })
+
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, 15*time.Minute, cfg.JWT.AccessTokenTTL)
+		assert.True(t, cfg.Database.AutoMigrate)
+		assert.True(t, cfg.Janitor.Enabled)
+		assert.Equal(t, RateLimitRule{Limit: 5, Period: time.Hour}, cfg.RateLimits.Rules["register"])
+		assert.Equal(t, RateLimitRule{Limit: 5, Period: 15 * time.Minute}, cfg.RateLimits.Rules["login_link"])
+		assert.Equal(t, RateLimitRule{Limit: 3, Period: 5 * time.Minute}, cfg.RateLimits.Rules["mfa_email_code"])
+		assert.True(t, cfg.Lockout.Enabled)
+		assert.Equal(t, int32(5), cfg.Lockout.MaxUserFailures)
+	})
+
+	t.Run("lockout is on by default", func(t *testing.T) {
+		t.Parallel()
+
+		cfg, err := loadConfig("", func(string) (string, bool) { return "", false })
+
+		require.NoError(t, err)
+		assert.True(t, cfg.Lockout.Enabled)
+		assert.False(t, cfg.EnumerationProtection.Enabled)
+		assert.Empty(t, cfg.MFA.EncryptionKey)
+	})
+
+	t.Run("environment overrides the file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "vauthd.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("listen: \":9000\"\nsmtp:\n  port: 25\n"), 0o600))
+		env := map[string]string{
+			"VAUTH_DATABASE_DSN":                   "postgres://localhost/vauth",
+			"VAUTH_SMTP_PORT":                      "2525",
+			"VAUTH_JWT_ACCESS_TOKEN_TTL":           "5m",
+			"VAUTH_LOCKOUT_ENABLED":                "false",
+			"VAUTH_DATABASE_BASELINE_VERSION":      "3",
+			"VAUTH_LOCKOUT_MAX_IP_FAILURES":        "100",
+			"VAUTH_ENUMERATION_PROTECTION_ENABLED": "true",
+			"VAUTH_MFA_ENCRYPTION_KEY":             base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		}
+
+		cfg, err := loadConfig(path, func(name string) (string, bool) {
+			value, ok := env[name]
+
+			return value, ok
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, ":9000", cfg.Listen)
+		assert.Equal(t, "postgres://localhost/vauth", cfg.Database.DSN)
+		assert.Equal(t, 2525, cfg.SMTP.Port)
+		assert.Equal(t, 5*time.Minute, cfg.JWT.AccessTokenTTL)
+		assert.Equal(t, 7*24*time.Hour, cfg.JWT.RefreshTokenTTL)
+		assert.Equal(t, int64(3), cfg.Database.BaselineVersion)
+		assert.False(t, cfg.Lockout.Enabled)
+		assert.Equal(t, int32(100), cfg.Lockout.MaxIPFailures)
+		assert.True(t, cfg.EnumerationProtection.Enabled)
+		key, err := cfg.mfaEncryptionKey()
+		require.NoError(t, err)
+		assert.Len(t, key, 32)
+	})
+
+	t.Run("malformed environment value", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loadConfig("", func(name string) (string, bool) {
+			return "soon", name == "VAUTH_SHUTDOWN_TIMEOUT"
+		})
+
+		require.ErrorIs(t, err, errInvalidConfig)
+	})
+
+	t.Run("validation reports every problem", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := defaultConfig()
+		cfg.JWT.Secret = "short"
+		cfg.TLS.CertFile = "tls.crt"
+		cfg.RateLimits.Rules = map[string]RateLimitRule{"login": {Limit: 1, Period: time.Minute}}
+		cfg.SessionCookies.SameSite = "relaxed"
+		cfg.SessionCookies.Enabled = true
+		cfg.Janitor.BatchSize = -1
+		cfg.Lockout.Window = -time.Minute
+		cfg.Database.BaselineVersion = -1
+		cfg.MFA.EncryptionKey = "c2hvcnQ="
+
+		err := cfg.Validate()
+
+		require.ErrorIs(t, err, errInvalidConfig)
+		for _, problem := range []string{
+			"database.dsn", "jwt.secret", "tls.cert_file", "smtp.host", `"login"`, "session_cookies.same_site",
+			"session_cookies.csrf_key", "janitor.batch_size", "lockout.window", "mfa.encryption_key",
+			"database.baseline_version",
+		} {
+			assert.Contains(t, err.Error(), problem)
+		}
+	})
+}
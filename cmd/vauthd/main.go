@@ -0,0 +1,43 @@
+// Command vauthd serves the vauth REST API as a standalone service.
+//
+// Configuration is read from the YAML file given by -config or VAUTH_CONFIG,
+// then overridden by VAUTH_* environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	os.Exit(vauthd())
+}
+
+func vauthd() int {
+	configPath := flag.String("config", os.Getenv("VAUTH_CONFIG"), "path to the YAML configuration file")
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath, os.LookupEnv)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vauthd: %s\n", err)
+
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err = run(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "vauthd: %s\n", err)
+
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpapi"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail/smtp"
+	"github.com/github.com/VadimOcLock/vauth/pkg/migrate"
+	"github.com/github.com/VadimOcLock/vauth/pkg/pg"
+	"github.com/github.com/VadimOcLock/vauth/pkg/ratelimit"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	readinessTimeout  = 2 * time.Second
+)
+
+// run serves until ctx is done, then drains requests for up to ShutdownTimeout.
+func run(ctx context.Context, cfg Config) error {
+	jwtConfig, err := cfg.jwtConfig()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	pool, err := pg.New(ctx, pg.Config{DSN: cfg.Database.DSN})
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	defer pool.Close()
+	if cfg.Database.BaselineVersion > 0 {
+		if err = migrate.Baseline(ctx, pool, cfg.Database.BaselineVersion); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+
+	transport, err := smtp.New(smtp.Config{
+		Host:        cfg.SMTP.Host,
+		Port:        cfg.SMTP.Port,
+		Username:    cfg.SMTP.Username,
+		Password:    cfg.SMTP.Password,
+		Auth:        smtpAuth(cfg.SMTP),
+		Security:    cfg.SMTP.Security,
+		TLSConfig:   nil,
+		From:        cfg.SMTP.From,
+		LocalName:   "",
+		Timeout:     0,
+		IdleTimeout: 0,
+	})
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	defer transport.Close()
+	renderer, err := mail.NewRenderer()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	var clientOpts []authclient.Option
+	if cfg.Database.AutoMigrate {
+		clientOpts = append(clientOpts, authclient.WithAutoMigrate())
+	}
+	if cfg.Lockout.Enabled {
+		clientOpts = append(clientOpts, authclient.WithLockout(cfg.lockoutConfig()))
+	}
+	if cfg.EnumerationProtection.Enabled {
+		clientOpts = append(clientOpts, authclient.WithEnumerationProtection(authclient.EnumerationProtectionConfig{
+			MinDuration: cfg.EnumerationProtection.MinDuration,
+		}))
+	}
+	var mfaKey []byte
+	if cfg.MFA.EncryptionKey != "" {
+		if mfaKey, err = cfg.mfaEncryptionKey(); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+	client, err := authclient.New(authclient.Config{
+		PgClient:         pool,
+		JWTConfig:        jwtConfig,
+		EmailSender:      mail.NewSender(renderer, transport),
+		EmailLinkBuilder: cfg.linkBuilder(),
+		RateLimiter:      ratelimit.NewPostgres(pool, cfg.rateLimitConfig()),
+		MFAEncryptionKey: mfaKey,
+	}, clientOpts...)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	if cfg.Janitor.Enabled {
+		janitor := client.NewJanitor(cfg.janitorConfig())
+		go func() { _ = janitor.Run(ctx) }()
+	}
+
+	handler, err := newHandler(cfg, client, jwtConfig, pool)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if cfg.TLS.CertFile != "" {
+			serveErr <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err = <-serveErr:
+		return werr.Wrap(err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		return werr.Wrap(err)
+	}
+	if err = <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+func newHandler(
+	cfg Config,
+	client *authclient.Client,
+	jwtConfig jwtgen.CreatorConfig,
+	pool *pgxpool.Pool,
+) (http.Handler, error) {
+	var opts []httpapi.Option
+	if cfg.ClientIPHeader != "" {
+		opts = append(opts, httpapi.WithClientIP(headerIP(cfg.ClientIPHeader)))
+	}
+	if cfg.SessionCookies.Enabled {
+		csrf, err := httpauth.NewCSRF([]byte(cfg.SessionCookies.CSRFKey),
+			httpauth.WithCSRFSessionCookies(cfg.sessionCookies()),
+			httpauth.WithTrustedOrigins(cfg.SessionCookies.TrustedOrigins...))
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		opts = append(opts, httpapi.WithSessionCookies(cfg.sessionCookies()), httpapi.WithCSRF(csrf))
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", httpapi.New(client, opts...))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		if err := pool.Ping(ctx); err != nil {
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /openapi.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(httpapi.OpenAPI)
+	})
+	if jwtConfig.SigningKey != nil {
+		jwks, err := jwtgen.NewJWKS(jwtConfig)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		body, err := json.Marshal(jwks)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		mux.HandleFunc("GET /.well-known/jwks.json", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "public, max-age=300")
+			_, _ = w.Write(body)
+		})
+	}
+
+	return mux, nil
+}
+
+func (cfg Config) jwtConfig() (jwtgen.CreatorConfig, error) {
+	jwtConfig := jwtgen.CreatorConfig{
+		SecretKey:  nil,
+		SigningKey: nil,
+		KeyID:      cfg.JWT.KeyID,
+		TokenOpts: []jwtgen.CreatorOption{
+			jwtgen.WithAccessTokenTTL(cfg.JWT.AccessTokenTTL),
+			jwtgen.WithRefreshTokenTTL(cfg.JWT.RefreshTokenTTL),
+		},
+	}
+	if cfg.JWT.PrivateKeyFile == "" {
+		jwtConfig.SecretKey = []byte(cfg.JWT.Secret)
+
+		return jwtConfig, nil
+	}
+
+	data, err := os.ReadFile(cfg.JWT.PrivateKeyFile)
+	if err != nil {
+		return jwtgen.CreatorConfig{}, werr.Wrap(err)
+	}
+	if jwtConfig.SigningKey, err = jwtgen.ParsePrivateKeyPEM(data); err != nil {
+		return jwtgen.CreatorConfig{}, werr.Wrap(err)
+	}
+
+	return jwtConfig, nil
+}
+
+func (cfg Config) linkBuilder() authclient.LinkBuilder {
+	if cfg.Email.LinkBaseURL == "" {
+		return nil
+	}
+	base := strings.TrimSuffix(cfg.Email.LinkBaseURL, "/")
+
+	return func(purpose authclient.EmailPurpose, code string) string {
+		return base + "/" + string(purpose) + "?code=" + code
+	}
+}
+
+func (cfg Config) rateLimitConfig() ratelimit.Config {
+	rules := make(map[string]ratelimit.Rule, len(cfg.RateLimits.Rules))
+	for name, rule := range cfg.RateLimits.Rules {
+		rules[name] = ratelimit.Rule{Limit: rule.Limit, Period: rule.Period}
+	}
+
+	return ratelimit.Config{
+		Default: ratelimit.Rule{Limit: cfg.RateLimits.Default.Limit, Period: cfg.RateLimits.Default.Period},
+		Rules:   rules,
+	}
+}
+
+func smtpAuth(cfg SMTPConfig) smtp.AuthMechanism {
+	if cfg.Username == "" {
+		return smtp.AuthNone
+	}
+
+	return smtp.AuthPlain
+}
+
+// headerIP reads the client IP from the first entry of a proxy header.
+func headerIP(header string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		ip, _, _ := strings.Cut(r.Header.Get(header), ",")
+		if ip = strings.TrimSpace(ip); ip != "" {
+			return ip
+		}
+		if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			return ip
+		}
+
+		return r.RemoteAddr
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE email_confirmations
+    DROP COLUMN IF EXISTS purpose;
\ No newline at end of file
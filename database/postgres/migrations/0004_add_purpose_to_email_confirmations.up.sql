@@ -0,0 +1,2 @@
+ALTER TABLE email_confirmations
+    ADD COLUMN purpose VARCHAR(32) NOT NULL DEFAULT 'confirmation';
\ No newline at end of file
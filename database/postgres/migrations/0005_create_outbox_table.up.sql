@@ -0,0 +1,15 @@
+CREATE TABLE outbox
+(
+    id              UUID PRIMARY KEY,
+    kind            VARCHAR(32) NOT NULL,
+    user_id         UUID REFERENCES users (id) ON DELETE CASCADE,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    created_at      timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at      timestamp without time zone default timezone('utc'::text, now()) not null
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,22 @@
+CREATE TABLE user_mfa_factors
+(
+    id             UUID PRIMARY KEY,
+    user_id        UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    factor_type    VARCHAR(16) NOT NULL,
+    secret         TEXT        NOT NULL,
+    confirmed      BOOLEAN     NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT      NOT NULL DEFAULT 0,
+    created_at     timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at     timestamp without time zone default timezone('utc'::text, now()) not null,
+    CONSTRAINT unique_user_factor UNIQUE (user_id, factor_type)
+);
+
+CREATE TABLE mfa_challenges
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    attempts   INTEGER     NOT NULL DEFAULT 0,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null
+);
@@ -0,0 +1,9 @@
+CREATE TABLE mfa_recovery_codes
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    timestamp without time zone,
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    CONSTRAINT unique_user_recovery_code UNIQUE (user_id, code_hash)
+);
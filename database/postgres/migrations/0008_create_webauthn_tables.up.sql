@@ -0,0 +1,25 @@
+CREATE TABLE webauthn_credentials
+(
+    id              UUID PRIMARY KEY,
+    user_id         UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    credential_id   BYTEA       NOT NULL UNIQUE,
+    public_key      BYTEA       NOT NULL,
+    sign_count      BIGINT      NOT NULL DEFAULT 0,
+    aaguid          BYTEA       NOT NULL,
+    name            VARCHAR(64) NOT NULL DEFAULT '',
+    backup_eligible BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at      timestamp without time zone default timezone('utc'::text, now()) not null,
+    last_used_at    timestamp without time zone
+);
+
+CREATE INDEX webauthn_credentials_user_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_sessions
+(
+    id             UUID PRIMARY KEY,
+    user_id        UUID REFERENCES users (id) ON DELETE CASCADE,
+    ceremony       VARCHAR(16) NOT NULL,
+    challenge_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at     timestamp without time zone NOT NULL,
+    created_at     timestamp without time zone default timezone('utc'::text, now()) not null
+);
@@ -0,0 +1,6 @@
+ALTER TABLE mfa_challenges
+    DROP COLUMN IF EXISTS email_code_expires_at,
+    DROP COLUMN IF EXISTS email_code_hash;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS preferred_mfa_factor;
\ No newline at end of file
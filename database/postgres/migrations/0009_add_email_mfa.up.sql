@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN preferred_mfa_factor VARCHAR(16);
+
+ALTER TABLE mfa_challenges
+    ADD COLUMN email_code_hash       VARCHAR(64),
+    ADD COLUMN email_code_expires_at timestamp without time zone;
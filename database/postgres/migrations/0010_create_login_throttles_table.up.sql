@@ -0,0 +1,11 @@
+CREATE TABLE login_throttles
+(
+    scope             VARCHAR(8)   NOT NULL,
+    subject           VARCHAR(255) NOT NULL,
+    failures          INTEGER      NOT NULL DEFAULT 0,
+    lockouts          INTEGER      NOT NULL DEFAULT 0,
+    window_started_at timestamp without time zone NOT NULL,
+    locked_until      timestamp without time zone,
+    updated_at        timestamp without time zone default timezone('utc'::text, now()) not null,
+    PRIMARY KEY (scope, subject)
+);
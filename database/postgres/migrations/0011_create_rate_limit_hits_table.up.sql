@@ -0,0 +1,8 @@
+CREATE TABLE rate_limit_hits
+(
+    id     UUID PRIMARY KEY,
+    key    VARCHAR(320) NOT NULL,
+    hit_at timestamp without time zone NOT NULL
+);
+
+CREATE INDEX rate_limit_hits_key_idx ON rate_limit_hits (key, hit_at);
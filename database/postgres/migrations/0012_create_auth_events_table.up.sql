@@ -0,0 +1,15 @@
+CREATE TABLE auth_events
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    event_type VARCHAR(32)  NOT NULL,
+    ip         VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent TEXT         NOT NULL DEFAULT '',
+    outcome    VARCHAR(16)  NOT NULL,
+    error_code VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null
+);
+
+CREATE INDEX auth_events_user_idx ON auth_events (user_id, created_at);
+CREATE INDEX auth_events_created_at_idx ON auth_events (created_at);
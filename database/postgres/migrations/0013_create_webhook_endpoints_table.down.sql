@@ -0,0 +1 @@
+DROP TABLE IF EXISTS webhook_endpoints;
\ No newline at end of file
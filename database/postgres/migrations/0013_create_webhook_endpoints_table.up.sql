@@ -0,0 +1,11 @@
+CREATE TABLE webhook_endpoints
+(
+    id          UUID PRIMARY KEY,
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL DEFAULT '{}',
+    failures    INTEGER     NOT NULL DEFAULT 0,
+    disabled_at timestamp without time zone,
+    created_at  timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at  timestamp without time zone default timezone('utc'::text, now()) not null
+);
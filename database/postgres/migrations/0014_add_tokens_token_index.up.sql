@@ -0,0 +1 @@
+CREATE INDEX tokens_token_idx ON tokens USING hash (token);
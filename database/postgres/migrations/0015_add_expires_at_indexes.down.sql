@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS email_confirmations_expires_at_idx;
+DROP INDEX IF EXISTS tokens_expires_at_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX tokens_expires_at_idx ON tokens (expires_at);
+CREATE INDEX email_confirmations_expires_at_idx ON email_confirmations (expires_at);
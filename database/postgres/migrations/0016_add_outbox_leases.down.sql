@@ -0,0 +1,5 @@
+UPDATE outbox
+SET status = 'pending'
+WHERE status = 'in_progress';
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status IN ('pending', 'in_progress');
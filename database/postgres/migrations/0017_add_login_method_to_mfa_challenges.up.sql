@@ -0,0 +1,2 @@
+ALTER TABLE mfa_challenges
+    ADD COLUMN login_method VARCHAR(16) NOT NULL DEFAULT 'password';
@@ -0,0 +1 @@
+CREATE INDEX outbox_sent_idx ON outbox (updated_at) WHERE status = 'sent';
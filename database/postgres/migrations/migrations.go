@@ -0,0 +1,8 @@
+// Package migrations embeds the Postgres schema migrations applied by
+// pkg/migrate. Files are named <version>_<name>.up.sql and .down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -5,7 +5,8 @@ create table users
     password_hash varchar             not null,
     created_at    timestamp without time zone default timezone('utc'::text, now()) not null,
     updated_at    timestamp without time zone default timezone('utc'::text, now()) not null,
-    is_verified   bool default false
+    is_verified   bool default false,
+    preferred_mfa_factor VARCHAR(16)
 );
 
 CREATE TABLE tokens
@@ -15,8 +16,7 @@ CREATE TABLE tokens
     token      varchar     NOT NULL,
     revoked    BOOLEAN DEFAULT FALSE,
     expires_at timestamp without time zone NOT NULL,
-    created_at timestamp without time zone default timezone('utc'::text, now()) not null,
-    CONSTRAINT unique_token UNIQUE (token, token_type)
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null
 );
 
 CREATE TABLE email_confirmations
@@ -25,5 +25,138 @@ CREATE TABLE email_confirmations
     user_id    UUID REFERENCES users (id) ON DELETE CASCADE,
     code       VARCHAR(64) NOT NULL,
     expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    purpose    VARCHAR(32) NOT NULL DEFAULT 'confirmation'
+);
+
+CREATE TABLE outbox
+(
+    id              UUID PRIMARY KEY,
+    kind            VARCHAR(32) NOT NULL,
+    user_id         UUID REFERENCES users (id) ON DELETE CASCADE,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    created_at      timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at      timestamp without time zone default timezone('utc'::text, now()) not null
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status IN ('pending', 'in_progress');
+CREATE INDEX outbox_sent_idx ON outbox (updated_at) WHERE status = 'sent';
+
+CREATE TABLE user_mfa_factors
+(
+    id             UUID PRIMARY KEY,
+    user_id        UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    factor_type    VARCHAR(16) NOT NULL,
+    secret         TEXT        NOT NULL,
+    confirmed      BOOLEAN     NOT NULL DEFAULT FALSE,
+    last_used_step BIGINT      NOT NULL DEFAULT 0,
+    created_at     timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at     timestamp without time zone default timezone('utc'::text, now()) not null,
+    CONSTRAINT unique_user_factor UNIQUE (user_id, factor_type)
+);
+
+CREATE TABLE mfa_challenges
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    attempts   INTEGER     NOT NULL DEFAULT 0,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    email_code_hash       VARCHAR(64),
+    email_code_expires_at timestamp without time zone,
+    login_method          VARCHAR(16) NOT NULL DEFAULT 'password'
+);
+
+CREATE TABLE mfa_recovery_codes
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    timestamp without time zone,
+    created_at timestamp without time zone default timezone('utc'::text, now()) not null,
+    CONSTRAINT unique_user_recovery_code UNIQUE (user_id, code_hash)
+);
+
+CREATE TABLE webauthn_credentials
+(
+    id              UUID PRIMARY KEY,
+    user_id         UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    credential_id   BYTEA       NOT NULL UNIQUE,
+    public_key      BYTEA       NOT NULL,
+    sign_count      BIGINT      NOT NULL DEFAULT 0,
+    aaguid          BYTEA       NOT NULL,
+    name            VARCHAR(64) NOT NULL DEFAULT '',
+    backup_eligible BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at      timestamp without time zone default timezone('utc'::text, now()) not null,
+    last_used_at    timestamp without time zone
+);
+
+CREATE INDEX webauthn_credentials_user_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_sessions
+(
+    id             UUID PRIMARY KEY,
+    user_id        UUID REFERENCES users (id) ON DELETE CASCADE,
+    ceremony       VARCHAR(16) NOT NULL,
+    challenge_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at     timestamp without time zone NOT NULL,
+    created_at     timestamp without time zone default timezone('utc'::text, now()) not null
+);
+
+CREATE TABLE login_throttles
+(
+    scope             VARCHAR(8)   NOT NULL,
+    subject           VARCHAR(255) NOT NULL,
+    failures          INTEGER      NOT NULL DEFAULT 0,
+    lockouts          INTEGER      NOT NULL DEFAULT 0,
+    window_started_at timestamp without time zone NOT NULL,
+    locked_until      timestamp without time zone,
+    updated_at        timestamp without time zone default timezone('utc'::text, now()) not null,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE TABLE rate_limit_hits
+(
+    id     UUID PRIMARY KEY,
+    key    VARCHAR(320) NOT NULL,
+    hit_at timestamp without time zone NOT NULL
+);
+
+CREATE INDEX rate_limit_hits_key_idx ON rate_limit_hits (key, hit_at);
+
+CREATE TABLE auth_events
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    event_type VARCHAR(32)  NOT NULL,
+    ip         VARCHAR(64)  NOT NULL DEFAULT '',
+    user_agent TEXT         NOT NULL DEFAULT '',
+    outcome    VARCHAR(16)  NOT NULL,
+    error_code VARCHAR(64)  NOT NULL DEFAULT '',
     created_at timestamp without time zone default timezone('utc'::text, now()) not null
-);
\ No newline at end of file
+);
+
+CREATE INDEX auth_events_user_idx ON auth_events (user_id, created_at);
+CREATE INDEX auth_events_created_at_idx ON auth_events (created_at);
+
+CREATE TABLE webhook_endpoints
+(
+    id          UUID PRIMARY KEY,
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL DEFAULT '{}',
+    failures    INTEGER     NOT NULL DEFAULT 0,
+    disabled_at timestamp without time zone,
+    created_at  timestamp without time zone default timezone('utc'::text, now()) not null,
+    updated_at  timestamp without time zone default timezone('utc'::text, now()) not null
+);
+
+CREATE INDEX tokens_token_idx ON tokens USING hash (token);
+CREATE INDEX tokens_expires_at_idx ON tokens (expires_at);
+CREATE INDEX email_confirmations_expires_at_idx ON email_confirmations (expires_at);
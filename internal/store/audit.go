@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+type AuthEvent pgstore.AuthEvent
+
+func (m AuthEvent) Entity() entity.AuthEvent {
+	return entity.AuthEvent{
+		ID:        m.ID,
+		UserID:    m.UserID.UUID,
+		Email:     m.Email,
+		Type:      entity.AuthEventType(m.EventType),
+		IP:        m.Ip,
+		UserAgent: m.UserAgent,
+		Outcome:   entity.AuthEventOutcome(m.Outcome),
+		ErrorCode: m.ErrorCode,
+		CreatedAt: m.CreatedAt.Time,
+	}
+}
+
+type CreateAuthEventDTO struct {
+	UserID    uuid.UUID
+	Email     string
+	Type      entity.AuthEventType
+	IP        string
+	UserAgent string
+	Outcome   entity.AuthEventOutcome
+	ErrorCode string
+}
+
+func (s Impl) CreateAuthEvent(ctx context.Context, dto CreateAuthEventDTO) error {
+	if err := s.PgStore.CreateAuthEvent(ctx, pgstore.CreateAuthEventParams{
+		ID: NewUUID(),
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: dto.UserID != uuid.Nil,
+		},
+		Email:     dto.Email,
+		EventType: string(dto.Type),
+		Ip:        dto.IP,
+		UserAgent: dto.UserAgent,
+		Outcome:   string(dto.Outcome),
+		ErrorCode: dto.ErrorCode,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+type ListAuthEventsDTO struct {
+	// UserID uuid.Nil lists events of every user.
+	UserID uuid.UUID
+	From   time.Time
+	To     time.Time
+	Limit  int32
+	Offset int32
+}
+
+func (s Impl) ListAuthEvents(ctx context.Context, dto ListAuthEventsDTO) ([]AuthEvent, error) {
+	rows, err := s.PgStore.ListAuthEvents(ctx, pgstore.ListAuthEventsParams{
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: dto.UserID != uuid.Nil,
+		},
+		CreatedFrom: pgtype.Timestamp{
+			Time:             dto.From.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		CreatedTo: pgtype.Timestamp{
+			Time:             dto.To.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		RowOffset: dto.Offset,
+		RowLimit:  dto.Limit,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	events := make([]AuthEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, AuthEvent(row))
+	}
+
+	return events, nil
+}
+
+// DeleteAuthEventsBefore returns the number of deleted events.
+func (s Impl) DeleteAuthEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := s.PgStore.DeleteAuthEventsBefore(ctx, pgtype.Timestamp{
+		Time:             before.UTC(),
+		InfinityModifier: 0,
+		Valid:            true,
+	})
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	return deleted, nil
+}
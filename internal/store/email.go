@@ -14,9 +14,18 @@ type CreateEmailConfirmationDTO struct {
 	UserID           uuid.UUID
 	ConfirmationCode string
 	ExpiresAt        time.Time
+	Outbox           *CreateOutboxMessageDTO
 }
 
 func (s Impl) CreateEmailConfirmation(ctx context.Context, dto CreateEmailConfirmationDTO) (uuid.UUID, error) {
+	if dto.Outbox != nil {
+		return s.withOutbox(ctx, dto.UserID, *dto.Outbox, func(stx Store) (uuid.UUID, error) {
+			dto.Outbox = nil
+
+			return stx.CreateEmailConfirmation(ctx, dto)
+		})
+	}
+
 	id := NewUUID()
 	newID, err := s.PgStore.CreateEmailConfirmation(ctx, pgstore.CreateEmailConfirmationParams{
 		ID: id,
@@ -26,7 +35,44 @@ func (s Impl) CreateEmailConfirmation(ctx context.Context, dto CreateEmailConfir
 		},
 		Code: dto.ConfirmationCode,
 		ExpiresAt: pgtype.Timestamp{
-			Time:             dto.ExpiresAt,
+			Time:             dto.ExpiresAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+type CreateLoginCodeDTO struct {
+	UserID    uuid.UUID
+	LoginCode string
+	ExpiresAt time.Time
+	Outbox    *CreateOutboxMessageDTO
+}
+
+func (s Impl) CreateLoginCode(ctx context.Context, dto CreateLoginCodeDTO) (uuid.UUID, error) {
+	if dto.Outbox != nil {
+		return s.withOutbox(ctx, dto.UserID, *dto.Outbox, func(stx Store) (uuid.UUID, error) {
+			dto.Outbox = nil
+
+			return stx.CreateLoginCode(ctx, dto)
+		})
+	}
+
+	id := NewUUID()
+	newID, err := s.PgStore.CreateLoginCode(ctx, pgstore.CreateLoginCodeParams{
+		ID: id,
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: true,
+		},
+		Code: dto.LoginCode,
+		ExpiresAt: pgtype.Timestamp{
+			Time:             dto.ExpiresAt.UTC(),
 			InfinityModifier: 0,
 			Valid:            true,
 		},
@@ -0,0 +1,53 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingQueries records the parameters of the queries a test calls; any
+// other query panics.
+type recordingQueries struct {
+	pgstore.Querier
+
+	emailConfirmation pgstore.CreateEmailConfirmationParams
+	listUsers         pgstore.ListUsersParams
+}
+
+func (q *recordingQueries) CreateEmailConfirmation(
+	_ context.Context,
+	arg pgstore.CreateEmailConfirmationParams,
+) (uuid.UUID, error) {
+	q.emailConfirmation = arg
+
+	return arg.ID, nil
+}
+
+func TestImpl_CreateEmailConfirmation(t *testing.T) {
+	t.Parallel()
+
+	// The columns are timestamps without time zone, compared against UTC by
+	// the janitor, so a local expiry west of UTC would be purged early.
+	queries := &recordingQueries{}
+	s := store.Impl{PgClient: nil, PgStore: queries}
+	expiresAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	_, err := s.CreateEmailConfirmation(context.Background(), store.CreateEmailConfirmationDTO{
+		UserID:           uuid.New(),
+		ConfirmationCode: "123456",
+		ExpiresAt:        expiresAt,
+		Outbox:           nil,
+	})
+
+	require.NoError(t, err)
+	stored := queries.emailConfirmation.ExpiresAt.Time
+	assert.Equal(t, time.UTC, stored.Location())
+	assert.Equal(t, time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC), stored)
+}
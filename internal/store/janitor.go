@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+const janitorLockKey = "vauth.janitor"
+
+type DeleteExpiredDTO struct {
+	Before time.Time
+	// BatchSize bounds the rows deleted from each table.
+	BatchSize int32
+}
+
+type DeletedExpired struct {
+	// Locked is false when another replica holds the janitor lock and
+	// nothing was deleted.
+	Locked             bool
+	Tokens             int64
+	EmailConfirmations int64
+	OutboxMessages     int64
+}
+
+// DeleteExpired deletes one batch of tokens and email confirmations that
+// expired before Before, and of outbox messages sent before Before, in a
+// transaction holding the janitor advisory lock.
+func (s Impl) DeleteExpired(ctx context.Context, dto DeleteExpiredDTO) (DeletedExpired, error) {
+	var deleted DeletedExpired
+	err := s.PgTx(ctx, func(tx pgx.Tx, _ Store) error {
+		q := NewPgStore(tx)
+		locked, err := q.TryLockJanitor(ctx, janitorLockKey)
+		if err != nil || !locked {
+			return werr.Wrap(err)
+		}
+		deleted.Locked = true
+
+		before := pgtype.Timestamp{
+			Time:             dto.Before.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		}
+		if deleted.Tokens, err = q.DeleteExpiredTokens(ctx, pgstore.DeleteExpiredTokensParams{
+			Before:    before,
+			BatchSize: dto.BatchSize,
+		}); err != nil {
+			return werr.Wrap(err)
+		}
+		if deleted.EmailConfirmations, err = q.DeleteExpiredEmailConfirmations(ctx,
+			pgstore.DeleteExpiredEmailConfirmationsParams{
+				Before:    before,
+				BatchSize: dto.BatchSize,
+			}); err != nil {
+			return werr.Wrap(err)
+		}
+		deleted.OutboxMessages, err = q.DeleteSentOutboxMessages(ctx, pgstore.DeleteSentOutboxMessagesParams{
+			Before:    before,
+			BatchSize: dto.BatchSize,
+		})
+
+		return werr.Wrap(err)
+	})
+	if err != nil {
+		return DeletedExpired{}, werr.Wrap(err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+// LockoutKey identifies a failed login counter: a user ID or a client IP.
+type LockoutKey struct {
+	Scope   entity.LockoutScope
+	Subject string
+}
+
+// FindLoginLock returns when an active lock ends, or pgx.ErrNoRows when there is none.
+func (s Impl) FindLoginLock(ctx context.Context, key LockoutKey) (time.Time, error) {
+	lockedUntil, err := s.PgStore.FindLoginLock(ctx, pgstore.FindLoginLockParams{
+		Scope:   string(key.Scope),
+		Subject: key.Subject,
+	})
+	if err != nil {
+		return time.Time{}, werr.Wrap(err)
+	}
+
+	return lockedUntil.Time, nil
+}
+
+type RecordLoginFailureDTO struct {
+	Key LockoutKey
+	// WindowStart drops failures recorded before it from the count.
+	WindowStart time.Time
+}
+
+type LoginFailures struct {
+	Failures int32
+	Lockouts int32
+}
+
+func (s Impl) RecordLoginFailure(ctx context.Context, dto RecordLoginFailureDTO) (LoginFailures, error) {
+	row, err := s.PgStore.RecordLoginFailure(ctx, pgstore.RecordLoginFailureParams{
+		Scope:   string(dto.Key.Scope),
+		Subject: dto.Key.Subject,
+		WindowStart: pgtype.Timestamp{
+			Time:             dto.WindowStart.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	})
+	if err != nil {
+		return LoginFailures{}, werr.Wrap(err)
+	}
+
+	return LoginFailures(row), nil
+}
+
+type LockLoginDTO struct {
+	Key         LockoutKey
+	LockedUntil time.Time
+}
+
+// LockLogin resets the failure count and bumps the lockout count used for backoff.
+func (s Impl) LockLogin(ctx context.Context, dto LockLoginDTO) error {
+	if err := s.PgStore.LockLogin(ctx, pgstore.LockLoginParams{
+		LockedUntil: pgtype.Timestamp{
+			Time:             dto.LockedUntil.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		Scope:   string(dto.Key.Scope),
+		Subject: dto.Key.Subject,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// ForceLoginLock locks key until LockedUntil whether or not it has failures.
+func (s Impl) ForceLoginLock(ctx context.Context, dto LockLoginDTO) error {
+	if err := s.PgStore.ForceLoginLock(ctx, pgstore.ForceLoginLockParams{
+		Scope:   string(dto.Key.Scope),
+		Subject: dto.Key.Subject,
+		LockedUntil: pgtype.Timestamp{
+			Time:             dto.LockedUntil.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (s Impl) DeleteLoginThrottle(ctx context.Context, key LockoutKey) error {
+	if err := s.PgStore.DeleteLoginThrottle(ctx, pgstore.DeleteLoginThrottleParams{
+		Scope:   string(key.Scope),
+		Subject: key.Subject,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+type CreateUnlockCodeDTO struct {
+	UserID     uuid.UUID
+	UnlockCode string
+	ExpiresAt  time.Time
+	Outbox     *CreateOutboxMessageDTO
+}
+
+func (s Impl) CreateUnlockCode(ctx context.Context, dto CreateUnlockCodeDTO) (uuid.UUID, error) {
+	if dto.Outbox != nil {
+		return s.withOutbox(ctx, dto.UserID, *dto.Outbox, func(stx Store) (uuid.UUID, error) {
+			dto.Outbox = nil
+
+			return stx.CreateUnlockCode(ctx, dto)
+		})
+	}
+
+	id := NewUUID()
+	newID, err := s.PgStore.CreateUnlockCode(ctx, pgstore.CreateUnlockCodeParams{
+		ID: id,
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: true,
+		},
+		Code: dto.UnlockCode,
+		ExpiresAt: pgtype.Timestamp{
+			Time:             dto.ExpiresAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+// UnlockAccountWithCode consumes an unlock code and clears the user's counter.
+func (s Impl) UnlockAccountWithCode(ctx context.Context, code string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := s.PgTx(ctx, func(tx pgx.Tx, _ Store) error {
+		q := NewPgStore(tx)
+		consumed, err := q.ConsumeUnlockCode(ctx, code)
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		userID = consumed.UUID
+
+		return werr.Wrap(q.DeleteLoginThrottle(ctx, pgstore.DeleteLoginThrottleParams{
+			Scope:   string(entity.LockoutScopeUser),
+			Subject: userID.String(),
+		}))
+	})
+
+	return userID, werr.Wrap(err)
+}
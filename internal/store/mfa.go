@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+type MFAFactor pgstore.UserMfaFactor
+
+func (m MFAFactor) Entity() entity.MFAFactor {
+	return entity.MFAFactor{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Type:      entity.MFAFactorType(m.FactorType),
+		Confirmed: m.Confirmed,
+		CreatedAt: m.CreatedAt.Time,
+		UpdatedAt: m.UpdatedAt.Time,
+	}
+}
+
+type UpsertPendingMFAFactorDTO struct {
+	UserID     uuid.UUID
+	FactorType entity.MFAFactorType
+	Secret     string
+}
+
+// UpsertPendingMFAFactor creates or replaces an unconfirmed factor. It returns
+// pgx.ErrNoRows when the user already has a confirmed factor of that type.
+func (s Impl) UpsertPendingMFAFactor(ctx context.Context, dto UpsertPendingMFAFactorDTO) (uuid.UUID, error) {
+	id := NewUUID()
+	newID, err := s.PgStore.UpsertPendingMFAFactor(ctx, pgstore.UpsertPendingMFAFactorParams{
+		ID:         id,
+		UserID:     dto.UserID,
+		FactorType: string(dto.FactorType),
+		Secret:     dto.Secret,
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+func (s Impl) FindMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) (MFAFactor, error) {
+	factor, err := s.PgStore.FindMFAFactor(ctx, pgstore.FindMFAFactorParams{
+		UserID:     userID,
+		FactorType: string(factorType),
+	})
+	if err != nil {
+		return MFAFactor{}, werr.Wrap(err)
+	}
+
+	return MFAFactor(factor), nil
+}
+
+func (s Impl) ListConfirmedMFAFactors(ctx context.Context, userID uuid.UUID) ([]MFAFactor, error) {
+	rows, err := s.PgStore.ListConfirmedMFAFactors(ctx, userID)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	factors := make([]MFAFactor, 0, len(rows))
+	for _, row := range rows {
+		factors = append(factors, MFAFactor(row))
+	}
+
+	return factors, nil
+}
+
+type UseMFAFactorStepDTO struct {
+	FactorID uuid.UUID
+	Step     int64
+}
+
+// ConfirmMFAFactor activates a pending factor. It returns pgx.ErrNoRows if the
+// factor is already confirmed.
+func (s Impl) ConfirmMFAFactor(ctx context.Context, dto UseMFAFactorStepDTO) error {
+	if _, err := s.PgStore.ConfirmMFAFactor(ctx, pgstore.ConfirmMFAFactorParams{
+		LastUsedStep: dto.Step,
+		ID:           dto.FactorID,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateMFAFactorLastUsedStep records a used time step. It returns
+// pgx.ErrNoRows if that step or a later one was already used.
+func (s Impl) UpdateMFAFactorLastUsedStep(ctx context.Context, dto UseMFAFactorStepDTO) error {
+	if _, err := s.PgStore.UpdateMFAFactorLastUsedStep(ctx, pgstore.UpdateMFAFactorLastUsedStepParams{
+		LastUsedStep: dto.Step,
+		ID:           dto.FactorID,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+type CreateMFAChallengeDTO struct {
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	// LoginMethod is the first factor the login started with.
+	LoginMethod string
+}
+
+func (s Impl) CreateMFAChallenge(ctx context.Context, dto CreateMFAChallengeDTO) (uuid.UUID, error) {
+	id := NewUUID()
+	newID, err := s.PgStore.CreateMFAChallenge(ctx, pgstore.CreateMFAChallengeParams{
+		ID:        id,
+		UserID:    dto.UserID,
+		TokenHash: dto.TokenHash,
+		ExpiresAt: pgtype.Timestamp{
+			Time:             dto.ExpiresAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		LoginMethod: dto.LoginMethod,
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+type AttemptMFAChallengeDTO struct {
+	TokenHash   string
+	MaxAttempts int32
+}
+
+type MFAChallenge struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	// EmailCodeHash is set once an email code was sent for this challenge.
+	EmailCodeHash      string
+	EmailCodeExpiresAt time.Time
+	LoginMethod        string
+}
+
+// AttemptMFAChallenge counts a verification attempt against a live challenge.
+// It returns pgx.ErrNoRows once the challenge expired or ran out of attempts.
+func (s Impl) AttemptMFAChallenge(ctx context.Context, dto AttemptMFAChallengeDTO) (MFAChallenge, error) {
+	row, err := s.PgStore.AttemptMFAChallenge(ctx, pgstore.AttemptMFAChallengeParams{
+		TokenHash:   dto.TokenHash,
+		MaxAttempts: dto.MaxAttempts,
+	})
+	if err != nil {
+		return MFAChallenge{}, werr.Wrap(err)
+	}
+
+	return MFAChallenge{
+		ID:                 row.ID,
+		UserID:             row.UserID,
+		EmailCodeHash:      row.EmailCodeHash.String,
+		EmailCodeExpiresAt: row.EmailCodeExpiresAt.Time,
+		LoginMethod:        row.LoginMethod,
+	}, nil
+}
+
+// FindMFAChallengeUserID looks up the user of a challenge whatever its state,
+// so attempts on a used-up challenge still count against that user.
+func (s Impl) FindMFAChallengeUserID(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	userID, err := s.PgStore.FindMFAChallengeUserID(ctx, tokenHash)
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return userID, nil
+}
+
+func (s Impl) DeleteMFAChallenge(ctx context.Context, id uuid.UUID) error {
+	return werr.Wrap(s.PgStore.DeleteMFAChallenge(ctx, id))
+}
+
+type SetMFAChallengeEmailCodeDTO struct {
+	ChallengeID uuid.UUID
+	CodeHash    string
+	ExpiresAt   time.Time
+}
+
+func (s Impl) SetMFAChallengeEmailCode(ctx context.Context, dto SetMFAChallengeEmailCodeDTO) error {
+	return werr.Wrap(s.PgStore.SetMFAChallengeEmailCode(ctx, pgstore.SetMFAChallengeEmailCodeParams{
+		ID: dto.ChallengeID,
+		EmailCodeHash: pgtype.Text{
+			String: dto.CodeHash,
+			Valid:  true,
+		},
+		EmailCodeExpiresAt: pgtype.Timestamp{
+			Time:             dto.ExpiresAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	}))
+}
+
+// EnableMFAFactor activates a factor that needs no enrollment secret.
+func (s Impl) EnableMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) error {
+	return werr.Wrap(s.PgStore.EnableMFAFactor(ctx, pgstore.EnableMFAFactorParams{
+		ID:         NewUUID(),
+		UserID:     userID,
+		FactorType: string(factorType),
+	}))
+}
+
+type UpdateUserPreferredMFAFactorDTO struct {
+	UserID     uuid.UUID
+	FactorType entity.MFAFactorType
+}
+
+func (s Impl) UpdateUserPreferredMFAFactor(ctx context.Context, dto UpdateUserPreferredMFAFactorDTO) error {
+	return werr.Wrap(s.PgStore.UpdateUserPreferredMFAFactor(ctx, pgstore.UpdateUserPreferredMFAFactorParams{
+		ID: dto.UserID,
+		PreferredMfaFactor: pgtype.Text{
+			String: string(dto.FactorType),
+			Valid:  dto.FactorType != "",
+		},
+	}))
+}
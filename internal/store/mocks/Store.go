@@ -5,41 +5,1313 @@ package mocks
 import (
 	context "context"
 
-	pgx "github.com/jackc/pgx/v5"
+	entity "github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	mock "github.com/stretchr/testify/mock"
 
-	store "github.com/github.com/VadimOcLock/vauth/internal/store"
+	pgx "github.com/jackc/pgx/v5"
+
+	store "github.com/github.com/VadimOcLock/vauth/internal/store"
+
+	time "time"
+
+	uuid "github.com/google/uuid"
+)
+
+// Store is an autogenerated mock type for the Store type
+type Store struct {
+	mock.Mock
+}
+
+// AttemptMFAChallenge provides a mock function with given fields: ctx, dto
+func (_m *Store) AttemptMFAChallenge(ctx context.Context, dto store.AttemptMFAChallengeDTO) (store.MFAChallenge, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AttemptMFAChallenge")
+	}
+
+	var r0 store.MFAChallenge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.AttemptMFAChallengeDTO) (store.MFAChallenge, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.AttemptMFAChallengeDTO) store.MFAChallenge); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(store.MFAChallenge)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.AttemptMFAChallengeDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimOutboxMessages provides a mock function with given fields: ctx, dto
+func (_m *Store) ClaimOutboxMessages(ctx context.Context, dto store.ClaimOutboxMessagesDTO) ([]store.OutboxMessage, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimOutboxMessages")
+	}
+
+	var r0 []store.OutboxMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ClaimOutboxMessagesDTO) ([]store.OutboxMessage, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.ClaimOutboxMessagesDTO) []store.OutboxMessage); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.OutboxMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.ClaimOutboxMessagesDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmMFAFactor provides a mock function with given fields: ctx, dto
+func (_m *Store) ConfirmMFAFactor(ctx context.Context, dto store.UseMFAFactorStepDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmMFAFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.UseMFAFactorStepDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumeLoginCode provides a mock function with given fields: ctx, code
+func (_m *Store) ConsumeLoginCode(ctx context.Context, code string) (store.User, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeLoginCode")
+	}
+
+	var r0 store.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (store.User, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) store.User); ok {
+		r0 = rf(ctx, code)
+	} else {
+		r0 = ret.Get(0).(store.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConsumeRecoveryCode provides a mock function with given fields: ctx, dto
+func (_m *Store) ConsumeRecoveryCode(ctx context.Context, dto store.ConsumeRecoveryCodeDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeRecoveryCode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ConsumeRecoveryCodeDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConsumeWebAuthnSession provides a mock function with given fields: ctx, dto
+func (_m *Store) ConsumeWebAuthnSession(ctx context.Context, dto store.ConsumeWebAuthnSessionDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConsumeWebAuthnSession")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ConsumeWebAuthnSessionDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.ConsumeWebAuthnSessionDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.ConsumeWebAuthnSessionDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountRecoveryCodes provides a mock function with given fields: ctx, userID
+func (_m *Store) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountRecoveryCodes")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int64, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int64); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAuthEvent provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateAuthEvent(ctx context.Context, dto store.CreateAuthEventDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAuthEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateAuthEventDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateEmailConfirmation provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateEmailConfirmation(ctx context.Context, dto store.CreateEmailConfirmationDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateEmailConfirmation")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateEmailConfirmationDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateEmailConfirmationDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateEmailConfirmationDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateLoginCode provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateLoginCode(ctx context.Context, dto store.CreateLoginCodeDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLoginCode")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateLoginCodeDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateLoginCodeDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateLoginCodeDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMFAChallenge provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateMFAChallenge(ctx context.Context, dto store.CreateMFAChallengeDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMFAChallenge")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateMFAChallengeDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateMFAChallengeDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateMFAChallengeDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateOutboxMessage provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateOutboxMessage(ctx context.Context, dto store.CreateOutboxMessageDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxMessage")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateOutboxMessageDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateOutboxMessageDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateOutboxMessageDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateToken provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateToken(ctx context.Context, dto store.CreateTokenDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateToken")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateTokenDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateTokenDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateTokenDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUnlockCode provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateUnlockCode(ctx context.Context, dto store.CreateUnlockCodeDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUnlockCode")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUnlockCodeDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUnlockCodeDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateUnlockCodeDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateUser(ctx context.Context, dto store.CreateUserDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUser")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUserDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUserDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateUserDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebAuthnCredential provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateWebAuthnCredential(ctx context.Context, dto store.CreateWebAuthnCredentialDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebAuthnCredential")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebAuthnCredentialDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebAuthnCredentialDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateWebAuthnCredentialDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebAuthnSession provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateWebAuthnSession(ctx context.Context, dto store.CreateWebAuthnSessionDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebAuthnSession")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebAuthnSessionDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebAuthnSessionDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateWebAuthnSessionDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhookEndpoint provides a mock function with given fields: ctx, dto
+func (_m *Store) CreateWebhookEndpoint(ctx context.Context, dto store.CreateWebhookEndpointDTO) (store.WebhookEndpoint, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookEndpoint")
+	}
+
+	var r0 store.WebhookEndpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebhookEndpointDTO) (store.WebhookEndpoint, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.CreateWebhookEndpointDTO) store.WebhookEndpoint); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(store.WebhookEndpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.CreateWebhookEndpointDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAuthEventsBefore provides a mock function with given fields: ctx, before
+func (_m *Store) DeleteAuthEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAuthEventsBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteExpired provides a mock function with given fields: ctx, dto
+func (_m *Store) DeleteExpired(ctx context.Context, dto store.DeleteExpiredDTO) (store.DeletedExpired, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpired")
+	}
+
+	var r0 store.DeletedExpired
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.DeleteExpiredDTO) (store.DeletedExpired, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.DeleteExpiredDTO) store.DeletedExpired); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(store.DeletedExpired)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.DeleteExpiredDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteLoginThrottle provides a mock function with given fields: ctx, key
+func (_m *Store) DeleteLoginThrottle(ctx context.Context, key store.LockoutKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLoginThrottle")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.LockoutKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMFAChallenge provides a mock function with given fields: ctx, id
+func (_m *Store) DeleteMFAChallenge(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMFAChallenge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *Store) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhookEndpoint provides a mock function with given fields: ctx, id
+func (_m *Store) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhookEndpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableMFAFactor provides a mock function with given fields: ctx, userID, factorType
+func (_m *Store) EnableMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) error {
+	ret := _m.Called(ctx, userID, factorType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableMFAFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entity.MFAFactorType) error); ok {
+		r0 = rf(ctx, userID, factorType)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableWebhookEndpoint provides a mock function with given fields: ctx, id
+func (_m *Store) EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableWebhookEndpoint")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExistsUserByLogin provides a mock function with given fields: ctx, login
+func (_m *Store) ExistsUserByLogin(ctx context.Context, login string) (bool, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExistsUserByLogin")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, login)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindLoginLock provides a mock function with given fields: ctx, key
+func (_m *Store) FindLoginLock(ctx context.Context, key store.LockoutKey) (time.Time, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindLoginLock")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.LockoutKey) (time.Time, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.LockoutKey) time.Time); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.LockoutKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindMFAChallengeUserID provides a mock function with given fields: ctx, tokenHash
+func (_m *Store) FindMFAChallengeUserID(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindMFAChallengeUserID")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (uuid.UUID, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) uuid.UUID); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindMFAFactor provides a mock function with given fields: ctx, userID, factorType
+func (_m *Store) FindMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) (store.MFAFactor, error) {
+	ret := _m.Called(ctx, userID, factorType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindMFAFactor")
+	}
+
+	var r0 store.MFAFactor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entity.MFAFactorType) (store.MFAFactor, error)); ok {
+		return rf(ctx, userID, factorType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entity.MFAFactorType) store.MFAFactor); ok {
+		r0 = rf(ctx, userID, factorType)
+	} else {
+		r0 = ret.Get(0).(store.MFAFactor)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, entity.MFAFactorType) error); ok {
+		r1 = rf(ctx, userID, factorType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindToken provides a mock function with given fields: ctx, token
+func (_m *Store) FindToken(ctx context.Context, token string) (store.Token, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindToken")
+	}
+
+	var r0 store.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (store.Token, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) store.Token); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(store.Token)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindUserByConfirmationCode provides a mock function with given fields: ctx, code
+func (_m *Store) FindUserByConfirmationCode(ctx context.Context, code string) (store.User, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUserByConfirmationCode")
+	}
+
+	var r0 store.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (store.User, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) store.User); ok {
+		r0 = rf(ctx, code)
+	} else {
+		r0 = ret.Get(0).(store.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Store) FindUserByEmail(ctx context.Context, email string) (store.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUserByEmail")
+	}
+
+	var r0 store.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (store.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) store.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(store.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindUserByID provides a mock function with given fields: ctx, id
+func (_m *Store) FindUserByID(ctx context.Context, id uuid.UUID) (store.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUserByID")
+	}
+
+	var r0 store.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (store.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) store.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(store.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindWebAuthnCredential provides a mock function with given fields: ctx, credentialID
+func (_m *Store) FindWebAuthnCredential(ctx context.Context, credentialID []byte) (store.WebAuthnCredential, error) {
+	ret := _m.Called(ctx, credentialID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindWebAuthnCredential")
+	}
+
+	var r0 store.WebAuthnCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) (store.WebAuthnCredential, error)); ok {
+		return rf(ctx, credentialID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) store.WebAuthnCredential); ok {
+		r0 = rf(ctx, credentialID)
+	} else {
+		r0 = ret.Get(0).(store.WebAuthnCredential)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte) error); ok {
+		r1 = rf(ctx, credentialID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindWebhookEndpoint provides a mock function with given fields: ctx, id
+func (_m *Store) FindWebhookEndpoint(ctx context.Context, id uuid.UUID) (store.WebhookEndpoint, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindWebhookEndpoint")
+	}
+
+	var r0 store.WebhookEndpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (store.WebhookEndpoint, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) store.WebhookEndpoint); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(store.WebhookEndpoint)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForceLoginLock provides a mock function with given fields: ctx, dto
+func (_m *Store) ForceLoginLock(ctx context.Context, dto store.LockLoginDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForceLoginLock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.LockLoginDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// HitRateLimit provides a mock function with given fields: ctx, dto
+func (_m *Store) HitRateLimit(ctx context.Context, dto store.HitRateLimitDTO) (time.Duration, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HitRateLimit")
+	}
+
+	var r0 time.Duration
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.HitRateLimitDTO) (time.Duration, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.HitRateLimitDTO) time.Duration); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.HitRateLimitDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAuthEvents provides a mock function with given fields: ctx, dto
+func (_m *Store) ListAuthEvents(ctx context.Context, dto store.ListAuthEventsDTO) ([]store.AuthEvent, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuthEvents")
+	}
+
+	var r0 []store.AuthEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListAuthEventsDTO) ([]store.AuthEvent, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListAuthEventsDTO) []store.AuthEvent); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.AuthEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.ListAuthEventsDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListConfirmedMFAFactors provides a mock function with given fields: ctx, userID
+func (_m *Store) ListConfirmedMFAFactors(ctx context.Context, userID uuid.UUID) ([]store.MFAFactor, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListConfirmedMFAFactors")
+	}
+
+	var r0 []store.MFAFactor
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]store.MFAFactor, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []store.MFAFactor); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.MFAFactor)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOutboxMessages provides a mock function with given fields: ctx, dto
+func (_m *Store) ListOutboxMessages(ctx context.Context, dto store.ListOutboxMessagesDTO) ([]store.OutboxMessage, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOutboxMessages")
+	}
+
+	var r0 []store.OutboxMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListOutboxMessagesDTO) ([]store.OutboxMessage, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListOutboxMessagesDTO) []store.OutboxMessage); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.OutboxMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.ListOutboxMessagesDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, dto
+func (_m *Store) ListUsers(ctx context.Context, dto store.ListUsersDTO) ([]store.User, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []store.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListUsersDTO) ([]store.User, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListUsersDTO) []store.User); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.ListUsersDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebAuthnCredentials provides a mock function with given fields: ctx, userID
+func (_m *Store) ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]store.WebAuthnCredential, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebAuthnCredentials")
+	}
+
+	var r0 []store.WebAuthnCredential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) ([]store.WebAuthnCredential, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []store.WebAuthnCredential); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.WebAuthnCredential)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookEndpoints provides a mock function with given fields: ctx
+func (_m *Store) ListWebhookEndpoints(ctx context.Context) ([]store.WebhookEndpoint, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookEndpoints")
+	}
+
+	var r0 []store.WebhookEndpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]store.WebhookEndpoint, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []store.WebhookEndpoint); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.WebhookEndpoint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookEndpointsForEvent provides a mock function with given fields: ctx, eventType
+func (_m *Store) ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]store.WebhookEndpoint, error) {
+	ret := _m.Called(ctx, eventType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookEndpointsForEvent")
+	}
+
+	var r0 []store.WebhookEndpoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]store.WebhookEndpoint, error)); ok {
+		return rf(ctx, eventType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []store.WebhookEndpoint); ok {
+		r0 = rf(ctx, eventType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]store.WebhookEndpoint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, eventType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
 
-	uuid "github.com/google/uuid"
-)
+// LockLogin provides a mock function with given fields: ctx, dto
+func (_m *Store) LockLogin(ctx context.Context, dto store.LockLoginDTO) error {
+	ret := _m.Called(ctx, dto)
 
-// Store is an autogenerated mock type for the Store type
-type Store struct {
-	mock.Mock
+	if len(ret) == 0 {
+		panic("no return value specified for LockLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.LockLoginDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// CreateEmailConfirmation provides a mock function with given fields: ctx, dto
-func (_m *Store) CreateEmailConfirmation(ctx context.Context, dto store.CreateEmailConfirmationDTO) (uuid.UUID, error) {
+// MarkOutboxMessageFailed provides a mock function with given fields: ctx, dto
+func (_m *Store) MarkOutboxMessageFailed(ctx context.Context, dto store.MarkOutboxMessageFailedDTO) error {
 	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateEmailConfirmation")
+		panic("no return value specified for MarkOutboxMessageFailed")
 	}
 
-	var r0 uuid.UUID
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.MarkOutboxMessageFailedDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkOutboxMessageSent provides a mock function with given fields: ctx, msg
+func (_m *Store) MarkOutboxMessageSent(ctx context.Context, msg store.OutboxMessage) error {
+	ret := _m.Called(ctx, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOutboxMessageSent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.OutboxMessage) error); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PgTx provides a mock function with given fields: ctx, handler
+func (_m *Store) PgTx(ctx context.Context, handler func(pgx.Tx, store.Store) error) error {
+	ret := _m.Called(ctx, handler)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PgTx")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(pgx.Tx, store.Store) error) error); ok {
+		r0 = rf(ctx, handler)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordLoginFailure provides a mock function with given fields: ctx, dto
+func (_m *Store) RecordLoginFailure(ctx context.Context, dto store.RecordLoginFailureDTO) (store.LoginFailures, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLoginFailure")
+	}
+
+	var r0 store.LoginFailures
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateEmailConfirmationDTO) (uuid.UUID, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RecordLoginFailureDTO) (store.LoginFailures, error)); ok {
 		return rf(ctx, dto)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateEmailConfirmationDTO) uuid.UUID); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RecordLoginFailureDTO) store.LoginFailures); ok {
 		r0 = rf(ctx, dto)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(uuid.UUID)
-		}
+		r0 = ret.Get(0).(store.LoginFailures)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, store.CreateEmailConfirmationDTO) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, store.RecordLoginFailureDTO) error); ok {
 		r1 = rf(ctx, dto)
 	} else {
 		r1 = ret.Error(1)
@@ -48,20 +1320,56 @@ func (_m *Store) CreateEmailConfirmation(ctx context.Context, dto store.CreateEm
 	return r0, r1
 }
 
-// CreateToken provides a mock function with given fields: ctx, dto
-func (_m *Store) CreateToken(ctx context.Context, dto store.CreateTokenDTO) (uuid.UUID, error) {
+// RecordWebhookFailure provides a mock function with given fields: ctx, dto
+func (_m *Store) RecordWebhookFailure(ctx context.Context, dto store.RecordWebhookFailureDTO) error {
 	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateToken")
+		panic("no return value specified for RecordWebhookFailure")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.RecordWebhookFailureDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordWebhookSuccess provides a mock function with given fields: ctx, id
+func (_m *Store) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordWebhookSuccess")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterUserWithConfirmation provides a mock function with given fields: ctx, dto
+func (_m *Store) RegisterUserWithConfirmation(ctx context.Context, dto store.RegisterUserWithConfirmationDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RegisterUserWithConfirmation")
 	}
 
 	var r0 uuid.UUID
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateTokenDTO) (uuid.UUID, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RegisterUserWithConfirmationDTO) (uuid.UUID, error)); ok {
 		return rf(ctx, dto)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateTokenDTO) uuid.UUID); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RegisterUserWithConfirmationDTO) uuid.UUID); ok {
 		r0 = rf(ctx, dto)
 	} else {
 		if ret.Get(0) != nil {
@@ -69,7 +1377,7 @@ func (_m *Store) CreateToken(ctx context.Context, dto store.CreateTokenDTO) (uui
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, store.CreateTokenDTO) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, store.RegisterUserWithConfirmationDTO) error); ok {
 		r1 = rf(ctx, dto)
 	} else {
 		r1 = ret.Error(1)
@@ -78,20 +1386,20 @@ func (_m *Store) CreateToken(ctx context.Context, dto store.CreateTokenDTO) (uui
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: ctx, dto
-func (_m *Store) CreateUser(ctx context.Context, dto store.CreateUserDTO) (uuid.UUID, error) {
+// RegisterUserWithLoginCode provides a mock function with given fields: ctx, dto
+func (_m *Store) RegisterUserWithLoginCode(ctx context.Context, dto store.RegisterUserWithLoginCodeDTO) (uuid.UUID, error) {
 	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateUser")
+		panic("no return value specified for RegisterUserWithLoginCode")
 	}
 
 	var r0 uuid.UUID
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUserDTO) (uuid.UUID, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RegisterUserWithLoginCodeDTO) (uuid.UUID, error)); ok {
 		return rf(ctx, dto)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateUserDTO) uuid.UUID); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.RegisterUserWithLoginCodeDTO) uuid.UUID); ok {
 		r0 = rf(ctx, dto)
 	} else {
 		if ret.Get(0) != nil {
@@ -99,7 +1407,7 @@ func (_m *Store) CreateUser(ctx context.Context, dto store.CreateUserDTO) (uuid.
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, store.CreateUserDTO) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, store.RegisterUserWithLoginCodeDTO) error); ok {
 		r1 = rf(ctx, dto)
 	} else {
 		r1 = ret.Error(1)
@@ -108,55 +1416,63 @@ func (_m *Store) CreateUser(ctx context.Context, dto store.CreateUserDTO) (uuid.
 	return r0, r1
 }
 
-// ExistsUserByLogin provides a mock function with given fields: ctx, login
-func (_m *Store) ExistsUserByLogin(ctx context.Context, login string) (bool, error) {
-	ret := _m.Called(ctx, login)
+// ReplaceRecoveryCodes provides a mock function with given fields: ctx, dto
+func (_m *Store) ReplaceRecoveryCodes(ctx context.Context, dto store.ReplaceRecoveryCodesDTO) error {
+	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ExistsUserByLogin")
+		panic("no return value specified for ReplaceRecoveryCodes")
 	}
 
-	var r0 bool
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
-		return rf(ctx, login)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
-		r0 = rf(ctx, login)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.ReplaceRecoveryCodesDTO) error); ok {
+		r0 = rf(ctx, dto)
 	} else {
-		r0 = ret.Get(0).(bool)
+		r0 = ret.Error(0)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, login)
+	return r0
+}
+
+// RequeueOutboxMessage provides a mock function with given fields: ctx, dto
+func (_m *Store) RequeueOutboxMessage(ctx context.Context, dto store.RequeueOutboxMessageDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueOutboxMessage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.RequeueOutboxMessageDTO) error); ok {
+		r0 = rf(ctx, dto)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// FindUserByConfirmationCode provides a mock function with given fields: ctx, code
-func (_m *Store) FindUserByConfirmationCode(ctx context.Context, code string) (store.User, error) {
-	ret := _m.Called(ctx, code)
+// RevokeToken provides a mock function with given fields: ctx, token
+func (_m *Store) RevokeToken(ctx context.Context, token string) (store.Token, error) {
+	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindUserByConfirmationCode")
+		panic("no return value specified for RevokeToken")
 	}
 
-	var r0 store.User
+	var r0 store.Token
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (store.User, error)); ok {
-		return rf(ctx, code)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (store.Token, error)); ok {
+		return rf(ctx, token)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) store.User); ok {
-		r0 = rf(ctx, code)
+	if rf, ok := ret.Get(0).(func(context.Context, string) store.Token); ok {
+		r0 = rf(ctx, token)
 	} else {
-		r0 = ret.Get(0).(store.User)
+		r0 = ret.Get(0).(store.Token)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, code)
+		r1 = rf(ctx, token)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -164,27 +1480,27 @@ func (_m *Store) FindUserByConfirmationCode(ctx context.Context, code string) (s
 	return r0, r1
 }
 
-// FindUserByEmail provides a mock function with given fields: ctx, email
-func (_m *Store) FindUserByEmail(ctx context.Context, email string) (store.User, error) {
-	ret := _m.Called(ctx, email)
+// RevokeUserTokens provides a mock function with given fields: ctx, userID
+func (_m *Store) RevokeUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FindUserByEmail")
+		panic("no return value specified for RevokeUserTokens")
 	}
 
-	var r0 store.User
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (store.User, error)); ok {
-		return rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (int64, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) store.User); ok {
-		r0 = rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) int64); ok {
+		r0 = rf(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(store.User)
+		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, email)
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -192,17 +1508,17 @@ func (_m *Store) FindUserByEmail(ctx context.Context, email string) (store.User,
 	return r0, r1
 }
 
-// PgTx provides a mock function with given fields: ctx, handler
-func (_m *Store) PgTx(ctx context.Context, handler func(pgx.Tx, store.Store) error) error {
-	ret := _m.Called(ctx, handler)
+// SetMFAChallengeEmailCode provides a mock function with given fields: ctx, dto
+func (_m *Store) SetMFAChallengeEmailCode(ctx context.Context, dto store.SetMFAChallengeEmailCodeDTO) error {
+	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for PgTx")
+		panic("no return value specified for SetMFAChallengeEmailCode")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, func(pgx.Tx, store.Store) error) error); ok {
-		r0 = rf(ctx, handler)
+	if rf, ok := ret.Get(0).(func(context.Context, store.SetMFAChallengeEmailCodeDTO) error); ok {
+		r0 = rf(ctx, dto)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -210,16 +1526,46 @@ func (_m *Store) PgTx(ctx context.Context, handler func(pgx.Tx, store.Store) err
 	return r0
 }
 
-// RegisterUserWithConfirmation provides a mock function with given fields: ctx, dto
-func (_m *Store) RegisterUserWithConfirmation(ctx context.Context, dto store.RegisterUserWithConfirmationDTO) error {
+// UnlockAccountWithCode provides a mock function with given fields: ctx, code
+func (_m *Store) UnlockAccountWithCode(ctx context.Context, code string) (uuid.UUID, error) {
+	ret := _m.Called(ctx, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnlockAccountWithCode")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (uuid.UUID, error)); ok {
+		return rf(ctx, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) uuid.UUID); ok {
+		r0 = rf(ctx, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMFAFactorLastUsedStep provides a mock function with given fields: ctx, dto
+func (_m *Store) UpdateMFAFactorLastUsedStep(ctx context.Context, dto store.UseMFAFactorStepDTO) error {
 	ret := _m.Called(ctx, dto)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RegisterUserWithConfirmation")
+		panic("no return value specified for UpdateMFAFactorLastUsedStep")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, store.RegisterUserWithConfirmationDTO) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.UseMFAFactorStepDTO) error); ok {
 		r0 = rf(ctx, dto)
 	} else {
 		r0 = ret.Error(0)
@@ -264,6 +1610,72 @@ func (_m *Store) UpdateUserPassword(ctx context.Context, dto store.UpdateUserPas
 	return r0
 }
 
+// UpdateUserPreferredMFAFactor provides a mock function with given fields: ctx, dto
+func (_m *Store) UpdateUserPreferredMFAFactor(ctx context.Context, dto store.UpdateUserPreferredMFAFactorDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserPreferredMFAFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.UpdateUserPreferredMFAFactorDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebAuthnCredentialSignCount provides a mock function with given fields: ctx, dto
+func (_m *Store) UpdateWebAuthnCredentialSignCount(ctx context.Context, dto store.UpdateWebAuthnCredentialSignCountDTO) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebAuthnCredentialSignCount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.UpdateWebAuthnCredentialSignCountDTO) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpsertPendingMFAFactor provides a mock function with given fields: ctx, dto
+func (_m *Store) UpsertPendingMFAFactor(ctx context.Context, dto store.UpsertPendingMFAFactorDTO) (uuid.UUID, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertPendingMFAFactor")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, store.UpsertPendingMFAFactorDTO) (uuid.UUID, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, store.UpsertPendingMFAFactorDTO) uuid.UUID); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, store.UpsertPendingMFAFactorDTO) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewStore creates a new instance of Store. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewStore(t interface {
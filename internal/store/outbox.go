@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+type OutboxMessage pgstore.Outbox
+
+func (m OutboxMessage) Entity() entity.OutboxMessage {
+	return entity.OutboxMessage{
+		ID:            m.ID,
+		Kind:          m.Kind,
+		UserID:        m.UserID.UUID,
+		Payload:       m.Payload,
+		Status:        entity.OutboxStatus(m.Status),
+		Attempts:      int(m.Attempts),
+		LastError:     m.LastError.String,
+		NextAttemptAt: m.NextAttemptAt.Time,
+		CreatedAt:     m.CreatedAt.Time,
+		UpdatedAt:     m.UpdatedAt.Time,
+	}
+}
+
+// CreateOutboxMessageDTO is also embedded in other DTOs to enqueue a message
+// in the same transaction. UserID is then filled in by the store.
+type CreateOutboxMessageDTO struct {
+	Kind    string
+	UserID  uuid.UUID
+	Payload string
+}
+
+func (s Impl) CreateOutboxMessage(ctx context.Context, dto CreateOutboxMessageDTO) (uuid.UUID, error) {
+	id := NewUUID()
+	newID, err := s.PgStore.CreateOutboxMessage(ctx, pgstore.CreateOutboxMessageParams{
+		ID:   id,
+		Kind: dto.Kind,
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: dto.UserID != uuid.Nil,
+		},
+		Payload: dto.Payload,
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+type ClaimOutboxMessagesDTO struct {
+	Limit       int32
+	LeasedUntil time.Time
+}
+
+// ClaimOutboxMessages marks due messages in progress until LeasedUntil, when
+// other dispatchers may claim them again, and returns them with NextAttemptAt
+// set to the lease. Messages of expired leases are due again.
+func (s Impl) ClaimOutboxMessages(ctx context.Context, dto ClaimOutboxMessagesDTO) ([]OutboxMessage, error) {
+	rows, err := s.PgStore.ClaimOutboxMessages(ctx, pgstore.ClaimOutboxMessagesParams{
+		LeasedUntil: pgtype.Timestamp{
+			Time:             dto.LeasedUntil.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		BatchSize: dto.Limit,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return outboxMessages(rows), nil
+}
+
+// MarkOutboxMessageSent settles a claimed message. It does nothing once the
+// lease of msg was lost to another dispatcher.
+func (s Impl) MarkOutboxMessageSent(ctx context.Context, msg OutboxMessage) error {
+	return werr.Wrap(s.PgStore.MarkOutboxMessageSent(ctx, pgstore.MarkOutboxMessageSentParams{
+		ID:          msg.ID,
+		LeasedUntil: msg.NextAttemptAt,
+	}))
+}
+
+// MarkOutboxMessageFailedDTO settles a claimed message like MarkOutboxMessageSent.
+type MarkOutboxMessageFailedDTO struct {
+	Message       OutboxMessage
+	Dead          bool
+	LastError     string
+	NextAttemptAt time.Time
+}
+
+func (s Impl) MarkOutboxMessageFailed(ctx context.Context, dto MarkOutboxMessageFailedDTO) error {
+	status := entity.OutboxStatusPending
+	if dto.Dead {
+		status = entity.OutboxStatusDead
+	}
+
+	return werr.Wrap(s.PgStore.MarkOutboxMessageFailed(ctx, pgstore.MarkOutboxMessageFailedParams{
+		Status: string(status),
+		LastError: pgtype.Text{
+			String: dto.LastError,
+			Valid:  true,
+		},
+		NextAttemptAt: pgtype.Timestamp{
+			Time:             dto.NextAttemptAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+		ID:          dto.Message.ID,
+		LeasedUntil: dto.Message.NextAttemptAt,
+	}))
+}
+
+type ListOutboxMessagesDTO struct {
+	Kind   string
+	Status entity.OutboxStatus
+	Limit  int32
+	Offset int32
+}
+
+func (s Impl) ListOutboxMessages(ctx context.Context, dto ListOutboxMessagesDTO) ([]OutboxMessage, error) {
+	rows, err := s.PgStore.ListOutboxMessages(ctx, pgstore.ListOutboxMessagesParams{
+		Kind:   dto.Kind,
+		Status: string(dto.Status),
+		Limit:  dto.Limit,
+		Offset: dto.Offset,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return outboxMessages(rows), nil
+}
+
+type RequeueOutboxMessageDTO struct {
+	ID   uuid.UUID
+	Kind string
+}
+
+// RequeueOutboxMessage moves a dead message of the given kind back to
+// pending. It returns pgx.ErrNoRows if there is no such dead message.
+func (s Impl) RequeueOutboxMessage(ctx context.Context, dto RequeueOutboxMessageDTO) error {
+	if _, err := s.PgStore.RequeueOutboxMessage(ctx, pgstore.RequeueOutboxMessageParams{
+		ID:   dto.ID,
+		Kind: dto.Kind,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// withOutbox runs create and enqueues msg for userID in one transaction.
+func (s Impl) withOutbox(
+	ctx context.Context,
+	userID uuid.UUID,
+	msg CreateOutboxMessageDTO,
+	create func(stx Store) (uuid.UUID, error),
+) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.PgTx(ctx, func(tx pgx.Tx, stx Store) error {
+		var err error
+		if id, err = create(stx); err != nil {
+			return werr.Wrap(err)
+		}
+
+		return werr.Wrap(createOutboxMessage(ctx, stx, userID, msg))
+	})
+
+	return id, werr.Wrap(err)
+}
+
+func createOutboxMessage(ctx context.Context, stx Store, userID uuid.UUID, msg CreateOutboxMessageDTO) error {
+	msg.UserID = userID
+	_, err := stx.CreateOutboxMessage(ctx, msg)
+
+	return werr.Wrap(err)
+}
+
+func outboxMessages(rows []pgstore.Outbox) []OutboxMessage {
+	messages := make([]OutboxMessage, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, OutboxMessage(row))
+	}
+
+	return messages
+}
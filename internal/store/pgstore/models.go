@@ -9,12 +9,74 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AuthEvent struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	UserID    uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Email     string           `db:"email" json:"email"`
+	EventType string           `db:"event_type" json:"event_type"`
+	Ip        string           `db:"ip" json:"ip"`
+	UserAgent string           `db:"user_agent" json:"user_agent"`
+	Outcome   string           `db:"outcome" json:"outcome"`
+	ErrorCode string           `db:"error_code" json:"error_code"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
 type EmailConfirmation struct {
 	ID        uuid.UUID        `db:"id" json:"id"`
 	UserID    uuid.NullUUID    `db:"user_id" json:"user_id"`
 	Code      string           `db:"code" json:"code"`
 	ExpiresAt pgtype.Timestamp `db:"expires_at" json:"expires_at"`
 	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	Purpose   string           `db:"purpose" json:"purpose"`
+}
+
+type LoginThrottle struct {
+	Scope           string           `db:"scope" json:"scope"`
+	Subject         string           `db:"subject" json:"subject"`
+	Failures        int32            `db:"failures" json:"failures"`
+	Lockouts        int32            `db:"lockouts" json:"lockouts"`
+	WindowStartedAt pgtype.Timestamp `db:"window_started_at" json:"window_started_at"`
+	LockedUntil     pgtype.Timestamp `db:"locked_until" json:"locked_until"`
+	UpdatedAt       pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+}
+
+type MfaChallenge struct {
+	ID                 uuid.UUID        `db:"id" json:"id"`
+	UserID             uuid.UUID        `db:"user_id" json:"user_id"`
+	TokenHash          string           `db:"token_hash" json:"token_hash"`
+	Attempts           int32            `db:"attempts" json:"attempts"`
+	ExpiresAt          pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+	CreatedAt          pgtype.Timestamp `db:"created_at" json:"created_at"`
+	EmailCodeHash      pgtype.Text      `db:"email_code_hash" json:"email_code_hash"`
+	EmailCodeExpiresAt pgtype.Timestamp `db:"email_code_expires_at" json:"email_code_expires_at"`
+	LoginMethod        string           `db:"login_method" json:"login_method"`
+}
+
+type MfaRecoveryCode struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	UserID    uuid.UUID        `db:"user_id" json:"user_id"`
+	CodeHash  string           `db:"code_hash" json:"code_hash"`
+	UsedAt    pgtype.Timestamp `db:"used_at" json:"used_at"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+type Outbox struct {
+	ID            uuid.UUID        `db:"id" json:"id"`
+	Kind          string           `db:"kind" json:"kind"`
+	UserID        uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Payload       string           `db:"payload" json:"payload"`
+	Status        string           `db:"status" json:"status"`
+	Attempts      int32            `db:"attempts" json:"attempts"`
+	LastError     pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+}
+
+type RateLimitHit struct {
+	ID    uuid.UUID        `db:"id" json:"id"`
+	Key   string           `db:"key" json:"key"`
+	HitAt pgtype.Timestamp `db:"hit_at" json:"hit_at"`
 }
 
 type Token struct {
@@ -27,10 +89,55 @@ type Token struct {
 }
 
 type User struct {
+	ID                 uuid.UUID        `db:"id" json:"id"`
+	Email              string           `db:"email" json:"email"`
+	PasswordHash       string           `db:"password_hash" json:"password_hash"`
+	CreatedAt          pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt          pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+	IsVerified         pgtype.Bool      `db:"is_verified" json:"is_verified"`
+	PreferredMfaFactor pgtype.Text      `db:"preferred_mfa_factor" json:"preferred_mfa_factor"`
+}
+
+type UserMfaFactor struct {
 	ID           uuid.UUID        `db:"id" json:"id"`
-	Email        string           `db:"email" json:"email"`
-	PasswordHash string           `db:"password_hash" json:"password_hash"`
+	UserID       uuid.UUID        `db:"user_id" json:"user_id"`
+	FactorType   string           `db:"factor_type" json:"factor_type"`
+	Secret       string           `db:"secret" json:"secret"`
+	Confirmed    bool             `db:"confirmed" json:"confirmed"`
+	LastUsedStep int64            `db:"last_used_step" json:"last_used_step"`
 	CreatedAt    pgtype.Timestamp `db:"created_at" json:"created_at"`
 	UpdatedAt    pgtype.Timestamp `db:"updated_at" json:"updated_at"`
-	IsVerified   pgtype.Bool      `db:"is_verified" json:"is_verified"`
+}
+
+type WebauthnCredential struct {
+	ID             uuid.UUID        `db:"id" json:"id"`
+	UserID         uuid.UUID        `db:"user_id" json:"user_id"`
+	CredentialID   []byte           `db:"credential_id" json:"credential_id"`
+	PublicKey      []byte           `db:"public_key" json:"public_key"`
+	SignCount      int64            `db:"sign_count" json:"sign_count"`
+	Aaguid         []byte           `db:"aaguid" json:"aaguid"`
+	Name           string           `db:"name" json:"name"`
+	BackupEligible bool             `db:"backup_eligible" json:"backup_eligible"`
+	CreatedAt      pgtype.Timestamp `db:"created_at" json:"created_at"`
+	LastUsedAt     pgtype.Timestamp `db:"last_used_at" json:"last_used_at"`
+}
+
+type WebauthnSession struct {
+	ID            uuid.UUID        `db:"id" json:"id"`
+	UserID        uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Ceremony      string           `db:"ceremony" json:"ceremony"`
+	ChallengeHash string           `db:"challenge_hash" json:"challenge_hash"`
+	ExpiresAt     pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID         uuid.UUID        `db:"id" json:"id"`
+	URL        string           `db:"url" json:"url"`
+	Secret     string           `db:"secret" json:"secret"`
+	EventTypes []string         `db:"event_types" json:"event_types"`
+	Failures   int32            `db:"failures" json:"failures"`
+	DisabledAt pgtype.Timestamp `db:"disabled_at" json:"disabled_at"`
+	CreatedAt  pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `db:"updated_at" json:"updated_at"`
 }
@@ -8,17 +8,80 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (AttemptMFAChallengeRow, error)
+	ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]Outbox, error)
+	ConfirmMFAFactor(ctx context.Context, arg ConfirmMFAFactorParams) (bool, error)
+	ConsumeLoginCode(ctx context.Context, code string) (User, error)
+	ConsumeRecoveryCode(ctx context.Context, arg ConsumeRecoveryCodeParams) (bool, error)
+	ConsumeUnlockCode(ctx context.Context, code string) (uuid.NullUUID, error)
+	ConsumeWebAuthnSession(ctx context.Context, arg ConsumeWebAuthnSessionParams) (uuid.NullUUID, error)
+	CountRateLimitHits(ctx context.Context, key string) (CountRateLimitHitsRow, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateAuthEvent(ctx context.Context, arg CreateAuthEventParams) error
 	CreateEmailConfirmation(ctx context.Context, arg CreateEmailConfirmationParams) (uuid.UUID, error)
+	CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) (uuid.UUID, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (uuid.UUID, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (uuid.UUID, error)
+	CreateRateLimitHit(ctx context.Context, arg CreateRateLimitHitParams) error
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (uuid.UUID, error)
 	CreateToken(ctx context.Context, arg CreateTokenParams) (uuid.UUID, error)
+	CreateUnlockCode(ctx context.Context, arg CreateUnlockCodeParams) (uuid.UUID, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UUID, error)
+	CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (uuid.UUID, error)
+	CreateWebAuthnSession(ctx context.Context, arg CreateWebAuthnSessionParams) (uuid.UUID, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DeleteAuthEventsBefore(ctx context.Context, createdAt pgtype.Timestamp) (int64, error)
+	DeleteExpiredEmailConfirmations(ctx context.Context, arg DeleteExpiredEmailConfirmationsParams) (int64, error)
+	DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error)
+	DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) error
+	DeleteMFAChallenge(ctx context.Context, id uuid.UUID) error
+	DeleteRateLimitHits(ctx context.Context, arg DeleteRateLimitHitsParams) error
+	DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error
+	DeleteSentOutboxMessages(ctx context.Context, arg DeleteSentOutboxMessagesParams) (int64, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	EnableMFAFactor(ctx context.Context, arg EnableMFAFactorParams) error
+	EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
 	ExistsUserByEmail(ctx context.Context, email string) (bool, error)
+	FindLoginLock(ctx context.Context, arg FindLoginLockParams) (pgtype.Timestamp, error)
+	FindMFAChallengeUserID(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	FindMFAFactor(ctx context.Context, arg FindMFAFactorParams) (UserMfaFactor, error)
+	FindToken(ctx context.Context, token string) (Token, error)
 	FindUserByConfirmationCode(ctx context.Context, code string) (User, error)
 	FindUserByEmail(ctx context.Context, email string) (User, error)
+	FindUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	FindWebAuthnCredential(ctx context.Context, credentialID []byte) (WebauthnCredential, error)
+	FindWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error)
+	ForceLoginLock(ctx context.Context, arg ForceLoginLockParams) error
+	ListAuthEvents(ctx context.Context, arg ListAuthEventsParams) ([]AuthEvent, error)
+	ListConfirmedMFAFactors(ctx context.Context, userID uuid.UUID) ([]UserMfaFactor, error)
+	ListOutboxMessages(ctx context.Context, arg ListOutboxMessagesParams) ([]Outbox, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]WebauthnCredential, error)
+	ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error)
+	ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]WebhookEndpoint, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	LockRateLimitKey(ctx context.Context, key string) error
+	MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error
+	MarkOutboxMessageSent(ctx context.Context, arg MarkOutboxMessageSentParams) error
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (RecordLoginFailureRow, error)
+	RecordWebhookFailure(ctx context.Context, arg RecordWebhookFailureParams) error
+	RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error
+	RequeueOutboxMessage(ctx context.Context, arg RequeueOutboxMessageParams) (bool, error)
+	RevokeToken(ctx context.Context, token string) (Token, error)
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	SetMFAChallengeEmailCode(ctx context.Context, arg SetMFAChallengeEmailCodeParams) error
+	TryLockJanitor(ctx context.Context, key string) (bool, error)
+	UpdateMFAFactorLastUsedStep(ctx context.Context, arg UpdateMFAFactorLastUsedStepParams) (bool, error)
 	UpdateUserAsVerified(ctx context.Context, email string) (bool, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (bool, error)
+	UpdateUserPreferredMFAFactor(ctx context.Context, arg UpdateUserPreferredMFAFactorParams) error
+	UpdateWebAuthnCredentialSignCount(ctx context.Context, arg UpdateWebAuthnCredentialSignCountParams) error
+	UpsertPendingMFAFactor(ctx context.Context, arg UpsertPendingMFAFactorParams) (uuid.UUID, error)
 }
 
 var _ Querier = (*Queries)(nil)
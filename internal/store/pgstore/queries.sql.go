@@ -12,6 +12,261 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const attemptMFAChallenge = `-- name: AttemptMFAChallenge :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1
+  AND expires_at > timezone('utc', NOW())
+  AND attempts < $2::integer
+RETURNING id, user_id, email_code_hash, email_code_expires_at, login_method
+`
+
+type AttemptMFAChallengeParams struct {
+	TokenHash   string `db:"token_hash" json:"token_hash"`
+	MaxAttempts int32  `db:"max_attempts" json:"max_attempts"`
+}
+
+type AttemptMFAChallengeRow struct {
+	ID                 uuid.UUID        `db:"id" json:"id"`
+	UserID             uuid.UUID        `db:"user_id" json:"user_id"`
+	EmailCodeHash      pgtype.Text      `db:"email_code_hash" json:"email_code_hash"`
+	EmailCodeExpiresAt pgtype.Timestamp `db:"email_code_expires_at" json:"email_code_expires_at"`
+	LoginMethod        string           `db:"login_method" json:"login_method"`
+}
+
+func (q *Queries) AttemptMFAChallenge(ctx context.Context, arg AttemptMFAChallengeParams) (AttemptMFAChallengeRow, error) {
+	row := q.db.QueryRow(ctx, attemptMFAChallenge, arg.TokenHash, arg.MaxAttempts)
+	var i AttemptMFAChallengeRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.EmailCodeHash,
+		&i.EmailCodeExpiresAt,
+		&i.LoginMethod,
+	)
+	return i, err
+}
+
+const claimOutboxMessages = `-- name: ClaimOutboxMessages :many
+UPDATE outbox
+SET status          = 'in_progress',
+    next_attempt_at = $1,
+    updated_at      = timezone('utc', NOW())
+WHERE id IN (SELECT id
+             FROM outbox
+             WHERE status IN ('pending', 'in_progress')
+               AND next_attempt_at <= timezone('utc', NOW())
+             ORDER BY next_attempt_at
+             LIMIT $2 FOR UPDATE SKIP LOCKED)
+RETURNING id, kind, user_id, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at
+`
+
+type ClaimOutboxMessagesParams struct {
+	LeasedUntil pgtype.Timestamp `db:"leased_until" json:"leased_until"`
+	BatchSize   int32            `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, claimOutboxMessages, arg.LeasedUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const confirmMFAFactor = `-- name: ConfirmMFAFactor :one
+UPDATE user_mfa_factors
+SET confirmed      = TRUE,
+    last_used_step = $1,
+    updated_at     = timezone('utc', NOW())
+WHERE id = $2
+  AND confirmed = FALSE
+RETURNING TRUE AS updated
+`
+
+type ConfirmMFAFactorParams struct {
+	LastUsedStep int64     `db:"last_used_step" json:"last_used_step"`
+	ID           uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *Queries) ConfirmMFAFactor(ctx context.Context, arg ConfirmMFAFactorParams) (bool, error) {
+	row := q.db.QueryRow(ctx, confirmMFAFactor, arg.LastUsedStep, arg.ID)
+	var updated bool
+	err := row.Scan(&updated)
+	return updated, err
+}
+
+const consumeLoginCode = `-- name: ConsumeLoginCode :one
+WITH consumed AS (
+    DELETE FROM email_confirmations ec
+    WHERE ec.code = $1
+      AND ec.purpose = 'login'
+      AND ec.expires_at > timezone('utc', NOW())
+    RETURNING ec.user_id
+)
+SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified, u.preferred_mfa_factor
+FROM users u
+JOIN consumed c ON u.id = c.user_id
+`
+
+func (q *Queries) ConsumeLoginCode(ctx context.Context, code string) (User, error) {
+	row := q.db.QueryRow(ctx, consumeLoginCode, code)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsVerified,
+		&i.PreferredMfaFactor,
+	)
+	return i, err
+}
+
+const consumeRecoveryCode = `-- name: ConsumeRecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = timezone('utc', NOW())
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING TRUE AS updated
+`
+
+type ConsumeRecoveryCodeParams struct {
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	CodeHash string    `db:"code_hash" json:"code_hash"`
+}
+
+func (q *Queries) ConsumeRecoveryCode(ctx context.Context, arg ConsumeRecoveryCodeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, consumeRecoveryCode, arg.UserID, arg.CodeHash)
+	var updated bool
+	err := row.Scan(&updated)
+	return updated, err
+}
+
+const consumeUnlockCode = `-- name: ConsumeUnlockCode :one
+DELETE
+FROM email_confirmations
+WHERE code = $1
+  AND purpose = 'unlock'
+  AND expires_at > timezone('utc', NOW())
+RETURNING user_id
+`
+
+func (q *Queries) ConsumeUnlockCode(ctx context.Context, code string) (uuid.NullUUID, error) {
+	row := q.db.QueryRow(ctx, consumeUnlockCode, code)
+	var user_id uuid.NullUUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const consumeWebAuthnSession = `-- name: ConsumeWebAuthnSession :one
+DELETE
+FROM webauthn_sessions
+WHERE challenge_hash = $1
+  AND ceremony = $2
+  AND expires_at > timezone('utc', NOW())
+RETURNING user_id
+`
+
+type ConsumeWebAuthnSessionParams struct {
+	ChallengeHash string `db:"challenge_hash" json:"challenge_hash"`
+	Ceremony      string `db:"ceremony" json:"ceremony"`
+}
+
+func (q *Queries) ConsumeWebAuthnSession(ctx context.Context, arg ConsumeWebAuthnSessionParams) (uuid.NullUUID, error) {
+	row := q.db.QueryRow(ctx, consumeWebAuthnSession, arg.ChallengeHash, arg.Ceremony)
+	var user_id uuid.NullUUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const countRateLimitHits = `-- name: CountRateLimitHits :one
+SELECT COUNT(*) AS hits, MIN(hit_at)::timestamp AS oldest_hit_at
+FROM rate_limit_hits
+WHERE key = $1
+`
+
+type CountRateLimitHitsRow struct {
+	Hits        int64            `db:"hits" json:"hits"`
+	OldestHitAt pgtype.Timestamp `db:"oldest_hit_at" json:"oldest_hit_at"`
+}
+
+func (q *Queries) CountRateLimitHits(ctx context.Context, key string) (CountRateLimitHitsRow, error) {
+	row := q.db.QueryRow(ctx, countRateLimitHits, key)
+	var i CountRateLimitHitsRow
+	err := row.Scan(&i.Hits, &i.OldestHitAt)
+	return i, err
+}
+
+const countRecoveryCodes = `-- name: CountRecoveryCodes :one
+SELECT COUNT(*)
+FROM mfa_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL
+`
+
+func (q *Queries) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecoveryCodes, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuthEvent = `-- name: CreateAuthEvent :exec
+INSERT INTO auth_events(id, user_id, email, event_type, ip, user_agent, outcome, error_code)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateAuthEventParams struct {
+	ID        uuid.UUID     `db:"id" json:"id"`
+	UserID    uuid.NullUUID `db:"user_id" json:"user_id"`
+	Email     string        `db:"email" json:"email"`
+	EventType string        `db:"event_type" json:"event_type"`
+	Ip        string        `db:"ip" json:"ip"`
+	UserAgent string        `db:"user_agent" json:"user_agent"`
+	Outcome   string        `db:"outcome" json:"outcome"`
+	ErrorCode string        `db:"error_code" json:"error_code"`
+}
+
+func (q *Queries) CreateAuthEvent(ctx context.Context, arg CreateAuthEventParams) error {
+	_, err := q.db.Exec(ctx, createAuthEvent,
+		arg.ID,
+		arg.UserID,
+		arg.Email,
+		arg.EventType,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Outcome,
+		arg.ErrorCode,
+	)
+	return err
+}
+
 const createEmailConfirmation = `-- name: CreateEmailConfirmation :one
 INSERT INTO email_confirmations(id, user_id, code, expires_at)
 VALUES ($1, $2, $3, $4)
@@ -37,6 +292,118 @@ func (q *Queries) CreateEmailConfirmation(ctx context.Context, arg CreateEmailCo
 	return id, err
 }
 
+const createLoginCode = `-- name: CreateLoginCode :one
+INSERT INTO email_confirmations(id, user_id, code, expires_at, purpose)
+VALUES ($1, $2, $3, $4, 'login')
+RETURNING id
+`
+
+type CreateLoginCodeParams struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	UserID    uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Code      string           `db:"code" json:"code"`
+	ExpiresAt pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateLoginCode(ctx context.Context, arg CreateLoginCodeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createLoginCode,
+		arg.ID,
+		arg.UserID,
+		arg.Code,
+		arg.ExpiresAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createMFAChallenge = `-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges(id, user_id, token_hash, expires_at, login_method)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreateMFAChallengeParams struct {
+	ID          uuid.UUID        `db:"id" json:"id"`
+	UserID      uuid.UUID        `db:"user_id" json:"user_id"`
+	TokenHash   string           `db:"token_hash" json:"token_hash"`
+	ExpiresAt   pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+	LoginMethod string           `db:"login_method" json:"login_method"`
+}
+
+func (q *Queries) CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createMFAChallenge,
+		arg.ID,
+		arg.UserID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.LoginMethod,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createOutboxMessage = `-- name: CreateOutboxMessage :one
+INSERT INTO outbox(id, kind, user_id, payload)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+
+type CreateOutboxMessageParams struct {
+	ID      uuid.UUID     `db:"id" json:"id"`
+	Kind    string        `db:"kind" json:"kind"`
+	UserID  uuid.NullUUID `db:"user_id" json:"user_id"`
+	Payload string        `db:"payload" json:"payload"`
+}
+
+func (q *Queries) CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createOutboxMessage,
+		arg.ID,
+		arg.Kind,
+		arg.UserID,
+		arg.Payload,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createRateLimitHit = `-- name: CreateRateLimitHit :exec
+INSERT INTO rate_limit_hits(id, key, hit_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateRateLimitHitParams struct {
+	ID    uuid.UUID        `db:"id" json:"id"`
+	Key   string           `db:"key" json:"key"`
+	HitAt pgtype.Timestamp `db:"hit_at" json:"hit_at"`
+}
+
+func (q *Queries) CreateRateLimitHit(ctx context.Context, arg CreateRateLimitHitParams) error {
+	_, err := q.db.Exec(ctx, createRateLimitHit, arg.ID, arg.Key, arg.HitAt)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO mfa_recovery_codes(id, user_id, code_hash)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type CreateRecoveryCodeParams struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	UserID   uuid.UUID `db:"user_id" json:"user_id"`
+	CodeHash string    `db:"code_hash" json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createRecoveryCode, arg.ID, arg.UserID, arg.CodeHash)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createToken = `-- name: CreateToken :one
 INSERT INTO tokens(id, user_id, token, expires_at)
 VALUES ($1, $2, $3, $4)
@@ -62,6 +429,31 @@ func (q *Queries) CreateToken(ctx context.Context, arg CreateTokenParams) (uuid.
 	return id, err
 }
 
+const createUnlockCode = `-- name: CreateUnlockCode :one
+INSERT INTO email_confirmations(id, user_id, code, expires_at, purpose)
+VALUES ($1, $2, $3, $4, 'unlock')
+RETURNING id
+`
+
+type CreateUnlockCodeParams struct {
+	ID        uuid.UUID        `db:"id" json:"id"`
+	UserID    uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Code      string           `db:"code" json:"code"`
+	ExpiresAt pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateUnlockCode(ctx context.Context, arg CreateUnlockCodeParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createUnlockCode,
+		arg.ID,
+		arg.UserID,
+		arg.Code,
+		arg.ExpiresAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, email, password_hash, created_at, updated_at)
 VALUES ($1, $2, $3, timezone('utc', now()), timezone('utc', now()))
@@ -81,6 +473,297 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UU
 	return id, err
 }
 
+const createWebAuthnCredential = `-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials(id, user_id, credential_id, public_key, sign_count, aaguid, name, backup_eligible)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id
+`
+
+type CreateWebAuthnCredentialParams struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	CredentialID   []byte    `db:"credential_id" json:"credential_id"`
+	PublicKey      []byte    `db:"public_key" json:"public_key"`
+	SignCount      int64     `db:"sign_count" json:"sign_count"`
+	Aaguid         []byte    `db:"aaguid" json:"aaguid"`
+	Name           string    `db:"name" json:"name"`
+	BackupEligible bool      `db:"backup_eligible" json:"backup_eligible"`
+}
+
+func (q *Queries) CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createWebAuthnCredential,
+		arg.ID,
+		arg.UserID,
+		arg.CredentialID,
+		arg.PublicKey,
+		arg.SignCount,
+		arg.Aaguid,
+		arg.Name,
+		arg.BackupEligible,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createWebAuthnSession = `-- name: CreateWebAuthnSession :one
+INSERT INTO webauthn_sessions(id, user_id, ceremony, challenge_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreateWebAuthnSessionParams struct {
+	ID            uuid.UUID        `db:"id" json:"id"`
+	UserID        uuid.NullUUID    `db:"user_id" json:"user_id"`
+	Ceremony      string           `db:"ceremony" json:"ceremony"`
+	ChallengeHash string           `db:"challenge_hash" json:"challenge_hash"`
+	ExpiresAt     pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+}
+
+func (q *Queries) CreateWebAuthnSession(ctx context.Context, arg CreateWebAuthnSessionParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, createWebAuthnSession,
+		arg.ID,
+		arg.UserID,
+		arg.Ceremony,
+		arg.ChallengeHash,
+		arg.ExpiresAt,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints(id, url, secret, event_types)
+VALUES ($1, $2, $3, $4)
+RETURNING id, url, secret, event_types, failures, disabled_at, created_at, updated_at
+`
+
+type CreateWebhookEndpointParams struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	URL        string    `db:"url" json:"url"`
+	Secret     string    `db:"secret" json:"secret"`
+	EventTypes []string  `db:"event_types" json:"event_types"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.ID,
+		arg.URL,
+		arg.Secret,
+		arg.EventTypes,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.URL,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Failures,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteAuthEventsBefore = `-- name: DeleteAuthEventsBefore :execrows
+DELETE
+FROM auth_events
+WHERE created_at < $1
+`
+
+func (q *Queries) DeleteAuthEventsBefore(ctx context.Context, createdAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAuthEventsBefore, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredEmailConfirmations = `-- name: DeleteExpiredEmailConfirmations :execrows
+DELETE
+FROM email_confirmations
+WHERE id IN (SELECT ec.id
+             FROM email_confirmations ec
+             WHERE ec.expires_at < $1
+             LIMIT $2 FOR UPDATE SKIP LOCKED)
+`
+
+type DeleteExpiredEmailConfirmationsParams struct {
+	Before    pgtype.Timestamp `db:"before" json:"before"`
+	BatchSize int32            `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteExpiredEmailConfirmations(ctx context.Context, arg DeleteExpiredEmailConfirmationsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredEmailConfirmations, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredTokens = `-- name: DeleteExpiredTokens :execrows
+DELETE
+FROM tokens
+WHERE id IN (SELECT t.id
+             FROM tokens t
+             WHERE t.expires_at < $1
+             LIMIT $2 FOR UPDATE SKIP LOCKED)
+`
+
+type DeleteExpiredTokensParams struct {
+	Before    pgtype.Timestamp `db:"before" json:"before"`
+	BatchSize int32            `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteExpiredTokens(ctx context.Context, arg DeleteExpiredTokensParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredTokens, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoginThrottle = `-- name: DeleteLoginThrottle :exec
+DELETE
+FROM login_throttles
+WHERE scope = $1
+  AND subject = $2
+`
+
+type DeleteLoginThrottleParams struct {
+	Scope   string `db:"scope" json:"scope"`
+	Subject string `db:"subject" json:"subject"`
+}
+
+func (q *Queries) DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) error {
+	_, err := q.db.Exec(ctx, deleteLoginThrottle, arg.Scope, arg.Subject)
+	return err
+}
+
+const deleteMFAChallenge = `-- name: DeleteMFAChallenge :exec
+DELETE
+FROM mfa_challenges
+WHERE id = $1
+`
+
+func (q *Queries) DeleteMFAChallenge(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMFAChallenge, id)
+	return err
+}
+
+const deleteRateLimitHits = `-- name: DeleteRateLimitHits :exec
+DELETE
+FROM rate_limit_hits
+WHERE key = $1
+  AND hit_at <= $2
+`
+
+type DeleteRateLimitHitsParams struct {
+	Key         string           `db:"key" json:"key"`
+	WindowStart pgtype.Timestamp `db:"window_start" json:"window_start"`
+}
+
+func (q *Queries) DeleteRateLimitHits(ctx context.Context, arg DeleteRateLimitHitsParams) error {
+	_, err := q.db.Exec(ctx, deleteRateLimitHits, arg.Key, arg.WindowStart)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE
+FROM mfa_recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteSentOutboxMessages = `-- name: DeleteSentOutboxMessages :execrows
+DELETE
+FROM outbox
+WHERE id IN (SELECT o.id
+             FROM outbox o
+             WHERE o.status = 'sent'
+               AND o.updated_at < $1
+             LIMIT $2 FOR UPDATE SKIP LOCKED)
+`
+
+type DeleteSentOutboxMessagesParams struct {
+	Before    pgtype.Timestamp `db:"before" json:"before"`
+	BatchSize int32            `db:"batch_size" json:"batch_size"`
+}
+
+func (q *Queries) DeleteSentOutboxMessages(ctx context.Context, arg DeleteSentOutboxMessagesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSentOutboxMessages, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUser = `-- name: DeleteUser :one
+DELETE
+FROM users
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, deleteUser, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteWebhookEndpoint = `-- name: DeleteWebhookEndpoint :one
+DELETE
+FROM webhook_endpoints
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, deleteWebhookEndpoint, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const enableMFAFactor = `-- name: EnableMFAFactor :exec
+INSERT INTO user_mfa_factors(id, user_id, factor_type, secret, confirmed)
+VALUES ($1, $2, $3, '', TRUE)
+ON CONFLICT (user_id, factor_type) DO UPDATE
+    SET confirmed  = TRUE,
+        updated_at = timezone('utc', NOW())
+`
+
+type EnableMFAFactorParams struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	FactorType string    `db:"factor_type" json:"factor_type"`
+}
+
+func (q *Queries) EnableMFAFactor(ctx context.Context, arg EnableMFAFactorParams) error {
+	_, err := q.db.Exec(ctx, enableMFAFactor, arg.ID, arg.UserID, arg.FactorType)
+	return err
+}
+
+const enableWebhookEndpoint = `-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET failures    = 0,
+    disabled_at = NULL,
+    updated_at  = timezone('utc', NOW())
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, enableWebhookEndpoint, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
 const existsUserByEmail = `-- name: ExistsUserByEmail :one
 SELECT EXISTS(
     SELECT 1
@@ -96,10 +779,91 @@ func (q *Queries) ExistsUserByEmail(ctx context.Context, email string) (bool, er
 	return exists, err
 }
 
+const findLoginLock = `-- name: FindLoginLock :one
+SELECT locked_until
+FROM login_throttles
+WHERE scope = $1
+  AND subject = $2
+  AND locked_until > timezone('utc', NOW())
+`
+
+type FindLoginLockParams struct {
+	Scope   string `db:"scope" json:"scope"`
+	Subject string `db:"subject" json:"subject"`
+}
+
+func (q *Queries) FindLoginLock(ctx context.Context, arg FindLoginLockParams) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, findLoginLock, arg.Scope, arg.Subject)
+	var locked_until pgtype.Timestamp
+	err := row.Scan(&locked_until)
+	return locked_until, err
+}
+
+const findMFAChallengeUserID = `-- name: FindMFAChallengeUserID :one
+SELECT user_id
+FROM mfa_challenges
+WHERE token_hash = $1
+`
+
+func (q *Queries) FindMFAChallengeUserID(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, findMFAChallengeUserID, tokenHash)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const findMFAFactor = `-- name: FindMFAFactor :one
+SELECT id, user_id, factor_type, secret, confirmed, last_used_step, created_at, updated_at
+FROM user_mfa_factors
+WHERE user_id = $1
+  AND factor_type = $2
+`
+
+type FindMFAFactorParams struct {
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	FactorType string    `db:"factor_type" json:"factor_type"`
+}
+
+func (q *Queries) FindMFAFactor(ctx context.Context, arg FindMFAFactorParams) (UserMfaFactor, error) {
+	row := q.db.QueryRow(ctx, findMFAFactor, arg.UserID, arg.FactorType)
+	var i UserMfaFactor
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FactorType,
+		&i.Secret,
+		&i.Confirmed,
+		&i.LastUsedStep,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const findToken = `-- name: FindToken :one
+SELECT id, user_id, token, revoked, expires_at, created_at
+FROM tokens
+WHERE token = $1
+`
+
+func (q *Queries) FindToken(ctx context.Context, token string) (Token, error) {
+	row := q.db.QueryRow(ctx, findToken, token)
+	var i Token
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Token,
+		&i.Revoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const findUserByConfirmationCode = `-- name: FindUserByConfirmationCode :one
-SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified
+SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified, u.preferred_mfa_factor
 FROM users u
-WHERE id = (SELECT ec.user_id FROM email_confirmations ec WHERE code = $1)
+WHERE id = (SELECT ec.user_id FROM email_confirmations ec WHERE code = $1 AND ec.purpose = 'confirmation')
 `
 
 func (q *Queries) FindUserByConfirmationCode(ctx context.Context, code string) (User, error) {
@@ -112,12 +876,13 @@ func (q *Queries) FindUserByConfirmationCode(ctx context.Context, code string) (
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsVerified,
+		&i.PreferredMfaFactor,
 	)
 	return i, err
 }
 
 const findUserByEmail = `-- name: FindUserByEmail :one
-SELECT id, email, password_hash, created_at, updated_at, is_verified
+SELECT id, email, password_hash, created_at, updated_at, is_verified, preferred_mfa_factor
 FROM users
 WHERE email = $1
 LIMIT 1
@@ -133,10 +898,651 @@ func (q *Queries) FindUserByEmail(ctx context.Context, email string) (User, erro
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsVerified,
+		&i.PreferredMfaFactor,
+	)
+	return i, err
+}
+
+const findUserByID = `-- name: FindUserByID :one
+SELECT id, email, password_hash, created_at, updated_at, is_verified, preferred_mfa_factor
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) FindUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, findUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsVerified,
+		&i.PreferredMfaFactor,
+	)
+	return i, err
+}
+
+const findWebAuthnCredential = `-- name: FindWebAuthnCredential :one
+SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, backup_eligible, created_at, last_used_at
+FROM webauthn_credentials
+WHERE credential_id = $1
+`
+
+func (q *Queries) FindWebAuthnCredential(ctx context.Context, credentialID []byte) (WebauthnCredential, error) {
+	row := q.db.QueryRow(ctx, findWebAuthnCredential, credentialID)
+	var i WebauthnCredential
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CredentialID,
+		&i.PublicKey,
+		&i.SignCount,
+		&i.Aaguid,
+		&i.Name,
+		&i.BackupEligible,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const findWebhookEndpoint = `-- name: FindWebhookEndpoint :one
+SELECT id, url, secret, event_types, failures, disabled_at, created_at, updated_at
+FROM webhook_endpoints
+WHERE id = $1
+`
+
+func (q *Queries) FindWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, findWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.URL,
+		&i.Secret,
+		&i.EventTypes,
+		&i.Failures,
+		&i.DisabledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const forceLoginLock = `-- name: ForceLoginLock :exec
+INSERT INTO login_throttles (scope, subject, window_started_at, locked_until)
+VALUES ($1, $2, timezone('utc', NOW()), $3)
+ON CONFLICT (scope, subject) DO UPDATE
+    SET failures     = 0,
+        locked_until = EXCLUDED.locked_until,
+        updated_at   = timezone('utc', NOW())
+`
+
+type ForceLoginLockParams struct {
+	Scope       string           `db:"scope" json:"scope"`
+	Subject     string           `db:"subject" json:"subject"`
+	LockedUntil pgtype.Timestamp `db:"locked_until" json:"locked_until"`
+}
+
+func (q *Queries) ForceLoginLock(ctx context.Context, arg ForceLoginLockParams) error {
+	_, err := q.db.Exec(ctx, forceLoginLock, arg.Scope, arg.Subject, arg.LockedUntil)
+	return err
+}
+
+const listAuthEvents = `-- name: ListAuthEvents :many
+SELECT id, user_id, email, event_type, ip, user_agent, outcome, error_code, created_at
+FROM auth_events
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY created_at DESC
+LIMIT $5 OFFSET $4
+`
+
+type ListAuthEventsParams struct {
+	UserID      uuid.NullUUID    `db:"user_id" json:"user_id"`
+	CreatedFrom pgtype.Timestamp `db:"created_from" json:"created_from"`
+	CreatedTo   pgtype.Timestamp `db:"created_to" json:"created_to"`
+	RowOffset   int32            `db:"row_offset" json:"row_offset"`
+	RowLimit    int32            `db:"row_limit" json:"row_limit"`
+}
+
+func (q *Queries) ListAuthEvents(ctx context.Context, arg ListAuthEventsParams) ([]AuthEvent, error) {
+	rows, err := q.db.Query(ctx, listAuthEvents,
+		arg.UserID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuthEvent{}
+	for rows.Next() {
+		var i AuthEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Email,
+			&i.EventType,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Outcome,
+			&i.ErrorCode,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConfirmedMFAFactors = `-- name: ListConfirmedMFAFactors :many
+SELECT id, user_id, factor_type, secret, confirmed, last_used_step, created_at, updated_at
+FROM user_mfa_factors
+WHERE user_id = $1
+  AND confirmed = TRUE
+ORDER BY created_at
+`
+
+func (q *Queries) ListConfirmedMFAFactors(ctx context.Context, userID uuid.UUID) ([]UserMfaFactor, error) {
+	rows, err := q.db.Query(ctx, listConfirmedMFAFactors, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserMfaFactor{}
+	for rows.Next() {
+		var i UserMfaFactor
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.FactorType,
+			&i.Secret,
+			&i.Confirmed,
+			&i.LastUsedStep,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboxMessages = `-- name: ListOutboxMessages :many
+SELECT id, kind, user_id, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at
+FROM outbox
+WHERE kind = $1
+  AND status = $2
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListOutboxMessagesParams struct {
+	Kind   string `db:"kind" json:"kind"`
+	Status string `db:"status" json:"status"`
+	Limit  int32  `db:"limit" json:"limit"`
+	Offset int32  `db:"offset" json:"offset"`
+}
+
+func (q *Queries) ListOutboxMessages(ctx context.Context, arg ListOutboxMessagesParams) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, listOutboxMessages,
+		arg.Kind,
+		arg.Status,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Outbox{}
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.UserID,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, email, password_hash, created_at, updated_at, is_verified, preferred_mfa_factor
+FROM users
+WHERE email ILIKE '%' || $1::text || '%' ESCAPE '\'
+ORDER BY created_at, id
+LIMIT $3 OFFSET $2
+`
+
+type ListUsersParams struct {
+	Search    string `db:"search" json:"search"`
+	RowOffset int32  `db:"row_offset" json:"row_offset"`
+	RowLimit  int32  `db:"row_limit" json:"row_limit"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsers, arg.Search, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsVerified,
+			&i.PreferredMfaFactor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebAuthnCredentials = `-- name: ListWebAuthnCredentials :many
+SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, backup_eligible, created_at, last_used_at
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]WebauthnCredential, error) {
+	rows, err := q.db.Query(ctx, listWebAuthnCredentials, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebauthnCredential{}
+	for rows.Next() {
+		var i WebauthnCredential
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CredentialID,
+			&i.PublicKey,
+			&i.SignCount,
+			&i.Aaguid,
+			&i.Name,
+			&i.BackupEligible,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
+SELECT id, url, secret, event_types, failures, disabled_at, created_at, updated_at
+FROM webhook_endpoints
+ORDER BY created_at
+`
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpoints)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.URL,
+			&i.Secret,
+			&i.EventTypes,
+			&i.Failures,
+			&i.DisabledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpointsForEvent = `-- name: ListWebhookEndpointsForEvent :many
+SELECT id, url, secret, event_types, failures, disabled_at, created_at, updated_at
+FROM webhook_endpoints
+WHERE disabled_at IS NULL
+  AND (cardinality(event_types) = 0 OR $1::text = ANY (event_types))
+`
+
+func (q *Queries) ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpointsForEvent, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.URL,
+			&i.Secret,
+			&i.EventTypes,
+			&i.Failures,
+			&i.DisabledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_throttles
+SET failures     = 0,
+    lockouts     = lockouts + 1,
+    locked_until = $1,
+    updated_at   = timezone('utc', NOW())
+WHERE scope = $2
+  AND subject = $3
+`
+
+type LockLoginParams struct {
+	LockedUntil pgtype.Timestamp `db:"locked_until" json:"locked_until"`
+	Scope       string           `db:"scope" json:"scope"`
+	Subject     string           `db:"subject" json:"subject"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.db.Exec(ctx, lockLogin, arg.LockedUntil, arg.Scope, arg.Subject)
+	return err
+}
+
+const lockRateLimitKey = `-- name: LockRateLimitKey :exec
+SELECT pg_advisory_xact_lock(hashtext($1::text))
+`
+
+func (q *Queries) LockRateLimitKey(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, lockRateLimitKey, key)
+	return err
+}
+
+const markOutboxMessageFailed = `-- name: MarkOutboxMessageFailed :exec
+UPDATE outbox
+SET status          = $1,
+    attempts        = attempts + 1,
+    last_error      = $2,
+    next_attempt_at = $3,
+    updated_at      = timezone('utc', NOW())
+WHERE id = $4
+  AND status = 'in_progress'
+  AND next_attempt_at = $5
+`
+
+type MarkOutboxMessageFailedParams struct {
+	Status        string           `db:"status" json:"status"`
+	LastError     pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	ID            uuid.UUID        `db:"id" json:"id"`
+	LeasedUntil   pgtype.Timestamp `db:"leased_until" json:"leased_until"`
+}
+
+func (q *Queries) MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxMessageFailed,
+		arg.Status,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+		arg.LeasedUntil,
+	)
+	return err
+}
+
+const markOutboxMessageSent = `-- name: MarkOutboxMessageSent :exec
+UPDATE outbox
+SET status     = 'sent',
+    attempts   = attempts + 1,
+    last_error = NULL,
+    updated_at = timezone('utc', NOW())
+WHERE id = $1
+  AND status = 'in_progress'
+  AND next_attempt_at = $2
+`
+
+type MarkOutboxMessageSentParams struct {
+	ID          uuid.UUID        `db:"id" json:"id"`
+	LeasedUntil pgtype.Timestamp `db:"leased_until" json:"leased_until"`
+}
+
+func (q *Queries) MarkOutboxMessageSent(ctx context.Context, arg MarkOutboxMessageSentParams) error {
+	_, err := q.db.Exec(ctx, markOutboxMessageSent, arg.ID, arg.LeasedUntil)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles(scope, subject, failures, window_started_at)
+VALUES ($1, $2, 1, timezone('utc', NOW()))
+ON CONFLICT (scope, subject) DO UPDATE
+    SET failures          = CASE
+                                WHEN login_throttles.window_started_at > $3::timestamp
+                                    THEN login_throttles.failures + 1
+                                ELSE 1 END,
+        window_started_at = CASE
+                                WHEN login_throttles.window_started_at > $3::timestamp
+                                    THEN login_throttles.window_started_at
+                                ELSE timezone('utc', NOW()) END,
+        updated_at        = timezone('utc', NOW())
+RETURNING failures, lockouts
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string           `db:"scope" json:"scope"`
+	Subject     string           `db:"subject" json:"subject"`
+	WindowStart pgtype.Timestamp `db:"window_start" json:"window_start"`
+}
+
+type RecordLoginFailureRow struct {
+	Failures int32 `db:"failures" json:"failures"`
+	Lockouts int32 `db:"lockouts" json:"lockouts"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (RecordLoginFailureRow, error) {
+	row := q.db.QueryRow(ctx, recordLoginFailure, arg.Scope, arg.Subject, arg.WindowStart)
+	var i RecordLoginFailureRow
+	err := row.Scan(&i.Failures, &i.Lockouts)
+	return i, err
+}
+
+const recordWebhookFailure = `-- name: RecordWebhookFailure :exec
+UPDATE webhook_endpoints
+SET failures    = failures + 1,
+    disabled_at = CASE
+                      WHEN failures + 1 >= $1::integer THEN timezone('utc', NOW())
+                      ELSE disabled_at
+        END,
+    updated_at  = timezone('utc', NOW())
+WHERE id = $2
+`
+
+type RecordWebhookFailureParams struct {
+	MaxFailures int32     `db:"max_failures" json:"max_failures"`
+	ID          uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *Queries) RecordWebhookFailure(ctx context.Context, arg RecordWebhookFailureParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookFailure, arg.MaxFailures, arg.ID)
+	return err
+}
+
+const recordWebhookSuccess = `-- name: RecordWebhookSuccess :exec
+UPDATE webhook_endpoints
+SET failures   = 0,
+    updated_at = timezone('utc', NOW())
+WHERE id = $1
+`
+
+func (q *Queries) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, recordWebhookSuccess, id)
+	return err
+}
+
+const requeueOutboxMessage = `-- name: RequeueOutboxMessage :one
+UPDATE outbox
+SET status          = 'pending',
+    next_attempt_at = timezone('utc', NOW()),
+    updated_at      = timezone('utc', NOW())
+WHERE id = $1
+  AND kind = $2
+  AND status = 'dead'
+RETURNING TRUE AS updated
+`
+
+type RequeueOutboxMessageParams struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Kind string    `db:"kind" json:"kind"`
+}
+
+func (q *Queries) RequeueOutboxMessage(ctx context.Context, arg RequeueOutboxMessageParams) (bool, error) {
+	row := q.db.QueryRow(ctx, requeueOutboxMessage, arg.ID, arg.Kind)
+	var updated bool
+	err := row.Scan(&updated)
+	return updated, err
+}
+
+const revokeToken = `-- name: RevokeToken :one
+UPDATE tokens
+SET revoked = TRUE
+WHERE token = $1
+  AND revoked IS NOT TRUE
+RETURNING id, user_id, token, revoked, expires_at, created_at
+`
+
+func (q *Queries) RevokeToken(ctx context.Context, token string) (Token, error) {
+	row := q.db.QueryRow(ctx, revokeToken, token)
+	var i Token
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Token,
+		&i.Revoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const revokeUserTokens = `-- name: RevokeUserTokens :execrows
+UPDATE tokens
+SET revoked = TRUE
+WHERE user_id = $1
+  AND revoked IS NOT TRUE
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeUserTokens, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setMFAChallengeEmailCode = `-- name: SetMFAChallengeEmailCode :exec
+UPDATE mfa_challenges
+SET email_code_hash       = $2,
+    email_code_expires_at = $3
+WHERE id = $1
+`
+
+type SetMFAChallengeEmailCodeParams struct {
+	ID                 uuid.UUID        `db:"id" json:"id"`
+	EmailCodeHash      pgtype.Text      `db:"email_code_hash" json:"email_code_hash"`
+	EmailCodeExpiresAt pgtype.Timestamp `db:"email_code_expires_at" json:"email_code_expires_at"`
+}
+
+func (q *Queries) SetMFAChallengeEmailCode(ctx context.Context, arg SetMFAChallengeEmailCodeParams) error {
+	_, err := q.db.Exec(ctx, setMFAChallengeEmailCode, arg.ID, arg.EmailCodeHash, arg.EmailCodeExpiresAt)
+	return err
+}
+
+const tryLockJanitor = `-- name: TryLockJanitor :one
+SELECT pg_try_advisory_xact_lock(hashtext($1::text))
+`
+
+func (q *Queries) TryLockJanitor(ctx context.Context, key string) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockJanitor, key)
+	var pg_try_advisory_xact_lock bool
+	err := row.Scan(&pg_try_advisory_xact_lock)
+	return pg_try_advisory_xact_lock, err
+}
+
+const updateMFAFactorLastUsedStep = `-- name: UpdateMFAFactorLastUsedStep :one
+UPDATE user_mfa_factors
+SET last_used_step = $1,
+    updated_at     = timezone('utc', NOW())
+WHERE id = $2
+  AND last_used_step < $1
+RETURNING TRUE AS updated
+`
+
+type UpdateMFAFactorLastUsedStepParams struct {
+	LastUsedStep int64     `db:"last_used_step" json:"last_used_step"`
+	ID           uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateMFAFactorLastUsedStep(ctx context.Context, arg UpdateMFAFactorLastUsedStepParams) (bool, error) {
+	row := q.db.QueryRow(ctx, updateMFAFactorLastUsedStep, arg.LastUsedStep, arg.ID)
+	var updated bool
+	err := row.Scan(&updated)
+	return updated, err
+}
+
 const updateUserAsVerified = `-- name: UpdateUserAsVerified :one
 UPDATE users
 SET is_verified = true,
@@ -171,3 +1577,67 @@ func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPassword
 	err := row.Scan(&updated)
 	return updated, err
 }
+
+const updateUserPreferredMFAFactor = `-- name: UpdateUserPreferredMFAFactor :exec
+UPDATE users
+SET preferred_mfa_factor = $2,
+    updated_at           = timezone('utc', NOW())
+WHERE id = $1
+`
+
+type UpdateUserPreferredMFAFactorParams struct {
+	ID                 uuid.UUID   `db:"id" json:"id"`
+	PreferredMfaFactor pgtype.Text `db:"preferred_mfa_factor" json:"preferred_mfa_factor"`
+}
+
+func (q *Queries) UpdateUserPreferredMFAFactor(ctx context.Context, arg UpdateUserPreferredMFAFactorParams) error {
+	_, err := q.db.Exec(ctx, updateUserPreferredMFAFactor, arg.ID, arg.PreferredMfaFactor)
+	return err
+}
+
+const updateWebAuthnCredentialSignCount = `-- name: UpdateWebAuthnCredentialSignCount :exec
+UPDATE webauthn_credentials
+SET sign_count   = $2,
+    last_used_at = timezone('utc', NOW())
+WHERE id = $1
+`
+
+type UpdateWebAuthnCredentialSignCountParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	SignCount int64     `db:"sign_count" json:"sign_count"`
+}
+
+func (q *Queries) UpdateWebAuthnCredentialSignCount(ctx context.Context, arg UpdateWebAuthnCredentialSignCountParams) error {
+	_, err := q.db.Exec(ctx, updateWebAuthnCredentialSignCount, arg.ID, arg.SignCount)
+	return err
+}
+
+const upsertPendingMFAFactor = `-- name: UpsertPendingMFAFactor :one
+INSERT INTO user_mfa_factors(id, user_id, factor_type, secret)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, factor_type) DO UPDATE
+    SET secret         = EXCLUDED.secret,
+        last_used_step = 0,
+        updated_at     = timezone('utc', NOW())
+WHERE user_mfa_factors.confirmed = FALSE
+RETURNING id
+`
+
+type UpsertPendingMFAFactorParams struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	FactorType string    `db:"factor_type" json:"factor_type"`
+	Secret     string    `db:"secret" json:"secret"`
+}
+
+func (q *Queries) UpsertPendingMFAFactor(ctx context.Context, arg UpsertPendingMFAFactorParams) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, upsertPendingMFAFactor,
+		arg.ID,
+		arg.UserID,
+		arg.FactorType,
+		arg.Secret,
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
@@ -21,15 +21,33 @@ INSERT INTO tokens(id, user_id, token, expires_at)
 VALUES ($1, $2, $3, $4)
 RETURNING id;
 
+-- name: FindToken :one
+SELECT *
+FROM tokens
+WHERE token = $1;
+
+-- name: RevokeToken :one
+UPDATE tokens
+SET revoked = TRUE
+WHERE token = $1
+  AND revoked IS NOT TRUE
+RETURNING *;
+
+-- name: RevokeUserTokens :execrows
+UPDATE tokens
+SET revoked = TRUE
+WHERE user_id = $1
+  AND revoked IS NOT TRUE;
+
 -- name: CreateEmailConfirmation :one
 INSERT INTO email_confirmations(id, user_id, code, expires_at)
 VALUES ($1, $2, $3, $4)
 RETURNING id;
 
 -- name: FindUserByConfirmationCode :one
-SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified
+SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified, u.preferred_mfa_factor
 FROM users u
-WHERE id = (SELECT ec.user_id FROM email_confirmations ec WHERE code = $1);
+WHERE id = (SELECT ec.user_id FROM email_confirmations ec WHERE code = $1 AND ec.purpose = 'confirmation');
 
 -- name: UpdateUserAsVerified :one
 UPDATE users
@@ -44,3 +62,409 @@ SET password_hash = $1,
     updated_at = timezone('utc', NOW())
 WHERE email = $2
 RETURNING TRUE AS updated;
+
+-- name: CreateLoginCode :one
+INSERT INTO email_confirmations(id, user_id, code, expires_at, purpose)
+VALUES ($1, $2, $3, $4, 'login')
+RETURNING id;
+
+-- name: ConsumeLoginCode :one
+WITH consumed AS (
+    DELETE FROM email_confirmations ec
+    WHERE ec.code = $1
+      AND ec.purpose = 'login'
+      AND ec.expires_at > timezone('utc', NOW())
+    RETURNING ec.user_id
+)
+SELECT u.id, u.email, u.password_hash, u.created_at, u.updated_at, u.is_verified, u.preferred_mfa_factor
+FROM users u
+JOIN consumed c ON u.id = c.user_id;
+
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox(id, kind, user_id, payload)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: ClaimOutboxMessages :many
+UPDATE outbox
+SET status          = 'in_progress',
+    next_attempt_at = @leased_until,
+    updated_at      = timezone('utc', NOW())
+WHERE id IN (SELECT id
+             FROM outbox
+             WHERE status IN ('pending', 'in_progress')
+               AND next_attempt_at <= timezone('utc', NOW())
+             ORDER BY next_attempt_at
+             LIMIT @batch_size FOR UPDATE SKIP LOCKED)
+RETURNING *;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE outbox
+SET status     = 'sent',
+    attempts   = attempts + 1,
+    last_error = NULL,
+    updated_at = timezone('utc', NOW())
+WHERE id = @id
+  AND status = 'in_progress'
+  AND next_attempt_at = @leased_until;
+
+-- name: MarkOutboxMessageFailed :exec
+UPDATE outbox
+SET status          = @status,
+    attempts        = attempts + 1,
+    last_error      = @last_error,
+    next_attempt_at = @next_attempt_at,
+    updated_at      = timezone('utc', NOW())
+WHERE id = @id
+  AND status = 'in_progress'
+  AND next_attempt_at = @leased_until;
+
+-- name: ListOutboxMessages :many
+SELECT *
+FROM outbox
+WHERE kind = $1
+  AND status = $2
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $4;
+
+-- name: RequeueOutboxMessage :one
+UPDATE outbox
+SET status          = 'pending',
+    next_attempt_at = timezone('utc', NOW()),
+    updated_at      = timezone('utc', NOW())
+WHERE id = $1
+  AND kind = $2
+  AND status = 'dead'
+RETURNING TRUE AS updated;
+
+-- name: FindUserByID :one
+SELECT *
+FROM users
+WHERE id = $1;
+
+-- name: UpsertPendingMFAFactor :one
+INSERT INTO user_mfa_factors(id, user_id, factor_type, secret)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, factor_type) DO UPDATE
+    SET secret         = EXCLUDED.secret,
+        last_used_step = 0,
+        updated_at     = timezone('utc', NOW())
+WHERE user_mfa_factors.confirmed = FALSE
+RETURNING id;
+
+-- name: FindMFAFactor :one
+SELECT *
+FROM user_mfa_factors
+WHERE user_id = $1
+  AND factor_type = $2;
+
+-- name: ListConfirmedMFAFactors :many
+SELECT *
+FROM user_mfa_factors
+WHERE user_id = $1
+  AND confirmed = TRUE
+ORDER BY created_at;
+
+-- name: ConfirmMFAFactor :one
+UPDATE user_mfa_factors
+SET confirmed      = TRUE,
+    last_used_step = @last_used_step,
+    updated_at     = timezone('utc', NOW())
+WHERE id = @id
+  AND confirmed = FALSE
+RETURNING TRUE AS updated;
+
+-- name: UpdateMFAFactorLastUsedStep :one
+UPDATE user_mfa_factors
+SET last_used_step = @last_used_step,
+    updated_at     = timezone('utc', NOW())
+WHERE id = @id
+  AND last_used_step < @last_used_step
+RETURNING TRUE AS updated;
+
+-- name: CreateMFAChallenge :one
+INSERT INTO mfa_challenges(id, user_id, token_hash, expires_at, login_method)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: AttemptMFAChallenge :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE token_hash = @token_hash
+  AND expires_at > timezone('utc', NOW())
+  AND attempts < @max_attempts::integer
+RETURNING id, user_id, email_code_hash, email_code_expires_at, login_method;
+
+-- name: FindMFAChallengeUserID :one
+SELECT user_id
+FROM mfa_challenges
+WHERE token_hash = $1;
+
+-- name: DeleteMFAChallenge :exec
+DELETE
+FROM mfa_challenges
+WHERE id = $1;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE
+FROM mfa_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :one
+INSERT INTO mfa_recovery_codes(id, user_id, code_hash)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ConsumeRecoveryCode :one
+UPDATE mfa_recovery_codes
+SET used_at = timezone('utc', NOW())
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING TRUE AS updated;
+
+-- name: CountRecoveryCodes :one
+SELECT COUNT(*)
+FROM mfa_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials(id, user_id, credential_id, public_key, sign_count, aaguid, name, backup_eligible)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id;
+
+-- name: FindWebAuthnCredential :one
+SELECT *
+FROM webauthn_credentials
+WHERE credential_id = $1;
+
+-- name: ListWebAuthnCredentials :many
+SELECT *
+FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: UpdateWebAuthnCredentialSignCount :exec
+UPDATE webauthn_credentials
+SET sign_count   = $2,
+    last_used_at = timezone('utc', NOW())
+WHERE id = $1;
+
+-- name: CreateWebAuthnSession :one
+INSERT INTO webauthn_sessions(id, user_id, ceremony, challenge_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: ConsumeWebAuthnSession :one
+DELETE
+FROM webauthn_sessions
+WHERE challenge_hash = $1
+  AND ceremony = $2
+  AND expires_at > timezone('utc', NOW())
+RETURNING user_id;
+
+-- name: SetMFAChallengeEmailCode :exec
+UPDATE mfa_challenges
+SET email_code_hash       = $2,
+    email_code_expires_at = $3
+WHERE id = $1;
+
+-- name: EnableMFAFactor :exec
+INSERT INTO user_mfa_factors(id, user_id, factor_type, secret, confirmed)
+VALUES ($1, $2, $3, '', TRUE)
+ON CONFLICT (user_id, factor_type) DO UPDATE
+    SET confirmed  = TRUE,
+        updated_at = timezone('utc', NOW());
+
+-- name: UpdateUserPreferredMFAFactor :exec
+UPDATE users
+SET preferred_mfa_factor = $2,
+    updated_at           = timezone('utc', NOW())
+WHERE id = $1;
+
+-- name: FindLoginLock :one
+SELECT locked_until
+FROM login_throttles
+WHERE scope = $1
+  AND subject = $2
+  AND locked_until > timezone('utc', NOW());
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles(scope, subject, failures, window_started_at)
+VALUES (@scope, @subject, 1, timezone('utc', NOW()))
+ON CONFLICT (scope, subject) DO UPDATE
+    SET failures          = CASE
+                                WHEN login_throttles.window_started_at > @window_start::timestamp
+                                    THEN login_throttles.failures + 1
+                                ELSE 1 END,
+        window_started_at = CASE
+                                WHEN login_throttles.window_started_at > @window_start::timestamp
+                                    THEN login_throttles.window_started_at
+                                ELSE timezone('utc', NOW()) END,
+        updated_at        = timezone('utc', NOW())
+RETURNING failures, lockouts;
+
+-- name: LockLogin :exec
+UPDATE login_throttles
+SET failures     = 0,
+    lockouts     = lockouts + 1,
+    locked_until = @locked_until,
+    updated_at   = timezone('utc', NOW())
+WHERE scope = @scope
+  AND subject = @subject;
+
+-- name: DeleteLoginThrottle :exec
+DELETE
+FROM login_throttles
+WHERE scope = $1
+  AND subject = $2;
+
+-- name: CreateUnlockCode :one
+INSERT INTO email_confirmations(id, user_id, code, expires_at, purpose)
+VALUES ($1, $2, $3, $4, 'unlock')
+RETURNING id;
+
+-- name: ConsumeUnlockCode :one
+DELETE
+FROM email_confirmations
+WHERE code = $1
+  AND purpose = 'unlock'
+  AND expires_at > timezone('utc', NOW())
+RETURNING user_id;
+
+-- name: LockRateLimitKey :exec
+SELECT pg_advisory_xact_lock(hashtext(@key::text));
+
+-- name: DeleteRateLimitHits :exec
+DELETE
+FROM rate_limit_hits
+WHERE key = @key
+  AND hit_at <= @window_start;
+
+-- name: CountRateLimitHits :one
+SELECT COUNT(*) AS hits, MIN(hit_at)::timestamp AS oldest_hit_at
+FROM rate_limit_hits
+WHERE key = $1;
+
+-- name: CreateRateLimitHit :exec
+INSERT INTO rate_limit_hits(id, key, hit_at)
+VALUES ($1, $2, $3);
+
+-- name: CreateAuthEvent :exec
+INSERT INTO auth_events(id, user_id, email, event_type, ip, user_agent, outcome, error_code)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListAuthEvents :many
+SELECT *
+FROM auth_events
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND created_at >= @created_from
+  AND created_at < @created_to
+ORDER BY created_at DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: DeleteAuthEventsBefore :execrows
+DELETE
+FROM auth_events
+WHERE created_at < $1;
+
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints(id, url, secret, event_types)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: FindWebhookEndpoint :one
+SELECT *
+FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: ListWebhookEndpoints :many
+SELECT *
+FROM webhook_endpoints
+ORDER BY created_at;
+
+-- name: ListWebhookEndpointsForEvent :many
+SELECT *
+FROM webhook_endpoints
+WHERE disabled_at IS NULL
+  AND (cardinality(event_types) = 0 OR @event_type::text = ANY (event_types));
+
+-- name: DeleteWebhookEndpoint :one
+DELETE
+FROM webhook_endpoints
+WHERE id = $1
+RETURNING id;
+
+-- name: RecordWebhookSuccess :exec
+UPDATE webhook_endpoints
+SET failures   = 0,
+    updated_at = timezone('utc', NOW())
+WHERE id = $1;
+
+-- name: RecordWebhookFailure :exec
+UPDATE webhook_endpoints
+SET failures    = failures + 1,
+    disabled_at = CASE
+                      WHEN failures + 1 >= @max_failures::integer THEN timezone('utc', NOW())
+                      ELSE disabled_at
+        END,
+    updated_at  = timezone('utc', NOW())
+WHERE id = @id;
+
+-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET failures    = 0,
+    disabled_at = NULL,
+    updated_at  = timezone('utc', NOW())
+WHERE id = $1
+RETURNING id;
+
+-- name: ListUsers :many
+SELECT *
+FROM users
+WHERE email ILIKE '%' || @search::text || '%' ESCAPE '\'
+ORDER BY created_at, id
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: ForceLoginLock :exec
+INSERT INTO login_throttles (scope, subject, window_started_at, locked_until)
+VALUES (@scope, @subject, timezone('utc', NOW()), @locked_until)
+ON CONFLICT (scope, subject) DO UPDATE
+    SET failures     = 0,
+        locked_until = EXCLUDED.locked_until,
+        updated_at   = timezone('utc', NOW());
+
+-- name: DeleteUser :one
+DELETE
+FROM users
+WHERE id = $1
+RETURNING id;
+
+-- name: TryLockJanitor :one
+SELECT pg_try_advisory_xact_lock(hashtext(@key::text));
+
+-- name: DeleteExpiredTokens :execrows
+DELETE
+FROM tokens
+WHERE id IN (SELECT t.id
+             FROM tokens t
+             WHERE t.expires_at < @before
+             LIMIT @batch_size FOR UPDATE SKIP LOCKED);
+
+-- name: DeleteExpiredEmailConfirmations :execrows
+DELETE
+FROM email_confirmations
+WHERE id IN (SELECT ec.id
+             FROM email_confirmations ec
+             WHERE ec.expires_at < @before
+             LIMIT @batch_size FOR UPDATE SKIP LOCKED);
+
+-- name: DeleteSentOutboxMessages :execrows
+DELETE
+FROM outbox
+WHERE id IN (SELECT o.id
+             FROM outbox o
+             WHERE o.status = 'sent'
+               AND o.updated_at < @before
+             LIMIT @batch_size FOR UPDATE SKIP LOCKED);
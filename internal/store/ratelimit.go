@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+type HitRateLimitDTO struct {
+	Key    string
+	Limit  int64
+	Period time.Duration
+}
+
+// HitRateLimit records a hit on a sliding window log unless the window is
+// full, in which case it returns how long until the oldest hit leaves it. An
+// advisory lock on the key serializes concurrent callers across replicas.
+func (s Impl) HitRateLimit(ctx context.Context, dto HitRateLimitDTO) (time.Duration, error) {
+	var retryAfter time.Duration
+	err := s.PgTx(ctx, func(tx pgx.Tx, _ Store) error {
+		q := NewPgStore(tx)
+		if err := q.LockRateLimitKey(ctx, dto.Key); err != nil {
+			return werr.Wrap(err)
+		}
+
+		now := time.Now().UTC()
+		if err := q.DeleteRateLimitHits(ctx, pgstore.DeleteRateLimitHitsParams{
+			Key: dto.Key,
+			WindowStart: pgtype.Timestamp{
+				Time:             now.Add(-dto.Period),
+				InfinityModifier: 0,
+				Valid:            true,
+			},
+		}); err != nil {
+			return werr.Wrap(err)
+		}
+		hits, err := q.CountRateLimitHits(ctx, dto.Key)
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		if hits.Hits >= dto.Limit {
+			retryAfter = hits.OldestHitAt.Time.Add(dto.Period).Sub(now)
+
+			return nil
+		}
+
+		return werr.Wrap(q.CreateRateLimitHit(ctx, pgstore.CreateRateLimitHitParams{
+			ID:  NewUUID(),
+			Key: dto.Key,
+			HitAt: pgtype.Timestamp{
+				Time:             now,
+				InfinityModifier: 0,
+				Valid:            true,
+			},
+		}))
+	})
+
+	return retryAfter, werr.Wrap(err)
+}
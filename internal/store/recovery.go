@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+type ReplaceRecoveryCodesDTO struct {
+	UserID     uuid.UUID
+	CodeHashes []string
+}
+
+// ReplaceRecoveryCodes drops every earlier code of the user, used or not.
+func (s Impl) ReplaceRecoveryCodes(ctx context.Context, dto ReplaceRecoveryCodesDTO) error {
+	err := s.PgTx(ctx, func(tx pgx.Tx, _ Store) error {
+		q := NewPgStore(tx)
+		if err := q.DeleteRecoveryCodes(ctx, dto.UserID); err != nil {
+			return werr.Wrap(err)
+		}
+		for _, codeHash := range dto.CodeHashes {
+			if _, err := q.CreateRecoveryCode(ctx, pgstore.CreateRecoveryCodeParams{
+				ID:       NewUUID(),
+				UserID:   dto.UserID,
+				CodeHash: codeHash,
+			}); err != nil {
+				return werr.Wrap(err)
+			}
+		}
+
+		return nil
+	})
+
+	return werr.Wrap(err)
+}
+
+type ConsumeRecoveryCodeDTO struct {
+	UserID   uuid.UUID
+	CodeHash string
+}
+
+// ConsumeRecoveryCode marks a code as used. It returns pgx.ErrNoRows for
+// unknown or already used codes.
+func (s Impl) ConsumeRecoveryCode(ctx context.Context, dto ConsumeRecoveryCodeDTO) error {
+	if _, err := s.PgStore.ConsumeRecoveryCode(ctx, pgstore.ConsumeRecoveryCodeParams{
+		UserID:   dto.UserID,
+		CodeHash: dto.CodeHash,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (s Impl) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.PgStore.CountRecoveryCodes(ctx, userID)
+
+	return count, werr.Wrap(err)
+}
@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"time"
 
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
@@ -11,12 +13,68 @@ type Store interface {
 	ExistsUserByLogin(ctx context.Context, login string) (bool, error)
 	CreateUser(ctx context.Context, dto CreateUserDTO) (uuid.UUID, error)
 	FindUserByEmail(ctx context.Context, email string) (User, error)
+	FindUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	ListUsers(ctx context.Context, dto ListUsersDTO) ([]User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
 	CreateToken(ctx context.Context, dto CreateTokenDTO) (uuid.UUID, error)
+	FindToken(ctx context.Context, token string) (Token, error)
+	RevokeToken(ctx context.Context, token string) (Token, error)
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) (int64, error)
 	CreateEmailConfirmation(ctx context.Context, dto CreateEmailConfirmationDTO) (uuid.UUID, error)
-	RegisterUserWithConfirmation(ctx context.Context, dto RegisterUserWithConfirmationDTO) error
+	RegisterUserWithConfirmation(ctx context.Context, dto RegisterUserWithConfirmationDTO) (uuid.UUID, error)
 	FindUserByConfirmationCode(ctx context.Context, code string) (User, error)
 	UpdateUserAsVerified(ctx context.Context, email string) error
 	UpdateUserPassword(ctx context.Context, dto UpdateUserPasswordDTO) error
+	CreateLoginCode(ctx context.Context, dto CreateLoginCodeDTO) (uuid.UUID, error)
+	RegisterUserWithLoginCode(ctx context.Context, dto RegisterUserWithLoginCodeDTO) (uuid.UUID, error)
+	ConsumeLoginCode(ctx context.Context, code string) (User, error)
+	CreateOutboxMessage(ctx context.Context, dto CreateOutboxMessageDTO) (uuid.UUID, error)
+	ClaimOutboxMessages(ctx context.Context, dto ClaimOutboxMessagesDTO) ([]OutboxMessage, error)
+	MarkOutboxMessageSent(ctx context.Context, msg OutboxMessage) error
+	MarkOutboxMessageFailed(ctx context.Context, dto MarkOutboxMessageFailedDTO) error
+	ListOutboxMessages(ctx context.Context, dto ListOutboxMessagesDTO) ([]OutboxMessage, error)
+	RequeueOutboxMessage(ctx context.Context, dto RequeueOutboxMessageDTO) error
+	UpsertPendingMFAFactor(ctx context.Context, dto UpsertPendingMFAFactorDTO) (uuid.UUID, error)
+	FindMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) (MFAFactor, error)
+	ListConfirmedMFAFactors(ctx context.Context, userID uuid.UUID) ([]MFAFactor, error)
+	ConfirmMFAFactor(ctx context.Context, dto UseMFAFactorStepDTO) error
+	UpdateMFAFactorLastUsedStep(ctx context.Context, dto UseMFAFactorStepDTO) error
+	CreateMFAChallenge(ctx context.Context, dto CreateMFAChallengeDTO) (uuid.UUID, error)
+	AttemptMFAChallenge(ctx context.Context, dto AttemptMFAChallengeDTO) (MFAChallenge, error)
+	FindMFAChallengeUserID(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	DeleteMFAChallenge(ctx context.Context, id uuid.UUID) error
+	SetMFAChallengeEmailCode(ctx context.Context, dto SetMFAChallengeEmailCodeDTO) error
+	EnableMFAFactor(ctx context.Context, userID uuid.UUID, factorType entity.MFAFactorType) error
+	UpdateUserPreferredMFAFactor(ctx context.Context, dto UpdateUserPreferredMFAFactorDTO) error
+	ReplaceRecoveryCodes(ctx context.Context, dto ReplaceRecoveryCodesDTO) error
+	ConsumeRecoveryCode(ctx context.Context, dto ConsumeRecoveryCodeDTO) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateWebAuthnCredential(ctx context.Context, dto CreateWebAuthnCredentialDTO) (uuid.UUID, error)
+	FindWebAuthnCredential(ctx context.Context, credentialID []byte) (WebAuthnCredential, error)
+	ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]WebAuthnCredential, error)
+	UpdateWebAuthnCredentialSignCount(ctx context.Context, dto UpdateWebAuthnCredentialSignCountDTO) error
+	CreateWebAuthnSession(ctx context.Context, dto CreateWebAuthnSessionDTO) (uuid.UUID, error)
+	ConsumeWebAuthnSession(ctx context.Context, dto ConsumeWebAuthnSessionDTO) (uuid.UUID, error)
+	FindLoginLock(ctx context.Context, key LockoutKey) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, dto RecordLoginFailureDTO) (LoginFailures, error)
+	LockLogin(ctx context.Context, dto LockLoginDTO) error
+	ForceLoginLock(ctx context.Context, dto LockLoginDTO) error
+	DeleteLoginThrottle(ctx context.Context, key LockoutKey) error
+	CreateUnlockCode(ctx context.Context, dto CreateUnlockCodeDTO) (uuid.UUID, error)
+	UnlockAccountWithCode(ctx context.Context, code string) (uuid.UUID, error)
+	HitRateLimit(ctx context.Context, dto HitRateLimitDTO) (time.Duration, error)
+	CreateAuthEvent(ctx context.Context, dto CreateAuthEventDTO) error
+	ListAuthEvents(ctx context.Context, dto ListAuthEventsDTO) ([]AuthEvent, error)
+	DeleteAuthEventsBefore(ctx context.Context, before time.Time) (int64, error)
+	CreateWebhookEndpoint(ctx context.Context, dto CreateWebhookEndpointDTO) (WebhookEndpoint, error)
+	FindWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error)
+	ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+	EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+	RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error
+	RecordWebhookFailure(ctx context.Context, dto RecordWebhookFailureDTO) error
+	DeleteExpired(ctx context.Context, dto DeleteExpiredDTO) (DeletedExpired, error)
 
 	PgTx(ctx context.Context, handler func(tx pgx.Tx, stx Store) error) error
 }
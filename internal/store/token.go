@@ -10,6 +10,8 @@ import (
 	"github.com/matchsystems/werr"
 )
 
+type Token pgstore.Token
+
 type CreateTokenDTO struct {
 	UserID    uuid.UUID
 	Token     string
@@ -23,7 +25,7 @@ func (s Impl) CreateToken(ctx context.Context, dto CreateTokenDTO) (uuid.UUID, e
 		UserID: dto.UserID,
 		Token:  dto.Token,
 		ExpiresAt: pgtype.Timestamp{
-			Time:             dto.ExpiresAt,
+			Time:             dto.ExpiresAt.UTC(),
 			InfinityModifier: 0,
 			Valid:            true,
 		},
@@ -34,3 +36,33 @@ func (s Impl) CreateToken(ctx context.Context, dto CreateTokenDTO) (uuid.UUID, e
 
 	return newID, nil
 }
+
+func (s Impl) FindToken(ctx context.Context, token string) (Token, error) {
+	row, err := s.PgStore.FindToken(ctx, token)
+	if err != nil {
+		return Token{}, werr.Wrap(err)
+	}
+
+	return Token(row), nil
+}
+
+// RevokeToken returns the revoked token, or pgx.ErrNoRows when it is unknown
+// or was already revoked.
+func (s Impl) RevokeToken(ctx context.Context, token string) (Token, error) {
+	row, err := s.PgStore.RevokeToken(ctx, token)
+	if err != nil {
+		return Token{}, werr.Wrap(err)
+	}
+
+	return Token(row), nil
+}
+
+// RevokeUserTokens returns the number of tokens it revoked.
+func (s Impl) RevokeUserTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	revoked, err := s.PgStore.RevokeUserTokens(ctx, userID)
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	return revoked, nil
+}
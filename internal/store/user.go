@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
@@ -17,10 +18,12 @@ func (m User) Entity() entity.User {
 	return entity.User{
 		ID:           m.ID,
 		Email:        m.Email,
-		PasswordHash: m.Email,
+		PasswordHash: m.PasswordHash,
 		CreatedAt:    m.CreatedAt.Time,
 		UpdatedAt:    m.UpdatedAt.Time,
 		IsVerified:   m.IsVerified.Bool,
+
+		PreferredMFAFactor: entity.MFAFactorType(m.PreferredMfaFactor.String),
 	}
 }
 
@@ -49,6 +52,12 @@ func (s Impl) CreateUser(ctx context.Context, dto CreateUserDTO) (uuid.UUID, err
 	return newID, nil
 }
 
+func (s Impl) FindUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	user, err := s.PgStore.FindUserByID(ctx, id)
+
+	return User(user), werr.Wrap(err)
+}
+
 func (s Impl) FindUserByEmail(ctx context.Context, email string) (User, error) {
 	user, err := s.PgStore.FindUserByEmail(ctx, email)
 
@@ -60,14 +69,17 @@ type RegisterUserWithConfirmationDTO struct {
 	PasswordHash     string
 	ConfirmationCode string
 	ExpiresAt        time.Time
+	Outbox           *CreateOutboxMessageDTO
 }
 
 func (s Impl) RegisterUserWithConfirmation(
 	ctx context.Context,
 	dto RegisterUserWithConfirmationDTO,
-) error {
-	return s.PgTx(ctx, func(tx pgx.Tx, stx Store) error {
-		userID, err := stx.CreateUser(ctx, CreateUserDTO{
+) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := s.PgTx(ctx, func(tx pgx.Tx, stx Store) error {
+		var err error
+		userID, err = stx.CreateUser(ctx, CreateUserDTO{
 			Email:        dto.Email,
 			PasswordHash: dto.PasswordHash,
 		})
@@ -78,12 +90,18 @@ func (s Impl) RegisterUserWithConfirmation(
 			UserID:           userID,
 			ConfirmationCode: dto.ConfirmationCode,
 			ExpiresAt:        dto.ExpiresAt,
+			Outbox:           nil,
 		}); err != nil {
 			return werr.Wrap(err)
 		}
+		if dto.Outbox != nil {
+			return werr.Wrap(createOutboxMessage(ctx, stx, userID, *dto.Outbox))
+		}
 
 		return nil
 	})
+
+	return userID, werr.Wrap(err)
 }
 
 func (s Impl) UpdateUserAsVerified(ctx context.Context, email string) error {
@@ -118,3 +136,94 @@ func (s Impl) UpdateUserPassword(ctx context.Context, dto UpdateUserPasswordDTO)
 
 	return nil
 }
+
+type RegisterUserWithLoginCodeDTO struct {
+	Email     string
+	LoginCode string
+	ExpiresAt time.Time
+	Outbox    *CreateOutboxMessageDTO
+}
+
+// RegisterUserWithLoginCode creates a passwordless user: an empty hash never matches a password.
+func (s Impl) RegisterUserWithLoginCode(ctx context.Context, dto RegisterUserWithLoginCodeDTO) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := s.PgTx(ctx, func(tx pgx.Tx, stx Store) error {
+		var err error
+		userID, err = stx.CreateUser(ctx, CreateUserDTO{
+			Email:        dto.Email,
+			PasswordHash: "",
+		})
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		if _, err = stx.CreateLoginCode(ctx, CreateLoginCodeDTO{
+			UserID:    userID,
+			LoginCode: dto.LoginCode,
+			ExpiresAt: dto.ExpiresAt,
+			Outbox:    nil,
+		}); err != nil {
+			return werr.Wrap(err)
+		}
+		if dto.Outbox != nil {
+			return werr.Wrap(createOutboxMessage(ctx, stx, userID, *dto.Outbox))
+		}
+
+		return nil
+	})
+
+	return userID, werr.Wrap(err)
+}
+
+func (s Impl) ConsumeLoginCode(ctx context.Context, code string) (User, error) {
+	user, err := s.PgStore.ConsumeLoginCode(ctx, code)
+	if err != nil {
+		return User{}, werr.Wrap(err)
+	}
+
+	return User(user), nil
+}
+
+// likeEscaper makes "%", "_" and the escape character itself match literally
+// in a pattern using ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type ListUsersDTO struct {
+	// Search keeps users whose email contains it, ignoring case.
+	Search string
+	Limit  int32
+	Offset int32
+}
+
+func (s Impl) ListUsers(ctx context.Context, dto ListUsersDTO) ([]User, error) {
+	rows, err := s.PgStore.ListUsers(ctx, pgstore.ListUsersParams{
+		Search:    likeEscaper.Replace(dto.Search),
+		RowOffset: dto.Offset,
+		RowLimit:  dto.Limit,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	users := make([]User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, User(row))
+	}
+
+	return users, nil
+}
+
+// DeleteUser deletes a user with everything referencing it and its failed
+// login counter. It returns pgx.ErrNoRows for an unknown user.
+func (s Impl) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return werr.Wrap(s.PgTx(ctx, func(tx pgx.Tx, _ Store) error {
+		q := NewPgStore(tx)
+		if _, err := q.DeleteUser(ctx, id); err != nil {
+			return werr.Wrap(err)
+		}
+
+		return werr.Wrap(q.DeleteLoginThrottle(ctx, pgstore.DeleteLoginThrottleParams{
+			Scope:   string(entity.LockoutScopeUser),
+			Subject: id.String(),
+		}))
+	}))
+}
@@ -0,0 +1,34 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (q *recordingQueries) ListUsers(_ context.Context, arg pgstore.ListUsersParams) ([]pgstore.User, error) {
+	q.listUsers = arg
+
+	return nil, nil
+}
+
+func TestImpl_ListUsers(t *testing.T) {
+	t.Parallel()
+
+	// The search is a substring, so wildcards in it must not widen the match.
+	queries := &recordingQueries{}
+	s := store.Impl{PgClient: nil, PgStore: queries}
+
+	_, err := s.ListUsers(context.Background(), store.ListUsersDTO{
+		Search: `a_b%c\d`,
+		Limit:  10,
+		Offset: 0,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, `a\_b\%c\\d`, queries.listUsers.Search)
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matchsystems/werr"
+)
+
+const uniqueViolation = "23505"
+
+type WebAuthnCredential pgstore.WebauthnCredential
+
+type CreateWebAuthnCredentialDTO struct {
+	UserID         uuid.UUID
+	CredentialID   []byte
+	PublicKey      []byte
+	SignCount      uint32
+	AAGUID         []byte
+	Name           string
+	BackupEligible bool
+}
+
+// CreateWebAuthnCredential returns errorz.ErrWebAuthnCredentialTaken when the
+// credential is already registered, to this or another user.
+func (s Impl) CreateWebAuthnCredential(ctx context.Context, dto CreateWebAuthnCredentialDTO) (uuid.UUID, error) {
+	id := NewUUID()
+	newID, err := s.PgStore.CreateWebAuthnCredential(ctx, pgstore.CreateWebAuthnCredentialParams{
+		ID:             id,
+		UserID:         dto.UserID,
+		CredentialID:   dto.CredentialID,
+		PublicKey:      dto.PublicKey,
+		SignCount:      int64(dto.SignCount),
+		Aaguid:         dto.AAGUID,
+		Name:           dto.Name,
+		BackupEligible: dto.BackupEligible,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return uuid.Nil, werr.Wrap(errorz.ErrWebAuthnCredentialTaken)
+		}
+
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+func (s Impl) FindWebAuthnCredential(ctx context.Context, credentialID []byte) (WebAuthnCredential, error) {
+	credential, err := s.PgStore.FindWebAuthnCredential(ctx, credentialID)
+	if err != nil {
+		return WebAuthnCredential{}, werr.Wrap(err)
+	}
+
+	return WebAuthnCredential(credential), nil
+}
+
+func (s Impl) ListWebAuthnCredentials(ctx context.Context, userID uuid.UUID) ([]WebAuthnCredential, error) {
+	rows, err := s.PgStore.ListWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	credentials := make([]WebAuthnCredential, 0, len(rows))
+	for _, row := range rows {
+		credentials = append(credentials, WebAuthnCredential(row))
+	}
+
+	return credentials, nil
+}
+
+type UpdateWebAuthnCredentialSignCountDTO struct {
+	ID        uuid.UUID
+	SignCount uint32
+}
+
+func (s Impl) UpdateWebAuthnCredentialSignCount(ctx context.Context, dto UpdateWebAuthnCredentialSignCountDTO) error {
+	return werr.Wrap(s.PgStore.UpdateWebAuthnCredentialSignCount(ctx, pgstore.UpdateWebAuthnCredentialSignCountParams{
+		ID:        dto.ID,
+		SignCount: int64(dto.SignCount),
+	}))
+}
+
+type CreateWebAuthnSessionDTO struct {
+	UserID        uuid.UUID
+	Ceremony      string
+	ChallengeHash string
+	ExpiresAt     time.Time
+}
+
+func (s Impl) CreateWebAuthnSession(ctx context.Context, dto CreateWebAuthnSessionDTO) (uuid.UUID, error) {
+	id := NewUUID()
+	newID, err := s.PgStore.CreateWebAuthnSession(ctx, pgstore.CreateWebAuthnSessionParams{
+		ID: id,
+		UserID: uuid.NullUUID{
+			UUID:  dto.UserID,
+			Valid: dto.UserID != uuid.Nil,
+		},
+		Ceremony:      dto.Ceremony,
+		ChallengeHash: dto.ChallengeHash,
+		ExpiresAt: pgtype.Timestamp{
+			Time:             dto.ExpiresAt.UTC(),
+			InfinityModifier: 0,
+			Valid:            true,
+		},
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return newID, nil
+}
+
+type ConsumeWebAuthnSessionDTO struct {
+	ChallengeHash string
+	Ceremony      string
+}
+
+// ConsumeWebAuthnSession deletes a live session and returns the user it was
+// started for, uuid.Nil for usernameless ceremonies. It returns pgx.ErrNoRows
+// for unknown, expired or already used challenges.
+func (s Impl) ConsumeWebAuthnSession(ctx context.Context, dto ConsumeWebAuthnSessionDTO) (uuid.UUID, error) {
+	userID, err := s.PgStore.ConsumeWebAuthnSession(ctx, pgstore.ConsumeWebAuthnSessionParams{
+		ChallengeHash: dto.ChallengeHash,
+		Ceremony:      dto.Ceremony,
+	})
+	if err != nil {
+		return uuid.Nil, werr.Wrap(err)
+	}
+
+	return userID.UUID, nil
+}
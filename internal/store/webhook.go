@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store/pgstore"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+type WebhookEndpoint pgstore.WebhookEndpoint
+
+func (m WebhookEndpoint) Entity() entity.WebhookEndpoint {
+	return entity.WebhookEndpoint{
+		ID:         m.ID,
+		URL:        m.URL,
+		Secret:     m.Secret,
+		EventTypes: m.EventTypes,
+		Failures:   int(m.Failures),
+		Disabled:   m.DisabledAt.Valid,
+		DisabledAt: m.DisabledAt.Time,
+		CreatedAt:  m.CreatedAt.Time,
+		UpdatedAt:  m.UpdatedAt.Time,
+	}
+}
+
+type CreateWebhookEndpointDTO struct {
+	URL        string
+	Secret     string
+	EventTypes []string
+}
+
+func (s Impl) CreateWebhookEndpoint(ctx context.Context, dto CreateWebhookEndpointDTO) (WebhookEndpoint, error) {
+	eventTypes := dto.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	row, err := s.PgStore.CreateWebhookEndpoint(ctx, pgstore.CreateWebhookEndpointParams{
+		ID:         NewUUID(),
+		URL:        dto.URL,
+		Secret:     dto.Secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return WebhookEndpoint{}, werr.Wrap(err)
+	}
+
+	return WebhookEndpoint(row), nil
+}
+
+func (s Impl) FindWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	row, err := s.PgStore.FindWebhookEndpoint(ctx, id)
+	if err != nil {
+		return WebhookEndpoint{}, werr.Wrap(err)
+	}
+
+	return WebhookEndpoint(row), nil
+}
+
+func (s Impl) ListWebhookEndpoints(ctx context.Context) ([]WebhookEndpoint, error) {
+	rows, err := s.PgStore.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return webhookEndpoints(rows), nil
+}
+
+// ListWebhookEndpointsForEvent returns the enabled endpoints subscribed to eventType.
+func (s Impl) ListWebhookEndpointsForEvent(ctx context.Context, eventType string) ([]WebhookEndpoint, error) {
+	rows, err := s.PgStore.ListWebhookEndpointsForEvent(ctx, eventType)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return webhookEndpoints(rows), nil
+}
+
+// DeleteWebhookEndpoint returns pgx.ErrNoRows for an unknown endpoint.
+func (s Impl) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.PgStore.DeleteWebhookEndpoint(ctx, id); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// EnableWebhookEndpoint clears the failure count of an endpoint and enables it again.
+func (s Impl) EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.PgStore.EnableWebhookEndpoint(ctx, id); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (s Impl) RecordWebhookSuccess(ctx context.Context, id uuid.UUID) error {
+	return werr.Wrap(s.PgStore.RecordWebhookSuccess(ctx, id))
+}
+
+type RecordWebhookFailureDTO struct {
+	ID uuid.UUID
+	// MaxFailures consecutive failures disable the endpoint.
+	MaxFailures int32
+}
+
+func (s Impl) RecordWebhookFailure(ctx context.Context, dto RecordWebhookFailureDTO) error {
+	return werr.Wrap(s.PgStore.RecordWebhookFailure(ctx, pgstore.RecordWebhookFailureParams{
+		MaxFailures: dto.MaxFailures,
+		ID:          dto.ID,
+	}))
+}
+
+func webhookEndpoints(rows []pgstore.WebhookEndpoint) []WebhookEndpoint {
+	endpoints := make([]WebhookEndpoint, 0, len(rows))
+	for _, row := range rows {
+		endpoints = append(endpoints, WebhookEndpoint(row))
+	}
+
+	return endpoints
+}
@@ -0,0 +1,193 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	defaultListUsersLimit = 50
+	maxListUsersLimit     = 1000
+)
+
+// The methods in this file are for operators. They trust the caller, so
+// they skip rate limits, enumeration protection and event listeners.
+
+type CreateUserParams struct {
+	Email string
+	// Password may be empty for a passwordless user.
+	Password string
+	// Verified marks the email as confirmed. Otherwise no confirmation email
+	// is sent, and the user stays unverified until MarkUserVerified.
+	Verified bool
+}
+
+func (dto CreateUserParams) Validate() error {
+	if dto.Password != "" && (len(dto.Password) < 6 || len(dto.Password) > 256) {
+		return errorz.ErrPasswordLength
+	}
+	if !emailRegex.MatchString(dto.Email) {
+		return errorz.ErrInvalidEmailFormat
+	}
+
+	return nil
+}
+
+// CreateUser adds a user without sending any email.
+func (c Client) CreateUser(ctx context.Context, dto CreateUserParams) (entity.User, error) {
+	if err := dto.Validate(); err != nil {
+		return entity.User{}, werr.Wrap(err)
+	}
+	exists, err := c.store.ExistsUserByLogin(ctx, dto.Email)
+	if err != nil {
+		return entity.User{}, werr.Wrap(err)
+	}
+	if exists {
+		return entity.User{}, werr.Wrap(errorz.ErrLoginAlreadyExists)
+	}
+
+	passHash := ""
+	if dto.Password != "" {
+		if passHash, err = c.hasher.HashPassword(dto.Password); err != nil {
+			return entity.User{}, werr.Wrap(err)
+		}
+	}
+	userID, err := c.store.CreateUser(ctx, store.CreateUserDTO{
+		Email:        dto.Email,
+		PasswordHash: passHash,
+	})
+	if err != nil {
+		return entity.User{}, werr.Wrap(err)
+	}
+	if dto.Verified {
+		if err = c.store.UpdateUserAsVerified(ctx, dto.Email); err != nil {
+			return entity.User{}, werr.Wrap(err)
+		}
+	}
+
+	return c.FindUserByID(ctx, userID)
+}
+
+type ListUsersParams struct {
+	// Search keeps users whose email contains it, ignoring case.
+	Search string
+	// Limit defaults to 50 and is capped at 1000.
+	Limit  int32
+	Offset int32
+}
+
+// ListUsers returns users in the order they were created.
+func (c Client) ListUsers(ctx context.Context, dto ListUsersParams) ([]entity.User, error) {
+	if dto.Limit <= 0 {
+		dto.Limit = defaultListUsersLimit
+	}
+	rows, err := c.store.ListUsers(ctx, store.ListUsersDTO{
+		Search: dto.Search,
+		Limit:  min(dto.Limit, maxListUsersLimit),
+		Offset: max(dto.Offset, 0),
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	users := make([]entity.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.Entity())
+	}
+
+	return users, nil
+}
+
+// FindUser looks a user up by ID or, when idOrEmail is not a UUID, by email.
+func (c Client) FindUser(ctx context.Context, idOrEmail string) (entity.User, error) {
+	idOrEmail = strings.TrimSpace(idOrEmail)
+	if id, err := uuid.Parse(idOrEmail); err == nil {
+		return c.FindUserByID(ctx, id)
+	}
+
+	user, err := c.store.FindUserByEmail(ctx, idOrEmail)
+	if err != nil {
+		return entity.User{}, werr.Wrap(userError(err))
+	}
+
+	return user.Entity(), nil
+}
+
+func (c Client) FindUserByID(ctx context.Context, userID uuid.UUID) (entity.User, error) {
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, werr.Wrap(userError(err))
+	}
+
+	return user.Entity(), nil
+}
+
+// MarkUserVerified confirms the user's email without a code.
+func (c Client) MarkUserVerified(ctx context.Context, userID uuid.UUID) error {
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		return werr.Wrap(userError(err))
+	}
+
+	return werr.Wrap(c.store.UpdateUserAsVerified(ctx, user.Email))
+}
+
+// ForcePasswordReset clears the user's password, signs them out everywhere
+// and emails them a reset code. They cannot log in with a password until
+// they reset it.
+func (c Client) ForcePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		return werr.Wrap(userError(err))
+	}
+	if err = c.store.UpdateUserPassword(ctx, store.UpdateUserPasswordDTO{
+		Email:        user.Email,
+		PasswordHash: "",
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+	if err = c.RevokeAllTokens(ctx, userID); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.sendResetCode(ctx, user))
+}
+
+// LockAccount locks the user until the given time, as if they had failed too
+// many logins. UnlockAccount lifts it early.
+func (c Client) LockAccount(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	if c.lockout == nil {
+		return werr.Wrap(errorz.ErrLockoutDisabled)
+	}
+	if _, err := c.store.FindUserByID(ctx, userID); err != nil {
+		return werr.Wrap(userError(err))
+	}
+
+	return werr.Wrap(c.store.ForceLoginLock(ctx, store.LockLoginDTO{
+		Key:         userLockoutKey(userID),
+		LockedUntil: until,
+	}))
+}
+
+// DeleteUser removes the user with their tokens, factors and pending codes.
+// Audit events are kept.
+func (c Client) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	return werr.Wrap(userError(c.store.DeleteUser(ctx, userID)))
+}
+
+func userError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errorz.ErrUserNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,181 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Admin(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+	}
+	newClient := func(t *testing.T, opts ...authclient.Option) (*authclient.Client, *storemocks.Store) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+			EmailSenderHook: func(ctx context.Context, email string, code string) error {
+				return nil
+			},
+		}, append([]authclient.Option{authclient.WithStore(mockStore)}, opts...)...)
+		require.NoError(t, err)
+
+		return client, mockStore
+	}
+
+	t.Run("create verified user", func(t *testing.T) {
+		t.Parallel()
+
+		mockHasher := hashermocks.NewHasher(t)
+		client, mockStore := newClient(t, authclient.WithHasher(mockHasher))
+		mockHasher.On("HashPassword", "password123").Return("hashed_password", nil)
+		mockStore.On("ExistsUserByLogin", ctx, user.Email).Return(false, nil)
+		mockStore.On("CreateUser", ctx, store.CreateUserDTO{
+			Email:        user.Email,
+			PasswordHash: "hashed_password",
+		}).Return(user.ID, nil)
+		mockStore.On("UpdateUserAsVerified", ctx, user.Email).Return(nil)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+
+		created, err := client.CreateUser(ctx, authclient.CreateUserParams{
+			Email:    user.Email,
+			Password: "password123",
+			Verified: true,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, created.ID)
+		assert.True(t, created.IsVerified)
+	})
+
+	t.Run("create taken email", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore := newClient(t)
+		mockStore.On("ExistsUserByLogin", ctx, user.Email).Return(true, nil)
+
+		_, err := client.CreateUser(ctx, authclient.CreateUserParams{
+			Email:    user.Email,
+			Password: "",
+			Verified: false,
+		})
+
+		require.ErrorIs(t, err, errorz.ErrLoginAlreadyExists)
+	})
+
+	t.Run("find by id or email", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore := newClient(t)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockStore.On("FindUserByEmail", ctx, "missing@example.com").Return(store.User{}, pgx.ErrNoRows)
+
+		found, err := client.FindUser(ctx, " "+user.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, found.Email)
+
+		_, err = client.FindUser(ctx, "missing@example.com")
+		require.ErrorIs(t, err, errorz.ErrUserNotFound)
+	})
+
+	t.Run("list caps the limit", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore := newClient(t)
+		mockStore.On("ListUsers", ctx, store.ListUsersDTO{
+			Search: "example",
+			Limit:  1000,
+			Offset: 0,
+		}).Return([]store.User{user}, nil)
+
+		users, err := client.ListUsers(ctx, authclient.ListUsersParams{
+			Search: "example",
+			Limit:  5000,
+			Offset: -1,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, user.ID, users[0].ID)
+	})
+
+	t.Run("force password reset", func(t *testing.T) {
+		t.Parallel()
+
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		client, mockStore := newClient(t, authclient.WithCodeGenerator(mockCodeGenerator))
+		expiresAt := time.Now().Add(15 * time.Minute)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockStore.On("UpdateUserPassword", ctx, store.UpdateUserPasswordDTO{
+			Email:        user.Email,
+			PasswordHash: "",
+		}).Return(nil)
+		mockStore.On("RevokeUserTokens", ctx, user.ID).Return(int64(2), nil)
+		mockCodeGenerator.On("GenerateResetCode").Return(codegen.Code{Code: "654321", ExpiresAt: expiresAt}, nil)
+		mockStore.On("CreateEmailConfirmation", ctx, store.CreateEmailConfirmationDTO{
+			UserID:           user.ID,
+			ConfirmationCode: "654321",
+			ExpiresAt:        expiresAt,
+		}).Return(uuid.New(), nil)
+
+		require.NoError(t, client.ForcePasswordReset(ctx, user.ID))
+	})
+
+	t.Run("lock requires lockout", func(t *testing.T) {
+		t.Parallel()
+
+		client, _ := newClient(t)
+
+		err := client.LockAccount(ctx, user.ID, time.Now().Add(time.Hour))
+
+		require.ErrorIs(t, err, errorz.ErrLockoutDisabled)
+	})
+
+	t.Run("lock account", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore := newClient(t, authclient.WithLockout(authclient.LockoutConfig{}))
+		until := time.Now().Add(time.Hour)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockStore.On("ForceLoginLock", ctx, store.LockLoginDTO{
+			Key:         store.LockoutKey{Scope: entity.LockoutScopeUser, Subject: user.ID.String()},
+			LockedUntil: until,
+		}).Return(nil)
+
+		require.NoError(t, client.LockAccount(ctx, user.ID, until))
+	})
+
+	t.Run("delete unknown user", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore := newClient(t)
+		mockStore.On("DeleteUser", ctx, user.ID).Return(pgx.ErrNoRows)
+
+		require.ErrorIs(t, client.DeleteUser(ctx, user.ID), errorz.ErrUserNotFound)
+	})
+}
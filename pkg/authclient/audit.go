@@ -0,0 +1,113 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+const defaultAuthEventsLimit = 100
+
+// WithAuditLog records logins, registrations, email confirmations and
+// password resets in auth_events, with the client IP and user agent from
+// RequestInfo. Recording is best effort and never fails the flow itself.
+func WithAuditLog() Option {
+	return func(c *Client) error {
+		c.auditLog = true
+
+		return nil
+	}
+}
+
+type authEvent struct {
+	Type   entity.AuthEventType
+	UserID uuid.UUID
+	Email  string
+}
+
+// recordAuthEvent is deferred by the audited flows with their named error result.
+func (c Client) recordAuthEvent(ctx context.Context, event authEvent, err error) {
+	if !c.auditLog {
+		return
+	}
+
+	outcome := entity.AuthEventSuccess
+	var mfaRequired *errorz.MFARequiredError
+	switch {
+	case errors.As(err, &mfaRequired):
+		outcome = entity.AuthEventChallenge
+	case err != nil:
+		outcome = entity.AuthEventFailure
+	}
+	info := RequestInfoFromContext(ctx)
+	_ = c.store.CreateAuthEvent(context.WithoutCancel(ctx), store.CreateAuthEventDTO{
+		UserID:    event.UserID,
+		Email:     event.Email,
+		Type:      event.Type,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+		Outcome:   outcome,
+		ErrorCode: errorz.Code(err),
+	})
+}
+
+type AuthEventsParams struct {
+	// UserID uuid.Nil lists events of every user.
+	UserID uuid.UUID
+	From   time.Time
+	// To defaults to now.
+	To     time.Time
+	Limit  int32
+	Offset int32
+}
+
+// AuthEvents lists audit events in [From, To), newest first.
+func (c Client) AuthEvents(ctx context.Context, dto AuthEventsParams) ([]entity.AuthEvent, error) {
+	if !c.auditLog {
+		return nil, werr.Wrap(errorz.ErrAuditLogDisabled)
+	}
+	if dto.To.IsZero() {
+		dto.To = time.Now()
+	}
+	if dto.Limit <= 0 {
+		dto.Limit = defaultAuthEventsLimit
+	}
+
+	events, err := c.store.ListAuthEvents(ctx, store.ListAuthEventsDTO{
+		UserID: dto.UserID,
+		From:   dto.From,
+		To:     dto.To,
+		Limit:  dto.Limit,
+		Offset: dto.Offset,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	result := make([]entity.AuthEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Entity())
+	}
+
+	return result, nil
+}
+
+// PurgeAuthEvents deletes events older than retention and returns how many were removed.
+func (c Client) PurgeAuthEvents(ctx context.Context, retention time.Duration) (int64, error) {
+	if !c.auditLog {
+		return 0, werr.Wrap(errorz.ErrAuditLogDisabled)
+	}
+
+	deleted, err := c.store.DeleteAuthEventsBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,137 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AuditLog(t *testing.T) {
+	t.Parallel()
+	ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{
+		IP:        "203.0.113.7",
+		UserAgent: "test-agent",
+	})
+
+	newClient := func(t *testing.T) (*authclient.Client, *storemocks.Store, *hashermocks.Hasher, *jwtmocks.Creator) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithAuditLog(),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		return client, mockStore, mockHasher, mockJWTCreator
+	}
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+	}
+
+	t.Run("failed login", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, _ := newClient(t)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockHasher.On("CheckPasswordHash", "wrongpassword", user.PasswordHash).Return(false, nil)
+		mockStore.On("CreateAuthEvent", mock.Anything, store.CreateAuthEventDTO{
+			UserID:    user.ID,
+			Email:     user.Email,
+			Type:      entity.AuthEventLogin,
+			IP:        "203.0.113.7",
+			UserAgent: "test-agent",
+			Outcome:   entity.AuthEventFailure,
+			ErrorCode: "invalid_credentials",
+		}).Return(nil)
+
+		_, err := client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "wrongpassword"})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("successful login", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, mockJWTCreator := newClient(t)
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", user.ID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil)
+		mockStore.On("CreateAuthEvent", mock.Anything, mock.MatchedBy(func(dto store.CreateAuthEventDTO) bool {
+			return dto.UserID == user.ID && dto.Outcome == entity.AuthEventSuccess && dto.ErrorCode == ""
+		})).Return(nil)
+
+		_, err := client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "securepassword"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("audit failure does not fail the flow", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("FindUserByConfirmationCode", ctx, "code").Return(store.User{}, pgx.ErrNoRows)
+		mockStore.On("CreateAuthEvent", mock.Anything, mock.MatchedBy(func(dto store.CreateAuthEventDTO) bool {
+			return dto.Type == entity.AuthEventPasswordReset && dto.UserID == uuid.Nil
+		})).Return(assert.AnError)
+
+		err := client.ResetPassword(ctx, authclient.ResetPasswordParams{Code: "code", Password: "securepassword"})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("query and purge", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		from := time.Now().Add(-24 * time.Hour)
+		mockStore.On("ListAuthEvents", ctx, mock.MatchedBy(func(dto store.ListAuthEventsDTO) bool {
+			return dto.UserID == user.ID && dto.From.Equal(from) && !dto.To.IsZero() && dto.Limit == 100
+		})).Return([]store.AuthEvent{{
+			ID:        uuid.New(),
+			UserID:    uuid.NullUUID{UUID: user.ID, Valid: true},
+			EventType: string(entity.AuthEventRegister),
+			Outcome:   string(entity.AuthEventSuccess),
+		}}, nil)
+		mockStore.On("DeleteAuthEventsBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) >= 90*24*time.Hour
+		})).Return(int64(3), nil)
+
+		events, err := client.AuthEvents(ctx, authclient.AuthEventsParams{UserID: user.ID, From: from})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, entity.AuthEventRegister, events[0].Type)
+		assert.Equal(t, user.ID, events[0].UserID)
+
+		deleted, err := client.PurgeAuthEvents(ctx, 90*24*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+}
@@ -2,33 +2,63 @@ package authclient
 
 import (
 	"context"
+	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store"
 	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
 	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
 	"github.com/github.com/VadimOcLock/vauth/pkg/hash"
 	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/github.com/VadimOcLock/vauth/pkg/migrate"
+	"github.com/github.com/VadimOcLock/vauth/pkg/secretbox"
+	"github.com/github.com/VadimOcLock/vauth/pkg/totp"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/matchsystems/werr"
 )
 
 type Client struct {
-	store           store.Store
-	jwtCreator      jwtgen.Creator
-	hasher          hash.Hasher
-	codeGenerator   codegen.Generator
-	emailSenderHook EmailSenderHook
+	store         store.Store
+	jwtCreator    jwtgen.Creator
+	jwtVerifier   jwtgen.Verifier
+	hasher        hash.Hasher
+	codeGenerator codegen.Generator
+	emailSender   EmailSender
+	linkBuilder   LinkBuilder
+	emailData     map[string]any
+	outbox        *OutboxConfig
+	lockout       *LockoutConfig
+	rateLimiter   RateLimiter
+	enumeration   *enumerationProtection
+	auditLog      bool
+	listeners     []eventListener
+	webhooks      *WebhookConfig
+	totp          totp.Authenticator
+	secretBox     secretbox.Box
+	recoveryKey   []byte
+	relyingParty  webauthn.RelyingParty
+
+	passwordlessSignUp bool
+	autoMigrate        bool
 }
 
 type Config struct {
-	PgClient        *pgxpool.Pool
-	JWTConfig       jwtgen.CreatorConfig
-	HasherConfig    hash.Config
-	EmailSenderHook EmailSenderHook
+	PgClient          *pgxpool.Pool
+	JWTConfig         jwtgen.CreatorConfig
+	HasherConfig      hash.Config
+	EmailSender       EmailSender
+	EmailSenderHook   EmailSenderHook
+	EmailLinkBuilder  LinkBuilder
+	EmailTemplateData map[string]any
+	// MFAEncryptionKey is a 32-byte key for second factor secrets at rest; MFA is off without it.
+	MFAEncryptionKey []byte
+	TOTPConfig       totp.Config
+	// WebAuthnConfig enables passkeys when RPID is set.
+	WebAuthnConfig webauthn.Config
+	// RateLimiter throttles the flows that send email; see pkg/ratelimit.
+	RateLimiter RateLimiter
 }
 
-type EmailSenderHook func(ctx context.Context, email string, code string) error
-
 type Option func(*Client) error
 
 func WithStore(s store.Store) Option {
@@ -47,6 +77,14 @@ func WithJWTCreator(creator jwtgen.Creator) Option {
 	}
 }
 
+func WithJWTVerifier(verifier jwtgen.Verifier) Option {
+	return func(c *Client) error {
+		c.jwtVerifier = verifier
+
+		return nil
+	}
+}
+
 func WithHasher(hasher hash.Hasher) Option {
 	return func(c *Client) error {
 		c.hasher = hasher
@@ -63,9 +101,67 @@ func WithCodeGenerator(codeGen codegen.Generator) Option {
 	}
 }
 
+func WithEmailSender(sender EmailSender) Option {
+	return func(c *Client) error {
+		c.emailSender = sender
+
+		return nil
+	}
+}
+
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(c *Client) error {
+		c.rateLimiter = limiter
+
+		return nil
+	}
+}
+
+func WithTOTPAuthenticator(authenticator totp.Authenticator) Option {
+	return func(c *Client) error {
+		c.totp = authenticator
+
+		return nil
+	}
+}
+
+func WithRelyingParty(rp webauthn.RelyingParty) Option {
+	return func(c *Client) error {
+		c.relyingParty = rp
+
+		return nil
+	}
+}
+
+// WithPasswordlessSignUp lets RequestLoginLink create unknown users; they are verified on first login.
+func WithPasswordlessSignUp() Option {
+	return func(c *Client) error {
+		c.passwordlessSignUp = true
+
+		return nil
+	}
+}
+
+// WithAutoMigrate applies pending schema migrations to Config.PgClient in
+// New; see pkg/migrate. Replicas starting together wait for each other. A
+// schema created by hand needs migrate.Baseline first.
+func WithAutoMigrate() Option {
+	return func(c *Client) error {
+		c.autoMigrate = true
+
+		return nil
+	}
+}
+
 func New(cfg Config, options ...Option) (*Client, error) {
 	client := &Client{
-		emailSenderHook: cfg.EmailSenderHook,
+		emailSender: cfg.EmailSender,
+		linkBuilder: cfg.EmailLinkBuilder,
+		emailData:   cfg.EmailTemplateData,
+		rateLimiter: cfg.RateLimiter,
+	}
+	if client.emailSender == nil && cfg.EmailSenderHook != nil {
+		client.emailSender = cfg.EmailSenderHook
 	}
 
 	for _, opt := range options {
@@ -73,6 +169,17 @@ func New(cfg Config, options ...Option) (*Client, error) {
 			return nil, werr.Wrap(err)
 		}
 	}
+	if client.webhooks != nil && client.outbox == nil {
+		return nil, werr.Wrap(errorz.ErrOutboxDisabled)
+	}
+	if client.autoMigrate {
+		if cfg.PgClient == nil {
+			return nil, werr.Wrap(errorz.ErrPostgresClientMissed)
+		}
+		if err := migrate.Up(context.Background(), cfg.PgClient); err != nil {
+			return nil, werr.Wrap(err)
+		}
+	}
 	if client.store == nil {
 		if cfg.PgClient == nil {
 			return nil, werr.Wrap(errorz.ErrPostgresClientMissed)
@@ -86,15 +193,100 @@ func New(cfg Config, options ...Option) (*Client, error) {
 		}
 		client.jwtCreator = creator
 	}
+	if client.jwtVerifier == nil && (len(cfg.JWTConfig.SecretKey) > 0 || cfg.JWTConfig.SigningKey != nil) {
+		verifier, err := jwtgen.NewVerifier(cfg.JWTConfig)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		client.jwtVerifier = verifier
+	}
 	if client.hasher == nil {
 		client.hasher = hash.NewHasher(cfg.HasherConfig)
 	}
+	if err := client.initDummyHash(); err != nil {
+		return nil, werr.Wrap(err)
+	}
 	if client.codeGenerator == nil {
 		client.codeGenerator = codegen.NewGenerator()
 	}
-	if client.emailSenderHook == nil {
+	if client.totp == nil {
+		client.totp = totp.NewAuthenticator(cfg.TOTPConfig)
+	}
+	if client.relyingParty == nil && cfg.WebAuthnConfig.RPID != "" {
+		client.relyingParty = webauthn.NewRelyingParty(cfg.WebAuthnConfig)
+	}
+	if cfg.MFAEncryptionKey != nil {
+		box, err := secretbox.New(cfg.MFAEncryptionKey)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		client.secretBox = box
+		client.recoveryKey = recoveryCodeKey(cfg.MFAEncryptionKey)
+	}
+	// With the outbox, another process may deliver the queued emails.
+	if client.emailSender == nil && client.outbox == nil {
 		return nil, werr.Wrap(errorz.ErrEmailSendFunctionMissed)
 	}
 
 	return client, nil
 }
+
+func (c Client) sendEmail(ctx context.Context, purpose EmailPurpose, user store.User, code codegen.Code) error {
+	if c.emailSender == nil {
+		return werr.Wrap(errorz.ErrEmailSendFunctionMissed)
+	}
+
+	return werr.Wrap(c.emailSender.SendEmail(ctx, c.emailMessage(ctx, purpose, user, code)))
+}
+
+func (c Client) emailMessage(
+	ctx context.Context,
+	purpose EmailPurpose,
+	user store.User,
+	code codegen.Code,
+) EmailMessage {
+	msg := EmailMessage{
+		Purpose:   purpose,
+		To:        user.Email,
+		Code:      code.Code,
+		Link:      "",
+		ExpiresAt: code.ExpiresAt,
+		UserID:    user.ID,
+		Locale:    RequestInfoFromContext(ctx).Locale,
+		Data:      c.emailData,
+	}
+	if c.linkBuilder != nil {
+		msg.Link = c.linkBuilder(purpose, code.Code)
+	}
+
+	return msg
+}
+
+// Security notifications are best effort: a delivery failure never fails the flow that triggered them.
+func (c Client) notifyPasswordChanged(ctx context.Context, user store.User) {
+	if c.emailSender == nil {
+		return
+	}
+	_ = c.emailSender.SendPasswordChanged(ctx, c.securityNotification(ctx, user))
+}
+
+func (c Client) notifyNewLogin(ctx context.Context, user store.User) {
+	if c.emailSender == nil {
+		return
+	}
+	_ = c.emailSender.SendNewLogin(ctx, c.securityNotification(ctx, user))
+}
+
+func (c Client) securityNotification(ctx context.Context, user store.User) SecurityNotification {
+	info := RequestInfoFromContext(ctx)
+
+	return SecurityNotification{
+		To:         user.Email,
+		UserID:     user.ID,
+		Locale:     info.Locale,
+		IP:         info.IP,
+		UserAgent:  info.UserAgent,
+		OccurredAt: time.Now(),
+		Data:       c.emailData,
+	}
+}
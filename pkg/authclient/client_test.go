@@ -0,0 +1,51 @@
+package authclient_test
+
+import (
+	"testing"
+
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("auto migrate requires a postgres client", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithAutoMigrate())
+
+		require.ErrorIs(t, err, errorz.ErrPostgresClientMissed)
+	})
+
+	t.Run("email sender missed", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)))
+
+		require.ErrorIs(t, err, errorz.ErrEmailSendFunctionMissed)
+	})
+
+	t.Run("the outbox may be delivered by another process", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithOutbox(authclient.OutboxConfig{}))
+
+		require.NoError(t, err)
+	})
+}
@@ -3,9 +3,12 @@ package authclient
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/matchsystems/werr"
 )
@@ -26,17 +29,21 @@ func (c Client) SendConfirmationEmail(ctx context.Context, dto SendConfirmationE
 	if err := dto.Validate(); err != nil {
 		return werr.Wrap(err)
 	}
+	if err := c.checkRateLimit(ctx, RateLimitConfirmationEmail, dto.Email); err != nil {
+		return werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
 
 	user, err := c.store.FindUserByEmail(ctx, dto.Email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return werr.Wrap(errorz.ErrInvalidCredentials)
+			return werr.Wrap(c.hiddenError(errorz.ErrInvalidCredentials))
 		}
 
 		return werr.Wrap(err)
 	}
 	if user.Entity().IsVerified {
-		return werr.Wrap(errorz.ErrEmailAlreadyVerified)
+		return werr.Wrap(c.hiddenError(errorz.ErrEmailAlreadyVerified))
 	}
 
 	confirmCode, err := c.codeGenerator.GenerateConfirmationCode()
@@ -44,15 +51,20 @@ func (c Client) SendConfirmationEmail(ctx context.Context, dto SendConfirmationE
 		return werr.Wrap(err)
 	}
 
+	queued, err := c.queueEmail(ctx, EmailPurposeConfirmation, user, confirmCode)
+	if err != nil {
+		return werr.Wrap(err)
+	}
 	if _, err = c.store.CreateEmailConfirmation(ctx, store.CreateEmailConfirmationDTO{
 		UserID:           user.ID,
 		ConfirmationCode: confirmCode.Code,
 		ExpiresAt:        confirmCode.ExpiresAt,
+		Outbox:           queued,
 	}); err != nil {
 		return werr.Wrap(err)
 	}
 
-	if err = c.emailSenderHook(ctx, dto.Email, confirmCode.Code); err != nil {
+	if err = c.flushEmail(ctx, queued, EmailPurposeConfirmation, user, confirmCode); err != nil {
 		return werr.Wrap(err)
 	}
 
@@ -63,7 +75,10 @@ type ConfirmEmailParams struct {
 	Code string
 }
 
-func (c Client) ConfirmEmail(ctx context.Context, dto ConfirmEmailParams) error {
+func (c Client) ConfirmEmail(ctx context.Context, dto ConfirmEmailParams) (err error) {
+	event := authEvent{Type: entity.AuthEventEmailConfirm, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
 	user, err := c.store.FindUserByConfirmationCode(ctx, dto.Code)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -72,14 +87,24 @@ func (c Client) ConfirmEmail(ctx context.Context, dto ConfirmEmailParams) error
 
 		return werr.Wrap(err)
 	}
+	event.UserID, event.Email = user.ID, user.Email
 
 	if user.Entity().IsVerified {
 		return werr.Wrap(errorz.ErrEmailAlreadyVerified)
 	}
 
-	if err = c.store.UpdateUserAsVerified(ctx, user.Entity().Email); err != nil {
+	return c.verifyEmail(ctx, user)
+}
+
+func (c Client) verifyEmail(ctx context.Context, user store.User) error {
+	event := EmailVerified{EventMeta: eventMeta(user)}
+	if err := c.vetoEvent(ctx, event); err != nil {
+		return werr.Wrap(err)
+	}
+	if err := c.store.UpdateUserAsVerified(ctx, user.Email); err != nil {
 		return werr.Wrap(err)
 	}
+	c.publishEvent(ctx, event)
 
 	return nil
 }
@@ -81,7 +81,7 @@ func TestClient_SendConfirmationEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		err = client.SendConfirmationEmail(ctx, authclient.SendConfirmationEmailParams{
@@ -103,6 +103,7 @@ func TestClient_SendConfirmationEmail(t *testing.T) {
 				},
 			},
 			authclient.WithStore(mockStore),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -128,7 +129,7 @@ func TestClient_SendConfirmationEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		email := "verified@example.com"
@@ -160,6 +161,7 @@ func TestClient_SendConfirmationEmail(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -194,6 +196,7 @@ func TestClient_SendConfirmationEmail(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -289,7 +292,7 @@ func TestClient_ConfirmEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		confirmationCode := "123456"
@@ -320,7 +323,7 @@ func TestClient_ConfirmEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		confirmationCode := "invalid_code"
@@ -344,7 +347,7 @@ func TestClient_ConfirmEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		confirmationCode := "123456"
@@ -374,7 +377,7 @@ func TestClient_ConfirmEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		confirmationCode := "123456"
@@ -398,7 +401,7 @@ func TestClient_ConfirmEmail(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		confirmationCode := "123456"
@@ -0,0 +1,119 @@
+package authclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	"github.com/matchsystems/werr"
+)
+
+const defaultEnumerationMinDuration = 300 * time.Millisecond
+
+type EnumerationProtectionConfig struct {
+	// MinDuration is the least time every protected call takes, whatever the outcome.
+	MinDuration time.Duration
+}
+
+type enumerationProtection struct {
+	minDuration time.Duration
+	dummyHash   string
+}
+
+// WithEnumerationProtection makes Login, Register, ForgotPassword,
+// SendConfirmationEmail, RequestLoginLink and RequestAccountUnlock answer the
+// same way for registered and unknown emails. Unknown users get a password
+// check against a dummy hash, and registering a taken email succeeds but
+// emails the owner instead. Pair it with WithOutbox so that no path waits
+// on the mail server.
+func WithEnumerationProtection(cfg EnumerationProtectionConfig) Option {
+	return func(c *Client) error {
+		if cfg.MinDuration <= 0 {
+			cfg.MinDuration = defaultEnumerationMinDuration
+		}
+		c.enumeration = &enumerationProtection{
+			minDuration: cfg.MinDuration,
+			dummyHash:   "",
+		}
+
+		return nil
+	}
+}
+
+// initDummyHash hashes a random password once, so checks against unknown
+// users cost the same as real ones whatever the hasher.
+func (c *Client) initDummyHash() error {
+	if c.enumeration == nil {
+		return nil
+	}
+
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return werr.Wrap(err)
+	}
+	dummyHash, err := c.hasher.HashPassword(hex.EncodeToString(password))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	c.enumeration.dummyHash = dummyHash
+
+	return nil
+}
+
+func (c Client) checkDummyPassword(password string) {
+	if c.enumeration == nil {
+		return
+	}
+	_, _ = c.hasher.CheckPasswordHash(password, c.enumeration.dummyHash)
+}
+
+// hiddenError drops an error that would tell the caller whether an email is
+// registered, or in which state, when enumeration protection is on.
+func (c Client) hiddenError(err error) error {
+	if c.enumeration != nil {
+		return nil
+	}
+
+	return err
+}
+
+// padResponse is deferred with the call's start time. It sleeps until
+// MinDuration has passed or ctx is done.
+func (c Client) padResponse(ctx context.Context, start time.Time) {
+	if c.enumeration == nil {
+		return
+	}
+
+	wait := time.Until(start.Add(c.enumeration.minDuration))
+	if wait <= 0 {
+		return
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// notifySignUpAttempt is best effort like the other security notifications:
+// an error here must not answer differently from a fresh sign-up.
+func (c Client) notifySignUpAttempt(ctx context.Context, user store.User) {
+	if c.emailSender == nil && c.outbox == nil {
+		return
+	}
+
+	queued, err := c.queueEmail(ctx, EmailPurposeSignUpAttempt, user, codegen.Code{})
+	if err != nil {
+		return
+	}
+	if queued != nil {
+		_, _ = c.store.CreateOutboxMessage(ctx, *queued)
+
+		return
+	}
+	_ = c.sendEmail(ctx, EmailPurposeSignUpAttempt, user, codegen.Code{})
+}
@@ -0,0 +1,125 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_EnumerationProtection(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	const minDuration = 30 * time.Millisecond
+	newClient := func(t *testing.T) (*authclient.Client, *storemocks.Store, *hashermocks.Hasher, *recordingSender) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockHasher.On("HashPassword", mock.Anything).Return("dummy_hash", nil).Once()
+		sender := &recordingSender{}
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(sender),
+			authclient.WithEnumerationProtection(authclient.EnumerationProtectionConfig{MinDuration: minDuration}),
+		)
+		require.NoError(t, err)
+
+		return client, mockStore, mockHasher, sender
+	}
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: false, Valid: true},
+	}
+
+	t.Run("unknown email checks a dummy hash", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, _ := newClient(t)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(store.User{}, pgx.ErrNoRows)
+		mockHasher.On("CheckPasswordHash", "securepassword", "dummy_hash").Return(false, nil)
+
+		start := time.Now()
+		_, err := client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "securepassword"})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+		assert.GreaterOrEqual(t, time.Since(start), minDuration)
+	})
+
+	t.Run("unconfirmed email needs the right password to show", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, _ := newClient(t)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockHasher.On("CheckPasswordHash", "wrongpassword", user.PasswordHash).Return(false, nil)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+
+		_, err := client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "wrongpassword"})
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+
+		_, err = client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "securepassword"})
+		require.ErrorIs(t, err, errorz.ErrEmailNotConfirmed)
+	})
+
+	t.Run("register with a taken email notifies the owner", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, sender := newClient(t)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockHasher.On("HashPassword", "securepassword").Return("hashed", nil)
+
+		start := time.Now()
+		err := client.Register(ctx, authclient.RegisterParams{Email: user.Email, Password: "securepassword"})
+
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), minDuration)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, authclient.EmailPurposeSignUpAttempt, sender.messages[0].Purpose)
+		assert.Equal(t, user.Email, sender.messages[0].To)
+		assert.Empty(t, sender.messages[0].Code)
+	})
+
+	t.Run("forgot password for an unknown email succeeds quietly", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, sender := newClient(t)
+		mockStore.On("FindUserByEmail", ctx, "unknown@example.com").Return(store.User{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+
+		require.NoError(t, client.ForgotPassword(ctx, authclient.ForgotPasswordParams{Email: "unknown@example.com"}))
+		require.NoError(t, client.ForgotPassword(ctx, authclient.ForgotPasswordParams{Email: user.Email}))
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("confirmation email for a verified user succeeds quietly", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, sender := newClient(t)
+		verified := user
+		verified.IsVerified = pgtype.Bool{Bool: true, Valid: true}
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(verified, nil)
+
+		err := client.SendConfirmationEmail(ctx, authclient.SendConfirmationEmailParams{Email: user.Email})
+
+		require.NoError(t, err)
+		assert.Empty(t, sender.messages)
+	})
+}
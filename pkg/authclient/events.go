@@ -0,0 +1,133 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+// Event is one of UserRegistered, EmailVerified, PasswordChanged, LoggedIn
+// or SessionRevoked. Listeners tell them apart with a type switch.
+type Event interface {
+	Meta() EventMeta
+}
+
+type EventMeta struct {
+	UserID     uuid.UUID
+	Email      string
+	OccurredAt time.Time
+}
+
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// UserRegistered has no UserID when delivered synchronously: the user does not exist yet.
+type UserRegistered struct {
+	EventMeta
+}
+
+type EmailVerified struct {
+	EventMeta
+}
+
+type PasswordChanged struct {
+	EventMeta
+}
+
+type LoginMethod string
+
+const (
+	LoginMethodPassword LoginMethod = "password"
+	LoginMethodLink     LoginMethod = "link"
+	LoginMethodPasskey  LoginMethod = "passkey"
+)
+
+type LoggedIn struct {
+	EventMeta
+	Method LoginMethod
+	// MFA is set when a second factor completed the login.
+	MFA bool
+}
+
+// SessionRevoked is sent when a user's tokens are revoked.
+type SessionRevoked struct {
+	EventMeta
+}
+
+type EventListener interface {
+	HandleEvent(ctx context.Context, event Event) error
+}
+
+type EventListenerFunc func(ctx context.Context, event Event) error
+
+var _ EventListener = EventListenerFunc(nil)
+
+func (f EventListenerFunc) HandleEvent(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type DeliveryMode int
+
+const (
+	// DeliverSync runs the listener before the change is made, on the
+	// caller's goroutine. Returning an error vetoes the operation.
+	DeliverSync DeliveryMode = iota
+	// DeliverAsync runs the listener on its own goroutine after the change
+	// succeeded. Its errors are dropped.
+	DeliverAsync
+)
+
+type eventListener struct {
+	listener EventListener
+	mode     DeliveryMode
+}
+
+func WithEventListener(listener EventListener, mode DeliveryMode) Option {
+	return func(c *Client) error {
+		c.listeners = append(c.listeners, eventListener{listener: listener, mode: mode})
+
+		return nil
+	}
+}
+
+// vetoEvent runs the synchronous listeners. The first error is returned
+// joined with errorz.ErrOperationVetoed.
+func (c Client) vetoEvent(ctx context.Context, event Event) error {
+	for _, l := range c.listeners {
+		if l.mode != DeliverSync {
+			continue
+		}
+		if err := l.listener.HandleEvent(ctx, event); err != nil {
+			return werr.Wrap(errors.Join(errorz.ErrOperationVetoed, err))
+		}
+	}
+
+	return nil
+}
+
+// publishEvent runs the asynchronous listeners and queues webhook deliveries.
+func (c Client) publishEvent(ctx context.Context, event Event) {
+	c.enqueueWebhooks(ctx, event)
+	for _, l := range c.listeners {
+		if l.mode != DeliverAsync {
+			continue
+		}
+		go func() {
+			_ = l.listener.HandleEvent(context.WithoutCancel(ctx), event)
+		}()
+	}
+}
+
+func eventMeta(user store.User) EventMeta {
+	return EventMeta{
+		UserID:     user.ID,
+		Email:      user.Email,
+		OccurredAt: time.Now(),
+	}
+}
@@ -0,0 +1,160 @@
+package authclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_EventListeners(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("sync listener vetoes login", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		mockHasher := hashermocks.NewHasher(t)
+		errBlocked := errors.New("blocked")
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithHasher(mockHasher),
+			authclient.WithEventListener(
+				authclient.EventListenerFunc(func(_ context.Context, event authclient.Event) error {
+					if _, ok := event.(authclient.LoggedIn); ok {
+						return errBlocked
+					}
+
+					return nil
+				}),
+				authclient.DeliverSync,
+			),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		email := "test@example.com"
+		userID := uuid.New()
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{
+			ID:           userID,
+			Email:        email,
+			PasswordHash: "hashed_password",
+			IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+		mockHasher.On("CheckPasswordHash", "password", "hashed_password").Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
+
+		_, err = client.Login(ctx, authclient.LoginParams{Email: email, Password: "password"})
+
+		require.ErrorIs(t, err, errorz.ErrOperationVetoed)
+		require.ErrorIs(t, err, errBlocked)
+	})
+
+	t.Run("async listener receives login", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		mockHasher := hashermocks.NewHasher(t)
+		events := make(chan authclient.Event, 1)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithHasher(mockHasher),
+			authclient.WithEventListener(
+				authclient.EventListenerFunc(func(_ context.Context, event authclient.Event) error {
+					events <- event
+
+					return nil
+				}),
+				authclient.DeliverAsync,
+			),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		email := "test@example.com"
+		userID := uuid.New()
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Minute)}
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{
+			ID:           userID,
+			Email:        email,
+			PasswordHash: "hashed_password",
+			IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+		mockHasher.On("CheckPasswordHash", "password", "hashed_password").Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    userID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		_, err = client.Login(ctx, authclient.LoginParams{Email: email, Password: "password"})
+		require.NoError(t, err)
+
+		select {
+		case event := <-events:
+			loggedIn, ok := event.(authclient.LoggedIn)
+			require.True(t, ok)
+			assert.Equal(t, userID, loggedIn.UserID)
+			assert.Equal(t, authclient.LoginMethodPassword, loggedIn.Method)
+			assert.False(t, loggedIn.MFA)
+		case <-time.After(time.Second):
+			t.Fatal("event not delivered")
+		}
+	})
+
+	t.Run("confirm email emits email verified", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		var received []authclient.Event
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEventListener(
+				authclient.EventListenerFunc(func(_ context.Context, event authclient.Event) error {
+					received = append(received, event)
+
+					return nil
+				}),
+				authclient.DeliverSync,
+			),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		user := store.User{ID: uuid.New(), Email: "test@example.com"}
+		mockStore.On("FindUserByConfirmationCode", ctx, "code").Return(user, nil)
+		mockStore.On("UpdateUserAsVerified", ctx, user.Email).Return(nil)
+
+		err = client.ConfirmEmail(ctx, authclient.ConfirmEmailParams{Code: "code"})
+
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		verified, ok := received[0].(authclient.EmailVerified)
+		require.True(t, ok)
+		assert.Equal(t, user.ID, verified.UserID)
+		assert.Equal(t, user.Email, verified.Email)
+	})
+}
@@ -0,0 +1,150 @@
+package authclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	defaultJanitorInterval  = time.Hour
+	defaultJanitorBatchSize = 1000
+)
+
+type JanitorConfig struct {
+	// Interval between sweeps, an hour by default.
+	Interval time.Duration
+	// BatchSize bounds the rows deleted per table in each transaction.
+	BatchSize int32
+	// Retention keeps rows for this long after they expire.
+	Retention time.Duration
+}
+
+// JanitorMetrics are cumulative since the janitor was created.
+type JanitorMetrics struct {
+	Sweeps int64
+	// SkippedSweeps found another replica sweeping.
+	SkippedSweeps             int64
+	FailedSweeps              int64
+	DeletedTokens             int64
+	DeletedEmailConfirmations int64
+	DeletedOutboxMessages     int64
+	LastSweepAt               time.Time
+	LastSweepDuration         time.Duration
+}
+
+type JanitorSweep struct {
+	Skipped            bool
+	Tokens             int64
+	EmailConfirmations int64
+	OutboxMessages     int64
+}
+
+// Janitor deletes expired tokens and email confirmation codes. Revoked
+// tokens are deleted once they expire too: until then a revoked refresh
+// token is kept so that its reuse revokes the rest of the session. Sent
+// outbox messages, which hold the codes they emailed, are deleted after
+// Retention.
+//
+// Replicas may all run a janitor; an advisory lock lets one sweep at a time.
+type Janitor struct {
+	store store.Store
+	cfg   JanitorConfig
+
+	mu      sync.Mutex
+	metrics JanitorMetrics
+}
+
+func (c Client) NewJanitor(cfg JanitorConfig) *Janitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultJanitorInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultJanitorBatchSize
+	}
+	cfg.Retention = max(cfg.Retention, 0)
+
+	return &Janitor{
+		store:   c.store,
+		cfg:     cfg,
+		mu:      sync.Mutex{},
+		metrics: JanitorMetrics{},
+	}
+}
+
+// Run sweeps right away and then every Interval until ctx is done. Failed
+// sweeps are retried at the next interval.
+func (j *Janitor) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		_, _ = j.Sweep(ctx)
+		timer.Reset(j.cfg.Interval)
+	}
+}
+
+// Sweep deletes batches until one comes back short, ctx is done or another
+// replica takes over.
+func (j *Janitor) Sweep(ctx context.Context) (JanitorSweep, error) {
+	start := time.Now()
+	var sweep JanitorSweep
+	var err error
+	for first := true; ctx.Err() == nil; first = false {
+		var deleted store.DeletedExpired
+		deleted, err = j.store.DeleteExpired(ctx, store.DeleteExpiredDTO{
+			Before:    start.Add(-j.cfg.Retention),
+			BatchSize: j.cfg.BatchSize,
+		})
+		if err != nil || !deleted.Locked {
+			sweep.Skipped = first && err == nil
+
+			break
+		}
+		sweep.Tokens += deleted.Tokens
+		sweep.EmailConfirmations += deleted.EmailConfirmations
+		sweep.OutboxMessages += deleted.OutboxMessages
+		batch := int64(j.cfg.BatchSize)
+		if deleted.Tokens < batch && deleted.EmailConfirmations < batch && deleted.OutboxMessages < batch {
+			break
+		}
+	}
+	j.record(sweep, err, start)
+
+	return sweep, werr.Wrap(err)
+}
+
+// Metrics returns a snapshot, e.g. for exporting as counters.
+func (j *Janitor) Metrics() JanitorMetrics {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.metrics
+}
+
+func (j *Janitor) record(sweep JanitorSweep, err error, start time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	switch {
+	case err != nil:
+		j.metrics.FailedSweeps++
+	case sweep.Skipped:
+		j.metrics.SkippedSweeps++
+	default:
+		j.metrics.Sweeps++
+	}
+	j.metrics.DeletedTokens += sweep.Tokens
+	j.metrics.DeletedEmailConfirmations += sweep.EmailConfirmations
+	j.metrics.DeletedOutboxMessages += sweep.OutboxMessages
+	j.metrics.LastSweepAt = start
+	j.metrics.LastSweepDuration = time.Since(start)
+}
@@ -0,0 +1,156 @@
+package authclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newJanitor(t *testing.T, mockStore *storemocks.Store, cfg authclient.JanitorConfig) *authclient.Janitor {
+	t.Helper()
+
+	client, err := authclient.New(
+		authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		},
+		authclient.WithStore(mockStore),
+		authclient.WithEmailSender(&recordingSender{}),
+	)
+	require.NoError(t, err)
+
+	return client.NewJanitor(cfg)
+}
+
+func TestJanitor_Sweep(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("deletes batches until one is short", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		janitor := newJanitor(t, mockStore, authclient.JanitorConfig{BatchSize: 2, Retention: time.Hour})
+
+		start := time.Now()
+		mockStore.On("DeleteExpired", ctx, mock.MatchedBy(func(dto store.DeleteExpiredDTO) bool {
+			return dto.BatchSize == 2 && dto.Before.Before(start.Add(-time.Hour+time.Second)) &&
+				dto.Before.After(start.Add(-time.Hour-time.Minute))
+		})).Return(store.DeletedExpired{Locked: true, Tokens: 2, EmailConfirmations: 1}, nil).Once()
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{Locked: true, Tokens: 1, EmailConfirmations: 2}, nil).Once()
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{Locked: true, Tokens: 0, EmailConfirmations: 1}, nil).Once()
+
+		sweep, err := janitor.Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, authclient.JanitorSweep{Skipped: false, Tokens: 3, EmailConfirmations: 4}, sweep)
+		metrics := janitor.Metrics()
+		assert.Equal(t, int64(1), metrics.Sweeps)
+		assert.Equal(t, int64(3), metrics.DeletedTokens)
+		assert.Equal(t, int64(4), metrics.DeletedEmailConfirmations)
+		assert.WithinDuration(t, start, metrics.LastSweepAt, time.Second)
+	})
+
+	t.Run("purges sent outbox messages", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		janitor := newJanitor(t, mockStore, authclient.JanitorConfig{BatchSize: 2, Retention: 0})
+
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{Locked: true, Tokens: 0, EmailConfirmations: 0, OutboxMessages: 2}, nil).Once()
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{Locked: true, Tokens: 0, EmailConfirmations: 0, OutboxMessages: 1}, nil).Once()
+
+		sweep, err := janitor.Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), sweep.OutboxMessages)
+		assert.Equal(t, int64(3), janitor.Metrics().DeletedOutboxMessages)
+	})
+
+	t.Run("skips when another replica holds the lock", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		janitor := newJanitor(t, mockStore, authclient.JanitorConfig{})
+
+		mockStore.On("DeleteExpired", ctx, mock.MatchedBy(func(dto store.DeleteExpiredDTO) bool {
+			return dto.BatchSize == 1000
+		})).Return(store.DeletedExpired{Locked: false}, nil).Once()
+
+		sweep, err := janitor.Sweep(ctx)
+
+		require.NoError(t, err)
+		assert.True(t, sweep.Skipped)
+		metrics := janitor.Metrics()
+		assert.Equal(t, int64(1), metrics.SkippedSweeps)
+		assert.Zero(t, metrics.Sweeps)
+	})
+
+	t.Run("keeps the counts of a failed sweep", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		janitor := newJanitor(t, mockStore, authclient.JanitorConfig{BatchSize: 1})
+
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{Locked: true, Tokens: 1, EmailConfirmations: 0}, nil).Once()
+		mockStore.On("DeleteExpired", ctx, mock.Anything).
+			Return(store.DeletedExpired{}, errors.New("connection reset")).Once()
+
+		sweep, err := janitor.Sweep(ctx)
+
+		require.Error(t, err)
+		assert.False(t, sweep.Skipped)
+		assert.Equal(t, int64(1), sweep.Tokens)
+		metrics := janitor.Metrics()
+		assert.Equal(t, int64(1), metrics.FailedSweeps)
+		assert.Equal(t, int64(1), metrics.DeletedTokens)
+	})
+}
+
+func TestJanitor_Run(t *testing.T) {
+	t.Parallel()
+
+	mockStore := storemocks.NewStore(t)
+	janitor := newJanitor(t, mockStore, authclient.JanitorConfig{Interval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	swept := make(chan struct{}, 1)
+	mockStore.On("DeleteExpired", ctx, mock.Anything).
+		Run(func(mock.Arguments) {
+			select {
+			case swept <- struct{}{}:
+			default:
+			}
+		}).
+		Return(store.DeletedExpired{Locked: true}, nil)
+
+	done := make(chan error)
+	go func() { done <- janitor.Run(ctx) }()
+	<-swept
+	<-swept
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+	assert.GreaterOrEqual(t, janitor.Metrics().Sweeps, int64(2))
+}
@@ -0,0 +1,285 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	defaultLockoutMaxUserFailures = 5
+	defaultLockoutMaxIPFailures   = 50
+	defaultLockoutWindow          = 15 * time.Minute
+	defaultLockoutDuration        = 15 * time.Minute
+)
+
+type LockoutConfig struct {
+	// MaxUserFailures and MaxIPFailures are the failed logins allowed within
+	// Window before the user or the client IP is locked. Negative disables a counter.
+	MaxUserFailures int32
+	MaxIPFailures   int32
+	Window          time.Duration
+	LockDuration    time.Duration
+	// MaxLockDuration above LockDuration doubles the lock on every consecutive
+	// lockout up to this cap. Otherwise every lock lasts LockDuration.
+	MaxLockDuration time.Duration
+}
+
+// WithLockout counts failed logins in Postgres, so every replica sees the
+// same counters. The client IP comes from RequestInfo in the context.
+func WithLockout(cfg LockoutConfig) Option {
+	return func(c *Client) error {
+		if cfg.MaxUserFailures == 0 {
+			cfg.MaxUserFailures = defaultLockoutMaxUserFailures
+		}
+		if cfg.MaxIPFailures == 0 {
+			cfg.MaxIPFailures = defaultLockoutMaxIPFailures
+		}
+		if cfg.Window <= 0 {
+			cfg.Window = defaultLockoutWindow
+		}
+		if cfg.LockDuration <= 0 {
+			cfg.LockDuration = defaultLockoutDuration
+		}
+		c.lockout = &cfg
+
+		return nil
+	}
+}
+
+// UnlockAccount clears the user's failed login counter and any lock on it.
+func (c Client) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	if c.lockout == nil {
+		return werr.Wrap(errorz.ErrLockoutDisabled)
+	}
+
+	return werr.Wrap(c.store.DeleteLoginThrottle(ctx, userLockoutKey(userID)))
+}
+
+// UnlockIP clears the failed login counter of a client IP.
+func (c Client) UnlockIP(ctx context.Context, ip string) error {
+	if c.lockout == nil {
+		return werr.Wrap(errorz.ErrLockoutDisabled)
+	}
+
+	return werr.Wrap(c.store.DeleteLoginThrottle(ctx, store.LockoutKey{
+		Scope:   entity.LockoutScopeIP,
+		Subject: ip,
+	}))
+}
+
+type RequestAccountUnlockParams struct {
+	Email string
+}
+
+func (dto RequestAccountUnlockParams) Validate() error {
+	if !emailRegex.MatchString(dto.Email) {
+		return errorz.ErrInvalidEmailFormat
+	}
+
+	return nil
+}
+
+// RequestAccountUnlock emails an unlock code to a locked user. It does
+// nothing when the account is not locked.
+func (c Client) RequestAccountUnlock(ctx context.Context, dto RequestAccountUnlockParams) error {
+	if c.lockout == nil {
+		return werr.Wrap(errorz.ErrLockoutDisabled)
+	}
+	if err := dto.Validate(); err != nil {
+		return werr.Wrap(err)
+	}
+	if err := c.checkRateLimit(ctx, RateLimitAccountUnlock, dto.Email); err != nil {
+		return werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
+
+	user, err := c.store.FindUserByEmail(ctx, dto.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(c.hiddenError(errorz.ErrInvalidCredentials))
+		}
+
+		return werr.Wrap(err)
+	}
+	if _, err = c.store.FindLoginLock(ctx, userLockoutKey(user.ID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return werr.Wrap(err)
+	}
+
+	unlockCode, err := c.codeGenerator.GenerateUnlockCode()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	queued, err := c.queueEmail(ctx, EmailPurposeUnlock, user, unlockCode)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if _, err = c.store.CreateUnlockCode(ctx, store.CreateUnlockCodeDTO{
+		UserID:     user.ID,
+		UnlockCode: unlockCode.Code,
+		ExpiresAt:  unlockCode.ExpiresAt,
+		Outbox:     queued,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.flushEmail(ctx, queued, EmailPurposeUnlock, user, unlockCode))
+}
+
+// UnlockAccountWithCode redeems a code sent by RequestAccountUnlock.
+func (c Client) UnlockAccountWithCode(ctx context.Context, code string) error {
+	if c.lockout == nil {
+		return werr.Wrap(errorz.ErrLockoutDisabled)
+	}
+
+	if _, err := c.store.UnlockAccountWithCode(ctx, code); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+type lockoutCounter struct {
+	key         store.LockoutKey
+	maxFailures int32
+}
+
+func (c Client) ipLockoutCounters(ctx context.Context) []lockoutCounter {
+	ip := RequestInfoFromContext(ctx).IP
+	if c.lockout == nil || c.lockout.MaxIPFailures <= 0 || ip == "" {
+		return nil
+	}
+
+	return []lockoutCounter{{
+		key:         store.LockoutKey{Scope: entity.LockoutScopeIP, Subject: ip},
+		maxFailures: c.lockout.MaxIPFailures,
+	}}
+}
+
+func (c Client) userLockoutCounters(userID uuid.UUID) []lockoutCounter {
+	if c.lockout == nil || c.lockout.MaxUserFailures <= 0 {
+		return nil
+	}
+
+	return []lockoutCounter{{
+		key:         userLockoutKey(userID),
+		maxFailures: c.lockout.MaxUserFailures,
+	}}
+}
+
+// unknownEmailLockoutCounters count failed logins for an email without an
+// account like a user's counters do. With enumeration protection, getting
+// locked out must not tell registered emails apart.
+func (c Client) unknownEmailLockoutCounters(email string) []lockoutCounter {
+	if c.lockout == nil || c.enumeration == nil || c.lockout.MaxUserFailures <= 0 {
+		return nil
+	}
+
+	return []lockoutCounter{{
+		key:         store.LockoutKey{Scope: entity.LockoutScopeEmail, Subject: hashToken(strings.ToLower(email))},
+		maxFailures: c.lockout.MaxUserFailures,
+	}}
+}
+
+// checkLoginLock returns an *errorz.AccountLockedError for the longest active lock.
+func (c Client) checkLoginLock(ctx context.Context, counters []lockoutCounter) error {
+	var lockedUntil time.Time
+	for _, counter := range counters {
+		until, err := c.store.FindLoginLock(ctx, counter.key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+
+			return werr.Wrap(err)
+		}
+		if until.After(lockedUntil) {
+			lockedUntil = until
+		}
+	}
+	if lockedUntil.IsZero() {
+		return nil
+	}
+
+	return werr.Wrap(&errorz.AccountLockedError{RetryAfter: time.Until(lockedUntil)})
+}
+
+// loginFailed counts a failed attempt against every counter. It returns
+// ErrInvalidCredentials, or an *errorz.AccountLockedError when this attempt
+// crossed a threshold.
+func (c Client) loginFailed(ctx context.Context, counters []lockoutCounter) error {
+	var lockFor time.Duration
+	for _, counter := range counters {
+		failures, err := c.store.RecordLoginFailure(ctx, store.RecordLoginFailureDTO{
+			Key:         counter.key,
+			WindowStart: time.Now().Add(-c.lockout.Window),
+		})
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		if failures.Failures < counter.maxFailures {
+			continue
+		}
+
+		duration := c.lockout.lockDuration(failures.Lockouts)
+		if err = c.store.LockLogin(ctx, store.LockLoginDTO{
+			Key:         counter.key,
+			LockedUntil: time.Now().Add(duration),
+		}); err != nil {
+			return werr.Wrap(err)
+		}
+		lockFor = max(lockFor, duration)
+	}
+	if lockFor > 0 {
+		return werr.Wrap(&errorz.AccountLockedError{RetryAfter: lockFor})
+	}
+
+	return werr.Wrap(errorz.ErrInvalidCredentials)
+}
+
+// loginSucceeded resets the user's counter. IP counters only expire, so one
+// valid account cannot be used to keep guessing others from the same address.
+func (c Client) loginSucceeded(ctx context.Context, userID uuid.UUID) error {
+	for _, counter := range c.userLockoutCounters(userID) {
+		if err := c.store.DeleteLoginThrottle(ctx, counter.key); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (cfg LockoutConfig) lockDuration(lockouts int32) time.Duration {
+	duration := cfg.LockDuration
+	if cfg.MaxLockDuration <= cfg.LockDuration {
+		return duration
+	}
+	for i := int32(0); i < lockouts && duration < cfg.MaxLockDuration; i++ {
+		duration *= 2
+	}
+
+	return min(duration, cfg.MaxLockDuration)
+}
+
+func userLockoutKey(userID uuid.UUID) store.LockoutKey {
+	return store.LockoutKey{
+		Scope:   entity.LockoutScopeUser,
+		Subject: userID.String(),
+	}
+}
@@ -0,0 +1,388 @@
+package authclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
+	webauthnmocks "github.com/github.com/VadimOcLock/vauth/pkg/webauthn/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Lockout(t *testing.T) {
+	t.Parallel()
+	ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{IP: "203.0.113.7"})
+
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+	}
+	ipKey := store.LockoutKey{Scope: entity.LockoutScopeIP, Subject: "203.0.113.7"}
+	userKey := store.LockoutKey{Scope: entity.LockoutScopeUser, Subject: user.ID.String()}
+	cfg := authclient.LockoutConfig{
+		MaxUserFailures: 3,
+		MaxIPFailures:   20,
+		Window:          10 * time.Minute,
+		LockDuration:    time.Minute,
+		MaxLockDuration: 10 * time.Minute,
+	}
+	newClient := func(t *testing.T) (*authclient.Client, *storemocks.Store, *hashermocks.Hasher, *jwtmocks.Creator) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithLockout(cfg),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		return client, mockStore, mockHasher, mockJWTCreator
+	}
+	login := authclient.LoginParams{Email: user.Email, Password: "wrongpassword"}
+
+	t.Run("wrong password below the threshold", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, _ := newClient(t)
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockHasher.On("CheckPasswordHash", login.Password, user.PasswordHash).Return(false, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey && time.Since(dto.WindowStart) >= cfg.Window
+		})).Return(store.LoginFailures{Failures: 5}, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == userKey
+		})).Return(store.LoginFailures{Failures: 2}, nil)
+
+		_, err := client.Login(ctx, login)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("threshold locks with backoff", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, _ := newClient(t)
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockHasher.On("CheckPasswordHash", login.Password, user.PasswordHash).Return(false, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey
+		})).Return(store.LoginFailures{Failures: 5}, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == userKey
+		})).Return(store.LoginFailures{Failures: 3, Lockouts: 2}, nil)
+		mockStore.On("LockLogin", ctx, mock.MatchedBy(func(dto store.LockLoginDTO) bool {
+			return dto.Key == userKey && time.Until(dto.LockedUntil) > 3*time.Minute
+		})).Return(nil)
+
+		_, err := client.Login(ctx, login)
+
+		var lockedErr *errorz.AccountLockedError
+		require.ErrorAs(t, err, &lockedErr)
+		require.ErrorIs(t, err, errorz.ErrAccountLocked)
+		assert.Equal(t, 4*time.Minute, lockedErr.RetryAfter)
+	})
+
+	t.Run("locked ip is rejected before the lookup", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Now().Add(time.Minute), nil)
+
+		_, err := client.Login(ctx, login)
+
+		var lockedErr *errorz.AccountLockedError
+		require.ErrorAs(t, err, &lockedErr)
+		assert.InDelta(t, time.Minute, lockedErr.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("unknown email counts against the ip", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(store.User{}, pgx.ErrNoRows)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey
+		})).Return(store.LoginFailures{Failures: 1}, nil)
+
+		_, err := client.Login(ctx, login)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("with enumeration protection an unknown email locks like a user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockHasher.On("HashPassword", mock.Anything).Return("dummy_hash", nil).Once()
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithLockout(cfg),
+			authclient.WithEnumerationProtection(authclient.EnumerationProtectionConfig{MinDuration: time.Millisecond}),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		sum := sha256.Sum256([]byte("unknown@example.com"))
+		emailKey := store.LockoutKey{Scope: entity.LockoutScopeEmail, Subject: hex.EncodeToString(sum[:])}
+		unknown := authclient.LoginParams{Email: "Unknown@example.com", Password: "wrongpassword"}
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, unknown.Email).Return(store.User{}, pgx.ErrNoRows)
+		mockStore.On("FindLoginLock", ctx, emailKey).Return(time.Time{}, pgx.ErrNoRows).Once()
+		mockHasher.On("CheckPasswordHash", unknown.Password, "dummy_hash").Return(false, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey
+		})).Return(store.LoginFailures{Failures: 5}, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == emailKey
+		})).Return(store.LoginFailures{Failures: 3}, nil)
+		mockStore.On("LockLogin", ctx, mock.MatchedBy(func(dto store.LockLoginDTO) bool {
+			return dto.Key == emailKey
+		})).Return(nil)
+
+		_, err = client.Login(ctx, unknown)
+		require.ErrorIs(t, err, errorz.ErrAccountLocked)
+
+		mockStore.On("FindLoginLock", ctx, emailKey).Return(time.Now().Add(time.Minute), nil).Once()
+		_, err = client.Login(ctx, unknown)
+		require.ErrorIs(t, err, errorz.ErrAccountLocked)
+	})
+
+	t.Run("successful login resets the user counter", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockHasher, mockJWTCreator := newClient(t)
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+		mockStore.On("DeleteLoginThrottle", ctx, userKey).Return(nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", user.ID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		result, err := client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "securepassword"})
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+	})
+
+	t.Run("login waiting for a second factor keeps the user counter", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		client, err := authclient.New(
+			authclient.Config{MFAEncryptionKey: mfaKey},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithLockout(cfg),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor{
+			{UserID: user.ID, FactorType: string(entity.MFAFactorTOTP), Confirmed: true},
+		}, nil)
+		mockStore.On("CreateMFAChallenge", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		_, err = client.Login(ctx, authclient.LoginParams{Email: user.Email, Password: "securepassword"})
+
+		require.ErrorIs(t, err, errorz.ErrMFARequired)
+		mockStore.AssertNotCalled(t, "DeleteLoginThrottle", ctx, userKey)
+	})
+
+	t.Run("wrong second factor counts against the user and ip", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		sum := sha256.Sum256([]byte("111111"))
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("AttemptMFAChallenge", ctx, mock.Anything).Return(store.MFAChallenge{
+			ID:                 uuid.New(),
+			UserID:             user.ID,
+			EmailCodeHash:      hex.EncodeToString(sum[:]),
+			EmailCodeExpiresAt: time.Now().Add(time.Minute),
+		}, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("FindMFAFactor", ctx, user.ID, entity.MFAFactorTOTP).Return(store.MFAFactor{}, pgx.ErrNoRows)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey
+		})).Return(store.LoginFailures{Failures: 5}, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == userKey
+		})).Return(store.LoginFailures{Failures: 3}, nil)
+		mockStore.On("LockLogin", ctx, mock.MatchedBy(func(dto store.LockLoginDTO) bool {
+			return dto.Key == userKey
+		})).Return(nil)
+
+		_, err := client.VerifyMFA(ctx, "challenge", "222222")
+
+		var lockedErr *errorz.AccountLockedError
+		require.ErrorAs(t, err, &lockedErr)
+	})
+
+	t.Run("used up challenge counts against its user", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		sum := sha256.Sum256([]byte("challenge"))
+		mockStore.On("FindLoginLock", ctx, ipKey).Return(time.Time{}, pgx.ErrNoRows)
+		mockStore.On("AttemptMFAChallenge", ctx, mock.Anything).Return(store.MFAChallenge{}, pgx.ErrNoRows)
+		mockStore.On("FindMFAChallengeUserID", ctx, hex.EncodeToString(sum[:])).Return(user.ID, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == ipKey
+		})).Return(store.LoginFailures{Failures: 5}, nil)
+		mockStore.On("RecordLoginFailure", ctx, mock.MatchedBy(func(dto store.RecordLoginFailureDTO) bool {
+			return dto.Key == userKey
+		})).Return(store.LoginFailures{Failures: 1}, nil)
+
+		_, err := client.VerifyMFA(ctx, "challenge", "123456")
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("magic link refuses a locked user", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("ConsumeLoginCode", ctx, "login-code").Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Now().Add(time.Minute), nil)
+
+		_, err := client.CompleteLogin(ctx, authclient.CompleteLoginParams{Code: "login-code"})
+
+		require.ErrorIs(t, err, errorz.ErrAccountLocked)
+	})
+
+	t.Run("passkey login refuses a locked user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithRelyingParty(webauthnmocks.NewRelyingParty(t)),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithLockout(cfg),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+		mockStore.On("ConsumeWebAuthnSession", ctx, store.ConsumeWebAuthnSessionDTO{
+			ChallengeHash: hashOf("abc"),
+			Ceremony:      "login",
+		}).Return(uuid.Nil, nil)
+		mockStore.On("FindWebAuthnCredential", ctx, []byte("cred")).
+			Return(store.WebAuthnCredential{ID: uuid.New(), UserID: user.ID, CredentialID: []byte("cred")}, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Now().Add(time.Minute), nil)
+
+		_, err = client.FinishPasskeyLogin(ctx, webauthn.AssertionResponse{
+			CredentialID:   []byte("cred"),
+			ClientDataJSON: []byte(`{"type":"webauthn.get","challenge":"abc","origin":"https://example.com"}`),
+		})
+
+		require.ErrorIs(t, err, errorz.ErrAccountLocked)
+	})
+
+	t.Run("unlock link", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		sender := &recordingSender{}
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(sender),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithHasher(hashermocks.NewHasher(t)),
+			authclient.WithLockout(cfg),
+		)
+		require.NoError(t, err)
+
+		code := codegen.Code{Code: "unlock-code", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockStore.On("FindLoginLock", ctx, userKey).Return(time.Now().Add(time.Minute), nil)
+		mockCodeGenerator.On("GenerateUnlockCode").Return(code, nil)
+		mockStore.On("CreateUnlockCode", ctx, store.CreateUnlockCodeDTO{
+			UserID:     user.ID,
+			UnlockCode: code.Code,
+			ExpiresAt:  code.ExpiresAt,
+			Outbox:     nil,
+		}).Return(uuid.New(), nil)
+
+		err = client.RequestAccountUnlock(ctx, authclient.RequestAccountUnlockParams{Email: user.Email})
+
+		require.NoError(t, err)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, authclient.EmailPurposeUnlock, sender.messages[0].Purpose)
+
+		mockStore.On("UnlockAccountWithCode", ctx, code.Code).Return(user.ID, nil)
+		mockStore.On("UnlockAccountWithCode", ctx, "used").Return(uuid.Nil, pgx.ErrNoRows)
+
+		require.NoError(t, client.UnlockAccountWithCode(ctx, code.Code))
+		require.ErrorIs(t, client.UnlockAccountWithCode(ctx, "used"), errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("admin unlock", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("DeleteLoginThrottle", ctx, userKey).Return(nil)
+
+		require.NoError(t, client.UnlockAccount(ctx, user.ID))
+	})
+
+	t.Run("unlock requires lockout", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(storemocks.NewStore(t)),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, client.UnlockAccount(ctx, user.ID), errorz.ErrLockoutDisabled)
+	})
+}
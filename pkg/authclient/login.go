@@ -3,9 +3,12 @@ package authclient
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/matchsystems/werr"
 )
@@ -26,43 +29,115 @@ func (dto LoginParams) Validate() error {
 	return nil
 }
 
+// Login returns an access token. When the user has an active second factor it
+// returns a challenge token instead, together with an *errorz.MFARequiredError;
+// the login is finished by VerifyMFA. With WithLockout, a locked user or IP
+// gets an *errorz.AccountLockedError before the password is checked.
 func (c Client) Login(ctx context.Context, dto LoginParams) (string, error) {
+	tokens, err := c.login(ctx, dto, false)
+	var mfaRequired *errorz.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		return mfaRequired.Challenge, werr.Wrap(err)
+	}
+
+	return tokens.AccessToken.Token, werr.Wrap(err)
+}
+
+// LoginTokens is Login that also issues a refresh token for Refresh. Logins
+// that need a second factor are finished by VerifyMFATokens.
+func (c Client) LoginTokens(ctx context.Context, dto LoginParams) (Tokens, error) {
+	return c.login(ctx, dto, true)
+}
+
+func (c Client) login(ctx context.Context, dto LoginParams, refresh bool) (_ Tokens, err error) {
+	event := authEvent{Type: entity.AuthEventLogin, UserID: uuid.Nil, Email: dto.Email}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
 	if err := dto.Validate(); err != nil {
-		return "", werr.Wrap(err)
+		return Tokens{}, werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
+	ipCounters := c.ipLockoutCounters(ctx)
+	if err := c.checkLoginLock(ctx, ipCounters); err != nil {
+		return Tokens{}, werr.Wrap(err)
 	}
 	user, err := c.store.FindUserByEmail(ctx, dto.Email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return "", werr.Wrap(errorz.ErrInvalidCredentials)
+			emailCounters := c.unknownEmailLockoutCounters(dto.Email)
+			if err := c.checkLoginLock(ctx, emailCounters); err != nil {
+				return Tokens{}, werr.Wrap(err)
+			}
+			c.checkDummyPassword(dto.Password)
+
+			return Tokens{}, werr.Wrap(c.loginFailed(ctx, append(ipCounters, emailCounters...)))
 		}
 
-		return "", werr.Wrap(err)
+		return Tokens{}, werr.Wrap(err)
 	}
-	if !user.Entity().IsVerified {
-		return "", werr.Wrap(errorz.ErrEmailNotConfirmed)
+	event.UserID = user.ID
+	userCounters := c.userLockoutCounters(user.ID)
+	if err = c.checkLoginLock(ctx, userCounters); err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	// With enumeration protection, only the right password learns that the email is unconfirmed.
+	if !user.Entity().IsVerified && c.enumeration == nil {
+		return Tokens{}, werr.Wrap(errorz.ErrEmailNotConfirmed)
 	}
 
 	equals, err := c.hasher.CheckPasswordHash(dto.Password, user.PasswordHash)
 	if err != nil {
-		return "", werr.Wrap(err)
+		return Tokens{}, werr.Wrap(err)
 	}
 	if !equals {
-		return "", werr.Wrap(errorz.ErrInvalidCredentials)
+		return Tokens{}, werr.Wrap(c.loginFailed(ctx, append(ipCounters, userCounters...)))
+	}
+	if !user.Entity().IsVerified {
+		return Tokens{}, werr.Wrap(errorz.ErrEmailNotConfirmed)
 	}
 
-	token, err := c.jwtCreator.CreateAccessToken(user.ID.String())
+	mfaRequired, err := c.beginMFAChallenge(ctx, user, LoginMethodPassword)
 	if err != nil {
-		return "", werr.Wrap(err)
+		return Tokens{}, werr.Wrap(err)
+	}
+	if mfaRequired != nil {
+		return Tokens{}, werr.Wrap(mfaRequired)
 	}
+	if err = c.loginSucceeded(ctx, user.ID); err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	return c.signInTokens(ctx, user, LoginMethodPassword, false, refresh)
+}
 
-	_, err = c.store.CreateToken(ctx, store.CreateTokenDTO{
-		UserID:    user.ID,
-		Token:     token.Token,
-		ExpiresAt: token.ExpiresAt,
-	})
+// signIn finishes every kind of login once all factors are verified.
+func (c Client) signIn(ctx context.Context, user store.User, method LoginMethod, mfa bool) (string, error) {
+	tokens, err := c.signInTokens(ctx, user, method, mfa, false)
 	if err != nil {
 		return "", werr.Wrap(err)
 	}
 
-	return token.Token, nil
+	return tokens.AccessToken.Token, nil
+}
+
+func (c Client) signInTokens(
+	ctx context.Context,
+	user store.User,
+	method LoginMethod,
+	mfa bool,
+	refresh bool,
+) (Tokens, error) {
+	event := LoggedIn{EventMeta: eventMeta(user), Method: method, MFA: mfa}
+	if err := c.vetoEvent(ctx, event); err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	tokens, err := c.issueTokens(ctx, user.ID, refresh)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	c.notifyNewLogin(ctx, user)
+	c.publishEvent(ctx, event)
+
+	return tokens, nil
 }
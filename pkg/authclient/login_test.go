@@ -35,6 +35,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -58,6 +59,7 @@ func TestClient_Login(t *testing.T) {
 			},
 		}, nil)
 		mockHasher.On("CheckPasswordHash", password, hashedPassword).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
 		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
 		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
 			UserID:    userID,
@@ -88,6 +90,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -110,6 +113,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -137,6 +141,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -176,6 +181,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -212,6 +218,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -230,6 +237,7 @@ func TestClient_Login(t *testing.T) {
 			},
 		}, nil)
 		mockHasher.On("CheckPasswordHash", password, hashedPassword).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
 		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(jwtgen.Token{}, errors.New("token creation error"))
 
 		_, err = client.Login(ctx, authclient.LoginParams{
@@ -253,6 +261,7 @@ func TestClient_Login(t *testing.T) {
 			authclient.WithStore(mockStore),
 			authclient.WithJWTCreator(mockJWTCreator),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -275,6 +284,7 @@ func TestClient_Login(t *testing.T) {
 			},
 		}, nil)
 		mockHasher.On("CheckPasswordHash", password, hashedPassword).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
 		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
 		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
 			UserID:    userID,
@@ -0,0 +1,379 @@
+package authclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+)
+
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+	// QRCode is a PNG encoding of URI.
+	QRCode []byte
+}
+
+// BeginTOTPEnrollment generates a new secret for the user. The factor stays
+// inactive until ConfirmTOTPEnrollment; calling this again replaces the secret.
+func (c Client) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (TOTPEnrollment, error) {
+	if c.secretBox == nil {
+		return TOTPEnrollment{}, werr.Wrap(errorz.ErrMFANotConfigured)
+	}
+
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TOTPEnrollment{}, werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return TOTPEnrollment{}, werr.Wrap(err)
+	}
+
+	secret, err := c.totp.GenerateSecret()
+	if err != nil {
+		return TOTPEnrollment{}, werr.Wrap(err)
+	}
+	sealed, err := c.secretBox.Seal([]byte(secret))
+	if err != nil {
+		return TOTPEnrollment{}, werr.Wrap(err)
+	}
+	if _, err = c.store.UpsertPendingMFAFactor(ctx, store.UpsertPendingMFAFactorDTO{
+		UserID:     user.ID,
+		FactorType: entity.MFAFactorTOTP,
+		Secret:     sealed,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TOTPEnrollment{}, werr.Wrap(errorz.ErrMFAAlreadyEnabled)
+		}
+
+		return TOTPEnrollment{}, werr.Wrap(err)
+	}
+
+	uri := c.totp.URI(secret, user.Email)
+	qrCode, err := c.totp.QRCode(uri)
+	if err != nil {
+		return TOTPEnrollment{}, werr.Wrap(err)
+	}
+
+	return TOTPEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: qrCode,
+	}, nil
+}
+
+type ConfirmTOTPEnrollmentParams struct {
+	UserID uuid.UUID
+	Code   string
+}
+
+func (c Client) ConfirmTOTPEnrollment(ctx context.Context, dto ConfirmTOTPEnrollmentParams) error {
+	if c.secretBox == nil {
+		return werr.Wrap(errorz.ErrMFANotConfigured)
+	}
+
+	factor, secret, err := c.totpFactor(ctx, dto.UserID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if factor.Confirmed {
+		return werr.Wrap(errorz.ErrMFAAlreadyEnabled)
+	}
+
+	step, ok := c.totp.Validate(secret, dto.Code, factor.LastUsedStep)
+	if !ok {
+		return werr.Wrap(errorz.ErrInvalidMFACode)
+	}
+	if err = c.store.ConfirmMFAFactor(ctx, store.UseMFAFactorStepDTO{
+		FactorID: factor.ID,
+		Step:     step,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrMFAAlreadyEnabled)
+		}
+
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// VerifyMFA completes a login that Login answered with an MFARequiredError.
+// code is a TOTP code, the emailed code or one of the user's recovery codes.
+// A challenge allows a few attempts and expires after five minutes.
+func (c Client) VerifyMFA(ctx context.Context, challenge string, code string) (string, error) {
+	tokens, err := c.verifyMFA(ctx, challenge, code, false)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return tokens.AccessToken.Token, nil
+}
+
+// VerifyMFATokens is VerifyMFA that also issues a refresh token for Refresh.
+func (c Client) VerifyMFATokens(ctx context.Context, challenge string, code string) (Tokens, error) {
+	return c.verifyMFA(ctx, challenge, code, true)
+}
+
+func (c Client) verifyMFA(ctx context.Context, challenge string, code string, refresh bool) (_ Tokens, err error) {
+	event := authEvent{Type: entity.AuthEventMFAVerify, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	mfaChallenge, err := c.attemptMFAChallenge(ctx, challenge)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	event.UserID = mfaChallenge.UserID
+	if err = c.verifySecondFactor(ctx, mfaChallenge, code); err != nil {
+		return Tokens{}, werr.Wrap(c.secondFactorFailed(ctx, mfaChallenge.UserID, err))
+	}
+
+	return c.completeMFA(ctx, mfaChallenge, refresh)
+}
+
+// attemptMFAChallenge uses up one attempt of a live challenge. With
+// WithLockout, a locked user or IP is refused and an expired, used-up or
+// unknown challenge counts as a failed login.
+func (c Client) attemptMFAChallenge(ctx context.Context, challenge string) (store.MFAChallenge, error) {
+	if err := c.checkLoginLock(ctx, c.ipLockoutCounters(ctx)); err != nil {
+		return store.MFAChallenge{}, werr.Wrap(err)
+	}
+	mfaChallenge, err := c.store.AttemptMFAChallenge(ctx, store.AttemptMFAChallengeDTO{
+		TokenHash:   hashToken(challenge),
+		MaxAttempts: mfaChallengeMaxAttempts,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return store.MFAChallenge{}, werr.Wrap(c.mfaChallengeFailed(ctx, challenge))
+		}
+
+		return store.MFAChallenge{}, werr.Wrap(err)
+	}
+	if err = c.checkLoginLock(ctx, c.userLockoutCounters(mfaChallenge.UserID)); err != nil {
+		return store.MFAChallenge{}, werr.Wrap(err)
+	}
+
+	return mfaChallenge, nil
+}
+
+// mfaChallengeFailed counts an attempt on a challenge AttemptMFAChallenge
+// refused against the IP and, if the challenge still exists, its user.
+func (c Client) mfaChallengeFailed(ctx context.Context, challenge string) error {
+	counters := c.ipLockoutCounters(ctx)
+	if c.lockout != nil {
+		userID, err := c.store.FindMFAChallengeUserID(ctx, hashToken(challenge))
+		switch {
+		case err == nil:
+			counters = append(counters, c.userLockoutCounters(userID)...)
+		case !errors.Is(err, pgx.ErrNoRows):
+			return werr.Wrap(err)
+		}
+	}
+
+	return werr.Wrap(c.loginFailed(ctx, counters))
+}
+
+// secondFactorFailed counts a wrong code like a wrong password. It keeps err
+// unless this attempt locked the user or the IP.
+func (c Client) secondFactorFailed(ctx context.Context, userID uuid.UUID, err error) error {
+	if !errors.Is(err, errorz.ErrInvalidMFACode) && !errors.Is(err, errorz.ErrInvalidCredentials) {
+		return err
+	}
+	counters := c.ipLockoutCounters(ctx)
+	counters = append(counters, c.userLockoutCounters(userID)...)
+	if failErr := c.loginFailed(ctx, counters); !errors.Is(failErr, errorz.ErrInvalidCredentials) {
+		return werr.Wrap(failErr)
+	}
+
+	return err
+}
+
+func (c Client) completeMFA(ctx context.Context, mfaChallenge store.MFAChallenge, refresh bool) (Tokens, error) {
+	if err := c.store.DeleteMFAChallenge(ctx, mfaChallenge.ID); err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	if err := c.loginSucceeded(ctx, mfaChallenge.UserID); err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	user, err := c.store.FindUserByID(ctx, mfaChallenge.UserID)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	return c.signInTokens(ctx, user, LoginMethod(mfaChallenge.LoginMethod), true, refresh)
+}
+
+// beginMFAChallenge returns nil when the user has no active factor. If the
+// preferred factor is email, the code is sent right away. method is recorded
+// for the LoggedIn event once the challenge is answered.
+func (c Client) beginMFAChallenge(
+	ctx context.Context,
+	user store.User,
+	method LoginMethod,
+) (*errorz.MFARequiredError, error) {
+	factors, err := c.mfaFactors(ctx, user.ID)
+	if err != nil || len(factors) == 0 {
+		return nil, werr.Wrap(err)
+	}
+
+	challenge, err := newOpaqueToken()
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	challengeID, err := c.store.CreateMFAChallenge(ctx, store.CreateMFAChallengeDTO{
+		UserID:      user.ID,
+		TokenHash:   hashToken(challenge),
+		ExpiresAt:   time.Now().Add(mfaChallengeTTL),
+		LoginMethod: string(method),
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	factor := factors[0]
+	available := make([]string, 0, len(factors))
+	for _, f := range factors {
+		if f == user.Entity().PreferredMFAFactor {
+			factor = f
+		}
+		available = append(available, string(f))
+	}
+	if factor == entity.MFAFactorEmail {
+		if err = c.sendMFAEmailCode(ctx, challengeID, user); err != nil {
+			return nil, werr.Wrap(err)
+		}
+	}
+
+	return &errorz.MFARequiredError{
+		Challenge: challenge,
+		Factor:    string(factor),
+		Factors:   available,
+	}, nil
+}
+
+// mfaFactors lists the user's active factors in enrollment order. Registered
+// passkeys count as one. It fails with ErrMFANotConfigured rather than
+// leaving out a TOTP factor that cannot be verified without the key.
+func (c Client) mfaFactors(ctx context.Context, userID uuid.UUID) ([]entity.MFAFactorType, error) {
+	confirmed, err := c.store.ListConfirmedMFAFactors(ctx, userID)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	factors := make([]entity.MFAFactorType, 0, len(confirmed)+1)
+	for _, factor := range confirmed {
+		factorType := entity.MFAFactorType(factor.FactorType)
+		if factorType == entity.MFAFactorTOTP && c.secretBox == nil {
+			return nil, werr.Wrap(errorz.ErrMFANotConfigured)
+		}
+		factors = append(factors, factorType)
+	}
+	if c.relyingParty != nil {
+		passkeys, err := c.passkeyIDs(ctx, userID)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		if len(passkeys) > 0 {
+			factors = append(factors, entity.MFAFactorWebAuthn)
+		}
+	}
+
+	return factors, nil
+}
+
+func (c Client) verifySecondFactor(ctx context.Context, challenge store.MFAChallenge, code string) error {
+	if recoveryCode, ok := normalizeRecoveryCode(code); ok {
+		return werr.Wrap(c.consumeRecoveryCode(ctx, challenge.UserID, recoveryCode))
+	}
+	if challenge.EmailCodeHash != "" {
+		if matchesEmailCode(challenge, code) {
+			return nil
+		}
+	}
+
+	err := c.verifyTOTP(ctx, challenge.UserID, code)
+	if errors.Is(err, errorz.ErrMFAFactorNotFound) && challenge.EmailCodeHash != "" {
+		return werr.Wrap(errorz.ErrInvalidMFACode)
+	}
+
+	return werr.Wrap(err)
+}
+
+func (c Client) verifyTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	factor, secret, err := c.totpFactor(ctx, userID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if !factor.Confirmed {
+		return werr.Wrap(errorz.ErrMFAFactorNotFound)
+	}
+
+	step, ok := c.totp.Validate(secret, code, factor.LastUsedStep)
+	if !ok {
+		return werr.Wrap(errorz.ErrInvalidMFACode)
+	}
+	// The conditional update makes a code single-use even under concurrent logins.
+	if err = c.store.UpdateMFAFactorLastUsedStep(ctx, store.UseMFAFactorStepDTO{
+		FactorID: factor.ID,
+		Step:     step,
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrInvalidMFACode)
+		}
+
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (c Client) totpFactor(ctx context.Context, userID uuid.UUID) (store.MFAFactor, string, error) {
+	factor, err := c.store.FindMFAFactor(ctx, userID, entity.MFAFactorTOTP)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return store.MFAFactor{}, "", werr.Wrap(errorz.ErrMFAFactorNotFound)
+		}
+
+		return store.MFAFactor{}, "", werr.Wrap(err)
+	}
+	if c.secretBox == nil {
+		return store.MFAFactor{}, "", werr.Wrap(errorz.ErrMFANotConfigured)
+	}
+	secret, err := c.secretBox.Open(factor.Secret)
+	if err != nil {
+		return store.MFAFactor{}, "", werr.Wrap(err)
+	}
+
+	return factor, string(secret), nil
+}
+
+func newOpaqueToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package authclient
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+// EnableEmailMFA turns on codes sent to the user's verified email as a second factor.
+func (c Client) EnableEmailMFA(ctx context.Context, userID uuid.UUID) error {
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return werr.Wrap(err)
+	}
+	if !user.Entity().IsVerified {
+		return werr.Wrap(errorz.ErrEmailNotConfirmed)
+	}
+
+	return werr.Wrap(c.store.EnableMFAFactor(ctx, user.ID, entity.MFAFactorEmail))
+}
+
+type SetPreferredMFAFactorParams struct {
+	UserID uuid.UUID
+	Factor entity.MFAFactorType
+}
+
+// SetPreferredMFAFactor picks the factor Login prompts for first. It must be
+// one of the user's active factors.
+func (c Client) SetPreferredMFAFactor(ctx context.Context, dto SetPreferredMFAFactorParams) error {
+	factors, err := c.mfaFactors(ctx, dto.UserID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if !slices.Contains(factors, dto.Factor) {
+		return werr.Wrap(errorz.ErrMFAFactorNotFound)
+	}
+
+	return werr.Wrap(c.store.UpdateUserPreferredMFAFactor(ctx, store.UpdateUserPreferredMFAFactorDTO{
+		UserID:     dto.UserID,
+		FactorType: dto.Factor,
+	}))
+}
+
+// RequestMFAEmailCode emails a code for a pending challenge, for users who
+// switch to email or did not receive the first one. Each request uses up one
+// of the challenge's attempts and replaces the previous code.
+func (c Client) RequestMFAEmailCode(ctx context.Context, challenge string) error {
+	mfaChallenge, err := c.attemptMFAChallenge(ctx, challenge)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	factor, err := c.store.FindMFAFactor(ctx, mfaChallenge.UserID, entity.MFAFactorEmail)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrMFAFactorNotFound)
+		}
+
+		return werr.Wrap(err)
+	}
+	if !factor.Confirmed {
+		return werr.Wrap(errorz.ErrMFAFactorNotFound)
+	}
+	user, err := c.store.FindUserByID(ctx, mfaChallenge.UserID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if err = c.checkRateLimit(ctx, RateLimitMFAEmailCode, user.Email); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.sendMFAEmailCode(ctx, mfaChallenge.ID, user))
+}
+
+func (c Client) sendMFAEmailCode(ctx context.Context, challengeID uuid.UUID, user store.User) error {
+	code, err := c.codeGenerator.GenerateMFACode()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if err = c.store.SetMFAChallengeEmailCode(ctx, store.SetMFAChallengeEmailCodeDTO{
+		ChallengeID: challengeID,
+		CodeHash:    hashToken(code.Code),
+		ExpiresAt:   code.ExpiresAt,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.sendEmail(ctx, EmailPurposeMFACode, user, code))
+}
+
+func matchesEmailCode(challenge store.MFAChallenge, code string) bool {
+	if time.Now().After(challenge.EmailCodeExpiresAt) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashToken(code)), []byte(challenge.EmailCodeHash)) == 1
+}
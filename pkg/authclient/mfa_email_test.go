@@ -0,0 +1,274 @@
+package authclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_EmailMFA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	newClient := func(t *testing.T) (*authclient.Client, *storemocks.Store, *codegenmocks.Generator, *recordingSender) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		sender := &recordingSender{}
+		client, err := authclient.New(
+			authclient.Config{MFAEncryptionKey: mfaKey},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(sender),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithHasher(hashermocks.NewHasher(t)),
+		)
+		require.NoError(t, err)
+
+		return client, mockStore, mockCodeGenerator, sender
+	}
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+	}
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	t.Run("enable requires a verified email", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		unverified := user
+		unverified.IsVerified = pgtype.Bool{Bool: false, Valid: true}
+		mockStore.On("FindUserByID", ctx, user.ID).Return(unverified, nil)
+
+		err := client.EnableEmailMFA(ctx, user.ID)
+
+		require.ErrorIs(t, err, errorz.ErrEmailNotConfirmed)
+	})
+
+	t.Run("enable confirms the email factor", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockStore.On("EnableMFAFactor", ctx, user.ID, entity.MFAFactorEmail).Return(nil)
+
+		require.NoError(t, client.EnableEmailMFA(ctx, user.ID))
+	})
+
+	t.Run("preferred factor must be active", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor{
+			{UserID: user.ID, FactorType: string(entity.MFAFactorTOTP), Confirmed: true},
+		}, nil)
+
+		err := client.SetPreferredMFAFactor(ctx, authclient.SetPreferredMFAFactorParams{
+			UserID: user.ID,
+			Factor: entity.MFAFactorEmail,
+		})
+
+		require.ErrorIs(t, err, errorz.ErrMFAFactorNotFound)
+	})
+
+	t.Run("login sends the code for the preferred factor", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		sender := &recordingSender{}
+		client, err := authclient.New(
+			authclient.Config{MFAEncryptionKey: mfaKey},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(sender),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithHasher(mockHasher),
+		)
+		require.NoError(t, err)
+
+		preferring := user
+		preferring.PreferredMfaFactor = pgtype.Text{String: string(entity.MFAFactorEmail), Valid: true}
+		challengeID := uuid.New()
+		code := codegen.Code{Code: "123456", ExpiresAt: time.Now().Add(10 * time.Minute)}
+
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(preferring, nil)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor{
+			{UserID: user.ID, FactorType: string(entity.MFAFactorTOTP), Confirmed: true},
+			{UserID: user.ID, FactorType: string(entity.MFAFactorEmail), Confirmed: true},
+		}, nil)
+		mockStore.On("CreateMFAChallenge", ctx, mock.Anything).Return(challengeID, nil)
+		mockCodeGenerator.On("GenerateMFACode").Return(code, nil)
+		mockStore.On("SetMFAChallengeEmailCode", ctx, store.SetMFAChallengeEmailCodeDTO{
+			ChallengeID: challengeID,
+			CodeHash:    hash(code.Code),
+			ExpiresAt:   code.ExpiresAt,
+		}).Return(nil)
+
+		challenge, err := client.Login(ctx, authclient.LoginParams{
+			Email:    user.Email,
+			Password: "securepassword",
+		})
+
+		var mfaErr *errorz.MFARequiredError
+		require.ErrorAs(t, err, &mfaErr)
+		assert.Equal(t, challenge, mfaErr.Challenge)
+		assert.Equal(t, string(entity.MFAFactorEmail), mfaErr.Factor)
+		assert.Equal(t, []string{"totp", "email"}, mfaErr.Factors)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, authclient.EmailPurposeMFACode, sender.messages[0].Purpose)
+		assert.Equal(t, code.Code, sender.messages[0].Code)
+
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("AttemptMFAChallenge", ctx, store.AttemptMFAChallengeDTO{
+			TokenHash:   hash(challenge),
+			MaxAttempts: 5,
+		}).Return(store.MFAChallenge{
+			ID:                 challengeID,
+			UserID:             user.ID,
+			EmailCodeHash:      hash(code.Code),
+			EmailCodeExpiresAt: code.ExpiresAt,
+		}, nil)
+		mockStore.On("DeleteMFAChallenge", ctx, challengeID).Return(nil)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(preferring, nil)
+		mockJWTCreator.On("CreateAccessToken", user.ID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		result, err := client.VerifyMFA(ctx, challenge, code.Code)
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+	})
+
+	t.Run("email factor does not need the encryption key", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		mockHasher := hashermocks.NewHasher(t)
+		sender := &recordingSender{}
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(sender),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithHasher(mockHasher),
+		)
+		require.NoError(t, err)
+
+		code := codegen.Code{Code: "123456", ExpiresAt: time.Now().Add(10 * time.Minute)}
+		mockStore.On("FindUserByEmail", ctx, user.Email).Return(user, nil)
+		mockHasher.On("CheckPasswordHash", "securepassword", user.PasswordHash).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, user.ID).Return([]store.MFAFactor{
+			{UserID: user.ID, FactorType: string(entity.MFAFactorEmail), Confirmed: true},
+		}, nil)
+		mockStore.On("CreateMFAChallenge", ctx, mock.Anything).Return(uuid.New(), nil)
+		mockCodeGenerator.On("GenerateMFACode").Return(code, nil)
+		mockStore.On("SetMFAChallengeEmailCode", ctx, mock.Anything).Return(nil)
+
+		_, err = client.Login(ctx, authclient.LoginParams{
+			Email:    user.Email,
+			Password: "securepassword",
+		})
+
+		var mfaErr *errorz.MFARequiredError
+		require.ErrorAs(t, err, &mfaErr)
+		assert.Equal(t, string(entity.MFAFactorEmail), mfaErr.Factor)
+		require.Len(t, sender.messages, 1)
+	})
+
+	t.Run("verified challenge keeps the first factor's login method", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		var received []authclient.Event
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithHasher(hashermocks.NewHasher(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+			authclient.WithEventListener(
+				authclient.EventListenerFunc(func(_ context.Context, event authclient.Event) error {
+					received = append(received, event)
+
+					return nil
+				}),
+				authclient.DeliverSync,
+			),
+		)
+		require.NoError(t, err)
+
+		challengeID := uuid.New()
+		mockStore.On("AttemptMFAChallenge", ctx, mock.Anything).Return(store.MFAChallenge{
+			ID:                 challengeID,
+			UserID:             user.ID,
+			EmailCodeHash:      hash("123456"),
+			EmailCodeExpiresAt: time.Now().Add(time.Minute),
+			LoginMethod:        string(authclient.LoginMethodLink),
+		}, nil)
+		mockStore.On("DeleteMFAChallenge", ctx, challengeID).Return(nil)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockJWTCreator.On("CreateAccessToken", user.ID.String()).
+			Return(jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockStore.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		_, err = client.VerifyMFA(ctx, "challenge", "123456")
+
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		loggedIn, ok := received[0].(authclient.LoggedIn)
+		require.True(t, ok)
+		assert.Equal(t, authclient.LoginMethodLink, loggedIn.Method)
+		assert.True(t, loggedIn.MFA)
+	})
+
+	t.Run("expired email code is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("AttemptMFAChallenge", ctx, mock.Anything).Return(store.MFAChallenge{
+			ID:                 uuid.New(),
+			UserID:             user.ID,
+			EmailCodeHash:      hash("123456"),
+			EmailCodeExpiresAt: time.Now().Add(-time.Minute),
+		}, nil)
+		mockStore.On("FindMFAFactor", ctx, user.ID, entity.MFAFactorTOTP).Return(store.MFAFactor{}, pgx.ErrNoRows)
+
+		_, err := client.VerifyMFA(ctx, "challenge", "123456")
+
+		require.ErrorIs(t, err, errorz.ErrInvalidMFACode)
+	})
+}
@@ -0,0 +1,349 @@
+package authclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/secretbox"
+	"github.com/github.com/VadimOcLock/vauth/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mfaKey = []byte("0123456789abcdef0123456789abcdef")
+
+type mfaFixture struct {
+	client        *authclient.Client
+	store         *storemocks.Store
+	jwtCreator    *jwtmocks.Creator
+	hasher        *hashermocks.Hasher
+	authenticator totp.Authenticator
+	user          store.User
+	secret        string
+	factor        store.MFAFactor
+}
+
+func newMFAFixture(t *testing.T) mfaFixture {
+	t.Helper()
+
+	now := time.Unix(1700000000, 0)
+	authenticator := totp.NewAuthenticator(totp.Config{Issuer: "vauth"}, totp.WithClock(func() time.Time { return now }))
+	mockStore := storemocks.NewStore(t)
+	mockJWTCreator := jwtmocks.NewCreator(t)
+	mockHasher := hashermocks.NewHasher(t)
+	client, err := authclient.New(
+		authclient.Config{MFAEncryptionKey: mfaKey},
+		authclient.WithStore(mockStore),
+		authclient.WithJWTCreator(mockJWTCreator),
+		authclient.WithHasher(mockHasher),
+		authclient.WithTOTPAuthenticator(authenticator),
+		authclient.WithEmailSender(&recordingSender{}),
+	)
+	require.NoError(t, err)
+
+	box, err := secretbox.New(mfaKey)
+	require.NoError(t, err)
+	secret, err := authenticator.GenerateSecret()
+	require.NoError(t, err)
+	sealed, err := box.Seal([]byte(secret))
+	require.NoError(t, err)
+
+	user := store.User{
+		ID:           uuid.New(),
+		Email:        "test@example.com",
+		PasswordHash: "hashed_password",
+		IsVerified:   pgtype.Bool{Bool: true, Valid: true},
+	}
+
+	return mfaFixture{
+		client:        client,
+		store:         mockStore,
+		jwtCreator:    mockJWTCreator,
+		hasher:        mockHasher,
+		authenticator: authenticator,
+		user:          user,
+		secret:        secret,
+		factor: store.MFAFactor{
+			ID:         uuid.New(),
+			UserID:     user.ID,
+			FactorType: string(entity.MFAFactorTOTP),
+			Secret:     sealed,
+			Confirmed:  true,
+		},
+	}
+}
+
+func (f mfaFixture) code(t *testing.T) string {
+	t.Helper()
+
+	code, err := f.authenticator.Code(f.secret, 1700000000/30)
+	require.NoError(t, err)
+
+	return code
+}
+
+func TestClient_TOTPEnrollment(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("begin stores an encrypted secret", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.store.On("FindUserByID", ctx, f.user.ID).Return(f.user, nil)
+		var sealed string
+		f.store.On("UpsertPendingMFAFactor", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				dto := args.Get(1).(store.UpsertPendingMFAFactorDTO)
+				sealed = dto.Secret
+			}).
+			Return(uuid.New(), nil)
+
+		enrollment, err := f.client.BeginTOTPEnrollment(ctx, f.user.ID)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.NotContains(t, sealed, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/vauth:test@example.com?")
+		assert.NotEmpty(t, enrollment.QRCode)
+	})
+
+	t.Run("begin fails when already enabled", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.store.On("FindUserByID", ctx, f.user.ID).Return(f.user, nil)
+		f.store.On("UpsertPendingMFAFactor", ctx, mock.Anything).Return(uuid.Nil, pgx.ErrNoRows)
+
+		_, err := f.client.BeginTOTPEnrollment(ctx, f.user.ID)
+
+		require.ErrorIs(t, err, errorz.ErrMFAAlreadyEnabled)
+	})
+
+	t.Run("confirm activates the factor", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.factor.Confirmed = false
+		f.store.On("FindMFAFactor", ctx, f.user.ID, entity.MFAFactorTOTP).Return(f.factor, nil)
+		f.store.On("ConfirmMFAFactor", ctx, store.UseMFAFactorStepDTO{
+			FactorID: f.factor.ID,
+			Step:     1700000000 / 30,
+		}).Return(nil)
+
+		err := f.client.ConfirmTOTPEnrollment(ctx, authclient.ConfirmTOTPEnrollmentParams{
+			UserID: f.user.ID,
+			Code:   f.code(t),
+		})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("confirm rejects a wrong code", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.factor.Confirmed = false
+		f.store.On("FindMFAFactor", ctx, f.user.ID, entity.MFAFactorTOTP).Return(f.factor, nil)
+
+		err := f.client.ConfirmTOTPEnrollment(ctx, authclient.ConfirmTOTPEnrollmentParams{
+			UserID: f.user.ID,
+			Code:   "000000x",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidMFACode)
+	})
+
+	t.Run("enrollment requires an encryption key", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{SecretKey: []byte("secret_key")},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		_, err = client.BeginTOTPEnrollment(ctx, uuid.New())
+
+		require.ErrorIs(t, err, errorz.ErrMFANotConfigured)
+	})
+}
+
+func TestClient_VerifyMFA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	expectChallenge := func(f mfaFixture) *string {
+		var challenge string
+		f.store.On("FindUserByEmail", ctx, f.user.Email).Return(f.user, nil)
+		f.hasher.On("CheckPasswordHash", "securepassword", f.user.PasswordHash).Return(true, nil)
+		f.store.On("ListConfirmedMFAFactors", ctx, f.user.ID).Return([]store.MFAFactor{f.factor}, nil)
+		f.store.On("CreateMFAChallenge", ctx, mock.MatchedBy(func(dto store.CreateMFAChallengeDTO) bool {
+			return dto.UserID == f.user.ID && dto.ExpiresAt.After(time.Now())
+		})).Run(func(args mock.Arguments) {
+			challenge = args.Get(1).(store.CreateMFAChallengeDTO).TokenHash
+		}).Return(uuid.New(), nil)
+
+		return &challenge
+	}
+
+	t.Run("login returns a challenge and verify issues the token", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		challengeHash := expectChallenge(f)
+
+		challenge, err := f.client.Login(ctx, authclient.LoginParams{
+			Email:    f.user.Email,
+			Password: "securepassword",
+		})
+
+		var mfaErr *errorz.MFARequiredError
+		require.ErrorAs(t, err, &mfaErr)
+		require.ErrorIs(t, err, errorz.ErrMFARequired)
+		assert.Equal(t, challenge, mfaErr.Challenge)
+		sum := sha256.Sum256([]byte(challenge))
+		assert.Equal(t, hex.EncodeToString(sum[:]), *challengeHash)
+
+		challengeID := uuid.New()
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}
+		f.store.On("AttemptMFAChallenge", ctx, store.AttemptMFAChallengeDTO{
+			TokenHash:   *challengeHash,
+			MaxAttempts: 5,
+		}).Return(store.MFAChallenge{ID: challengeID, UserID: f.user.ID}, nil)
+		f.store.On("FindMFAFactor", ctx, f.user.ID, entity.MFAFactorTOTP).Return(f.factor, nil)
+		f.store.On("UpdateMFAFactorLastUsedStep", ctx, store.UseMFAFactorStepDTO{
+			FactorID: f.factor.ID,
+			Step:     1700000000 / 30,
+		}).Return(nil)
+		f.store.On("DeleteMFAChallenge", ctx, challengeID).Return(nil)
+		f.store.On("FindUserByID", ctx, f.user.ID).Return(f.user, nil)
+		f.jwtCreator.On("CreateAccessToken", f.user.ID.String()).Return(token, nil)
+		f.store.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    f.user.ID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		result, err := f.client.VerifyMFA(ctx, challenge, f.code(t))
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+	})
+
+	t.Run("verify tokens also issues a refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		challengeID := uuid.New()
+		access := jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(time.Hour)}
+		refresh := jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(24 * time.Hour)}
+		f.store.On("AttemptMFAChallenge", ctx, mock.Anything).
+			Return(store.MFAChallenge{ID: challengeID, UserID: f.user.ID}, nil)
+		f.store.On("FindMFAFactor", ctx, f.user.ID, entity.MFAFactorTOTP).Return(f.factor, nil)
+		f.store.On("UpdateMFAFactorLastUsedStep", ctx, mock.Anything).Return(nil)
+		f.store.On("DeleteMFAChallenge", ctx, challengeID).Return(nil)
+		f.store.On("FindUserByID", ctx, f.user.ID).Return(f.user, nil)
+		f.jwtCreator.On("CreateAccessToken", f.user.ID.String()).Return(access, nil)
+		f.jwtCreator.On("CreateRefreshToken", f.user.ID.String()).Return(refresh, nil)
+		f.store.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil).Twice()
+
+		tokens, err := f.client.VerifyMFATokens(ctx, "challenge", f.code(t))
+
+		require.NoError(t, err)
+		assert.Equal(t, access, tokens.AccessToken)
+		assert.Equal(t, refresh, tokens.RefreshToken)
+	})
+
+	t.Run("replayed code is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.store.On("AttemptMFAChallenge", ctx, mock.Anything).
+			Return(store.MFAChallenge{ID: uuid.New(), UserID: f.user.ID}, nil)
+		f.store.On("FindMFAFactor", ctx, f.user.ID, entity.MFAFactorTOTP).Return(f.factor, nil)
+		f.store.On("UpdateMFAFactorLastUsedStep", ctx, mock.Anything).Return(pgx.ErrNoRows)
+
+		_, err := f.client.VerifyMFA(ctx, "challenge", f.code(t))
+
+		require.ErrorIs(t, err, errorz.ErrInvalidMFACode)
+	})
+
+	t.Run("expired or exhausted challenge", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.store.On("AttemptMFAChallenge", ctx, mock.Anything).Return(store.MFAChallenge{}, pgx.ErrNoRows)
+
+		_, err := f.client.VerifyMFA(ctx, "challenge", f.code(t))
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("login fails closed without the encryption key", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		f := newMFAFixture(t)
+		mockStore.On("FindUserByEmail", ctx, f.user.Email).Return(f.user, nil)
+		mockHasher.On("CheckPasswordHash", "securepassword", f.user.PasswordHash).Return(true, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, f.user.ID).Return([]store.MFAFactor{f.factor}, nil)
+
+		_, err = client.Login(ctx, authclient.LoginParams{
+			Email:    f.user.Email,
+			Password: "securepassword",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrMFANotConfigured)
+	})
+}
+
+func TestClient_CompleteLoginWithMFA(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	f := newMFAFixture(t)
+	var challengeHash string
+	f.store.On("ConsumeLoginCode", ctx, "login-code").Return(f.user, nil)
+	f.store.On("ListConfirmedMFAFactors", ctx, f.user.ID).Return([]store.MFAFactor{f.factor}, nil)
+	f.store.On("CreateMFAChallenge", ctx, mock.MatchedBy(func(dto store.CreateMFAChallengeDTO) bool {
+		return dto.UserID == f.user.ID && dto.LoginMethod == string(authclient.LoginMethodLink)
+	})).Run(func(args mock.Arguments) {
+		challengeHash = args.Get(1).(store.CreateMFAChallengeDTO).TokenHash
+	}).Return(uuid.New(), nil)
+
+	challenge, err := f.client.CompleteLogin(ctx, authclient.CompleteLoginParams{Code: "login-code"})
+
+	var mfaErr *errorz.MFARequiredError
+	require.ErrorAs(t, err, &mfaErr)
+	assert.Equal(t, challenge, mfaErr.Challenge)
+	assert.Equal(t, string(entity.MFAFactorTOTP), mfaErr.Factor)
+	sum := sha256.Sum256([]byte(challenge))
+	assert.Equal(t, hex.EncodeToString(sum[:]), challengeHash)
+	f.jwtCreator.AssertNotCalled(t, "CreateAccessToken", mock.Anything)
+	f.store.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything)
+}
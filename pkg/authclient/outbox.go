@@ -0,0 +1,278 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const OutboxKindEmail = "email"
+
+const (
+	defaultOutboxBatchSize    = 50
+	defaultOutboxPollInterval = 5 * time.Second
+	defaultOutboxMaxAttempts  = 8
+	defaultOutboxMinBackoff   = 30 * time.Second
+	defaultOutboxMaxBackoff   = time.Hour
+	defaultOutboxLease        = 10 * time.Minute
+)
+
+type OutboxConfig struct {
+	BatchSize    int32
+	PollInterval time.Duration
+	// MaxAttempts is the number of failed deliveries after which a message is dead.
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// Lease is how long a dispatcher owns the batch it claimed; after that,
+	// undelivered messages are claimed again, e.g. when the dispatcher died.
+	// It must exceed the time to deliver a batch. The default is 10 minutes.
+	Lease time.Duration
+}
+
+// WithOutbox stores outgoing emails in the outbox table in the same
+// transaction as the codes they carry. They are delivered by DispatchOutbox
+// or RunOutboxDispatcher instead of being sent inline.
+func WithOutbox(cfg OutboxConfig) Option {
+	return func(c *Client) error {
+		if cfg.BatchSize <= 0 {
+			cfg.BatchSize = defaultOutboxBatchSize
+		}
+		if cfg.PollInterval <= 0 {
+			cfg.PollInterval = defaultOutboxPollInterval
+		}
+		if cfg.MaxAttempts <= 0 {
+			cfg.MaxAttempts = defaultOutboxMaxAttempts
+		}
+		if cfg.MinBackoff <= 0 {
+			cfg.MinBackoff = defaultOutboxMinBackoff
+		}
+		if cfg.MaxBackoff < cfg.MinBackoff {
+			cfg.MaxBackoff = max(defaultOutboxMaxBackoff, cfg.MinBackoff)
+		}
+		if cfg.Lease <= 0 {
+			cfg.Lease = defaultOutboxLease
+		}
+		c.outbox = &cfg
+
+		return nil
+	}
+}
+
+type outboxEmail struct {
+	Purpose   EmailPurpose `json:"purpose"`
+	To        string       `json:"to"`
+	Code      string       `json:"code"`
+	Link      string       `json:"link"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Locale    string       `json:"locale"`
+}
+
+// queueEmail returns the outbox message for an email, or nil when the outbox is disabled.
+func (c Client) queueEmail(
+	ctx context.Context,
+	purpose EmailPurpose,
+	user store.User,
+	code codegen.Code,
+) (*store.CreateOutboxMessageDTO, error) {
+	if c.outbox == nil {
+		return nil, nil
+	}
+
+	msg := c.emailMessage(ctx, purpose, user, code)
+	payload, err := json.Marshal(outboxEmail{
+		Purpose:   msg.Purpose,
+		To:        msg.To,
+		Code:      msg.Code,
+		Link:      msg.Link,
+		ExpiresAt: msg.ExpiresAt,
+		Locale:    msg.Locale,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return &store.CreateOutboxMessageDTO{
+		Kind:    OutboxKindEmail,
+		UserID:  user.ID,
+		Payload: string(payload),
+	}, nil
+}
+
+// flushEmail sends the email inline unless it was queued.
+func (c Client) flushEmail(
+	ctx context.Context,
+	queued *store.CreateOutboxMessageDTO,
+	purpose EmailPurpose,
+	user store.User,
+	code codegen.Code,
+) error {
+	if queued != nil {
+		return nil
+	}
+
+	return werr.Wrap(c.sendEmail(ctx, purpose, user, code))
+}
+
+// DispatchOutbox delivers one batch of due messages and returns how many were
+// processed. The batch is leased to this dispatcher, so concurrent ones skip
+// it, and every message is settled on its own right after its delivery; no
+// transaction is held open while delivering.
+func (c Client) DispatchOutbox(ctx context.Context) (int, error) {
+	if c.outbox == nil {
+		return 0, werr.Wrap(errorz.ErrOutboxDisabled)
+	}
+
+	messages, err := c.store.ClaimOutboxMessages(ctx, store.ClaimOutboxMessagesDTO{
+		Limit:       c.outbox.BatchSize,
+		LeasedUntil: time.Now().Add(c.outbox.Lease),
+	})
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+	for i, msg := range messages {
+		if err = c.settleOutboxMessage(ctx, msg, c.deliverOutboxMessage(ctx, msg)); err != nil {
+			return i, werr.Wrap(err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+// RunOutboxDispatcher calls DispatchOutbox until ctx is done. Full batches
+// are followed immediately by the next one; errors are retried on the next poll.
+func (c Client) RunOutboxDispatcher(ctx context.Context) error {
+	if c.outbox == nil {
+		return werr.Wrap(errorz.ErrOutboxDisabled)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		processed, err := c.DispatchOutbox(ctx)
+		if err == nil && processed == int(c.outbox.BatchSize) {
+			timer.Reset(0)
+		} else {
+			timer.Reset(c.outbox.PollInterval)
+		}
+	}
+}
+
+type FailedEmailDeliveriesParams struct {
+	Limit  int32
+	Offset int32
+}
+
+// FailedEmailDeliveries lists dead email messages in the outbox, newest first.
+func (c Client) FailedEmailDeliveries(
+	ctx context.Context,
+	dto FailedEmailDeliveriesParams,
+) ([]entity.OutboxMessage, error) {
+	if c.outbox == nil {
+		return nil, werr.Wrap(errorz.ErrOutboxDisabled)
+	}
+
+	messages, err := c.store.ListOutboxMessages(ctx, store.ListOutboxMessagesDTO{
+		Kind:   OutboxKindEmail,
+		Status: entity.OutboxStatusDead,
+		Limit:  dto.Limit,
+		Offset: dto.Offset,
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	result := make([]entity.OutboxMessage, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, msg.Entity())
+	}
+
+	return result, nil
+}
+
+// RetryEmailDelivery puts a dead email message back in the queue.
+func (c Client) RetryEmailDelivery(ctx context.Context, id uuid.UUID) error {
+	if c.outbox == nil {
+		return werr.Wrap(errorz.ErrOutboxDisabled)
+	}
+
+	err := c.store.RequeueOutboxMessage(ctx, store.RequeueOutboxMessageDTO{ID: id, Kind: OutboxKindEmail})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return werr.Wrap(errorz.ErrOutboxMessageNotFound)
+	}
+
+	return werr.Wrap(err)
+}
+
+func (c Client) deliverOutboxMessage(ctx context.Context, msg store.OutboxMessage) error {
+	switch msg.Kind {
+	case OutboxKindEmail:
+		var payload outboxEmail
+		if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+			return werr.Wrap(err)
+		}
+		if c.emailSender == nil {
+			return werr.Wrap(errorz.ErrEmailSendFunctionMissed)
+		}
+
+		return werr.Wrap(c.emailSender.SendEmail(ctx, EmailMessage{
+			Purpose:   payload.Purpose,
+			To:        payload.To,
+			Code:      payload.Code,
+			Link:      payload.Link,
+			ExpiresAt: payload.ExpiresAt,
+			UserID:    msg.UserID.UUID,
+			Locale:    payload.Locale,
+			Data:      c.emailData,
+		}))
+	case OutboxKindWebhook:
+		return werr.Wrap(c.deliverWebhook(ctx, msg))
+	default:
+		return werr.Wrapf(errorz.ErrUnknownOutboxKind, "%q", msg.Kind)
+	}
+}
+
+func (c Client) settleOutboxMessage(ctx context.Context, msg store.OutboxMessage, err error) error {
+	if err == nil {
+		return werr.Wrap(c.store.MarkOutboxMessageSent(ctx, msg))
+	}
+
+	attempts := int(msg.Attempts) + 1
+
+	return werr.Wrap(c.store.MarkOutboxMessageFailed(ctx, store.MarkOutboxMessageFailedDTO{
+		Message:       msg,
+		Dead:          attempts >= c.outbox.MaxAttempts || undeliverable(err),
+		LastError:     err.Error(),
+		NextAttemptAt: time.Now().Add(c.outbox.backoff(attempts)),
+	}))
+}
+
+// undeliverable reports errors that retrying cannot fix.
+func undeliverable(err error) bool {
+	return errors.Is(err, errorz.ErrWebhookEndpointNotFound) || errors.Is(err, errorz.ErrWebhookEndpointDisabled)
+}
+
+// backoff doubles the delay after every failed attempt, up to MaxBackoff.
+func (cfg OutboxConfig) backoff(attempts int) time.Duration {
+	delay := cfg.MinBackoff
+	for i := 1; i < attempts && delay < cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, cfg.MaxBackoff)
+}
@@ -0,0 +1,226 @@
+package authclient_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSender struct {
+	recordingSender
+}
+
+func (s *failingSender) SendEmail(context.Context, authclient.EmailMessage) error {
+	return errors.New("smtp unavailable")
+}
+
+// claimBatch matches a claim of limit messages leased for the default 10 minutes.
+func claimBatch(limit int32) any {
+	return mock.MatchedBy(func(dto store.ClaimOutboxMessagesDTO) bool {
+		lease := time.Until(dto.LeasedUntil)
+
+		return dto.Limit == limit && lease > 9*time.Minute && lease <= 10*time.Minute
+	})
+}
+
+func newOutboxClient(t *testing.T, sender authclient.EmailSender, mockStore *storemocks.Store, opts ...authclient.Option) *authclient.Client {
+	t.Helper()
+
+	client, err := authclient.New(
+		authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+			EmailSender: sender,
+		},
+		append([]authclient.Option{
+			authclient.WithStore(mockStore),
+			authclient.WithOutbox(authclient.OutboxConfig{
+				MaxAttempts: 3,
+				MinBackoff:  time.Minute,
+				MaxBackoff:  time.Hour,
+			}),
+		}, opts...)...,
+	)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestClient_Outbox(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("register enqueues the email instead of sending it", func(t *testing.T) {
+		t.Parallel()
+
+		sender := &recordingSender{}
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		client := newOutboxClient(t, sender, mockStore,
+			authclient.WithHasher(mockHasher),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+		)
+
+		confirmCode := codegen.Code{Code: "123456", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("ExistsUserByLogin", ctx, "test@example.com").Return(false, nil)
+		mockHasher.On("HashPassword", "securepassword").Return("hash", nil)
+		mockCodeGenerator.On("GenerateConfirmationCode").Return(confirmCode, nil)
+		mockStore.On("RegisterUserWithConfirmation", ctx, mock.MatchedBy(func(dto store.RegisterUserWithConfirmationDTO) bool {
+			return dto.Outbox != nil &&
+				dto.Outbox.Kind == authclient.OutboxKindEmail &&
+				assert.Contains(t, dto.Outbox.Payload, `"code":"123456"`)
+		})).Return(uuid.New(), nil)
+
+		err := client.Register(ctx, authclient.RegisterParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, sender.messages)
+	})
+
+	t.Run("dispatch delivers and marks messages sent", func(t *testing.T) {
+		t.Parallel()
+
+		sender := &recordingSender{}
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, sender, mockStore)
+
+		msg := store.OutboxMessage{
+			ID:      uuid.New(),
+			Kind:    authclient.OutboxKindEmail,
+			UserID:  uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			Payload: `{"purpose":"confirmation","to":"test@example.com","code":"123456"}`,
+		}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{msg}, nil)
+		mockStore.On("MarkOutboxMessageSent", ctx, msg).Return(nil)
+
+		processed, err := client.DispatchOutbox(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, authclient.EmailPurposeConfirmation, sender.messages[0].Purpose)
+		assert.Equal(t, "123456", sender.messages[0].Code)
+		assert.Equal(t, msg.UserID.UUID, sender.messages[0].UserID)
+	})
+
+	t.Run("messages are settled one by one", func(t *testing.T) {
+		t.Parallel()
+
+		sender := &recordingSender{}
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, sender, mockStore)
+
+		first := store.OutboxMessage{ID: uuid.New(), Kind: authclient.OutboxKindEmail, Payload: `{"code":"1"}`}
+		second := store.OutboxMessage{ID: uuid.New(), Kind: authclient.OutboxKindEmail, Payload: `{"code":"2"}`}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{first, second}, nil)
+		mockStore.On("MarkOutboxMessageSent", ctx, first).Return(nil).Once()
+		mockStore.On("MarkOutboxMessageSent", ctx, second).Return(assert.AnError).Once()
+
+		processed, err := client.DispatchOutbox(ctx)
+
+		require.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, processed)
+		assert.Len(t, sender.messages, 2)
+	})
+
+	t.Run("failed delivery is retried with backoff, then dead", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, &failingSender{}, mockStore)
+
+		retried := store.OutboxMessage{ID: uuid.New(), Kind: authclient.OutboxKindEmail, Attempts: 1, Payload: `{}`}
+		exhausted := store.OutboxMessage{ID: uuid.New(), Kind: authclient.OutboxKindEmail, Attempts: 2, Payload: `{}`}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{retried, exhausted}, nil)
+		mockStore.On("MarkOutboxMessageFailed", ctx, mock.MatchedBy(func(dto store.MarkOutboxMessageFailedDTO) bool {
+			delay := time.Until(dto.NextAttemptAt)
+
+			return dto.Message.ID == retried.ID && !dto.Dead && delay > time.Minute && delay <= 2*time.Minute
+		})).Return(nil).Once()
+		mockStore.On("MarkOutboxMessageFailed", ctx, mock.MatchedBy(func(dto store.MarkOutboxMessageFailedDTO) bool {
+			return dto.Message.ID == exhausted.ID && dto.Dead && dto.LastError != ""
+		})).Return(nil).Once()
+
+		processed, err := client.DispatchOutbox(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+	})
+
+	t.Run("failed deliveries lists dead emails only", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, &recordingSender{}, mockStore)
+		dead := store.OutboxMessage{ID: uuid.New(), Kind: authclient.OutboxKindEmail, Payload: `{}`}
+		mockStore.On("ListOutboxMessages", ctx, store.ListOutboxMessagesDTO{
+			Kind:   authclient.OutboxKindEmail,
+			Status: entity.OutboxStatusDead,
+			Limit:  10,
+			Offset: 0,
+		}).Return([]store.OutboxMessage{dead}, nil)
+
+		messages, err := client.FailedEmailDeliveries(ctx, authclient.FailedEmailDeliveriesParams{Limit: 10, Offset: 0})
+
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, dead.ID, messages[0].ID)
+	})
+
+	t.Run("retrying a message that is not a dead email", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, &recordingSender{}, mockStore)
+		id := uuid.New()
+		mockStore.On("RequeueOutboxMessage", ctx, store.RequeueOutboxMessageDTO{
+			ID:   id,
+			Kind: authclient.OutboxKindEmail,
+		}).Return(pgx.ErrNoRows)
+
+		err := client.RetryEmailDelivery(ctx, id)
+
+		require.ErrorIs(t, err, errorz.ErrOutboxMessageNotFound)
+	})
+
+	t.Run("dispatch requires the outbox", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		_, err = client.DispatchOutbox(ctx)
+		require.ErrorIs(t, err, errorz.ErrOutboxDisabled)
+
+		_, err = client.FailedEmailDeliveries(ctx, authclient.FailedEmailDeliveriesParams{Limit: 10, Offset: 0})
+		require.ErrorIs(t, err, errorz.ErrOutboxDisabled)
+
+		err = client.RetryEmailDelivery(ctx, uuid.New())
+		require.ErrorIs(t, err, errorz.ErrOutboxDisabled)
+	})
+}
@@ -0,0 +1,311 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	webAuthnSessionTTL = 5 * time.Minute
+
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+	ceremonyMFA          = "mfa"
+)
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create.
+func (c Client) BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (webauthn.CreationOptions, error) {
+	if c.relyingParty == nil {
+		return webauthn.CreationOptions{}, werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	user, err := c.store.FindUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return webauthn.CreationOptions{}, werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return webauthn.CreationOptions{}, werr.Wrap(err)
+	}
+	existing, err := c.passkeyIDs(ctx, user.ID)
+	if err != nil {
+		return webauthn.CreationOptions{}, werr.Wrap(err)
+	}
+	challenge, err := c.beginWebAuthnSession(ctx, user.ID, ceremonyRegistration)
+	if err != nil {
+		return webauthn.CreationOptions{}, werr.Wrap(err)
+	}
+
+	return c.relyingParty.CreationOptions(challenge, webauthn.User{
+		ID:          user.ID[:],
+		Name:        user.Email,
+		DisplayName: user.Email,
+	}, existing), nil
+}
+
+type FinishPasskeyRegistrationParams struct {
+	UserID   uuid.UUID
+	Name     string
+	Response webauthn.RegistrationResponse
+}
+
+func (c Client) FinishPasskeyRegistration(ctx context.Context, dto FinishPasskeyRegistrationParams) error {
+	if c.relyingParty == nil {
+		return werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	challenge, err := c.consumeWebAuthnSession(ctx, dto.Response.ClientDataJSON, ceremonyRegistration, dto.UserID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	credential, err := c.relyingParty.VerifyRegistration(challenge, dto.Response)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	if _, err = c.store.CreateWebAuthnCredential(ctx, store.CreateWebAuthnCredentialDTO{
+		UserID:         dto.UserID,
+		CredentialID:   credential.ID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      credential.SignCount,
+		AAGUID:         credential.AAGUID,
+		Name:           dto.Name,
+		BackupEligible: credential.BackupEligible,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+// BeginPasskeyLogin returns the options for a usernameless
+// navigator.credentials.get; the authenticator picks the account.
+func (c Client) BeginPasskeyLogin(ctx context.Context) (webauthn.RequestOptions, error) {
+	if c.relyingParty == nil {
+		return webauthn.RequestOptions{}, werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	challenge, err := c.beginWebAuthnSession(ctx, uuid.Nil, ceremonyLogin)
+	if err != nil {
+		return webauthn.RequestOptions{}, werr.Wrap(err)
+	}
+
+	return c.relyingParty.RequestOptions(challenge, nil, webauthn.UserVerificationRequired), nil
+}
+
+// FinishPasskeyLogin signs the user in with a passkey alone. User verification
+// is required, so the passkey counts as both factors. Like Login, it refuses a
+// locked user.
+func (c Client) FinishPasskeyLogin(ctx context.Context, response webauthn.AssertionResponse) (_ string, err error) {
+	event := authEvent{Type: entity.AuthEventLogin, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	if c.relyingParty == nil {
+		return "", werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	challenge, err := c.consumeWebAuthnSession(ctx, response.ClientDataJSON, ceremonyLogin, uuid.Nil)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	credential, err := c.store.FindWebAuthnCredential(ctx, response.CredentialID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return "", werr.Wrap(err)
+	}
+	event.UserID = credential.UserID
+	if err = c.checkLoginLock(ctx, c.userLockoutCounters(credential.UserID)); err != nil {
+		return "", werr.Wrap(err)
+	}
+	if len(response.UserHandle) != 0 && !bytes.Equal(response.UserHandle, credential.UserID[:]) {
+		return "", werr.Wrap(errorz.ErrInvalidCredentials)
+	}
+	if err = c.verifyPasskey(ctx, challenge, credential, response, true); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	user, err := c.store.FindUserByID(ctx, credential.UserID)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	event.Email = user.Email
+
+	return c.signIn(ctx, user, LoginMethodPasskey, false)
+}
+
+// BeginPasskeyMFA returns the options for answering an MFA challenge with one
+// of the user's passkeys.
+func (c Client) BeginPasskeyMFA(ctx context.Context, challenge string) (webauthn.RequestOptions, error) {
+	if c.relyingParty == nil {
+		return webauthn.RequestOptions{}, werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	mfaChallenge, err := c.attemptMFAChallenge(ctx, challenge)
+	if err != nil {
+		return webauthn.RequestOptions{}, werr.Wrap(err)
+	}
+	allowed, err := c.passkeyIDs(ctx, mfaChallenge.UserID)
+	if err != nil {
+		return webauthn.RequestOptions{}, werr.Wrap(err)
+	}
+	if len(allowed) == 0 {
+		return webauthn.RequestOptions{}, werr.Wrap(errorz.ErrMFAFactorNotFound)
+	}
+	webAuthnChallenge, err := c.beginWebAuthnSession(ctx, mfaChallenge.UserID, ceremonyMFA)
+	if err != nil {
+		return webauthn.RequestOptions{}, werr.Wrap(err)
+	}
+
+	return c.relyingParty.RequestOptions(webAuthnChallenge, allowed, webauthn.UserVerificationDiscouraged), nil
+}
+
+// VerifyMFAWithPasskey is VerifyMFA for a passkey assertion.
+func (c Client) VerifyMFAWithPasskey(
+	ctx context.Context,
+	challenge string,
+	response webauthn.AssertionResponse,
+) (_ string, err error) {
+	event := authEvent{Type: entity.AuthEventMFAVerify, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	if c.relyingParty == nil {
+		return "", werr.Wrap(errorz.ErrWebAuthnNotConfigured)
+	}
+
+	mfaChallenge, err := c.attemptMFAChallenge(ctx, challenge)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	event.UserID = mfaChallenge.UserID
+	if err = c.verifyPasskeyFactor(ctx, mfaChallenge.UserID, response); err != nil {
+		return "", werr.Wrap(c.secondFactorFailed(ctx, mfaChallenge.UserID, err))
+	}
+
+	tokens, err := c.completeMFA(ctx, mfaChallenge, false)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return tokens.AccessToken.Token, nil
+}
+
+func (c Client) verifyPasskeyFactor(ctx context.Context, userID uuid.UUID, response webauthn.AssertionResponse) error {
+	webAuthnChallenge, err := c.consumeWebAuthnSession(ctx, response.ClientDataJSON, ceremonyMFA, userID)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	credential, err := c.store.FindWebAuthnCredential(ctx, response.CredentialID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(errorz.ErrInvalidMFACode)
+		}
+
+		return werr.Wrap(err)
+	}
+	if credential.UserID != userID {
+		return werr.Wrap(errorz.ErrInvalidMFACode)
+	}
+
+	return werr.Wrap(c.verifyPasskey(ctx, webAuthnChallenge, credential, response, false))
+}
+
+func (c Client) verifyPasskey(
+	ctx context.Context,
+	challenge string,
+	credential store.WebAuthnCredential,
+	response webauthn.AssertionResponse,
+	requireUV bool,
+) error {
+	signCount, err := c.relyingParty.VerifyAssertion(challenge, webauthn.Credential{
+		ID:             credential.CredentialID,
+		PublicKey:      credential.PublicKey,
+		Algorithm:      0,
+		SignCount:      uint32(credential.SignCount),
+		AAGUID:         credential.Aaguid,
+		UserVerified:   false,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    false,
+	}, response, requireUV)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.store.UpdateWebAuthnCredentialSignCount(ctx, store.UpdateWebAuthnCredentialSignCountDTO{
+		ID:        credential.ID,
+		SignCount: signCount,
+	}))
+}
+
+func (c Client) beginWebAuthnSession(ctx context.Context, userID uuid.UUID, ceremony string) (string, error) {
+	challenge, err := c.relyingParty.NewChallenge()
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	if _, err = c.store.CreateWebAuthnSession(ctx, store.CreateWebAuthnSessionDTO{
+		UserID:        userID,
+		Ceremony:      ceremony,
+		ChallengeHash: hashToken(challenge),
+		ExpiresAt:     time.Now().Add(webAuthnSessionTTL),
+	}); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return challenge, nil
+}
+
+// consumeWebAuthnSession finds the ceremony a response belongs to through the
+// challenge it signed, and makes sure it is used once and by the same user.
+func (c Client) consumeWebAuthnSession(
+	ctx context.Context,
+	clientDataJSON []byte,
+	ceremony string,
+	userID uuid.UUID,
+) (string, error) {
+	challenge, err := webauthn.ChallengeFromClientData(clientDataJSON)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	sessionUserID, err := c.store.ConsumeWebAuthnSession(ctx, store.ConsumeWebAuthnSessionDTO{
+		ChallengeHash: hashToken(challenge),
+		Ceremony:      ceremony,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return "", werr.Wrap(err)
+	}
+	if sessionUserID != userID {
+		return "", werr.Wrap(errorz.ErrInvalidCredentials)
+	}
+
+	return challenge, nil
+}
+
+func (c Client) passkeyIDs(ctx context.Context, userID uuid.UUID) ([][]byte, error) {
+	credentials, err := c.store.ListWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	ids := make([][]byte, 0, len(credentials))
+	for _, credential := range credentials {
+		ids = append(ids, credential.CredentialID)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,183 @@
+package authclient_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
+	webauthnmocks "github.com/github.com/VadimOcLock/vauth/pkg/webauthn/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func hashOf(value string) string {
+	sum := sha256.Sum256([]byte(value))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func TestClient_Passkeys(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	newClient := func(t *testing.T) (*authclient.Client, *storemocks.Store, *webauthnmocks.RelyingParty, *jwtmocks.Creator) {
+		t.Helper()
+
+		mockStore := storemocks.NewStore(t)
+		mockRP := webauthnmocks.NewRelyingParty(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithRelyingParty(mockRP),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		return client, mockStore, mockRP, mockJWTCreator
+	}
+	userID := uuid.New()
+	user := store.User{ID: userID, Email: "test@example.com"}
+	clientData := []byte(`{"type":"webauthn.get","challenge":"abc","origin":"https://example.com"}`)
+
+	t.Run("registration excludes existing credentials and stores the new one", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockRP, _ := newClient(t)
+		mockStore.On("FindUserByID", ctx, userID).Return(user, nil)
+		mockStore.On("ListWebAuthnCredentials", ctx, userID).Return([]store.WebAuthnCredential{
+			{CredentialID: []byte("old")},
+		}, nil)
+		mockRP.On("NewChallenge").Return("abc", nil)
+		mockStore.On("CreateWebAuthnSession", ctx, mock.MatchedBy(func(dto store.CreateWebAuthnSessionDTO) bool {
+			return dto.UserID == userID && dto.Ceremony == "registration" && dto.ChallengeHash == hashOf("abc")
+		})).Return(uuid.New(), nil)
+		mockRP.On("CreationOptions", "abc", webauthn.User{
+			ID:          userID[:],
+			Name:        user.Email,
+			DisplayName: user.Email,
+		}, [][]byte{[]byte("old")}).Return(webauthn.CreationOptions{Challenge: "abc"})
+
+		options, err := client.BeginPasskeyRegistration(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "abc", options.Challenge)
+
+		response := webauthn.RegistrationResponse{ClientDataJSON: clientData, AttestationObject: []byte{0xa0}}
+		mockStore.On("ConsumeWebAuthnSession", ctx, store.ConsumeWebAuthnSessionDTO{
+			ChallengeHash: hashOf("abc"),
+			Ceremony:      "registration",
+		}).Return(userID, nil)
+		mockRP.On("VerifyRegistration", "abc", response).Return(webauthn.Credential{
+			ID:        []byte("new"),
+			PublicKey: []byte("key"),
+			AAGUID:    make([]byte, 16),
+		}, nil)
+		mockStore.On("CreateWebAuthnCredential", ctx, store.CreateWebAuthnCredentialDTO{
+			UserID:       userID,
+			CredentialID: []byte("new"),
+			PublicKey:    []byte("key"),
+			AAGUID:       make([]byte, 16),
+			Name:         "laptop",
+		}).Return(uuid.New(), nil)
+
+		err = client.FinishPasskeyRegistration(ctx, authclient.FinishPasskeyRegistrationParams{
+			UserID:   userID,
+			Name:     "laptop",
+			Response: response,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("registration session belongs to another user", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("ConsumeWebAuthnSession", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		err := client.FinishPasskeyRegistration(ctx, authclient.FinishPasskeyRegistrationParams{
+			UserID:   userID,
+			Response: webauthn.RegistrationResponse{ClientDataJSON: clientData},
+		})
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("passkey login requires user verification and updates the counter", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, mockRP, mockJWTCreator := newClient(t)
+		credential := store.WebAuthnCredential{
+			ID:           uuid.New(),
+			UserID:       userID,
+			CredentialID: []byte("cred"),
+			PublicKey:    []byte("key"),
+			SignCount:    4,
+		}
+		response := webauthn.AssertionResponse{
+			CredentialID:   []byte("cred"),
+			ClientDataJSON: clientData,
+			UserHandle:     userID[:],
+		}
+		token := jwtgen.Token{Token: "jwt_token", ExpiresAt: time.Now().Add(time.Hour)}
+		mockStore.On("ConsumeWebAuthnSession", ctx, store.ConsumeWebAuthnSessionDTO{
+			ChallengeHash: hashOf("abc"),
+			Ceremony:      "login",
+		}).Return(uuid.Nil, nil)
+		mockStore.On("FindWebAuthnCredential", ctx, []byte("cred")).Return(credential, nil)
+		mockRP.On("VerifyAssertion", "abc", mock.MatchedBy(func(c webauthn.Credential) bool {
+			return c.SignCount == 4
+		}), response, true).Return(uint32(5), nil)
+		mockStore.On("UpdateWebAuthnCredentialSignCount", ctx, store.UpdateWebAuthnCredentialSignCountDTO{
+			ID:        credential.ID,
+			SignCount: 5,
+		}).Return(nil)
+		mockStore.On("FindUserByID", ctx, userID).Return(user, nil)
+		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    userID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		result, err := client.FinishPasskeyLogin(ctx, response)
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+	})
+
+	t.Run("passkey login with a used challenge", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockStore, _, _ := newClient(t)
+		mockStore.On("ConsumeWebAuthnSession", ctx, mock.Anything).Return(uuid.Nil, pgx.ErrNoRows)
+
+		_, err := client.FinishPasskeyLogin(ctx, webauthn.AssertionResponse{ClientDataJSON: clientData})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+	})
+
+	t.Run("passkeys require a relying party", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{SecretKey: []byte("secret_key")},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		_, err = client.BeginPasskeyLogin(ctx)
+
+		require.ErrorIs(t, err, errorz.ErrWebAuthnNotConfigured)
+	})
+}
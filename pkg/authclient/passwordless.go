@@ -0,0 +1,144 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+type RequestLoginLinkParams struct {
+	Email string
+}
+
+func (dto RequestLoginLinkParams) Validate() error {
+	if !emailRegex.MatchString(dto.Email) {
+		return errorz.ErrInvalidEmailFormat
+	}
+
+	return nil
+}
+
+func (c Client) RequestLoginLink(ctx context.Context, dto RequestLoginLinkParams) error {
+	if err := dto.Validate(); err != nil {
+		return werr.Wrap(err)
+	}
+	if err := c.checkRateLimit(ctx, RateLimitLoginLink, dto.Email); err != nil {
+		return werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
+
+	loginCode, err := c.codeGenerator.GenerateLoginCode()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	user, err := c.store.FindUserByEmail(ctx, dto.Email)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		if !c.passwordlessSignUp {
+			return werr.Wrap(c.hiddenError(errorz.ErrInvalidCredentials))
+		}
+		user.Email = dto.Email
+		registered := UserRegistered{EventMeta: eventMeta(user)}
+		if err = c.vetoEvent(ctx, registered); err != nil {
+			return werr.Wrap(err)
+		}
+		queued, qErr := c.queueEmail(ctx, EmailPurposeLoginLink, user, loginCode)
+		if qErr != nil {
+			return werr.Wrap(qErr)
+		}
+		if user.ID, err = c.store.RegisterUserWithLoginCode(ctx, store.RegisterUserWithLoginCodeDTO{
+			Email:     dto.Email,
+			LoginCode: loginCode.Code,
+			ExpiresAt: loginCode.ExpiresAt,
+			Outbox:    queued,
+		}); err != nil {
+			return werr.Wrap(err)
+		}
+		registered.UserID = user.ID
+		c.publishEvent(ctx, registered)
+
+		return werr.Wrap(c.flushEmail(ctx, queued, EmailPurposeLoginLink, user, loginCode))
+	case err != nil:
+		return werr.Wrap(err)
+	}
+
+	queued, err := c.queueEmail(ctx, EmailPurposeLoginLink, user, loginCode)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if _, err = c.store.CreateLoginCode(ctx, store.CreateLoginCodeDTO{
+		UserID:    user.ID,
+		LoginCode: loginCode.Code,
+		ExpiresAt: loginCode.ExpiresAt,
+		Outbox:    queued,
+	}); err != nil {
+		return werr.Wrap(err)
+	}
+
+	if err = c.flushEmail(ctx, queued, EmailPurposeLoginLink, user, loginCode); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return nil
+}
+
+type CompleteLoginParams struct {
+	Code string
+}
+
+// CompleteLogin returns an access token for a code sent by RequestLoginLink.
+// Like Login, a user with an active second factor gets a challenge token and
+// an *errorz.MFARequiredError instead; the login is finished by VerifyMFA.
+// With WithLockout, a locked user gets an *errorz.AccountLockedError.
+func (c Client) CompleteLogin(ctx context.Context, dto CompleteLoginParams) (_ string, err error) {
+	event := authEvent{Type: entity.AuthEventLogin, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	user, err := c.store.ConsumeLoginCode(ctx, dto.Code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", werr.Wrap(errorz.ErrInvalidCredentials)
+		}
+
+		return "", werr.Wrap(err)
+	}
+	event.UserID, event.Email = user.ID, user.Email
+	if err = c.checkLoginLock(ctx, c.userLockoutCounters(user.ID)); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	// Following the emailed code proves ownership of the address. A password
+	// set by whoever registered it unverified is not trusted.
+	if !user.Entity().IsVerified {
+		if user.PasswordHash != "" {
+			if err = c.store.UpdateUserPassword(ctx, store.UpdateUserPasswordDTO{
+				Email:        user.Email,
+				PasswordHash: "",
+			}); err != nil {
+				return "", werr.Wrap(err)
+			}
+			user.PasswordHash = ""
+		}
+		if err = c.verifyEmail(ctx, user); err != nil {
+			return "", werr.Wrap(err)
+		}
+	}
+
+	mfaRequired, err := c.beginMFAChallenge(ctx, user, LoginMethodLink)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+	if mfaRequired != nil {
+		return mfaRequired.Challenge, werr.Wrap(mfaRequired)
+	}
+
+	return c.signIn(ctx, user, LoginMethodLink, false)
+}
@@ -0,0 +1,326 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RequestLoginLink(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("existing user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		var sentTo, sentCode string
+		client, err := authclient.New(
+			authclient.Config{
+				JWTConfig: jwtgen.CreatorConfig{
+					SecretKey: []byte("secret_key"),
+				},
+				EmailSenderHook: func(ctx context.Context, email string, code string) error {
+					sentTo, sentCode = email, code
+
+					return nil
+				},
+			},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+		)
+		require.NoError(t, err)
+
+		email := "test@example.com"
+		userID := uuid.New()
+		loginCode := codegen.Code{
+			Code:      "login-code",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}
+
+		mockCodeGenerator.On("GenerateLoginCode").Return(loginCode, nil)
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{
+			ID:    userID,
+			Email: email,
+		}, nil)
+		mockStore.On("CreateLoginCode", ctx, store.CreateLoginCodeDTO{
+			UserID:    userID,
+			LoginCode: loginCode.Code,
+			ExpiresAt: loginCode.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		err = client.RequestLoginLink(ctx, authclient.RequestLoginLinkParams{
+			Email: email,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, email, sentTo)
+		assert.Equal(t, loginCode.Code, sentCode)
+		mockStore.AssertExpectations(t)
+		mockCodeGenerator.AssertExpectations(t)
+	})
+
+	t.Run("unknown user without sign up", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		client, err := authclient.New(
+			authclient.Config{
+				JWTConfig: jwtgen.CreatorConfig{
+					SecretKey: []byte("secret_key"),
+				},
+			},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		email := "unknown@example.com"
+
+		mockCodeGenerator.On("GenerateLoginCode").Return(codegen.Code{Code: "login-code"}, nil)
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{}, pgx.ErrNoRows)
+
+		err = client.RequestLoginLink(ctx, authclient.RequestLoginLinkParams{
+			Email: email,
+		})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("unknown user with sign up", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		client, err := authclient.New(
+			authclient.Config{
+				JWTConfig: jwtgen.CreatorConfig{
+					SecretKey: []byte("secret_key"),
+				},
+				EmailSenderHook: func(ctx context.Context, email string, code string) error {
+					return nil
+				},
+			},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithPasswordlessSignUp(),
+		)
+		require.NoError(t, err)
+
+		email := "new@example.com"
+		loginCode := codegen.Code{
+			Code:      "login-code",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}
+
+		mockCodeGenerator.On("GenerateLoginCode").Return(loginCode, nil)
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{}, pgx.ErrNoRows)
+		mockStore.On("RegisterUserWithLoginCode", ctx, store.RegisterUserWithLoginCodeDTO{
+			Email:     email,
+			LoginCode: loginCode.Code,
+			ExpiresAt: loginCode.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		err = client.RequestLoginLink(ctx, authclient.RequestLoginLinkParams{
+			Email: email,
+		})
+
+		require.NoError(t, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		err = client.RequestLoginLink(ctx, authclient.RequestLoginLinkParams{
+			Email: "invalid_email",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidEmailFormat)
+	})
+}
+
+func TestClient_CompleteLogin(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("verified user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		userID := uuid.New()
+		token := jwtgen.Token{
+			Token:     "jwt_token",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}
+
+		mockStore.On("ConsumeLoginCode", ctx, "login-code").Return(store.User{
+			ID:         userID,
+			Email:      "test@example.com",
+			IsVerified: pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    userID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		result, err := client.CompleteLogin(ctx, authclient.CompleteLoginParams{
+			Code: "login-code",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+		mockStore.AssertExpectations(t)
+		mockJWTCreator.AssertExpectations(t)
+	})
+
+	t.Run("first login verifies user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		email := "new@example.com"
+		userID := uuid.New()
+		token := jwtgen.Token{
+			Token:     "jwt_token",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}
+
+		mockStore.On("ConsumeLoginCode", ctx, "login-code").Return(store.User{
+			ID:         userID,
+			Email:      email,
+			IsVerified: pgtype.Bool{Bool: false, Valid: true},
+		}, nil)
+		mockStore.On("UpdateUserAsVerified", ctx, email).Return(nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    userID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		result, err := client.CompleteLogin(ctx, authclient.CompleteLoginParams{
+			Code: "login-code",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("first login clears the password of an unverified user", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		mockJWTCreator := jwtmocks.NewCreator(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(mockJWTCreator),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		email := "victim@example.com"
+		userID := uuid.New()
+		token := jwtgen.Token{
+			Token:     "jwt_token",
+			ExpiresAt: time.Now().Add(15 * time.Minute),
+		}
+
+		mockStore.On("ConsumeLoginCode", ctx, "login-code").Return(store.User{
+			ID:           userID,
+			Email:        email,
+			PasswordHash: "registrant_hash",
+			IsVerified:   pgtype.Bool{Bool: false, Valid: true},
+		}, nil)
+		mockStore.On("UpdateUserPassword", ctx, store.UpdateUserPasswordDTO{
+			Email:        email,
+			PasswordHash: "",
+		}).Return(nil).Once()
+		mockStore.On("UpdateUserAsVerified", ctx, email).Return(nil)
+		mockStore.On("ListConfirmedMFAFactors", ctx, userID).Return([]store.MFAFactor(nil), nil)
+		mockJWTCreator.On("CreateAccessToken", userID.String()).Return(token, nil)
+		mockStore.On("CreateToken", ctx, store.CreateTokenDTO{
+			UserID:    userID,
+			Token:     token.Token,
+			ExpiresAt: token.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		result, err := client.CompleteLogin(ctx, authclient.CompleteLoginParams{
+			Code: "login-code",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, token.Token, result)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("invalid or used code", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(
+			authclient.Config{},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		mockStore.On("ConsumeLoginCode", ctx, "bad-code").Return(store.User{}, pgx.ErrNoRows)
+
+		_, err = client.CompleteLogin(ctx, authclient.CompleteLoginParams{
+			Code: "bad-code",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrInvalidCredentials)
+		mockStore.AssertExpectations(t)
+	})
+}
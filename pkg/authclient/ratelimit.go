@@ -0,0 +1,52 @@
+package authclient
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+// Operations used as the first part of rate limit keys. Keys look like
+// "forgot_password:email:user@example.com" or "forgot_password:ip:203.0.113.7".
+// mfa_email_code keys use the email of the challenge's user.
+const (
+	RateLimitRegister          = "register"
+	RateLimitForgotPassword    = "forgot_password"
+	RateLimitConfirmationEmail = "confirmation_email"
+	RateLimitAccountUnlock     = "account_unlock"
+	RateLimitLoginLink         = "login_link"
+	RateLimitMFAEmailCode      = "mfa_email_code"
+)
+
+// RateLimiter reports how long the caller has to wait before key may be used
+// again; zero allows the call. pkg/ratelimit has in-memory and Postgres implementations.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (time.Duration, error)
+}
+
+// checkRateLimit counts the call against the client IP, when known, and the email.
+func (c Client) checkRateLimit(ctx context.Context, operation string, email string) error {
+	if c.rateLimiter == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, 2)
+	if ip := RequestInfoFromContext(ctx).IP; ip != "" {
+		keys = append(keys, operation+":ip:"+ip)
+	}
+	keys = append(keys, operation+":email:"+strings.ToLower(email))
+	for _, key := range keys {
+		retryAfter, err := c.rateLimiter.Allow(ctx, key)
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		if retryAfter > 0 {
+			return werr.Wrap(&errorz.RateLimitedError{RetryAfter: retryAfter})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	ratelimitmocks "github.com/github.com/VadimOcLock/vauth/pkg/ratelimit/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RateLimit(t *testing.T) {
+	t.Parallel()
+	ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{IP: "203.0.113.7"})
+
+	newClient := func(t *testing.T) (*authclient.Client, *ratelimitmocks.Limiter) {
+		t.Helper()
+
+		mockLimiter := ratelimitmocks.NewLimiter(t)
+		client, err := authclient.New(
+			authclient.Config{RateLimiter: mockLimiter},
+			authclient.WithStore(storemocks.NewStore(t)),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+
+		return client, mockLimiter
+	}
+
+	t.Run("ip limit", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockLimiter := newClient(t)
+		mockLimiter.On("Allow", ctx, "forgot_password:ip:203.0.113.7").Return(time.Minute, nil)
+
+		err := client.ForgotPassword(ctx, authclient.ForgotPasswordParams{Email: "Test@example.com"})
+
+		var limitedErr *errorz.RateLimitedError
+		require.ErrorAs(t, err, &limitedErr)
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+		assert.Equal(t, time.Minute, limitedErr.RetryAfter)
+	})
+
+	t.Run("email limit", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockLimiter := newClient(t)
+		mockLimiter.On("Allow", ctx, "confirmation_email:ip:203.0.113.7").Return(time.Duration(0), nil)
+		mockLimiter.On("Allow", ctx, "confirmation_email:email:test@example.com").Return(time.Hour, nil)
+
+		err := client.SendConfirmationEmail(ctx, authclient.SendConfirmationEmailParams{Email: "Test@example.com"})
+
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+	})
+
+	t.Run("register without request info", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockLimiter := newClient(t)
+		mockLimiter.On("Allow", context.Background(), "register:email:test@example.com").Return(time.Second, nil)
+
+		err := client.Register(context.Background(), authclient.RegisterParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+	})
+
+	t.Run("login link", func(t *testing.T) {
+		t.Parallel()
+
+		client, mockLimiter := newClient(t)
+		mockLimiter.On("Allow", ctx, "login_link:ip:203.0.113.7").Return(time.Duration(0), nil)
+		mockLimiter.On("Allow", ctx, "login_link:email:test@example.com").Return(time.Hour, nil)
+
+		err := client.RequestLoginLink(ctx, authclient.RequestLoginLinkParams{Email: "test@example.com"})
+
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+	})
+
+	t.Run("mfa email code", func(t *testing.T) {
+		t.Parallel()
+
+		mockLimiter := ratelimitmocks.NewLimiter(t)
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(
+			authclient.Config{RateLimiter: mockLimiter},
+			authclient.WithStore(mockStore),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+		)
+		require.NoError(t, err)
+		user := store.User{ID: uuid.New(), Email: "Test@example.com"}
+		mockStore.On("AttemptMFAChallenge", ctx, mock.Anything).
+			Return(store.MFAChallenge{ID: uuid.New(), UserID: user.ID}, nil)
+		mockStore.On("FindMFAFactor", ctx, user.ID, entity.MFAFactorEmail).
+			Return(store.MFAFactor{UserID: user.ID, FactorType: string(entity.MFAFactorEmail), Confirmed: true}, nil)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockLimiter.On("Allow", ctx, "mfa_email_code:ip:203.0.113.7").Return(time.Duration(0), nil)
+		mockLimiter.On("Allow", ctx, "mfa_email_code:email:test@example.com").Return(time.Minute, nil)
+
+		err = client.RequestMFAEmailCode(ctx, "challenge")
+
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+	})
+
+	t.Run("account unlock", func(t *testing.T) {
+		t.Parallel()
+
+		mockLimiter := ratelimitmocks.NewLimiter(t)
+		client, err := authclient.New(
+			authclient.Config{RateLimiter: mockLimiter},
+			authclient.WithStore(storemocks.NewStore(t)),
+			authclient.WithJWTCreator(jwtmocks.NewCreator(t)),
+			authclient.WithEmailSender(&recordingSender{}),
+			authclient.WithLockout(authclient.LockoutConfig{}),
+		)
+		require.NoError(t, err)
+		mockLimiter.On("Allow", ctx, "account_unlock:ip:203.0.113.7").Return(time.Duration(0), nil)
+		mockLimiter.On("Allow", ctx, "account_unlock:email:victim@example.com").Return(time.Hour, nil)
+
+		err = client.RequestAccountUnlock(ctx, authclient.RequestAccountUnlockParams{Email: "victim@example.com"})
+
+		require.ErrorIs(t, err, errorz.ErrRateLimited)
+	})
+}
@@ -0,0 +1,121 @@
+package authclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCodes returns a fresh set of one-time codes formatted as
+// "xxxxx-xxxxx". Only an HMAC keyed from Config.MFAEncryptionKey is stored, so
+// a copy of the database alone cannot be used to guess them. Any earlier set
+// stops working.
+func (c Client) GenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if c.secretBox == nil {
+		return nil, werr.Wrap(errorz.ErrMFANotConfigured)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		raw := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, werr.Wrap(err)
+		}
+		code := recoveryCodeEncoding.EncodeToString(raw)
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, c.recoveryCodeHash(userID, code))
+	}
+
+	if err := c.store.ReplaceRecoveryCodes(ctx, store.ReplaceRecoveryCodesDTO{
+		UserID:     userID,
+		CodeHashes: hashes,
+	}); err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return codes, nil
+}
+
+// RemainingRecoveryCodes reports how many unused recovery codes the user has.
+func (c Client) RemainingRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	count, err := c.store.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	return int(count), nil
+}
+
+// consumeRecoveryCode also accepts codes from sets generated before they were
+// hashed with a key, which were stored as plain SHA-256.
+func (c Client) consumeRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	hashes := make([]string, 0, 2)
+	if c.recoveryKey != nil {
+		hashes = append(hashes, c.recoveryCodeHash(userID, code))
+	}
+	hashes = append(hashes, hashToken(code))
+	for _, hash := range hashes {
+		err := c.store.ConsumeRecoveryCode(ctx, store.ConsumeRecoveryCodeDTO{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return werr.Wrap(err)
+		}
+	}
+
+	return werr.Wrap(errorz.ErrInvalidMFACode)
+}
+
+// recoveryCodeKey derives the recovery code HMAC key, so that the MFA key is
+// not used for two purposes.
+func recoveryCodeKey(mfaKey []byte) []byte {
+	mac := hmac.New(sha256.New, mfaKey)
+	mac.Write([]byte("vauth recovery codes"))
+
+	return mac.Sum(nil)
+}
+
+func (c Client) recoveryCodeHash(userID uuid.UUID, code string) string {
+	mac := hmac.New(sha256.New, c.recoveryKey)
+	mac.Write(userID[:])
+	mac.Write([]byte(code))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeRecoveryCode tolerates case, spaces and the dash users copy along.
+// It reports false for anything that cannot be a recovery code.
+func normalizeRecoveryCode(code string) (string, bool) {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+
+		return r
+	}, strings.ToLower(code))
+
+	return code, len(code) == recoveryCodeLength
+}
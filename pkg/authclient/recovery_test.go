@@ -0,0 +1,124 @@
+package authclient_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recoveryHash is the keyed hash stored for code with mfaKey.
+func recoveryHash(userID uuid.UUID, code string) string {
+	keyMAC := hmac.New(sha256.New, mfaKey)
+	keyMAC.Write([]byte("vauth recovery codes"))
+	mac := hmac.New(sha256.New, keyMAC.Sum(nil))
+	mac.Write(userID[:])
+	mac.Write([]byte(code))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestClient_RecoveryCodes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("generate stores hashes of ten unique codes", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		var stored store.ReplaceRecoveryCodesDTO
+		f.store.On("ReplaceRecoveryCodes", ctx, mock.Anything).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(store.ReplaceRecoveryCodesDTO)
+			}).
+			Return(nil)
+
+		codes, err := f.client.GenerateRecoveryCodes(ctx, f.user.ID)
+
+		require.NoError(t, err)
+		require.Len(t, codes, 10)
+		assert.Equal(t, f.user.ID, stored.UserID)
+		seen := map[string]bool{}
+		for i, code := range codes {
+			assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+			assert.False(t, seen[code])
+			seen[code] = true
+
+			raw := strings.ReplaceAll(code, "-", "")
+			sum := sha256.Sum256([]byte(raw))
+			assert.Equal(t, recoveryHash(f.user.ID, raw), stored.CodeHashes[i])
+			assert.NotEqual(t, hex.EncodeToString(sum[:]), stored.CodeHashes[i])
+		}
+	})
+
+	t.Run("verify rejects a used recovery code", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		sum := sha256.Sum256([]byte("abcdefghij"))
+		f.store.On("AttemptMFAChallenge", ctx, mock.Anything).
+			Return(store.MFAChallenge{ID: uuid.New(), UserID: f.user.ID}, nil)
+		f.store.On("ConsumeRecoveryCode", ctx, store.ConsumeRecoveryCodeDTO{
+			UserID:   f.user.ID,
+			CodeHash: recoveryHash(f.user.ID, "abcdefghij"),
+		}).Return(pgx.ErrNoRows)
+		f.store.On("ConsumeRecoveryCode", ctx, store.ConsumeRecoveryCodeDTO{
+			UserID:   f.user.ID,
+			CodeHash: hex.EncodeToString(sum[:]),
+		}).Return(pgx.ErrNoRows)
+
+		_, err := f.client.VerifyMFA(ctx, "challenge", "ABCDE-fghij")
+
+		require.ErrorIs(t, err, errorz.ErrInvalidMFACode)
+	})
+
+	t.Run("verify accepts a code from a set hashed without a key", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		challengeID := uuid.New()
+		sum := sha256.Sum256([]byte("abcdefghij"))
+		f.store.On("AttemptMFAChallenge", ctx, mock.Anything).
+			Return(store.MFAChallenge{ID: challengeID, UserID: f.user.ID}, nil)
+		f.store.On("ConsumeRecoveryCode", ctx, store.ConsumeRecoveryCodeDTO{
+			UserID:   f.user.ID,
+			CodeHash: recoveryHash(f.user.ID, "abcdefghij"),
+		}).Return(pgx.ErrNoRows)
+		f.store.On("ConsumeRecoveryCode", ctx, store.ConsumeRecoveryCodeDTO{
+			UserID:   f.user.ID,
+			CodeHash: hex.EncodeToString(sum[:]),
+		}).Return(nil)
+		f.store.On("DeleteMFAChallenge", ctx, challengeID).Return(nil)
+		f.store.On("FindUserByID", ctx, f.user.ID).Return(f.user, nil)
+		f.jwtCreator.On("CreateAccessToken", f.user.ID.String()).Return(jwtgen.Token{Token: "jwt_token"}, nil)
+		f.store.On("CreateToken", ctx, mock.Anything).Return(uuid.New(), nil)
+
+		result, err := f.client.VerifyMFA(ctx, "challenge", "abcde-fghij")
+
+		require.NoError(t, err)
+		assert.Equal(t, "jwt_token", result)
+	})
+
+	t.Run("remaining count", func(t *testing.T) {
+		t.Parallel()
+
+		f := newMFAFixture(t)
+		f.store.On("CountRecoveryCodes", ctx, f.user.ID).Return(int64(3), nil)
+
+		remaining, err := f.client.RemainingRecoveryCodes(ctx, f.user.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, remaining)
+	})
+}
@@ -2,10 +2,15 @@ package authclient
 
 import (
 	"context"
+	"errors"
 	"regexp"
+	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/matchsystems/werr"
 )
 
@@ -27,12 +32,24 @@ func (dto RegisterParams) Validate() error {
 	return nil
 }
 
-func (c Client) Register(ctx context.Context, dto RegisterParams) error {
+func (c Client) Register(ctx context.Context, dto RegisterParams) (err error) {
+	event := authEvent{Type: entity.AuthEventRegister, UserID: uuid.Nil, Email: dto.Email}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
 	if err := dto.Validate(); err != nil {
 		return werr.Wrap(err)
 	}
+	if err := c.checkRateLimit(ctx, RateLimitRegister, dto.Email); err != nil {
+		return werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
 
-	if err := c.checkUserExistence(ctx, dto.Email); err != nil {
+	if c.enumeration != nil {
+		taken, err := c.signUpAttempt(ctx, dto)
+		if taken || err != nil {
+			return werr.Wrap(err)
+		}
+	} else if err := c.checkUserExistence(ctx, dto.Email); err != nil {
 		return werr.Wrap(err)
 	}
 	passHash, err := c.hasher.HashPassword(dto.Password)
@@ -43,21 +60,55 @@ func (c Client) Register(ctx context.Context, dto RegisterParams) error {
 	if err != nil {
 		return werr.Wrap(err)
 	}
-	if err = c.store.RegisterUserWithConfirmation(ctx, store.RegisterUserWithConfirmationDTO{
+	user := store.User{Email: dto.Email}
+	registered := UserRegistered{EventMeta: eventMeta(user)}
+	if err = c.vetoEvent(ctx, registered); err != nil {
+		return werr.Wrap(err)
+	}
+	queued, err := c.queueEmail(ctx, EmailPurposeConfirmation, user, confirmCode)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	user.ID, err = c.store.RegisterUserWithConfirmation(ctx, store.RegisterUserWithConfirmationDTO{
 		Email:            dto.Email,
 		PasswordHash:     passHash,
 		ConfirmationCode: confirmCode.Code,
 		ExpiresAt:        confirmCode.ExpiresAt,
-	}); err != nil {
+		Outbox:           queued,
+	})
+	if err != nil {
 		return werr.Wrap(err)
 	}
-	if err = c.emailSenderHook(ctx, dto.Email, confirmCode.Code); err != nil {
+	event.UserID = user.ID
+	registered.UserID = user.ID
+	c.publishEvent(ctx, registered)
+	if err = c.flushEmail(ctx, queued, EmailPurposeConfirmation, user, confirmCode); err != nil {
 		return werr.Wrap(err)
 	}
 
 	return nil
 }
 
+// signUpAttempt handles registering a taken email under enumeration
+// protection: the password is hashed as for a new user and the owner is told
+// instead of the caller.
+func (c Client) signUpAttempt(ctx context.Context, dto RegisterParams) (bool, error) {
+	user, err := c.store.FindUserByEmail(ctx, dto.Email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, werr.Wrap(err)
+	}
+	if _, err = c.hasher.HashPassword(dto.Password); err != nil {
+		return true, werr.Wrap(err)
+	}
+	c.notifySignUpAttempt(ctx, user)
+
+	return true, nil
+}
+
 func (c Client) checkUserExistence(ctx context.Context, email string) error {
 	exists, err := c.store.ExistsUserByLogin(ctx, email)
 	if err != nil {
@@ -15,6 +15,7 @@ import (
 	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
 	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
 	jwtmocks "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen/mocks"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -60,7 +61,7 @@ func TestClient_Register(t *testing.T) {
 			PasswordHash:     hashedPassword,
 			ConfirmationCode: confirmCode.Code,
 			ExpiresAt:        confirmCode.ExpiresAt,
-		}).Return(nil)
+		}).Return(uuid.New(), nil)
 
 		err = client.Register(ctx, authclient.RegisterParams{
 			Email:    email,
@@ -82,7 +83,7 @@ func TestClient_Register(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		err = client.Register(ctx, authclient.RegisterParams{
@@ -101,7 +102,7 @@ func TestClient_Register(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		email := "test@example.com"
@@ -127,7 +128,7 @@ func TestClient_Register(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		email := "test@example.com"
@@ -184,7 +185,7 @@ func TestClient_Register(t *testing.T) {
 			PasswordHash:     hashedPassword,
 			ConfirmationCode: confirmCode.Code,
 			ExpiresAt:        confirmCode.ExpiresAt,
-		}).Return(nil)
+		}).Return(uuid.New(), nil)
 
 		err = client.Register(ctx, authclient.RegisterParams{
 			Email:    email,
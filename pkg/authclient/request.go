@@ -0,0 +1,21 @@
+package authclient
+
+import "context"
+
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	Locale    string
+}
+
+type requestInfoKey struct{}
+
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+
+	return info
+}
@@ -3,9 +3,12 @@ package authclient
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
 	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/matchsystems/werr"
 )
@@ -22,42 +25,56 @@ func (dto ForgotPasswordParams) Validate() error {
 	return nil
 }
 
-func (c Client) ForgotPassword(ctx context.Context, dto ForgotPasswordParams) error {
+func (c Client) ForgotPassword(ctx context.Context, dto ForgotPasswordParams) (err error) {
+	event := authEvent{Type: entity.AuthEventPasswordResetRequest, UserID: uuid.Nil, Email: dto.Email}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
 	if err := dto.Validate(); err != nil {
 		return werr.Wrap(err)
 	}
+	if err := c.checkRateLimit(ctx, RateLimitForgotPassword, dto.Email); err != nil {
+		return werr.Wrap(err)
+	}
+	defer c.padResponse(ctx, time.Now())
 
 	user, err := c.store.FindUserByEmail(ctx, dto.Email)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return werr.Wrap(errorz.ErrInvalidCredentials)
+			return werr.Wrap(c.hiddenError(errorz.ErrInvalidCredentials))
 		}
 
 		return werr.Wrap(err)
 	}
 
+	event.UserID = user.ID
+
 	if !user.Entity().IsVerified {
-		return werr.Wrap(errorz.ErrEmailNotConfirmed)
+		return werr.Wrap(c.hiddenError(errorz.ErrEmailNotConfirmed))
 	}
 
+	return werr.Wrap(c.sendResetCode(ctx, user))
+}
+
+func (c Client) sendResetCode(ctx context.Context, user store.User) error {
 	resetCode, err := c.codeGenerator.GenerateResetCode()
 	if err != nil {
 		return werr.Wrap(err)
 	}
 
+	queued, err := c.queueEmail(ctx, EmailPurposeReset, user, resetCode)
+	if err != nil {
+		return werr.Wrap(err)
+	}
 	if _, err = c.store.CreateEmailConfirmation(ctx, store.CreateEmailConfirmationDTO{
 		UserID:           user.ID,
 		ConfirmationCode: resetCode.Code,
 		ExpiresAt:        resetCode.ExpiresAt,
+		Outbox:           queued,
 	}); err != nil {
 		return werr.Wrap(err)
 	}
 
-	if err = c.emailSenderHook(ctx, dto.Email, resetCode.Code); err != nil {
-		return werr.Wrap(err)
-	}
-
-	return nil
+	return werr.Wrap(c.flushEmail(ctx, queued, EmailPurposeReset, user, resetCode))
 }
 
 type ResetPasswordParams struct {
@@ -73,7 +90,10 @@ func (dto ResetPasswordParams) Validate() error {
 	return nil
 }
 
-func (c Client) ResetPassword(ctx context.Context, dto ResetPasswordParams) error {
+func (c Client) ResetPassword(ctx context.Context, dto ResetPasswordParams) (err error) {
+	event := authEvent{Type: entity.AuthEventPasswordReset, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
 	if err := dto.Validate(); err != nil {
 		return werr.Wrap(err)
 	}
@@ -86,18 +106,25 @@ func (c Client) ResetPassword(ctx context.Context, dto ResetPasswordParams) erro
 
 		return werr.Wrap(err)
 	}
+	event.UserID, event.Email = user.ID, user.Email
 
 	newPasswordHash, err := c.hasher.HashPassword(dto.Password)
 	if err != nil {
 		return werr.Wrap(err)
 	}
 
+	changed := PasswordChanged{EventMeta: eventMeta(user)}
+	if err = c.vetoEvent(ctx, changed); err != nil {
+		return werr.Wrap(err)
+	}
 	if err = c.store.UpdateUserPassword(ctx, store.UpdateUserPasswordDTO{
 		Email:        user.Email,
 		PasswordHash: newPasswordHash,
 	}); err != nil {
 		return werr.Wrap(err)
 	}
+	c.notifyPasswordChanged(ctx, user)
+	c.publishEvent(ctx, changed)
 
 	return nil
 }
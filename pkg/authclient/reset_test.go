@@ -83,7 +83,7 @@ func TestClient_ForgotPassword(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		err = client.ForgotPassword(ctx, authclient.ForgotPasswordParams{
@@ -102,7 +102,7 @@ func TestClient_ForgotPassword(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		email := "notfound@example.com"
@@ -126,7 +126,7 @@ func TestClient_ForgotPassword(t *testing.T) {
 			JWTConfig: jwtgen.CreatorConfig{
 				SecretKey: []byte("secret_key"),
 			},
-		}, authclient.WithStore(mockStore))
+		}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
 		require.NoError(t, err)
 
 		email := "unverified@example.com"
@@ -160,6 +160,7 @@ func TestClient_ForgotPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -197,6 +198,7 @@ func TestClient_ForgotPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithCodeGenerator(mockCodeGenerator),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -301,6 +303,7 @@ func TestClient_ResetPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -345,6 +348,7 @@ func TestClient_ResetPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -370,6 +374,7 @@ func TestClient_ResetPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -400,6 +405,7 @@ func TestClient_ResetPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
@@ -437,6 +443,7 @@ func TestClient_ResetPassword(t *testing.T) {
 			},
 			authclient.WithStore(mockStore),
 			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(&recordingSender{}),
 		)
 		require.NoError(t, err)
 
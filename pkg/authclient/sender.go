@@ -0,0 +1,66 @@
+package authclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailPurpose string
+
+const (
+	EmailPurposeConfirmation  EmailPurpose = "confirmation"
+	EmailPurposeReset         EmailPurpose = "reset"
+	EmailPurposeLoginLink     EmailPurpose = "login_link"
+	EmailPurposeMFACode       EmailPurpose = "mfa_code"
+	EmailPurposeUnlock        EmailPurpose = "unlock"
+	EmailPurposeSignUpAttempt EmailPurpose = "signup_attempt"
+)
+
+type EmailMessage struct {
+	Purpose   EmailPurpose
+	To        string
+	Code      string
+	Link      string
+	ExpiresAt time.Time
+	UserID    uuid.UUID
+	Locale    string
+	Data      map[string]any
+}
+
+type SecurityNotification struct {
+	To         string
+	UserID     uuid.UUID
+	Locale     string
+	IP         string
+	UserAgent  string
+	OccurredAt time.Time
+	Data       map[string]any
+}
+
+type EmailSender interface {
+	SendEmail(ctx context.Context, msg EmailMessage) error
+	SendPasswordChanged(ctx context.Context, notification SecurityNotification) error
+	SendNewLogin(ctx context.Context, notification SecurityNotification) error
+}
+
+// EmailSenderHook adapts a plain function to EmailSender. It only receives
+// the recipient and the code, and drops security notifications.
+type EmailSenderHook func(ctx context.Context, email string, code string) error
+
+var _ EmailSender = EmailSenderHook(nil)
+
+func (h EmailSenderHook) SendEmail(ctx context.Context, msg EmailMessage) error {
+	return h(ctx, msg.To, msg.Code)
+}
+
+func (h EmailSenderHook) SendPasswordChanged(context.Context, SecurityNotification) error {
+	return nil
+}
+
+func (h EmailSenderHook) SendNewLogin(context.Context, SecurityNotification) error {
+	return nil
+}
+
+type LinkBuilder func(purpose EmailPurpose, code string) string
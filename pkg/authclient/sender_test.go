@@ -0,0 +1,150 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/codegen"
+	codegenmocks "github.com/github.com/VadimOcLock/vauth/pkg/codegen/mocks"
+	hashermocks "github.com/github.com/VadimOcLock/vauth/pkg/hash/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSender struct {
+	messages        []authclient.EmailMessage
+	passwordChanged []authclient.SecurityNotification
+	newLogins       []authclient.SecurityNotification
+}
+
+func (s *recordingSender) SendEmail(_ context.Context, msg authclient.EmailMessage) error {
+	s.messages = append(s.messages, msg)
+
+	return nil
+}
+
+func (s *recordingSender) SendPasswordChanged(_ context.Context, n authclient.SecurityNotification) error {
+	s.passwordChanged = append(s.passwordChanged, n)
+
+	return nil
+}
+
+func (s *recordingSender) SendNewLogin(_ context.Context, n authclient.SecurityNotification) error {
+	s.newLogins = append(s.newLogins, n)
+
+	return nil
+}
+
+func TestClient_EmailSender(t *testing.T) {
+	t.Parallel()
+
+	t.Run("message carries purpose, link and request locale", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{
+			Locale: "de",
+		})
+		sender := &recordingSender{}
+		mockStore := storemocks.NewStore(t)
+		mockCodeGenerator := codegenmocks.NewGenerator(t)
+		client, err := authclient.New(
+			authclient.Config{
+				JWTConfig: jwtgen.CreatorConfig{
+					SecretKey: []byte("secret_key"),
+				},
+				EmailSender: sender,
+				EmailLinkBuilder: func(purpose authclient.EmailPurpose, code string) string {
+					return "https://example.com/" + string(purpose) + "?code=" + code
+				},
+				EmailTemplateData: map[string]any{"AppName": "vauth"},
+			},
+			authclient.WithStore(mockStore),
+			authclient.WithCodeGenerator(mockCodeGenerator),
+		)
+		require.NoError(t, err)
+
+		email := "test@example.com"
+		userID := uuid.New()
+		resetCode := codegen.Code{
+			Code:      "654321",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockStore.On("FindUserByEmail", ctx, email).Return(store.User{
+			ID:         userID,
+			Email:      email,
+			IsVerified: pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+		mockCodeGenerator.On("GenerateResetCode").Return(resetCode, nil)
+		mockStore.On("CreateEmailConfirmation", ctx, store.CreateEmailConfirmationDTO{
+			UserID:           userID,
+			ConfirmationCode: resetCode.Code,
+			ExpiresAt:        resetCode.ExpiresAt,
+		}).Return(uuid.New(), nil)
+
+		err = client.ForgotPassword(ctx, authclient.ForgotPasswordParams{Email: email})
+
+		require.NoError(t, err)
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, authclient.EmailMessage{
+			Purpose:   authclient.EmailPurposeReset,
+			To:        email,
+			Code:      resetCode.Code,
+			Link:      "https://example.com/reset?code=654321",
+			ExpiresAt: resetCode.ExpiresAt,
+			UserID:    userID,
+			Locale:    "de",
+			Data:      map[string]any{"AppName": "vauth"},
+		}, sender.messages[0])
+	})
+
+	t.Run("password reset sends security notification", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{
+			IP:        "203.0.113.7",
+			UserAgent: "test-agent",
+		})
+		sender := &recordingSender{}
+		mockStore := storemocks.NewStore(t)
+		mockHasher := hashermocks.NewHasher(t)
+		client, err := authclient.New(
+			authclient.Config{
+				JWTConfig: jwtgen.CreatorConfig{
+					SecretKey: []byte("secret_key"),
+				},
+			},
+			authclient.WithStore(mockStore),
+			authclient.WithHasher(mockHasher),
+			authclient.WithEmailSender(sender),
+		)
+		require.NoError(t, err)
+
+		user := store.User{ID: uuid.New(), Email: "test@example.com"}
+
+		mockStore.On("FindUserByConfirmationCode", ctx, "reset-code").Return(user, nil)
+		mockHasher.On("HashPassword", "newpassword").Return("new_hash", nil)
+		mockStore.On("UpdateUserPassword", ctx, store.UpdateUserPasswordDTO{
+			Email:        user.Email,
+			PasswordHash: "new_hash",
+		}).Return(nil)
+
+		err = client.ResetPassword(ctx, authclient.ResetPasswordParams{
+			Code:     "reset-code",
+			Password: "newpassword",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, sender.passwordChanged, 1)
+		assert.Equal(t, user.ID, sender.passwordChanged[0].UserID)
+		assert.Equal(t, "203.0.113.7", sender.passwordChanged[0].IP)
+		assert.Equal(t, "test-agent", sender.passwordChanged[0].UserAgent)
+	})
+}
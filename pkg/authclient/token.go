@@ -0,0 +1,211 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+type Claims struct {
+	UserID uuid.UUID
+	// TokenID identifies the stored token.
+	TokenID   uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// ValidateToken checks the signature, expiry and type of an access token
+// and that it is still stored and not revoked.
+func (c Client) ValidateToken(ctx context.Context, token string) (Claims, error) {
+	if c.jwtVerifier == nil {
+		return Claims{}, werr.Wrap(errorz.ErrJWTSecretKeyRequired)
+	}
+
+	parsed, err := c.jwtVerifier.ParseAccessToken(token)
+	if err != nil {
+		return Claims{}, werr.Wrap(err)
+	}
+	userID, err := uuid.Parse(parsed.UserID)
+	if err != nil {
+		return Claims{}, werr.Wrap(errorz.ErrInvalidToken)
+	}
+
+	stored, err := c.store.FindToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Claims{}, werr.Wrap(errorz.ErrTokenRevoked)
+		}
+
+		return Claims{}, werr.Wrap(err)
+	}
+	if stored.Revoked.Bool || stored.UserID != userID {
+		return Claims{}, werr.Wrap(errorz.ErrTokenRevoked)
+	}
+
+	return Claims{
+		UserID:    userID,
+		TokenID:   stored.ID,
+		IssuedAt:  parsed.IssuedAt,
+		ExpiresAt: parsed.ExpiresAt,
+	}, nil
+}
+
+// Tokens is an access token and, when requested, the refresh token that renews it.
+type Tokens struct {
+	AccessToken  jwtgen.Token
+	RefreshToken jwtgen.Token
+}
+
+func (c Client) issueTokens(ctx context.Context, userID uuid.UUID, refresh bool) (Tokens, error) {
+	access, err := c.issueToken(ctx, userID, c.jwtCreator.CreateAccessToken)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	if !refresh {
+		return Tokens{AccessToken: access, RefreshToken: jwtgen.Token{}}, nil
+	}
+	refreshToken, err := c.issueToken(ctx, userID, c.jwtCreator.CreateRefreshToken)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	return Tokens{AccessToken: access, RefreshToken: refreshToken}, nil
+}
+
+func (c Client) issueToken(
+	ctx context.Context,
+	userID uuid.UUID,
+	create func(userID string) (jwtgen.Token, error),
+) (jwtgen.Token, error) {
+	token, err := create(userID.String())
+	if err != nil {
+		return jwtgen.Token{}, werr.Wrap(err)
+	}
+
+	_, err = c.store.CreateToken(ctx, store.CreateTokenDTO{
+		UserID:    userID,
+		Token:     token.Token,
+		ExpiresAt: token.ExpiresAt,
+	})
+	if err != nil {
+		return jwtgen.Token{}, werr.Wrap(err)
+	}
+
+	return token, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token. Each
+// refresh token works once: presenting a used one again revokes every token
+// of its user, since one of the two holders must have stolen it.
+func (c Client) Refresh(ctx context.Context, refreshToken string) (_ Tokens, err error) {
+	event := authEvent{Type: entity.AuthEventTokenRefresh, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	if c.jwtVerifier == nil {
+		return Tokens{}, werr.Wrap(errorz.ErrJWTSecretKeyRequired)
+	}
+	parsed, err := c.jwtVerifier.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+
+	stored, err := c.store.RevokeToken(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Tokens{}, werr.Wrap(c.refreshTokenReused(ctx, refreshToken))
+		}
+
+		return Tokens{}, werr.Wrap(err)
+	}
+	if stored.UserID.String() != parsed.UserID {
+		return Tokens{}, werr.Wrap(errorz.ErrInvalidToken)
+	}
+	event.UserID = stored.UserID
+
+	user, err := c.store.FindUserByID(ctx, stored.UserID)
+	if err != nil {
+		return Tokens{}, werr.Wrap(err)
+	}
+	event.Email = user.Email
+
+	return c.issueTokens(ctx, user.ID, true)
+}
+
+func (c Client) refreshTokenReused(ctx context.Context, refreshToken string) error {
+	stored, err := c.store.FindToken(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errorz.ErrTokenRevoked
+		}
+
+		return werr.Wrap(err)
+	}
+	if err = c.RevokeAllTokens(ctx, stored.UserID); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return errorz.ErrTokenRevoked
+}
+
+type LogoutParams struct {
+	AccessToken string
+	// RefreshToken is optional.
+	RefreshToken string
+}
+
+// Logout revokes the tokens of one session. Tokens that are unknown or
+// already revoked are ignored.
+func (c Client) Logout(ctx context.Context, dto LogoutParams) (err error) {
+	event := authEvent{Type: entity.AuthEventLogout, UserID: uuid.Nil, Email: ""}
+	defer func() { c.recordAuthEvent(ctx, event, err) }()
+
+	if dto.AccessToken == "" && dto.RefreshToken == "" {
+		return werr.Wrap(errorz.ErrInvalidToken)
+	}
+
+	for _, token := range []string{dto.AccessToken, dto.RefreshToken} {
+		if token == "" {
+			continue
+		}
+		stored, err := c.store.RevokeToken(ctx, token)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+
+			return werr.Wrap(err)
+		}
+		event.UserID = stored.UserID
+	}
+	if event.UserID != uuid.Nil {
+		c.publishEvent(ctx, SessionRevoked{EventMeta: EventMeta{
+			UserID:     event.UserID,
+			Email:      "",
+			OccurredAt: time.Now(),
+		}})
+	}
+
+	return nil
+}
+
+// RevokeAllTokens signs a user out everywhere.
+func (c Client) RevokeAllTokens(ctx context.Context, userID uuid.UUID) error {
+	if _, err := c.store.RevokeUserTokens(ctx, userID); err != nil {
+		return werr.Wrap(err)
+	}
+	c.publishEvent(ctx, SessionRevoked{EventMeta: EventMeta{
+		UserID:     userID,
+		Email:      "",
+		OccurredAt: time.Now(),
+	}})
+
+	return nil
+}
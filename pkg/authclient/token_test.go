@@ -0,0 +1,223 @@
+package authclient_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ValidateToken(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	jwtConfig := jwtgen.CreatorConfig{SecretKey: []byte("secret_key")}
+	creator, err := jwtgen.NewCreator(jwtConfig)
+	require.NoError(t, err)
+	userID := uuid.New()
+	access, err := creator.CreateAccessToken(userID.String())
+	require.NoError(t, err)
+
+	newClient := func(t *testing.T, mockStore *storemocks.Store) *authclient.Client {
+		t.Helper()
+
+		client, err := authclient.New(authclient.Config{JWTConfig: jwtConfig}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		return client
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newClient(t, mockStore)
+		tokenID := uuid.New()
+		mockStore.On("FindToken", ctx, access.Token).Return(store.Token{ID: tokenID, UserID: userID}, nil)
+
+		claims, err := client.ValidateToken(ctx, access.Token)
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, tokenID, claims.TokenID)
+		assert.WithinDuration(t, access.ExpiresAt, claims.ExpiresAt, time.Second)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newClient(t, mockStore)
+		mockStore.On("FindToken", ctx, access.Token).Return(store.Token{
+			ID:      uuid.New(),
+			UserID:  userID,
+			Revoked: pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+
+		_, err := client.ValidateToken(ctx, access.Token)
+
+		require.ErrorIs(t, err, errorz.ErrTokenRevoked)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newClient(t, mockStore)
+		mockStore.On("FindToken", ctx, access.Token).Return(store.Token{}, pgx.ErrNoRows)
+
+		_, err := client.ValidateToken(ctx, access.Token)
+
+		require.ErrorIs(t, err, errorz.ErrTokenRevoked)
+	})
+
+	t.Run("refresh token is not an access token", func(t *testing.T) {
+		t.Parallel()
+
+		refresh, err := creator.CreateRefreshToken(userID.String())
+		require.NoError(t, err)
+
+		_, err = newClient(t, storemocks.NewStore(t)).ValidateToken(ctx, refresh.Token)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidToken)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		t.Parallel()
+
+		expiredCreator, err := jwtgen.NewCreator(jwtgen.CreatorConfig{
+			SecretKey: jwtConfig.SecretKey,
+			TokenOpts: []jwtgen.CreatorOption{jwtgen.WithAccessTokenTTL(-time.Minute)},
+		})
+		require.NoError(t, err)
+		expired, err := expiredCreator.CreateAccessToken(userID.String())
+		require.NoError(t, err)
+
+		_, err = newClient(t, storemocks.NewStore(t)).ValidateToken(ctx, expired.Token)
+
+		require.ErrorIs(t, err, errorz.ErrTokenExpired)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		t.Parallel()
+
+		otherCreator, err := jwtgen.NewCreator(jwtgen.CreatorConfig{SecretKey: []byte("other_key")})
+		require.NoError(t, err)
+		forged, err := otherCreator.CreateAccessToken(userID.String())
+		require.NoError(t, err)
+
+		_, err = newClient(t, storemocks.NewStore(t)).ValidateToken(ctx, forged.Token)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidToken)
+	})
+}
+
+func TestClient_Refresh(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	jwtConfig := jwtgen.CreatorConfig{SecretKey: []byte("secret_key")}
+	creator, err := jwtgen.NewCreator(jwtConfig)
+	require.NoError(t, err)
+	user := store.User{ID: uuid.New(), Email: "test@example.com"}
+	refresh, err := creator.CreateRefreshToken(user.ID.String())
+	require.NoError(t, err)
+
+	t.Run("rotates the refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(authclient.Config{JWTConfig: jwtConfig}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		mockStore.On("RevokeToken", ctx, refresh.Token).Return(store.Token{ID: uuid.New(), UserID: user.ID}, nil)
+		mockStore.On("FindUserByID", ctx, user.ID).Return(user, nil)
+		mockStore.On("CreateToken", ctx, mock.MatchedBy(func(dto store.CreateTokenDTO) bool {
+			return dto.UserID == user.ID
+		})).Return(uuid.New(), nil).Twice()
+
+		tokens, err := client.Refresh(ctx, refresh.Token)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken.Token)
+		assert.NotEmpty(t, tokens.RefreshToken.Token)
+		assert.NotEqual(t, tokens.AccessToken.Token, tokens.RefreshToken.Token)
+	})
+
+	t.Run("reused refresh token revokes every session", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client, err := authclient.New(authclient.Config{JWTConfig: jwtConfig}, authclient.WithStore(mockStore), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+
+		mockStore.On("RevokeToken", ctx, refresh.Token).Return(store.Token{}, pgx.ErrNoRows)
+		mockStore.On("FindToken", ctx, refresh.Token).Return(store.Token{
+			UserID:  user.ID,
+			Revoked: pgtype.Bool{Bool: true, Valid: true},
+		}, nil)
+		mockStore.On("RevokeUserTokens", ctx, user.ID).Return(int64(3), nil)
+
+		_, err = client.Refresh(ctx, refresh.Token)
+
+		require.ErrorIs(t, err, errorz.ErrTokenRevoked)
+	})
+
+	t.Run("access token cannot refresh", func(t *testing.T) {
+		t.Parallel()
+
+		client, err := authclient.New(authclient.Config{JWTConfig: jwtConfig}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithEmailSender(&recordingSender{}))
+		require.NoError(t, err)
+		access, err := creator.CreateAccessToken(user.ID.String())
+		require.NoError(t, err)
+
+		_, err = client.Refresh(ctx, access.Token)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidToken)
+	})
+}
+
+func TestClient_Logout(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	mockStore := storemocks.NewStore(t)
+	revoked := make(chan authclient.Event, 1)
+	client, err := authclient.New(
+		authclient.Config{JWTConfig: jwtgen.CreatorConfig{SecretKey: []byte("secret_key")}},
+		authclient.WithStore(mockStore),
+		authclient.WithEventListener(authclient.EventListenerFunc(func(_ context.Context, event authclient.Event) error {
+			revoked <- event
+
+			return nil
+		}), authclient.DeliverAsync),
+		authclient.WithEmailSender(&recordingSender{}),
+	)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	mockStore.On("RevokeToken", ctx, "access").Return(store.Token{UserID: userID}, nil)
+	mockStore.On("RevokeToken", ctx, "refresh").Return(store.Token{}, pgx.ErrNoRows)
+
+	err = client.Logout(ctx, authclient.LogoutParams{AccessToken: "access", RefreshToken: "refresh"})
+
+	require.NoError(t, err)
+	select {
+	case event := <-revoked:
+		assert.IsType(t, authclient.SessionRevoked{}, event)
+		assert.Equal(t, userID, event.Meta().UserID)
+	case <-time.After(time.Second):
+		t.Fatal("event not delivered")
+	}
+}
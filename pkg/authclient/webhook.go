@@ -0,0 +1,302 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/github.com/VadimOcLock/vauth/pkg/entity"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/matchsystems/werr"
+)
+
+const OutboxKindWebhook = "webhook"
+
+// Webhook event types, sent in the "type" field and the Vauth-Event header.
+const (
+	WebhookEventUserRegistered  = "user.registered"
+	WebhookEventEmailVerified   = "user.email_verified"
+	WebhookEventPasswordChanged = "user.password_changed"
+	WebhookEventLoggedIn        = "user.logged_in"
+	WebhookEventSessionRevoked  = "user.session_revoked"
+)
+
+var webhookEventTypes = []string{
+	WebhookEventUserRegistered,
+	WebhookEventEmailVerified,
+	WebhookEventPasswordChanged,
+	WebhookEventLoggedIn,
+	WebhookEventSessionRevoked,
+}
+
+const (
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultWebhookMaxFailures = 20
+	maxWebhookResponseBody    = 64 << 10
+)
+
+type WebhookConfig struct {
+	// HTTPClient defaults to a client with Timeout.
+	HTTPClient *http.Client
+	Timeout    time.Duration
+	// MaxFailures consecutive failed deliveries disable an endpoint until
+	// EnableWebhookEndpoint is called.
+	MaxFailures int32
+}
+
+// WithWebhooks POSTs every published event as signed JSON to the endpoints
+// added with CreateWebhookEndpoint. Deliveries are queued in the outbox and
+// retried with its backoff, so WithOutbox is required.
+func WithWebhooks(cfg WebhookConfig) Option {
+	return func(c *Client) error {
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = defaultWebhookTimeout
+		}
+		if cfg.HTTPClient == nil {
+			cfg.HTTPClient = &http.Client{Timeout: cfg.Timeout}
+		}
+		if cfg.MaxFailures <= 0 {
+			cfg.MaxFailures = defaultWebhookMaxFailures
+		}
+		c.webhooks = &cfg
+
+		return nil
+	}
+}
+
+type CreateWebhookEndpointParams struct {
+	URL string
+	// EventTypes subscribes to some of the WebhookEvent types; empty means all of them.
+	EventTypes []string
+	// Secret is generated when empty.
+	Secret string
+}
+
+func (dto CreateWebhookEndpointParams) Validate() error {
+	u, err := url.Parse(dto.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errorz.ErrInvalidWebhookURL
+	}
+	for _, eventType := range dto.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return werr.Wrapf(errorz.ErrUnknownWebhookEvent, "%q", eventType)
+		}
+	}
+
+	return nil
+}
+
+// CreateWebhookEndpoint registers an endpoint. The returned Secret is what
+// receivers pass to webhook.Verify.
+func (c Client) CreateWebhookEndpoint(
+	ctx context.Context,
+	dto CreateWebhookEndpointParams,
+) (entity.WebhookEndpoint, error) {
+	if c.webhooks == nil {
+		return entity.WebhookEndpoint{}, werr.Wrap(errorz.ErrWebhooksDisabled)
+	}
+	if err := dto.Validate(); err != nil {
+		return entity.WebhookEndpoint{}, werr.Wrap(err)
+	}
+	if dto.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return entity.WebhookEndpoint{}, werr.Wrap(err)
+		}
+		dto.Secret = secret
+	}
+
+	endpoint, err := c.store.CreateWebhookEndpoint(ctx, store.CreateWebhookEndpointDTO{
+		URL:        dto.URL,
+		Secret:     dto.Secret,
+		EventTypes: dto.EventTypes,
+	})
+	if err != nil {
+		return entity.WebhookEndpoint{}, werr.Wrap(err)
+	}
+
+	return endpoint.Entity(), nil
+}
+
+func (c Client) WebhookEndpoints(ctx context.Context) ([]entity.WebhookEndpoint, error) {
+	if c.webhooks == nil {
+		return nil, werr.Wrap(errorz.ErrWebhooksDisabled)
+	}
+
+	endpoints, err := c.store.ListWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	result := make([]entity.WebhookEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		result = append(result, endpoint.Entity())
+	}
+
+	return result, nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint; its queued deliveries are dropped.
+func (c Client) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	if c.webhooks == nil {
+		return werr.Wrap(errorz.ErrWebhooksDisabled)
+	}
+
+	return werr.Wrap(webhookEndpointError(c.store.DeleteWebhookEndpoint(ctx, id)))
+}
+
+// EnableWebhookEndpoint re-enables an endpoint disabled after MaxFailures.
+// Deliveries dropped while it was disabled are not replayed.
+func (c Client) EnableWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	if c.webhooks == nil {
+		return werr.Wrap(errorz.ErrWebhooksDisabled)
+	}
+
+	return werr.Wrap(webhookEndpointError(c.store.EnableWebhookEndpoint(ctx, id)))
+}
+
+type webhookPayload struct {
+	Type       string      `json:"type"`
+	UserID     uuid.UUID   `json:"user_id"`
+	Email      string      `json:"email"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Method     LoginMethod `json:"method,omitempty"`
+	MFA        bool        `json:"mfa,omitempty"`
+}
+
+type outboxWebhook struct {
+	EndpointID uuid.UUID       `json:"endpoint_id"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// enqueueWebhooks queues one delivery per subscribed endpoint. Like the audit
+// log it is best effort: the event has already happened.
+func (c Client) enqueueWebhooks(ctx context.Context, event Event) {
+	if c.webhooks == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	meta := event.Meta()
+	payload := webhookPayload{
+		Type:       "",
+		UserID:     meta.UserID,
+		Email:      meta.Email,
+		OccurredAt: meta.OccurredAt.UTC(),
+		Method:     "",
+		MFA:        false,
+	}
+	switch e := event.(type) {
+	case UserRegistered:
+		payload.Type = WebhookEventUserRegistered
+	case EmailVerified:
+		payload.Type = WebhookEventEmailVerified
+	case PasswordChanged:
+		payload.Type = WebhookEventPasswordChanged
+	case LoggedIn:
+		payload.Type = WebhookEventLoggedIn
+		payload.Method, payload.MFA = e.Method, e.MFA
+	case SessionRevoked:
+		payload.Type = WebhookEventSessionRevoked
+	default:
+		return
+	}
+
+	endpoints, err := c.store.ListWebhookEndpointsForEvent(ctx, payload.Type)
+	if err != nil || len(endpoints) == 0 {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	for _, endpoint := range endpoints {
+		msg, err := json.Marshal(outboxWebhook{EndpointID: endpoint.ID, Event: payload.Type, Body: body})
+		if err != nil {
+			return
+		}
+		_, _ = c.store.CreateOutboxMessage(ctx, store.CreateOutboxMessageDTO{
+			Kind:    OutboxKindWebhook,
+			UserID:  meta.UserID,
+			Payload: string(msg),
+		})
+	}
+}
+
+// deliverWebhook posts one queued delivery and keeps the endpoint's count of
+// consecutive failures.
+func (c Client) deliverWebhook(ctx context.Context, msg store.OutboxMessage) error {
+	if c.webhooks == nil {
+		return werr.Wrap(errorz.ErrWebhooksDisabled)
+	}
+
+	var payload outboxWebhook
+	if err := json.Unmarshal([]byte(msg.Payload), &payload); err != nil {
+		return werr.Wrap(err)
+	}
+	endpoint, err := c.store.FindWebhookEndpoint(ctx, payload.EndpointID)
+	if err != nil {
+		return werr.Wrap(webhookEndpointError(err))
+	}
+	if endpoint.DisabledAt.Valid {
+		return werr.Wrap(errorz.ErrWebhookEndpointDisabled)
+	}
+
+	if err = c.postWebhook(ctx, endpoint, msg.ID, payload); err != nil {
+		_ = c.store.RecordWebhookFailure(ctx, store.RecordWebhookFailureDTO{
+			ID:          endpoint.ID,
+			MaxFailures: c.webhooks.MaxFailures,
+		})
+
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(c.store.RecordWebhookSuccess(ctx, endpoint.ID))
+}
+
+func (c Client) postWebhook(
+	ctx context.Context,
+	endpoint store.WebhookEndpoint,
+	deliveryID uuid.UUID,
+	payload outboxWebhook,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(payload.Body))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderEvent, payload.Event)
+	req.Header.Set(webhook.HeaderDelivery, deliveryID.String())
+	webhook.SetHeaders(req.Header, []byte(endpoint.Secret), time.Now(), payload.Body)
+
+	resp, err := c.webhooks.HTTPClient.Do(req)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxWebhookResponseBody))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return werr.Wrapf(errorz.ErrWebhookDeliveryFailed, "status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func webhookEndpointError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errorz.ErrWebhookEndpointNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,182 @@
+package authclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	storemocks "github.com/github.com/VadimOcLock/vauth/internal/store/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Webhooks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("confirmed email is queued for subscribed endpoints", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, nil, mockStore, authclient.WithWebhooks(authclient.WebhookConfig{}))
+
+		user := store.User{ID: uuid.New(), Email: "test@example.com"}
+		endpoint := store.WebhookEndpoint{ID: uuid.New(), URL: "https://example.com/hook"}
+		mockStore.On("FindUserByConfirmationCode", ctx, "code").Return(user, nil)
+		mockStore.On("UpdateUserAsVerified", ctx, user.Email).Return(nil)
+		mockStore.On("ListWebhookEndpointsForEvent", mock.Anything, authclient.WebhookEventEmailVerified).
+			Return([]store.WebhookEndpoint{endpoint}, nil)
+		mockStore.On("CreateOutboxMessage", mock.Anything, mock.MatchedBy(func(dto store.CreateOutboxMessageDTO) bool {
+			return dto.Kind == authclient.OutboxKindWebhook &&
+				dto.UserID == user.ID &&
+				assert.Contains(t, dto.Payload, endpoint.ID.String()) &&
+				assert.Contains(t, dto.Payload, `"type":"user.email_verified"`)
+		})).Return(uuid.New(), nil)
+
+		err := client.ConfirmEmail(ctx, authclient.ConfirmEmailParams{Code: "code"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("dispatch posts a signed delivery", func(t *testing.T) {
+		t.Parallel()
+
+		secret := "webhook_secret"
+		received := make(chan []byte, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := webhook.VerifyRequest(r, []byte(secret))
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			assert.Equal(t, authclient.WebhookEventLoggedIn, r.Header.Get(webhook.HeaderEvent))
+			received <- body
+		}))
+		defer server.Close()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, nil, mockStore, authclient.WithWebhooks(authclient.WebhookConfig{}))
+
+		endpoint := store.WebhookEndpoint{ID: uuid.New(), URL: server.URL, Secret: secret}
+		body := `{"type":"user.logged_in","method":"password"}`
+		msg := store.OutboxMessage{
+			ID:      uuid.New(),
+			Kind:    authclient.OutboxKindWebhook,
+			Payload: `{"endpoint_id":"` + endpoint.ID.String() + `","event":"user.logged_in","body":` + body + `}`,
+		}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{msg}, nil)
+		mockStore.On("FindWebhookEndpoint", ctx, endpoint.ID).Return(endpoint, nil)
+		mockStore.On("RecordWebhookSuccess", ctx, endpoint.ID).Return(nil)
+		mockStore.On("MarkOutboxMessageSent", ctx, msg).Return(nil)
+
+		processed, err := client.DispatchOutbox(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+		select {
+		case got := <-received:
+			assert.JSONEq(t, body, string(got))
+		default:
+			t.Fatal("webhook not delivered")
+		}
+	})
+
+	t.Run("failed delivery counts against the endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, nil, mockStore, authclient.WithWebhooks(authclient.WebhookConfig{
+			MaxFailures: 5,
+		}))
+
+		endpoint := store.WebhookEndpoint{ID: uuid.New(), URL: server.URL, Secret: "secret"}
+		msg := store.OutboxMessage{
+			ID:      uuid.New(),
+			Kind:    authclient.OutboxKindWebhook,
+			Payload: `{"endpoint_id":"` + endpoint.ID.String() + `","event":"user.logged_in","body":{}}`,
+		}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{msg}, nil)
+		mockStore.On("FindWebhookEndpoint", ctx, endpoint.ID).Return(endpoint, nil)
+		mockStore.On("RecordWebhookFailure", ctx, store.RecordWebhookFailureDTO{
+			ID:          endpoint.ID,
+			MaxFailures: 5,
+		}).Return(nil)
+		mockStore.On("MarkOutboxMessageFailed", ctx, mock.MatchedBy(func(dto store.MarkOutboxMessageFailedDTO) bool {
+			return dto.Message.ID == msg.ID && !dto.Dead && assert.Contains(t, dto.LastError, "status 500")
+		})).Return(nil)
+
+		_, err := client.DispatchOutbox(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("deliveries to a disabled endpoint are dropped", func(t *testing.T) {
+		t.Parallel()
+
+		mockStore := storemocks.NewStore(t)
+		client := newOutboxClient(t, nil, mockStore, authclient.WithWebhooks(authclient.WebhookConfig{}))
+
+		endpoint := store.WebhookEndpoint{
+			ID:         uuid.New(),
+			URL:        "https://example.com/hook",
+			DisabledAt: pgtype.Timestamp{Time: time.Now(), Valid: true},
+		}
+		msg := store.OutboxMessage{
+			ID:      uuid.New(),
+			Kind:    authclient.OutboxKindWebhook,
+			Payload: `{"endpoint_id":"` + endpoint.ID.String() + `","event":"user.logged_in","body":{}}`,
+		}
+		mockStore.On("ClaimOutboxMessages", ctx, claimBatch(50)).Return([]store.OutboxMessage{msg}, nil)
+		mockStore.On("FindWebhookEndpoint", ctx, endpoint.ID).Return(endpoint, nil)
+		mockStore.On("MarkOutboxMessageFailed", ctx, mock.MatchedBy(func(dto store.MarkOutboxMessageFailedDTO) bool {
+			return dto.Message.ID == msg.ID && dto.Dead
+		})).Return(nil)
+
+		_, err := client.DispatchOutbox(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("endpoint validation", func(t *testing.T) {
+		t.Parallel()
+
+		client := newOutboxClient(t, nil, storemocks.NewStore(t), authclient.WithWebhooks(authclient.WebhookConfig{}))
+
+		_, err := client.CreateWebhookEndpoint(ctx, authclient.CreateWebhookEndpointParams{URL: "ftp://example.com"})
+		require.ErrorIs(t, err, errorz.ErrInvalidWebhookURL)
+
+		_, err = client.CreateWebhookEndpoint(ctx, authclient.CreateWebhookEndpointParams{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{"user.deleted"},
+		})
+		require.ErrorIs(t, err, errorz.ErrUnknownWebhookEvent)
+	})
+
+	t.Run("webhooks require the outbox", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := authclient.New(authclient.Config{
+			JWTConfig: jwtgen.CreatorConfig{
+				SecretKey: []byte("secret_key"),
+			},
+		}, authclient.WithStore(storemocks.NewStore(t)), authclient.WithWebhooks(authclient.WebhookConfig{}))
+
+		require.ErrorIs(t, err, errorz.ErrOutboxDisabled)
+	})
+}
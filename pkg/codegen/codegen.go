@@ -5,16 +5,26 @@ import "time"
 const (
 	defaultConfirmationCodeTTL = 1 * time.Hour
 	defaultResetCodeTTL        = 1 * time.Hour
+	defaultLoginCodeTTL        = 15 * time.Minute
+	defaultMFACodeTTL          = 10 * time.Minute
+	defaultUnlockCodeTTL       = 1 * time.Hour
 )
 
 type Generator interface {
 	GenerateConfirmationCode() (Code, error)
 	GenerateResetCode() (Code, error)
+	GenerateLoginCode() (Code, error)
+	// GenerateMFACode returns a short numeric code meant to be typed in.
+	GenerateMFACode() (Code, error)
+	GenerateUnlockCode() (Code, error)
 }
 
 type generatorImpl struct {
 	confirmationCodeTTL time.Duration
 	resetCodeTTL        time.Duration
+	loginCodeTTL        time.Duration
+	mfaCodeTTL          time.Duration
+	unlockCodeTTL       time.Duration
 }
 
 type GeneratorOption func(*generatorImpl)
@@ -31,10 +41,31 @@ func WithResetCodeTTL(ttl time.Duration) GeneratorOption {
 	}
 }
 
+func WithLoginCodeTTL(ttl time.Duration) GeneratorOption {
+	return func(impl *generatorImpl) {
+		impl.loginCodeTTL = ttl
+	}
+}
+
+func WithMFACodeTTL(ttl time.Duration) GeneratorOption {
+	return func(impl *generatorImpl) {
+		impl.mfaCodeTTL = ttl
+	}
+}
+
+func WithUnlockCodeTTL(ttl time.Duration) GeneratorOption {
+	return func(impl *generatorImpl) {
+		impl.unlockCodeTTL = ttl
+	}
+}
+
 func NewGenerator(opts ...GeneratorOption) Generator {
 	impl := generatorImpl{
 		confirmationCodeTTL: defaultConfirmationCodeTTL,
 		resetCodeTTL:        defaultResetCodeTTL,
+		loginCodeTTL:        defaultLoginCodeTTL,
+		mfaCodeTTL:          defaultMFACodeTTL,
+		unlockCodeTTL:       defaultUnlockCodeTTL,
 	}
 
 	for _, opt := range opts {
@@ -1,6 +1,9 @@
 package codegen
 
 import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/matchsystems/werr"
@@ -31,3 +34,39 @@ func (g generatorImpl) GenerateResetCode() (Code, error) {
 		ExpiresAt: time.Now().Add(g.confirmationCodeTTL),
 	}, nil
 }
+
+func (g generatorImpl) GenerateLoginCode() (Code, error) {
+	code, err := uuid.NewRandom()
+	if err != nil {
+		return Code{}, werr.Wrap(err)
+	}
+
+	return Code{
+		Code:      code.String(),
+		ExpiresAt: time.Now().Add(g.loginCodeTTL),
+	}, nil
+}
+
+func (g generatorImpl) GenerateMFACode() (Code, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return Code{}, werr.Wrap(err)
+	}
+
+	return Code{
+		Code:      fmt.Sprintf("%06d", n.Int64()),
+		ExpiresAt: time.Now().Add(g.mfaCodeTTL),
+	}, nil
+}
+
+func (g generatorImpl) GenerateUnlockCode() (Code, error) {
+	code, err := uuid.NewRandom()
+	if err != nil {
+		return Code{}, werr.Wrap(err)
+	}
+
+	return Code{
+		Code:      code.String(),
+		ExpiresAt: time.Now().Add(g.unlockCodeTTL),
+	}, nil
+}
@@ -40,6 +40,62 @@ func (_m *Generator) GenerateConfirmationCode() (codegen.Code, error) {
 	return r0, r1
 }
 
+// GenerateLoginCode provides a mock function with given fields:
+func (_m *Generator) GenerateLoginCode() (codegen.Code, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateLoginCode")
+	}
+
+	var r0 codegen.Code
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (codegen.Code, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() codegen.Code); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(codegen.Code)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateMFACode provides a mock function with given fields:
+func (_m *Generator) GenerateMFACode() (codegen.Code, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateMFACode")
+	}
+
+	var r0 codegen.Code
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (codegen.Code, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() codegen.Code); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(codegen.Code)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GenerateResetCode provides a mock function with given fields:
 func (_m *Generator) GenerateResetCode() (codegen.Code, error) {
 	ret := _m.Called()
@@ -68,6 +124,34 @@ func (_m *Generator) GenerateResetCode() (codegen.Code, error) {
 	return r0, r1
 }
 
+// GenerateUnlockCode provides a mock function with given fields:
+func (_m *Generator) GenerateUnlockCode() (codegen.Code, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateUnlockCode")
+	}
+
+	var r0 codegen.Code
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (codegen.Code, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() codegen.Code); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(codegen.Code)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewGenerator creates a new instance of Generator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewGenerator(t interface {
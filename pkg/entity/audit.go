@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthEventType string
+
+const (
+	AuthEventLogin                AuthEventType = "login"
+	AuthEventMFAVerify            AuthEventType = "mfa_verify"
+	AuthEventRegister             AuthEventType = "register"
+	AuthEventEmailConfirm         AuthEventType = "email_confirm"
+	AuthEventPasswordResetRequest AuthEventType = "password_reset_request"
+	AuthEventPasswordReset        AuthEventType = "password_reset"
+	AuthEventTokenRefresh         AuthEventType = "token_refresh"
+	AuthEventLogout               AuthEventType = "logout"
+)
+
+type AuthEventOutcome string
+
+const (
+	AuthEventSuccess AuthEventOutcome = "success"
+	AuthEventFailure AuthEventOutcome = "failure"
+	// AuthEventChallenge is a correct password that still needs a second factor.
+	AuthEventChallenge AuthEventOutcome = "challenge"
+)
+
+type AuthEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Email     string
+	Type      AuthEventType
+	IP        string
+	UserAgent string
+	Outcome   AuthEventOutcome
+	ErrorCode string
+	CreatedAt time.Time
+}
@@ -0,0 +1,9 @@
+package entity
+
+type LockoutScope string
+
+const (
+	LockoutScopeUser  LockoutScope = "user"
+	LockoutScopeIP    LockoutScope = "ip"
+	LockoutScopeEmail LockoutScope = "email"
+)
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MFAFactorType string
+
+const (
+	MFAFactorTOTP     MFAFactorType = "totp"
+	MFAFactorEmail    MFAFactorType = "email"
+	MFAFactorWebAuthn MFAFactorType = "webauthn"
+)
+
+type MFAFactor struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Type      MFAFactorType
+	Confirmed bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
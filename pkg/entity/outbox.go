@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending    OutboxStatus = "pending"
+	OutboxStatusInProgress OutboxStatus = "in_progress"
+	OutboxStatusSent       OutboxStatus = "sent"
+	OutboxStatusDead       OutboxStatus = "dead"
+)
+
+type OutboxMessage struct {
+	ID            uuid.UUID
+	Kind          string
+	UserID        uuid.UUID
+	Payload       string
+	Status        OutboxStatus
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
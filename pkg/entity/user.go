@@ -13,4 +13,6 @@ type User struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	IsVerified   bool
+
+	PreferredMFAFactor MFAFactorType
 }
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookEndpoint struct {
+	ID     uuid.UUID
+	URL    string
+	Secret string
+	// EventTypes is empty when the endpoint receives every event.
+	EventTypes []string
+	Failures   int
+	Disabled   bool
+	DisabledAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,74 @@
+package errorz
+
+import "errors"
+
+// CodeInternal is the code of errors that are not part of the API, such as
+// database failures.
+const CodeInternal = "internal"
+
+var codes = []struct {
+	err  error
+	code string
+}{
+	{ErrPasswordLength, "password_length"},
+	{ErrLoginAlreadyExists, "login_already_exists"},
+	{ErrInvalidCredentials, "invalid_credentials"},
+	{ErrInvalidEmailFormat, "invalid_email_format"},
+	{ErrEmailNotConfirmed, "email_not_confirmed"},
+	{ErrEmailAlreadyVerified, "email_already_verified"},
+	{ErrMFANotConfigured, "mfa_not_configured"},
+	{ErrMFARequired, "mfa_required"},
+	{ErrMFAAlreadyEnabled, "mfa_already_enabled"},
+	{ErrMFAFactorNotFound, "mfa_factor_not_found"},
+	{ErrInvalidMFACode, "invalid_mfa_code"},
+	{ErrWebAuthnNotConfigured, "webauthn_not_configured"},
+	{ErrWebAuthnMalformed, "webauthn_malformed"},
+	{ErrWebAuthnVerification, "webauthn_verification"},
+	{ErrWebAuthnUnsupported, "webauthn_unsupported"},
+	{ErrWebAuthnSignCount, "webauthn_sign_count"},
+	{ErrWebAuthnCredentialTaken, "webauthn_credential_taken"},
+	{ErrAccountLocked, "account_locked"},
+	{ErrLockoutDisabled, "lockout_disabled"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrOutboxDisabled, "outbox_disabled"},
+	{ErrOutboxMessageNotFound, "outbox_message_not_found"},
+	{ErrAuditLogDisabled, "audit_log_disabled"},
+	{ErrOperationVetoed, "operation_vetoed"},
+	{ErrWebhooksDisabled, "webhooks_disabled"},
+	{ErrWebhookEndpointNotFound, "webhook_endpoint_not_found"},
+	{ErrUserNotFound, "user_not_found"},
+	{ErrInvalidWebhookURL, "invalid_webhook_url"},
+	{ErrUnknownWebhookEvent, "unknown_webhook_event"},
+	{ErrInvalidToken, "invalid_token"},
+	{ErrTokenExpired, "token_expired"},
+	{ErrTokenRevoked, "token_revoked"},
+	{ErrCSRFTokenInvalid, "csrf_token_invalid"},
+	{ErrCSRFOriginMismatch, "csrf_origin_mismatch"},
+}
+
+// Code returns a stable snake_case identifier for err, suitable for logs and
+// API responses. It is empty for nil and CodeInternal for unknown errors.
+func Code(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	return CodeInternal
+}
+
+// FromCode returns the error whose Code is code, so that clients of a remote
+// API can match it with errors.Is. It is nil for unknown codes.
+func FromCode(code string) error {
+	for _, c := range codes {
+		if c.code == code {
+			return c.err
+		}
+	}
+
+	return nil
+}
@@ -1,15 +1,111 @@
 package errorz
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrPasswordLength          = errors.New("password length must be longer 6 and shorter than 256 characters")
 	ErrLoginAlreadyExists      = errors.New("login already exists")
 	ErrJWTSecretKeyRequired    = errors.New("JWT SecretKey is required")
+	ErrUnsupportedSigningKey   = errors.New("unsupported JWT signing key")
+	ErrNoPublicKey             = errors.New("JWT secret keys have no public key")
 	ErrInvalidCredentials      = errors.New("invalid credentials")
 	ErrPostgresClientMissed    = errors.New("pgClient cannot be nil when store is not provided")
 	ErrInvalidEmailFormat      = errors.New("invalid email format")
 	ErrEmailNotConfirmed       = errors.New("email not confirmed")
 	ErrEmailAlreadyVerified    = errors.New("email already verified")
 	ErrEmailSendFunctionMissed = errors.New("email send function missed")
+	ErrMailTemplateNotFound    = errors.New("mail template not found")
+	ErrSMTPHostRequired        = errors.New("SMTP host is required")
+	ErrSMTPSenderRequired      = errors.New("SMTP sender address is required")
+	ErrSMTPAuthUnsupported     = errors.New("SMTP server does not support the configured auth mechanism")
+	ErrSMTPInsecureAuth        = errors.New("SMTP auth requires an encrypted connection")
+	ErrSMTPStartTLSUnsupported = errors.New("SMTP server does not support STARTTLS")
+	ErrSMTPSecurityUnknown     = errors.New("unknown SMTP security mode")
+	ErrOutboxDisabled          = errors.New("outbox is not enabled")
+	ErrUnknownOutboxKind       = errors.New("unknown outbox message kind")
+	ErrOutboxMessageNotFound   = errors.New("outbox message not found")
+	ErrEncryptionKeySize       = errors.New("encryption key must be 32 bytes")
+	ErrSealedValueCorrupted    = errors.New("sealed value is corrupted or was encrypted with another key")
+	ErrMFANotConfigured        = errors.New("MFA encryption key is not configured")
+	ErrMFARequired             = errors.New("MFA required")
+	ErrMFAAlreadyEnabled       = errors.New("MFA factor already enabled")
+	ErrMFAFactorNotFound       = errors.New("MFA factor not found")
+	ErrInvalidMFACode          = errors.New("invalid MFA code")
+	ErrWebAuthnNotConfigured   = errors.New("WebAuthn relying party is not configured")
+	ErrWebAuthnMalformed       = errors.New("malformed WebAuthn data")
+	ErrWebAuthnVerification    = errors.New("WebAuthn verification failed")
+	ErrWebAuthnUnsupported     = errors.New("unsupported WebAuthn algorithm or attestation format")
+	ErrWebAuthnSignCount       = errors.New("WebAuthn signature counter did not increase, authenticator may be cloned")
+	ErrWebAuthnCredentialTaken = errors.New("WebAuthn credential already registered")
+	ErrAccountLocked           = errors.New("account locked")
+	ErrLockoutDisabled         = errors.New("account lockout is not enabled")
+	ErrRateLimited             = errors.New("rate limited")
+	ErrAuditLogDisabled        = errors.New("audit log is not enabled")
+	ErrOperationVetoed         = errors.New("operation vetoed by event listener")
+	ErrWebhooksDisabled        = errors.New("webhooks are not enabled")
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookEndpointDisabled = errors.New("webhook endpoint is disabled")
+	ErrInvalidWebhookURL       = errors.New("invalid webhook URL")
+	ErrUnknownWebhookEvent     = errors.New("unknown webhook event type")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrTokenExpired            = errors.New("token expired")
+	ErrTokenRevoked            = errors.New("token revoked")
+	ErrWebhookDeliveryFailed   = errors.New("webhook delivery failed")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookTimestampExpired = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidBaseURL          = errors.New("invalid base URL")
+	ErrCSRFKeySize             = errors.New("CSRF key must be at least 32 bytes")
+	ErrCSRFTokenInvalid        = errors.New("missing or invalid CSRF token")
+	ErrCSRFOriginMismatch      = errors.New("cross-origin request")
+	ErrUnexpectedResponse      = errors.New("unexpected response from auth server")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrInvalidMigration        = errors.New("invalid migration")
+	ErrUnknownMigration        = errors.New("unknown migration version")
 )
+
+// MFARequiredError is returned by Login when the password was correct but a
+// second factor is needed. Challenge is passed to VerifyMFA. Factor is the one
+// to prompt for, out of Factors; for "email" the code has already been sent.
+type MFARequiredError struct {
+	Challenge string
+	Factor    string
+	Factors   []string
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// AccountLockedError is returned by Login while too many recent failures lock
+// the account or the client IP. RetryAfter is how long the lock still holds.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// RateLimitedError is returned by flows throttled by the client's RateLimiter.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return ErrRateLimited.Error()
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
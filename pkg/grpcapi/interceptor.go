@@ -0,0 +1,146 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	vauthv1 "github.com/github.com/VadimOcLock/vauth/pkg/grpcapi/gen/vauth/v1"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authServiceMethods authenticate with their request fields rather than
+// metadata, so the interceptors never require a token for them.
+var authServiceMethods = []string{
+	vauthv1.AuthService_Register_FullMethodName,
+	vauthv1.AuthService_Login_FullMethodName,
+	vauthv1.AuthService_VerifyMFA_FullMethodName,
+	vauthv1.AuthService_Refresh_FullMethodName,
+	vauthv1.AuthService_Logout_FullMethodName,
+	vauthv1.AuthService_ConfirmEmail_FullMethodName,
+	vauthv1.AuthService_ForgotPassword_FullMethodName,
+	vauthv1.AuthService_ResetPassword_FullMethodName,
+	vauthv1.AuthService_ValidateToken_FullMethodName,
+}
+
+type authConfig struct {
+	public   map[string]bool
+	optional bool
+}
+
+type AuthOption func(*authConfig)
+
+// WithPublicMethods lets calls of the given full method names, such as
+// "/pkg.Service/Method", through without a token.
+func WithPublicMethods(methods ...string) AuthOption {
+	return func(c *authConfig) {
+		for _, method := range methods {
+			c.public[method] = true
+		}
+	}
+}
+
+// Optional lets calls without a token through with no claims in their
+// context. Calls with an invalid token are still rejected.
+func Optional() AuthOption {
+	return func(c *authConfig) {
+		c.optional = true
+	}
+}
+
+// UnaryServerInterceptor authenticates calls with the bearer token of their
+// authorization metadata and stores its claims in the context, where
+// httpauth.ClaimsFromContext reads them.
+func UnaryServerInterceptor(validator httpauth.Validator, opts ...AuthOption) grpc.UnaryServerInterceptor {
+	cfg := newAuthConfig(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := cfg.authenticate(ctx, validator, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(validator httpauth.Validator, opts ...AuthOption) grpc.StreamServerInterceptor {
+	cfg := newAuthConfig(opts)
+
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := cfg.authenticate(stream.Context(), validator, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func newAuthConfig(opts []AuthOption) authConfig {
+	cfg := authConfig{public: make(map[string]bool), optional: false}
+	WithPublicMethods(authServiceMethods...)(&cfg)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+func (c authConfig) authenticate(
+	ctx context.Context,
+	validator httpauth.Validator,
+	method string,
+) (context.Context, error) {
+	if c.public[method] {
+		return ctx, nil
+	}
+
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		if c.optional {
+			return ctx, nil
+		}
+
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	claims, err := validator.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return httpauth.ContextWithClaims(ctx, claims), nil
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := first(md, "authorization")
+	if header == "" {
+		return "", nil
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", status.Error(codes.Unauthenticated, "malformed authorization metadata")
+	}
+
+	return token, nil
+}
+
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
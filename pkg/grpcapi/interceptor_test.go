@@ -0,0 +1,119 @@
+package grpcapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/grpcapi"
+	vauthv1 "github.com/github.com/VadimOcLock/vauth/pkg/grpcapi/gen/vauth/v1"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type stream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	validator := httpauth.ValidatorFunc(func(_ context.Context, token string) (authclient.Claims, error) {
+		switch token {
+		case "valid":
+			return authclient.Claims{UserID: userID}, nil
+		case "revoked":
+			return authclient.Claims{}, errorz.ErrTokenRevoked
+		default:
+			return authclient.Claims{}, errorz.ErrInvalidToken
+		}
+	})
+	withAuthorization := func(value string) context.Context {
+		if value == "" {
+			return context.Background()
+		}
+
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+	}
+	unary := func(ctx context.Context, method string, opts ...grpcapi.AuthOption) (uuid.UUID, error) {
+		var got uuid.UUID
+		_, err := grpcapi.UnaryServerInterceptor(validator, opts...)(ctx, nil,
+			&grpc.UnaryServerInfo{FullMethod: method},
+			func(ctx context.Context, _ any) (any, error) {
+				got, _ = httpauth.UserIDFromContext(ctx)
+
+				return nil, nil
+			})
+
+		return got, err
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		method        string
+		opts          []grpcapi.AuthOption
+		wantCode      codes.Code
+		wantUserID    uuid.UUID
+	}{
+		{"valid token", "Bearer valid", "/svc.Service/Call", nil, codes.OK, userID},
+		{"missing token", "", "/svc.Service/Call", nil, codes.Unauthenticated, uuid.Nil},
+		{"missing token optional", "", "/svc.Service/Call", []grpcapi.AuthOption{grpcapi.Optional()}, codes.OK, uuid.Nil},
+		{"revoked token", "Bearer revoked", "/svc.Service/Call", nil, codes.Unauthenticated, uuid.Nil},
+		{"malformed header", "Basic dXNlcg==", "/svc.Service/Call", nil, codes.Unauthenticated, uuid.Nil},
+		{"auth service is public", "", vauthv1.AuthService_Login_FullMethodName, nil, codes.OK, uuid.Nil},
+		{
+			"public method", "", "/svc.Service/Health",
+			[]grpcapi.AuthOption{grpcapi.WithPublicMethods("/svc.Service/Health")}, codes.OK, uuid.Nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := unary(withAuthorization(tt.authorization), tt.method, tt.opts...)
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantUserID, got)
+		})
+	}
+
+	t.Run("stream gets claims in its context", func(t *testing.T) {
+		t.Parallel()
+
+		var got uuid.UUID
+		interceptor := grpcapi.StreamServerInterceptor(validator)
+		err := interceptor(nil, stream{ctx: withAuthorization("Bearer valid")},
+			&grpc.StreamServerInfo{FullMethod: "/svc.Service/Watch"},
+			func(_ any, ss grpc.ServerStream) error {
+				got, _ = httpauth.UserIDFromContext(ss.Context())
+
+				return nil
+			})
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, got)
+
+		err = interceptor(nil, stream{ctx: withAuthorization("Bearer invalid")},
+			&grpc.StreamServerInfo{FullMethod: "/svc.Service/Watch"},
+			func(_ any, _ grpc.ServerStream) error {
+				return nil
+			})
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
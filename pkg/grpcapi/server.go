@@ -0,0 +1,224 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	vauthv1 "github.com/github.com/VadimOcLock/vauth/pkg/grpcapi/gen/vauth/v1"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Client is the part of *authclient.Client served by Server.
+type Client interface {
+	Register(ctx context.Context, dto authclient.RegisterParams) error
+	LoginTokens(ctx context.Context, dto authclient.LoginParams) (authclient.Tokens, error)
+	VerifyMFATokens(ctx context.Context, challenge string, code string) (authclient.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error)
+	Logout(ctx context.Context, dto authclient.LogoutParams) error
+	ConfirmEmail(ctx context.Context, dto authclient.ConfirmEmailParams) error
+	ForgotPassword(ctx context.Context, dto authclient.ForgotPasswordParams) error
+	ResetPassword(ctx context.Context, dto authclient.ResetPasswordParams) error
+	ValidateToken(ctx context.Context, token string) (authclient.Claims, error)
+}
+
+var _ Client = (*authclient.Client)(nil)
+
+// Server implements vauthv1.AuthServiceServer on top of a Client. Register it
+// with vauthv1.RegisterAuthServiceServer.
+type Server struct {
+	vauthv1.UnimplementedAuthServiceServer
+
+	client   Client
+	clientIP func(ctx context.Context) string
+}
+
+var _ vauthv1.AuthServiceServer = (*Server)(nil)
+
+type Option func(*Server)
+
+// WithClientIP replaces the peer address as the client IP passed to the
+// client in RequestInfo, e.g. to read x-forwarded-for metadata behind a
+// trusted proxy.
+func WithClientIP(clientIP func(ctx context.Context) string) Option {
+	return func(s *Server) {
+		s.clientIP = clientIP
+	}
+}
+
+func New(client Client, opts ...Option) *Server {
+	s := &Server{
+		UnimplementedAuthServiceServer: vauthv1.UnimplementedAuthServiceServer{},
+		client:                         client,
+		clientIP:                       peerIP,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Server) Register(ctx context.Context, req *vauthv1.RegisterRequest) (*vauthv1.RegisterResponse, error) {
+	if err := s.client.Register(s.requestContext(ctx), authclient.RegisterParams{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}); err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.RegisterResponse{}, nil
+}
+
+func (s *Server) Login(ctx context.Context, req *vauthv1.LoginRequest) (*vauthv1.LoginResponse, error) {
+	tokens, err := s.client.LoginTokens(s.requestContext(ctx), authclient.LoginParams{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.LoginResponse{
+		AccessToken:  token(tokens.AccessToken),
+		RefreshToken: token(tokens.RefreshToken),
+	}, nil
+}
+
+func (s *Server) VerifyMFA(ctx context.Context, req *vauthv1.VerifyMFARequest) (*vauthv1.VerifyMFAResponse, error) {
+	tokens, err := s.client.VerifyMFATokens(s.requestContext(ctx), req.GetChallenge(), req.GetCode())
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.VerifyMFAResponse{
+		AccessToken:  token(tokens.AccessToken),
+		RefreshToken: token(tokens.RefreshToken),
+	}, nil
+}
+
+func (s *Server) Refresh(ctx context.Context, req *vauthv1.RefreshRequest) (*vauthv1.RefreshResponse, error) {
+	tokens, err := s.client.Refresh(s.requestContext(ctx), req.GetRefreshToken())
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.RefreshResponse{
+		AccessToken:  token(tokens.AccessToken),
+		RefreshToken: token(tokens.RefreshToken),
+	}, nil
+}
+
+func (s *Server) Logout(ctx context.Context, req *vauthv1.LogoutRequest) (*vauthv1.LogoutResponse, error) {
+	if err := s.client.Logout(s.requestContext(ctx), authclient.LogoutParams{
+		AccessToken:  req.GetAccessToken(),
+		RefreshToken: req.GetRefreshToken(),
+	}); err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.LogoutResponse{}, nil
+}
+
+func (s *Server) ConfirmEmail(
+	ctx context.Context,
+	req *vauthv1.ConfirmEmailRequest,
+) (*vauthv1.ConfirmEmailResponse, error) {
+	if err := s.client.ConfirmEmail(s.requestContext(ctx), authclient.ConfirmEmailParams{
+		Code: req.GetCode(),
+	}); err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.ConfirmEmailResponse{}, nil
+}
+
+func (s *Server) ForgotPassword(
+	ctx context.Context,
+	req *vauthv1.ForgotPasswordRequest,
+) (*vauthv1.ForgotPasswordResponse, error) {
+	if err := s.client.ForgotPassword(s.requestContext(ctx), authclient.ForgotPasswordParams{
+		Email: req.GetEmail(),
+	}); err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.ForgotPasswordResponse{}, nil
+}
+
+func (s *Server) ResetPassword(
+	ctx context.Context,
+	req *vauthv1.ResetPasswordRequest,
+) (*vauthv1.ResetPasswordResponse, error) {
+	if err := s.client.ResetPassword(s.requestContext(ctx), authclient.ResetPasswordParams{
+		Code:     req.GetCode(),
+		Password: req.GetPassword(),
+	}); err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.ResetPasswordResponse{}, nil
+}
+
+func (s *Server) ValidateToken(
+	ctx context.Context,
+	req *vauthv1.ValidateTokenRequest,
+) (*vauthv1.ValidateTokenResponse, error) {
+	claims, err := s.client.ValidateToken(s.requestContext(ctx), req.GetAccessToken())
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return &vauthv1.ValidateTokenResponse{
+		UserId:    claims.UserID.String(),
+		TokenId:   claims.TokenID.String(),
+		IssuedAt:  timestamppb.New(claims.IssuedAt),
+		ExpiresAt: timestamppb.New(claims.ExpiresAt),
+	}, nil
+}
+
+// requestContext adds the RequestInfo of the call, read from its peer and
+// its user-agent and accept-language metadata.
+func (s *Server) requestContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tag, _, _ := strings.Cut(first(md, "accept-language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+
+	return authclient.ContextWithRequestInfo(ctx, authclient.RequestInfo{
+		IP:        s.clientIP(ctx),
+		UserAgent: first(md, "user-agent"),
+		Locale:    strings.TrimSpace(tag),
+	})
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func token(t jwtgen.Token) *vauthv1.Token {
+	return &vauthv1.Token{
+		Token:     t.Token,
+		ExpiresAt: timestamppb.New(t.ExpiresAt),
+	}
+}
@@ -0,0 +1,221 @@
+package grpcapi_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/grpcapi"
+	vauthv1 "github.com/github.com/VadimOcLock/vauth/pkg/grpcapi/gen/vauth/v1"
+	grpcapimocks "github.com/github.com/VadimOcLock/vauth/pkg/grpcapi/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newAuthServiceClient(t *testing.T, client grpcapi.Client, opts ...grpcapi.Option) vauthv1.AuthServiceClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	vauthv1.RegisterAuthServiceServer(srv, grpcapi.New(client, opts...))
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return vauthv1.NewAuthServiceClient(conn)
+}
+
+func errorInfo(t *testing.T, err error) (*status.Status, *errdetails.ErrorInfo) {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st, info
+		}
+	}
+	require.Fail(t, "status has no ErrorInfo")
+
+	return st, nil
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("login returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		expiresAt := time.Now().Add(15 * time.Minute).UTC().Truncate(time.Second)
+		client := grpcapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, authclient.LoginParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		}).Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: expiresAt},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: expiresAt.Add(time.Hour)},
+		}, nil)
+
+		resp, err := newAuthServiceClient(t, client).Login(context.Background(), &vauthv1.LoginRequest{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", resp.GetAccessToken().GetToken())
+		assert.Equal(t, expiresAt, resp.GetAccessToken().GetExpiresAt().AsTime())
+		assert.Equal(t, "refresh", resp.GetRefreshToken().GetToken())
+	})
+
+	t.Run("mfa verify returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		client := grpcapimocks.NewClient(t)
+		client.On("VerifyMFATokens", mock.Anything, "challenge", "123456").Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		resp, err := newAuthServiceClient(t, client).VerifyMFA(context.Background(), &vauthv1.VerifyMFARequest{
+			Challenge: "challenge",
+			Code:      "123456",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", resp.GetAccessToken().GetToken())
+		assert.Equal(t, "refresh", resp.GetRefreshToken().GetToken())
+	})
+
+	t.Run("request info is passed to the client", func(t *testing.T) {
+		t.Parallel()
+
+		client := grpcapimocks.NewClient(t)
+		client.On("ForgotPassword", mock.MatchedBy(func(ctx context.Context) bool {
+			info := authclient.RequestInfoFromContext(ctx)
+
+			return info.IP == "192.0.2.1" && info.Locale == "de-DE"
+		}), authclient.ForgotPasswordParams{Email: "test@example.com"}).Return(nil)
+		service := newAuthServiceClient(t, client, grpcapi.WithClientIP(func(ctx context.Context) string {
+			md, _ := metadata.FromIncomingContext(ctx)
+
+			return md.Get("x-forwarded-for")[0]
+		}))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"x-forwarded-for", "192.0.2.1", "accept-language", "de-DE,de;q=0.9")
+		_, err := service.ForgotPassword(ctx, &vauthv1.ForgotPasswordRequest{Email: "test@example.com"})
+
+		require.NoError(t, err)
+	})
+
+	t.Run("validate token returns claims", func(t *testing.T) {
+		t.Parallel()
+
+		claims := authclient.Claims{
+			UserID:    uuid.New(),
+			TokenID:   uuid.New(),
+			IssuedAt:  time.Now().UTC().Truncate(time.Second),
+			ExpiresAt: time.Now().Add(time.Minute).UTC().Truncate(time.Second),
+		}
+		client := grpcapimocks.NewClient(t)
+		client.On("ValidateToken", mock.Anything, "access").Return(claims, nil)
+
+		resp, err := newAuthServiceClient(t, client).ValidateToken(context.Background(),
+			&vauthv1.ValidateTokenRequest{AccessToken: "access"})
+
+		require.NoError(t, err)
+		assert.Equal(t, claims.UserID.String(), resp.GetUserId())
+		assert.Equal(t, claims.TokenID.String(), resp.GetTokenId())
+		assert.Equal(t, claims.IssuedAt, resp.GetIssuedAt().AsTime())
+		assert.Equal(t, claims.ExpiresAt, resp.GetExpiresAt().AsTime())
+	})
+
+	t.Run("errors map to status codes with details", func(t *testing.T) {
+		t.Parallel()
+
+		client := grpcapimocks.NewClient(t)
+		client.On("Register", mock.Anything, mock.Anything).Return(werr.Wrap(errorz.ErrPasswordLength))
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(authclient.Tokens{}, &errorz.MFARequiredError{
+			Challenge: "challenge",
+			Factor:    "totp",
+			Factors:   []string{"totp", "email"},
+		})
+		client.On("ConfirmEmail", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+		service := newAuthServiceClient(t, client)
+
+		_, err := service.Register(context.Background(), &vauthv1.RegisterRequest{Email: "test@example.com"})
+		st, info := errorInfo(t, err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Equal(t, "password_length", info.GetReason())
+		assert.Equal(t, grpcapi.ErrorDomain, info.GetDomain())
+		var badRequest *errdetails.BadRequest
+		for _, detail := range st.Details() {
+			if br, ok := detail.(*errdetails.BadRequest); ok {
+				badRequest = br
+			}
+		}
+		require.NotNil(t, badRequest)
+		assert.Equal(t, "password", badRequest.GetFieldViolations()[0].GetField())
+
+		_, err = service.Login(context.Background(), &vauthv1.LoginRequest{Email: "test@example.com"})
+		st, info = errorInfo(t, err)
+		assert.Equal(t, codes.Unauthenticated, st.Code())
+		assert.Equal(t, "mfa_required", info.GetReason())
+		assert.Equal(t, map[string]string{
+			"challenge": "challenge",
+			"factor":    "totp",
+			"factors":   "totp,email",
+		}, info.GetMetadata())
+
+		_, err = service.ConfirmEmail(context.Background(), &vauthv1.ConfirmEmailRequest{Code: "123456"})
+		st, info = errorInfo(t, err)
+		assert.Equal(t, codes.Internal, st.Code())
+		assert.Equal(t, errorz.CodeInternal, info.GetReason())
+		assert.NotContains(t, st.Message(), "connection refused")
+	})
+}
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	err := grpcapi.Error(werr.Wrap(&errorz.RateLimitedError{RetryAfter: 30 * time.Second}))
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if ri, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = ri
+		}
+	}
+	require.NotNil(t, retryInfo)
+	assert.Equal(t, 30*time.Second, retryInfo.GetRetryDelay().AsDuration())
+
+	assert.NoError(t, grpcapi.Error(nil))
+	notFound := status.Error(codes.NotFound, "not found")
+	assert.Equal(t, notFound, grpcapi.Error(notFound))
+}
@@ -0,0 +1,99 @@
+package grpcapi
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo attached to errors.
+const ErrorDomain = "vauth"
+
+var statuses = []struct {
+	err   error
+	code  codes.Code
+	field string
+}{
+	{errorz.ErrPasswordLength, codes.InvalidArgument, "password"},
+	{errorz.ErrInvalidEmailFormat, codes.InvalidArgument, "email"},
+	{errorz.ErrLoginAlreadyExists, codes.AlreadyExists, ""},
+	{errorz.ErrInvalidCredentials, codes.Unauthenticated, ""},
+	{errorz.ErrEmailNotConfirmed, codes.FailedPrecondition, ""},
+	{errorz.ErrEmailAlreadyVerified, codes.AlreadyExists, ""},
+	{errorz.ErrMFARequired, codes.Unauthenticated, ""},
+	{errorz.ErrMFAFactorNotFound, codes.Unauthenticated, ""},
+	{errorz.ErrInvalidMFACode, codes.Unauthenticated, ""},
+	{errorz.ErrAccountLocked, codes.FailedPrecondition, ""},
+	{errorz.ErrRateLimited, codes.ResourceExhausted, ""},
+	{errorz.ErrOperationVetoed, codes.PermissionDenied, ""},
+	{errorz.ErrInvalidToken, codes.Unauthenticated, ""},
+	{errorz.ErrTokenExpired, codes.Unauthenticated, ""},
+	{errorz.ErrTokenRevoked, codes.Unauthenticated, ""},
+}
+
+// Error maps an authclient error to a gRPC status error. It carries a
+// google.rpc.ErrorInfo whose reason is the errorz.Code of err, a
+// google.rpc.RetryInfo for locked or rate-limited calls and a
+// google.rpc.BadRequest for invalid arguments. Errors without an errorz code
+// become INTERNAL without revealing their message.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	st := status.New(codes.Internal, "internal error")
+	details := []protoadapt.MessageV1{}
+	for _, s := range statuses {
+		if !errors.Is(err, s.err) {
+			continue
+		}
+		st = status.New(s.code, s.err.Error())
+		if s.field != "" {
+			details = append(details, &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+					Field:       s.field,
+					Description: s.err.Error(),
+				}},
+			})
+		}
+
+		break
+	}
+
+	info := &errdetails.ErrorInfo{
+		Reason:   errorz.Code(err),
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{},
+	}
+	var (
+		mfaRequired *errorz.MFARequiredError
+		locked      *errorz.AccountLockedError
+		rateLimited *errorz.RateLimitedError
+	)
+	switch {
+	case errors.As(err, &mfaRequired):
+		info.Metadata["challenge"] = mfaRequired.Challenge
+		info.Metadata["factor"] = mfaRequired.Factor
+		info.Metadata["factors"] = strings.Join(mfaRequired.Factors, ",")
+	case errors.As(err, &locked):
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(locked.RetryAfter)})
+	case errors.As(err, &rateLimited):
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(rateLimited.RetryAfter)})
+	}
+
+	withDetails, detailsErr := st.WithDetails(append([]protoadapt.MessageV1{info}, details...)...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
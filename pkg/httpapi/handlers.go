@@ -0,0 +1,264 @@
+package httpapi
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+)
+
+type credentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type emailRequest struct {
+	Email string `json:"email"`
+}
+
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+type verifyMFARequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+type resetPasswordRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse follows the OAuth 2.0 token response of RFC 6749.
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
+
+func (h *handler) register(w http.ResponseWriter, r *http.Request) {
+	var req credentialsRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.client.Register(r.Context(), authclient.RegisterParams{
+		Email:    req.Email,
+		Password: req.Password,
+	}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *handler) login(w http.ResponseWriter, r *http.Request) {
+	var req credentialsRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	tokens, err := h.client.LoginTokens(r.Context(), authclient.LoginParams{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+	h.rotateCSRF(w, tokens)
+	h.writeTokens(w, r, tokens)
+}
+
+// verifyMFA finishes a login answered with an mfa_required problem.
+func (h *handler) verifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req verifyMFARequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	tokens, err := h.client.VerifyMFATokens(r.Context(), req.Challenge, req.Code)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+	h.rotateCSRF(w, tokens)
+	h.writeTokens(w, r, tokens)
+}
+
+// refresh takes the refresh token from the body or, without one, from the
+// session cookie.
+func (h *handler) refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if (h.cookies == nil || r.ContentLength != 0) && !h.decode(w, r, &req) {
+		return
+	}
+	if req.RefreshToken == "" && h.cookies != nil {
+		req.RefreshToken = h.cookies.RefreshToken(r)
+	}
+	tokens, err := h.client.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		problem := ProblemFromError(err)
+		if h.cookies != nil && problem.Status == http.StatusUnauthorized {
+			h.cookies.Clear(w, r)
+			h.rotateCSRF(w, authclient.Tokens{})
+		}
+		writeProblem(w, problem)
+
+		return
+	}
+	h.rotateCSRF(w, tokens)
+	h.writeTokens(w, r, tokens)
+}
+
+// logout revokes the bearer token of the request and the refresh token in
+// the body, which may be empty. With session cookies, tokens missing from the
+// request are taken from the cookies, which are cleared.
+func (h *handler) logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if r.ContentLength != 0 && !h.decode(w, r, &req) {
+		return
+	}
+	dto := authclient.LogoutParams{AccessToken: "", RefreshToken: req.RefreshToken}
+	if h.cookies != nil {
+		dto.AccessToken = h.cookies.AccessToken(r)
+		if dto.RefreshToken == "" {
+			dto.RefreshToken = h.cookies.RefreshToken(r)
+		}
+		h.cookies.Clear(w, r)
+	}
+	h.rotateCSRF(w, authclient.Tokens{})
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, "malformed Authorization header"))
+
+			return
+		}
+		dto.AccessToken = strings.TrimSpace(token)
+	}
+	if err := h.client.Logout(r.Context(), dto); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) confirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req codeRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.client.ConfirmEmail(r.Context(), authclient.ConfirmEmailParams{Code: req.Code}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) resendEmail(w http.ResponseWriter, r *http.Request) {
+	var req emailRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.client.SendConfirmationEmail(r.Context(), authclient.SendConfirmationEmailParams{
+		Email: req.Email,
+	}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req emailRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.client.ForgotPassword(r.Context(), authclient.ForgotPasswordParams{Email: req.Email}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.client.ResetPassword(r.Context(), authclient.ResetPasswordParams{
+		Code:     req.Code,
+		Password: req.Password,
+	}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+	if h.csrf != nil {
+		h.csrf.Rotate(w, r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type csrfResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+func (h *handler) csrfToken(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, csrfResponse{CSRFToken: h.csrf.Token(w, r)})
+}
+
+// rotateCSRF binds a new CSRF token to the session of tokens when they are
+// set as cookies; otherwise the request has no session to bind to.
+func (h *handler) rotateCSRF(w http.ResponseWriter, tokens authclient.Tokens) {
+	if h.csrf == nil {
+		return
+	}
+	if h.cookies == nil {
+		tokens = authclient.Tokens{}
+	}
+	h.csrf.RotateSession(w, tokens)
+}
+
+func (h *handler) writeTokens(w http.ResponseWriter, r *http.Request, tokens authclient.Tokens) {
+	if h.cookies != nil {
+		h.cookies.SetTokens(w, r, tokens)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+	writeJSON(w, http.StatusOK, tokenResponse(tokens))
+}
+
+func tokenResponse(tokens authclient.Tokens) TokenResponse {
+	return TokenResponse{
+		AccessToken:      tokens.AccessToken.Token,
+		TokenType:        "Bearer",
+		ExpiresIn:        expiresIn(tokens.AccessToken.ExpiresAt),
+		RefreshToken:     tokens.RefreshToken.Token,
+		RefreshExpiresIn: expiresIn(tokens.RefreshToken.ExpiresAt),
+	}
+}
+
+func expiresIn(expiresAt time.Time) int64 {
+	if expiresAt.IsZero() {
+		return 0
+	}
+
+	return int64(math.Ceil(time.Until(expiresAt).Seconds()))
+}
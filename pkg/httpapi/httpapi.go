@@ -0,0 +1,172 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+)
+
+const defaultMaxBodySize = 64 << 10
+
+// Client is the part of *authclient.Client served by the handler.
+type Client interface {
+	Register(ctx context.Context, dto authclient.RegisterParams) error
+	LoginTokens(ctx context.Context, dto authclient.LoginParams) (authclient.Tokens, error)
+	VerifyMFATokens(ctx context.Context, challenge string, code string) (authclient.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error)
+	Logout(ctx context.Context, dto authclient.LogoutParams) error
+	ConfirmEmail(ctx context.Context, dto authclient.ConfirmEmailParams) error
+	SendConfirmationEmail(ctx context.Context, dto authclient.SendConfirmationEmailParams) error
+	ForgotPassword(ctx context.Context, dto authclient.ForgotPasswordParams) error
+	ResetPassword(ctx context.Context, dto authclient.ResetPasswordParams) error
+}
+
+var _ Client = (*authclient.Client)(nil)
+
+type handler struct {
+	client      Client
+	maxBodySize int64
+	clientIP    func(r *http.Request) string
+	cookies     *httpauth.SessionCookies
+	csrf        *httpauth.CSRF
+	mux         *http.ServeMux
+}
+
+type Option func(*handler)
+
+// WithMaxBodySize limits request bodies; larger ones get 413. The default is 64 KiB.
+func WithMaxBodySize(size int64) Option {
+	return func(h *handler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithClientIP replaces the remote address as the client IP passed to the
+// client in RequestInfo, e.g. to read X-Forwarded-For behind a trusted proxy.
+func WithClientIP(clientIP func(r *http.Request) string) Option {
+	return func(h *handler) {
+		h.clientIP = clientIP
+	}
+}
+
+// WithSessionCookies answers /login, /mfa/verify and /refresh with 204 and the tokens in
+// cookies instead of the JSON body. /refresh and /logout read the tokens from
+// the cookies when the request does not carry them, and /logout clears them.
+// Protect the rest of the app with httpauth.WithSessionCookies using the same
+// cookies.
+func WithSessionCookies(cookies httpauth.SessionCookies) Option {
+	return func(h *handler) {
+		h.cookies = &cookies
+	}
+}
+
+// WithCSRF checks the POST requests against csrf, answering failures with a
+// 403 problem, and serves GET /csrf for clients to obtain their token. Create
+// csrf with httpauth.WithCSRFSessionCookies of the same cookies: the token is
+// bound to the session and rotated whenever its cookies change, as well as on
+// password reset. Clients answered with csrf_token_invalid fetch a new one.
+func WithCSRF(csrf *httpauth.CSRF) Option {
+	return func(h *handler) {
+		h.csrf = csrf
+	}
+}
+
+// New returns a handler serving the authclient flows as JSON over POST:
+//
+//	/register, /login, /mfa/verify, /refresh, /logout, /email/confirm,
+//	/email/resend, /password/forgot, /password/reset
+//
+// Errors are answered with RFC 7807 problem details. Mount it with
+// http.StripPrefix to serve it under a path prefix.
+func New(client Client, opts ...Option) http.Handler {
+	h := &handler{
+		client:      client,
+		maxBodySize: defaultMaxBodySize,
+		clientIP:    remoteIP,
+		cookies:     nil,
+		csrf:        nil,
+		mux:         http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux.HandleFunc("POST /register", h.register)
+	h.mux.HandleFunc("POST /login", h.login)
+	h.mux.HandleFunc("POST /mfa/verify", h.verifyMFA)
+	h.mux.HandleFunc("POST /refresh", h.refresh)
+	h.mux.HandleFunc("POST /logout", h.logout)
+	h.mux.HandleFunc("POST /email/confirm", h.confirmEmail)
+	h.mux.HandleFunc("POST /email/resend", h.resendEmail)
+	h.mux.HandleFunc("POST /password/forgot", h.forgotPassword)
+	h.mux.HandleFunc("POST /password/reset", h.resetPassword)
+	if h.csrf != nil {
+		h.mux.HandleFunc("GET /csrf", h.csrfToken)
+	}
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := authclient.ContextWithRequestInfo(r.Context(), authclient.RequestInfo{
+		IP:        h.clientIP(r),
+		UserAgent: r.UserAgent(),
+		Locale:    locale(r),
+	})
+	if h.csrf != nil {
+		if err := h.csrf.Check(r); err != nil {
+			writeError(w, err)
+
+			return
+		}
+	}
+	h.mux.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// decode reads a JSON body into dst and answers malformed or oversized bodies itself.
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, dst any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, newProblem(http.StatusRequestEntityTooLarge, codeBodyTooLarge, "request body too large"))
+		} else {
+			writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, "malformed JSON body"))
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// locale returns the first language of Accept-Language.
+func locale(r *http.Request) string {
+	tag, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+
+	return strings.TrimSpace(tag)
+}
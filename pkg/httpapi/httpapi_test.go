@@ -0,0 +1,465 @@
+package httpapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpapi"
+	httpapimocks "github.com/github.com/VadimOcLock/vauth/pkg/httpapi/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/matchsystems/werr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) httpapi.Problem {
+	t.Helper()
+
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	var problem httpapi.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+
+	return problem
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("login returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, authclient.LoginParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		}).Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		w := serve(httpapi.New(client), http.MethodPost, "/login",
+			`{"email":"test@example.com","password":"securepassword"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp httpapi.TokenResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "access", resp.AccessToken)
+		assert.Equal(t, "refresh", resp.RefreshToken)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.InDelta(t, 900, resp.ExpiresIn, 1)
+	})
+
+	t.Run("request info is passed to the client", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("ForgotPassword", mock.MatchedBy(func(ctx context.Context) bool {
+			info := authclient.RequestInfoFromContext(ctx)
+
+			return info.IP == "192.0.2.1" && info.Locale == "de-DE"
+		}), authclient.ForgotPasswordParams{Email: "test@example.com"}).Return(nil)
+
+		r := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(`{"email":"test@example.com"}`))
+		r.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.8")
+		w := httptest.NewRecorder()
+		httpapi.New(client).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("errors become problem details", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("Register", mock.Anything, mock.Anything).Return(werr.Wrap(errorz.ErrLoginAlreadyExists))
+
+		w := serve(httpapi.New(client), http.MethodPost, "/register",
+			`{"email":"test@example.com","password":"securepassword"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "login_already_exists", problem.Code)
+		assert.Equal(t, "urn:vauth:error:login_already_exists", problem.Type)
+		assert.Equal(t, http.StatusConflict, problem.Status)
+	})
+
+	t.Run("rate limit sets Retry-After", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("SendConfirmationEmail", mock.Anything, mock.Anything).
+			Return(werr.Wrap(&errorz.RateLimitedError{RetryAfter: 1500 * time.Millisecond}))
+
+		w := serve(httpapi.New(client), http.MethodPost, "/email/resend", `{"email":"test@example.com"}`)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("mfa challenge is returned in the problem", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(authclient.Tokens{}, werr.Wrap(
+			&errorz.MFARequiredError{Challenge: "challenge", Factor: "totp", Factors: []string{"totp"}}))
+
+		w := serve(httpapi.New(client), http.MethodPost, "/login",
+			`{"email":"test@example.com","password":"securepassword"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, "mfa_required", problem.Code)
+		assert.Equal(t, "challenge", problem.Challenge)
+	})
+
+	t.Run("mfa verify returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("VerifyMFATokens", mock.Anything, "challenge", "123456").Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		w := serve(httpapi.New(client), http.MethodPost, "/mfa/verify", `{"challenge":"challenge","code":"123456"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp httpapi.TokenResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, "access", resp.AccessToken)
+		assert.Equal(t, "refresh", resp.RefreshToken)
+	})
+
+	t.Run("wrong mfa code is unauthorized", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("VerifyMFATokens", mock.Anything, mock.Anything, mock.Anything).
+			Return(authclient.Tokens{}, werr.Wrap(errorz.ErrInvalidMFACode))
+
+		w := serve(httpapi.New(client), http.MethodPost, "/mfa/verify", `{"challenge":"challenge","code":"000000"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "invalid_mfa_code", decodeProblem(t, w).Code)
+	})
+
+	t.Run("internal errors are not revealed", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("ConfirmEmail", mock.Anything, mock.Anything).Return(werr.Wrap(assert.AnError))
+
+		w := serve(httpapi.New(client), http.MethodPost, "/email/confirm", `{"code":"123456"}`)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		problem := decodeProblem(t, w)
+		assert.Equal(t, errorz.CodeInternal, problem.Code)
+		assert.Empty(t, problem.Detail)
+	})
+
+	t.Run("logout revokes bearer and refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("Logout", mock.Anything, authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+		}).Return(nil)
+
+		r := httptest.NewRequest(http.MethodPost, "/logout", strings.NewReader(`{"refresh_token":"refresh"}`))
+		r.Header.Set("Authorization", "Bearer access")
+		w := httptest.NewRecorder()
+		httpapi.New(client).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("malformed and oversized bodies", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpapi.New(httpapimocks.NewClient(t), httpapi.WithMaxBodySize(32))
+
+		w := serve(handler, http.MethodPost, "/password/reset", `{"code":`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "invalid_request", decodeProblem(t, w).Code)
+
+		w = serve(handler, http.MethodPost, "/password/reset", `{"code":"`+strings.Repeat("a", 64)+`"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+		w = serve(handler, http.MethodPost, "/password/reset", `{"code":"1","admin":true}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("only POST is served", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(httpapi.New(httpapimocks.NewClient(t)), http.MethodGet, "/login", "")
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
+
+func TestHandler_SessionCookies(t *testing.T) {
+	t.Parallel()
+
+	cookies := httpauth.SessionCookies{
+		AccessName:  "at",
+		RefreshName: "rt",
+		Domain:      "",
+		Path:        "/",
+		SameSite:    http.SameSiteStrictMode,
+		Insecure:    false,
+	}
+	tokens := authclient.Tokens{
+		AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+		RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	withCookies := func(r *http.Request) *http.Request {
+		r.AddCookie(&http.Cookie{Name: "at", Value: "access"})
+		r.AddCookie(&http.Cookie{Name: "rt", Value: "refresh"})
+
+		return r
+	}
+
+	t.Run("login sets cookies instead of returning tokens", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil)
+
+		w := serve(httpapi.New(client, httpapi.WithSessionCookies(cookies)), http.MethodPost, "/login",
+			`{"email":"test@example.com","password":"securepassword"}`)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+		set := w.Result().Cookies()
+		require.Len(t, set, 2)
+		assert.Equal(t, "at", set[0].Name)
+		assert.Equal(t, "access", set[0].Value)
+		assert.True(t, set[0].HttpOnly)
+		assert.True(t, set[0].Secure)
+		assert.Equal(t, "rt", set[1].Name)
+		assert.Equal(t, "refresh", set[1].Value)
+	})
+
+	t.Run("refresh reads the refresh cookie", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("Refresh", mock.Anything, "refresh").Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access2", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh2", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		httpapi.New(client, httpapi.WithSessionCookies(cookies)).
+			ServeHTTP(w, withCookies(httptest.NewRequest(http.MethodPost, "/refresh", nil)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		set := w.Result().Cookies()
+		require.Len(t, set, 2)
+		assert.Equal(t, "access2", set[0].Value)
+		assert.Equal(t, "refresh2", set[1].Value)
+	})
+
+	t.Run("rejected refresh clears cookies", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("Refresh", mock.Anything, "refresh").Return(authclient.Tokens{}, werr.Wrap(errorz.ErrTokenRevoked))
+
+		w := httptest.NewRecorder()
+		httpapi.New(client, httpapi.WithSessionCookies(cookies)).
+			ServeHTTP(w, withCookies(httptest.NewRequest(http.MethodPost, "/refresh", nil)))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		for _, cookie := range w.Result().Cookies() {
+			assert.Negative(t, cookie.MaxAge)
+		}
+	})
+
+	t.Run("logout revokes and clears cookies", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("Logout", mock.Anything, authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+		}).Return(nil)
+
+		w := httptest.NewRecorder()
+		httpapi.New(client, httpapi.WithSessionCookies(cookies)).
+			ServeHTTP(w, withCookies(httptest.NewRequest(http.MethodPost, "/logout", nil)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		set := w.Result().Cookies()
+		require.Len(t, set, 2)
+		for _, cookie := range set {
+			assert.Negative(t, cookie.MaxAge)
+		}
+	})
+}
+
+func newCSRF(t *testing.T, opts ...httpauth.CSRFOption) *httpauth.CSRF {
+	t.Helper()
+
+	csrf, err := httpauth.NewCSRF([]byte("0123456789abcdef0123456789abcdef"), opts...)
+	require.NoError(t, err)
+
+	return csrf
+}
+
+func TestHandler_CSRF(t *testing.T) {
+	t.Parallel()
+
+	tokens := authclient.Tokens{
+		AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+		RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	csrfCookie := func(t *testing.T, handler http.Handler) *http.Cookie {
+		t.Helper()
+
+		w := serve(handler, http.MethodGet, "/csrf", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, resp.CSRFToken, cookies[0].Value)
+		assert.False(t, cookies[0].HttpOnly)
+
+		return cookies[0]
+	}
+	login := func(handler http.Handler, cookie *http.Cookie, token, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login",
+			strings.NewReader(`{"email":"test@example.com","password":"securepassword"}`))
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		if token != "" {
+			r.Header.Set("X-CSRF-Token", token)
+		}
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("login with the token rotates it", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil)
+		handler := httpapi.New(client, httpapi.WithCSRF(newCSRF(t)))
+		cookie := csrfCookie(t, handler)
+
+		w := login(handler, cookie, cookie.Value, "http://example.com")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		rotated := w.Result().Cookies()
+		require.Len(t, rotated, 1)
+		assert.Equal(t, "vauth_csrf", rotated[0].Name)
+		assert.NotEqual(t, cookie.Value, rotated[0].Value)
+	})
+
+	t.Run("token follows the session cookies", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil)
+		client.On("Logout", mock.Anything, mock.Anything).Return(nil)
+		handler := httpapi.New(client, httpapi.WithSessionCookies(httpauth.SessionCookies{}),
+			httpapi.WithCSRF(newCSRF(t)))
+		anonymous := csrfCookie(t, handler)
+
+		w := login(handler, anonymous, anonymous.Value, "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+		logout := func(csrf string) int {
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			r.AddCookie(cookies["vauth_access"])
+			r.AddCookie(cookies["vauth_refresh"])
+			r.AddCookie(&http.Cookie{Name: "vauth_csrf", Value: csrf})
+			r.Header.Set("X-CSRF-Token", csrf)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			return w.Code
+		}
+		assert.Equal(t, http.StatusForbidden, logout(anonymous.Value))
+		assert.Equal(t, http.StatusNoContent, logout(cookies["vauth_csrf"].Value))
+	})
+
+	t.Run("missing, mismatched and forged tokens are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpapi.New(httpapimocks.NewClient(t), httpapi.WithCSRF(newCSRF(t)))
+		cookie := csrfCookie(t, handler)
+		forged := &http.Cookie{Name: "vauth_csrf", Value: "forged.token"}
+
+		for _, w := range []*httptest.ResponseRecorder{
+			login(handler, nil, "", ""),
+			login(handler, cookie, "", ""),
+			login(handler, cookie, cookie.Value+"x", ""),
+			login(handler, forged, forged.Value, ""),
+		} {
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Equal(t, "csrf_token_invalid", decodeProblem(t, w).Code)
+		}
+	})
+
+	t.Run("cross-origin requests are rejected unless trusted", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil)
+		handler := httpapi.New(client, httpapi.WithCSRF(newCSRF(t, httpauth.WithTrustedOrigins("https://app.example.org"))))
+		cookie := csrfCookie(t, handler)
+
+		w := login(handler, cookie, cookie.Value, "https://evil.example.net")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "csrf_origin_mismatch", decodeProblem(t, w).Code)
+
+		w = login(handler, cookie, cookie.Value, "https://app.example.org")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("exempt paths skip the checks", func(t *testing.T) {
+		t.Parallel()
+
+		client := httpapimocks.NewClient(t)
+		client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil)
+		handler := httpapi.New(client, httpapi.WithCSRF(newCSRF(t, httpauth.WithCSRFExempt("/login"))))
+
+		w := login(handler, nil, "", "https://evil.example.net")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,222 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	authclient "github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Client is an autogenerated mock type for the Client type
+type Client struct {
+	mock.Mock
+}
+
+// ConfirmEmail provides a mock function with given fields: ctx, dto
+func (_m *Client) ConfirmEmail(ctx context.Context, dto authclient.ConfirmEmailParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.ConfirmEmailParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ForgotPassword provides a mock function with given fields: ctx, dto
+func (_m *Client) ForgotPassword(ctx context.Context, dto authclient.ForgotPasswordParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForgotPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.ForgotPasswordParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LoginTokens provides a mock function with given fields: ctx, dto
+func (_m *Client) LoginTokens(ctx context.Context, dto authclient.LoginParams) (authclient.Tokens, error) {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoginTokens")
+	}
+
+	var r0 authclient.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.LoginParams) (authclient.Tokens, error)); ok {
+		return rf(ctx, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.LoginParams) authclient.Tokens); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Get(0).(authclient.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, authclient.LoginParams) error); ok {
+		r1 = rf(ctx, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, dto
+func (_m *Client) Logout(ctx context.Context, dto authclient.LogoutParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.LogoutParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *Client) Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Refresh")
+	}
+
+	var r0 authclient.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (authclient.Tokens, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) authclient.Tokens); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		r0 = ret.Get(0).(authclient.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, dto
+func (_m *Client) Register(ctx context.Context, dto authclient.RegisterParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Register")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.RegisterParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, dto
+func (_m *Client) ResetPassword(ctx context.Context, dto authclient.ResetPasswordParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.ResetPasswordParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SendConfirmationEmail provides a mock function with given fields: ctx, dto
+func (_m *Client) SendConfirmationEmail(ctx context.Context, dto authclient.SendConfirmationEmailParams) error {
+	ret := _m.Called(ctx, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SendConfirmationEmail")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, authclient.SendConfirmationEmailParams) error); ok {
+		r0 = rf(ctx, dto)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyMFATokens provides a mock function with given fields: ctx, challenge, code
+func (_m *Client) VerifyMFATokens(ctx context.Context, challenge string, code string) (authclient.Tokens, error) {
+	ret := _m.Called(ctx, challenge, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMFATokens")
+	}
+
+	var r0 authclient.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (authclient.Tokens, error)); ok {
+		return rf(ctx, challenge, code)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) authclient.Tokens); ok {
+		r0 = rf(ctx, challenge, code)
+	} else {
+		r0 = ret.Get(0).(authclient.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, challenge, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewClient creates a new instance of Client. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClient(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Client {
+	mock := &Client{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,8 @@
+package httpapi
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3.1 document of the handler returned by New.
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
@@ -0,0 +1,271 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpapi"
+	httpapimocks "github.com/github.com/VadimOcLock/vauth/pkg/httpapi/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type spec map[string]any
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(httpapi.OpenAPI, &doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+
+	return doc
+}
+
+// resolve follows a local $ref such as "#/components/schemas/Problem".
+func (s spec) resolve(t *testing.T, node any) map[string]any {
+	t.Helper()
+
+	obj, ok := node.(map[string]any)
+	require.True(t, ok, "expected an object, got %T", node)
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return obj
+	}
+	var cur any = map[string]any(s)
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := cur.(map[string]any)
+		require.True(t, ok, "unresolvable $ref %s", ref)
+		cur, ok = m[part]
+		require.True(t, ok, "unresolvable $ref %s", ref)
+	}
+
+	return s.resolve(t, cur)
+}
+
+func (s spec) operations(t *testing.T) map[string]map[string]any {
+	t.Helper()
+
+	ops := make(map[string]map[string]any)
+	paths, ok := s["paths"].(map[string]any)
+	require.True(t, ok)
+	for path, item := range paths {
+		for method, op := range s.resolve(t, item) {
+			ops[strings.ToUpper(method)+" "+path] = s.resolve(t, op)
+		}
+	}
+
+	return ops
+}
+
+// successClient answers every call of the handler successfully.
+func successClient(t *testing.T) *httpapimocks.Client {
+	t.Helper()
+
+	tokens := authclient.Tokens{
+		AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(time.Minute)},
+		RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	client := httpapimocks.NewClient(t)
+	client.On("LoginTokens", mock.Anything, mock.Anything).Return(tokens, nil).Maybe()
+	client.On("VerifyMFATokens", mock.Anything, mock.Anything, mock.Anything).Return(tokens, nil).Maybe()
+	client.On("Refresh", mock.Anything, mock.Anything).Return(tokens, nil).Maybe()
+	for _, method := range []string{
+		"Register", "Logout", "ConfirmEmail", "SendConfirmationEmail", "ForgotPassword", "ResetPassword",
+	} {
+		client.On(method, mock.Anything, mock.Anything).Return(nil).Maybe()
+	}
+
+	return client
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	doc := loadSpec(t)
+	ops := doc.operations(t)
+
+	t.Run("spec and handler have the same routes", func(t *testing.T) {
+		t.Parallel()
+
+		routes := []string{
+			"POST /register", "POST /login", "POST /mfa/verify", "POST /refresh", "POST /logout", "POST /email/confirm",
+			"POST /email/resend", "POST /password/forgot", "POST /password/reset", "GET /csrf",
+		}
+		documented := make([]string, 0, len(ops))
+		for route := range ops {
+			documented = append(documented, route)
+		}
+		assert.ElementsMatch(t, routes, documented)
+
+		handler := httpapi.New(httpapimocks.NewClient(t), httpapi.WithCSRF(newCSRF(t)))
+		for _, route := range routes {
+			method, path, _ := strings.Cut(route, " ")
+			w := serve(handler, http.MethodOptions, path, "")
+			assert.Equal(t, http.StatusMethodNotAllowed, w.Code, route)
+			assert.Contains(t, w.Header().Get("Allow"), method, route)
+		}
+	})
+
+	t.Run("example requests get documented responses", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpapi.New(successClient(t), httpapi.WithCSRF(newCSRF(t)))
+		csrf := serve(handler, http.MethodGet, "/csrf", "")
+		require.Equal(t, http.StatusOK, csrf.Code)
+		csrfCookie := csrf.Result().Cookies()[0]
+		for route, op := range ops {
+			method, path, _ := strings.Cut(route, " ")
+			body := ""
+			if rb, ok := op["requestBody"]; ok {
+				content, ok := doc.resolve(t, rb)["content"].(map[string]any)
+				require.True(t, ok, route)
+				media := doc.resolve(t, content["application/json"])
+				raw, err := json.Marshal(media["example"])
+				require.NoError(t, err)
+				body = string(raw)
+				assertMatchesSchema(t, doc, doc.resolve(t, media["schema"]), media["example"], route+" example")
+			}
+
+			r := httptest.NewRequest(method, path, strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-CSRF-Token", csrfCookie.Value)
+			r.AddCookie(csrfCookie)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			responses, ok := op["responses"].(map[string]any)
+			require.True(t, ok, route)
+			response, ok := responses[strconv.Itoa(w.Code)]
+			require.True(t, ok, "%s answered undocumented status %d", route, w.Code)
+			content, ok := doc.resolve(t, response)["content"].(map[string]any)
+			if !ok {
+				assert.Empty(t, w.Body.String(), route)
+
+				continue
+			}
+			media, ok := content[w.Header().Get("Content-Type")]
+			require.True(t, ok, "%s answered undocumented content type %q", route, w.Header().Get("Content-Type"))
+			var got any
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assertMatchesSchema(t, doc, doc.resolve(t, doc.resolve(t, media)["schema"]), got, route)
+		}
+	})
+
+	t.Run("malformed requests get documented problems", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpapi.New(httpapimocks.NewClient(t), httpapi.WithCSRF(newCSRF(t)))
+		for route, op := range ops {
+			method, path, _ := strings.Cut(route, " ")
+			if method == http.MethodGet {
+				continue
+			}
+			w := serve(handler, method, path, "{")
+
+			responses, ok := op["responses"].(map[string]any)
+			require.True(t, ok, route)
+			require.Contains(t, responses, strconv.Itoa(w.Code), route)
+			assertMatchesSchema(t, doc, doc.resolve(t, doc.lookup(t, "components", "schemas", "Problem")),
+				decodeAny(t, w.Body.Bytes()), route)
+		}
+	})
+
+	t.Run("problem codes are documented", func(t *testing.T) {
+		t.Parallel()
+
+		schema := doc.lookup(t, "components", "schemas", "Problem", "properties", "code")
+		enum, ok := schema["enum"].([]any)
+		require.True(t, ok)
+		for _, err := range []error{
+			errorz.ErrPasswordLength, errorz.ErrInvalidEmailFormat, errorz.ErrLoginAlreadyExists,
+			errorz.ErrInvalidCredentials, errorz.ErrEmailNotConfirmed, errorz.ErrEmailAlreadyVerified,
+			errorz.ErrMFARequired, errorz.ErrMFAFactorNotFound, errorz.ErrInvalidMFACode,
+			errorz.ErrAccountLocked, errorz.ErrRateLimited, errorz.ErrOperationVetoed,
+			errorz.ErrInvalidToken, errorz.ErrTokenExpired, errorz.ErrTokenRevoked, errorz.ErrCSRFTokenInvalid,
+			errorz.ErrCSRFOriginMismatch, errors.New("db down"),
+		} {
+			assert.Contains(t, enum, httpapi.ProblemFromError(err).Code)
+		}
+	})
+}
+
+func (s spec) lookup(t *testing.T, path ...string) map[string]any {
+	t.Helper()
+
+	cur := map[string]any(s)
+	for _, part := range path {
+		cur = s.resolve(t, cur[part])
+	}
+
+	return cur
+}
+
+func decodeAny(t *testing.T, data []byte) any {
+	t.Helper()
+
+	var v any
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	return v
+}
+
+// assertMatchesSchema checks the subset of JSON Schema used by openapi.yaml.
+func assertMatchesSchema(t *testing.T, doc spec, schema map[string]any, value any, where string) {
+	t.Helper()
+
+	if c, ok := schema["const"]; ok {
+		assert.Equal(t, c, value, where)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		assert.Contains(t, enum, value, where)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !assert.True(t, ok, "%s: expected an object, got %T", where, value) {
+			return
+		}
+		props, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			assert.Contains(t, obj, name, "%s: missing required property", where)
+		}
+		for name, v := range obj {
+			prop, ok := props[name]
+			if !ok {
+				assert.NotEqual(t, false, schema["additionalProperties"], "%s: undocumented property %s", where, name)
+
+				continue
+			}
+			assertMatchesSchema(t, doc, doc.resolve(t, prop), v, where+"."+name)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if assert.True(t, ok, "%s: expected an array, got %T", where, value) && schema["items"] != nil {
+			for _, item := range items {
+				assertMatchesSchema(t, doc, doc.resolve(t, schema["items"]), item, where+"[]")
+			}
+		}
+	case "string":
+		_, ok := value.(string)
+		assert.True(t, ok, "%s: expected a string, got %T", where, value)
+	case "integer":
+		n, ok := value.(float64)
+		assert.True(t, ok && n == float64(int64(n)), "%s: expected an integer, got %v", where, value)
+	default:
+		assert.Nil(t, schema["type"], "%s: unsupported schema type", where)
+	}
+}
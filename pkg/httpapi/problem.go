@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+)
+
+const (
+	codeInvalidRequest = "invalid_request"
+	codeBodyTooLarge   = "body_too_large"
+)
+
+// Problem is an RFC 7807 problem details body. Code is the errorz.Code of
+// the error; Type is derived from it.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+
+	// Set for mfa_required.
+	Challenge string   `json:"challenge,omitempty"`
+	Factor    string   `json:"factor,omitempty"`
+	Factors   []string `json:"factors,omitempty"`
+
+	retryAfter time.Duration
+}
+
+var statuses = []struct {
+	err    error
+	status int
+}{
+	{errorz.ErrPasswordLength, http.StatusBadRequest},
+	{errorz.ErrInvalidEmailFormat, http.StatusBadRequest},
+	{errorz.ErrLoginAlreadyExists, http.StatusConflict},
+	{errorz.ErrInvalidCredentials, http.StatusUnauthorized},
+	{errorz.ErrEmailNotConfirmed, http.StatusForbidden},
+	{errorz.ErrEmailAlreadyVerified, http.StatusConflict},
+	{errorz.ErrMFARequired, http.StatusUnauthorized},
+	{errorz.ErrMFAFactorNotFound, http.StatusUnauthorized},
+	{errorz.ErrInvalidMFACode, http.StatusUnauthorized},
+	{errorz.ErrAccountLocked, http.StatusLocked},
+	{errorz.ErrRateLimited, http.StatusTooManyRequests},
+	{errorz.ErrOperationVetoed, http.StatusForbidden},
+	{errorz.ErrInvalidToken, http.StatusUnauthorized},
+	{errorz.ErrTokenExpired, http.StatusUnauthorized},
+	{errorz.ErrTokenRevoked, http.StatusUnauthorized},
+	{errorz.ErrCSRFTokenInvalid, http.StatusForbidden},
+	{errorz.ErrCSRFOriginMismatch, http.StatusForbidden},
+}
+
+func newProblem(status int, code, detail string) Problem {
+	return Problem{
+		Type:       "urn:vauth:error:" + code,
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Code:       code,
+		Challenge:  "",
+		Factor:     "",
+		Factors:    nil,
+		retryAfter: 0,
+	}
+}
+
+// ProblemFromError maps an authclient error to its problem. Errors without an
+// errorz code become a 500 that does not reveal their message.
+func ProblemFromError(err error) Problem {
+	problem := newProblem(http.StatusInternalServerError, errorz.CodeInternal, "")
+	for _, s := range statuses {
+		if errors.Is(err, s.err) {
+			problem = newProblem(s.status, errorz.Code(s.err), s.err.Error())
+
+			break
+		}
+	}
+
+	var (
+		mfaRequired *errorz.MFARequiredError
+		locked      *errorz.AccountLockedError
+		rateLimited *errorz.RateLimitedError
+	)
+	switch {
+	case errors.As(err, &mfaRequired):
+		problem.Challenge, problem.Factor, problem.Factors = mfaRequired.Challenge, mfaRequired.Factor, mfaRequired.Factors
+	case errors.As(err, &locked):
+		problem.retryAfter = locked.RetryAfter
+	case errors.As(err, &rateLimited):
+		problem.retryAfter = rateLimited.RetryAfter
+	}
+
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	if problem.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(problem.retryAfter.Seconds()))))
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeProblem(w, ProblemFromError(err))
+}
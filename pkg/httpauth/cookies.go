@@ -0,0 +1,154 @@
+package httpauth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+)
+
+const (
+	// maxCookieSize is the smallest per-cookie limit browsers must support,
+	// counting name, value and attributes.
+	maxCookieSize = 4096
+
+	defaultAccessCookie  = "vauth_access"
+	defaultRefreshCookie = "vauth_refresh"
+)
+
+// SessionCookies keeps the tokens of browser sessions in HttpOnly cookies.
+// Cookies larger than 4 KiB are split into chunks named <name>.0, <name>.1, …
+//
+// The zero value uses the cookies vauth_access and vauth_refresh on path "/"
+// of the current host with SameSite=Lax and Secure.
+type SessionCookies struct {
+	AccessName  string
+	RefreshName string
+	Domain      string
+	Path        string
+	SameSite    http.SameSite
+	// Insecure omits the Secure attribute, for development over plain HTTP.
+	Insecure bool
+}
+
+func (c SessionCookies) withDefaults() SessionCookies {
+	if c.AccessName == "" {
+		c.AccessName = defaultAccessCookie
+	}
+	if c.RefreshName == "" {
+		c.RefreshName = defaultRefreshCookie
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	if c.SameSite == 0 {
+		c.SameSite = http.SameSiteLaxMode
+	}
+
+	return c
+}
+
+// SetTokens writes both tokens, each expiring with its token, and expires
+// chunks of the previous cookies that are no longer needed.
+func (c SessionCookies) SetTokens(w http.ResponseWriter, r *http.Request, tokens authclient.Tokens) {
+	c = c.withDefaults()
+	c.write(w, r, c.AccessName, tokens.AccessToken.Token, tokens.AccessToken.ExpiresAt)
+	c.write(w, r, c.RefreshName, tokens.RefreshToken.Token, tokens.RefreshToken.ExpiresAt)
+}
+
+// Clear expires the session cookies sent with r.
+func (c SessionCookies) Clear(w http.ResponseWriter, r *http.Request) {
+	c = c.withDefaults()
+	c.write(w, r, c.AccessName, "", time.Time{})
+	c.write(w, r, c.RefreshName, "", time.Time{})
+}
+
+// AccessToken returns the access token of r, or "" without one.
+func (c SessionCookies) AccessToken(r *http.Request) string {
+	return c.read(r, c.withDefaults().AccessName)
+}
+
+// RefreshToken returns the refresh token of r, or "" without one.
+func (c SessionCookies) RefreshToken(r *http.Request) string {
+	return c.read(r, c.withDefaults().RefreshName)
+}
+
+func (c SessionCookies) read(r *http.Request, name string) string {
+	if cookie, err := r.Cookie(name); err == nil {
+		return cookie.Value
+	}
+
+	var value strings.Builder
+	for i := 0; ; i++ {
+		cookie, err := r.Cookie(chunkName(name, i))
+		if err != nil {
+			break
+		}
+		value.WriteString(cookie.Value)
+	}
+
+	return value.String()
+}
+
+// write sets name to value, chunked if needed, and expires every other
+// cookie of name sent with r. An empty value only expires.
+func (c SessionCookies) write(w http.ResponseWriter, r *http.Request, name, value string, expires time.Time) {
+	written := make(map[string]bool)
+	if value != "" {
+		chunks := []string{value}
+		if len(c.cookie(name, value, expires).String()) > maxCookieSize {
+			chunks = split(value, maxCookieSize-len(c.cookie(chunkName(name, 99), "", expires).String()))
+			for i := range chunks {
+				written[chunkName(name, i)] = true
+			}
+		} else {
+			written[name] = true
+		}
+		for i, chunk := range chunks {
+			cookieName := name
+			if len(chunks) > 1 {
+				cookieName = chunkName(name, i)
+			}
+			http.SetCookie(w, c.cookie(cookieName, chunk, expires))
+		}
+	}
+
+	for _, cookie := range r.Cookies() {
+		if written[cookie.Name] || (cookie.Name != name && !strings.HasPrefix(cookie.Name, name+".")) {
+			continue
+		}
+		expired := c.cookie(cookie.Name, "", time.Time{})
+		expired.MaxAge = -1
+		http.SetCookie(w, expired)
+		written[cookie.Name] = true
+	}
+}
+
+func (c SessionCookies) cookie(name, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   !c.Insecure,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+}
+
+func chunkName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
+}
+
+func split(value string, size int) []string {
+	chunks := make([]string, 0, len(value)/size+1)
+	for len(value) > size {
+		chunks = append(chunks, value[:size])
+		value = value[size:]
+	}
+
+	return append(chunks, value)
+}
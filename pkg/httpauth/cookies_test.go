@@ -0,0 +1,136 @@
+package httpauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestWith returns a request carrying the cookies set on w.
+func requestWith(w *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+	}
+
+	return r
+}
+
+func newTokens(access, refresh string) authclient.Tokens {
+	return authclient.Tokens{
+		AccessToken:  jwtgen.Token{Token: access, ExpiresAt: time.Now().Add(15 * time.Minute)},
+		RefreshToken: jwtgen.Token{Token: refresh, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+}
+
+func TestSessionCookies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tokens round-trip with secure defaults", func(t *testing.T) {
+		t.Parallel()
+
+		var cookies httpauth.SessionCookies
+		w := httptest.NewRecorder()
+		cookies.SetTokens(w, httptest.NewRequest(http.MethodGet, "/", nil), newTokens("access", "refresh"))
+
+		set := w.Result().Cookies()
+		require.Len(t, set, 2)
+		for _, cookie := range set {
+			assert.True(t, cookie.HttpOnly)
+			assert.True(t, cookie.Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			assert.Equal(t, "/", cookie.Path)
+		}
+		assert.Equal(t, "vauth_access", set[0].Name)
+		assert.Equal(t, "vauth_refresh", set[1].Name)
+
+		r := requestWith(w)
+		assert.Equal(t, "access", cookies.AccessToken(r))
+		assert.Equal(t, "refresh", cookies.RefreshToken(r))
+	})
+
+	t.Run("names, domain and path are configurable", func(t *testing.T) {
+		t.Parallel()
+
+		cookies := httpauth.SessionCookies{
+			AccessName:  "__Secure-at",
+			RefreshName: "__Secure-rt",
+			Domain:      "example.com",
+			Path:        "/app",
+			SameSite:    http.SameSiteStrictMode,
+			Insecure:    false,
+		}
+		w := httptest.NewRecorder()
+		cookies.SetTokens(w, httptest.NewRequest(http.MethodGet, "/", nil), newTokens("access", "refresh"))
+
+		header := w.Header().Values("Set-Cookie")
+		require.Len(t, header, 2)
+		assert.True(t, strings.HasPrefix(header[0], "__Secure-at=access;"))
+		assert.Contains(t, header[0], "Domain=example.com")
+		assert.Contains(t, header[0], "Path=/app")
+		assert.Contains(t, header[0], "SameSite=Strict")
+		assert.True(t, strings.HasPrefix(header[1], "__Secure-rt=refresh;"))
+	})
+
+	t.Run("large tokens are chunked", func(t *testing.T) {
+		t.Parallel()
+
+		var cookies httpauth.SessionCookies
+		access := strings.Repeat("a", 9000)
+		w := httptest.NewRecorder()
+		cookies.SetTokens(w, httptest.NewRequest(http.MethodGet, "/", nil), newTokens(access, "refresh"))
+
+		header := w.Header().Values("Set-Cookie")
+		require.Len(t, header, 4)
+		for _, value := range header {
+			assert.LessOrEqual(t, len(value), 4096)
+		}
+		assert.True(t, strings.HasPrefix(header[0], "vauth_access.0="))
+		assert.True(t, strings.HasPrefix(header[2], "vauth_access.2="))
+		assert.Equal(t, access, cookies.AccessToken(requestWith(w)))
+
+		// A small token replaces the chunks, which are expired.
+		w2 := httptest.NewRecorder()
+		cookies.SetTokens(w2, requestWith(w), newTokens("access", "refresh"))
+
+		r := requestWith(w2)
+		assert.Equal(t, "access", cookies.AccessToken(r))
+		expired := 0
+		for _, cookie := range w2.Result().Cookies() {
+			if cookie.MaxAge < 0 {
+				assert.True(t, strings.HasPrefix(cookie.Name, "vauth_access."))
+				expired++
+			}
+		}
+		assert.Equal(t, 3, expired)
+	})
+
+	t.Run("clear expires every cookie", func(t *testing.T) {
+		t.Parallel()
+
+		var cookies httpauth.SessionCookies
+		w := httptest.NewRecorder()
+		cookies.SetTokens(w, httptest.NewRequest(http.MethodGet, "/", nil),
+			newTokens(strings.Repeat("a", 5000), "refresh"))
+
+		w2 := httptest.NewRecorder()
+		cookies.Clear(w2, requestWith(w))
+
+		set := w2.Result().Cookies()
+		require.Len(t, set, 3)
+		for _, cookie := range set {
+			assert.Negative(t, cookie.MaxAge)
+		}
+		assert.Empty(t, cookies.AccessToken(requestWith(w2)))
+	})
+}
@@ -0,0 +1,273 @@
+package httpauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	minCSRFKeySize = 32
+	csrfNonceSize  = 32
+
+	defaultCSRFCookie = "vauth_csrf"
+	defaultCSRFHeader = "X-CSRF-Token"
+	defaultCSRFField  = "csrf_token"
+)
+
+// CSRF protects cookie-authenticated endpoints with signed double-submit
+// cookies. Every unsafe request must echo the token of the CSRF cookie in a
+// header or form field, and its Origin or Referer, when sent, must be the
+// request host or a trusted origin.
+//
+// The token is an HMAC over a nonce and the session, which is identified by
+// the refresh token cookie, or the access token cookie without one. A token
+// planted by an attacker was issued for another session and fails the
+// check. Call RotateSession whenever the session cookies change, e.g. on
+// login, refresh and logout, and Rotate when a user changes their password.
+type CSRF struct {
+	key        []byte
+	cookieName string
+	headerName string
+	fieldName  string
+	cookies    SessionCookies
+	trusted    map[string]bool
+	exempt     map[string]bool
+}
+
+type CSRFOption func(*CSRF)
+
+// WithCSRFCookie names the cookie, "vauth_csrf" by default. Its domain, path
+// and SameSite follow WithCSRFSessionCookies.
+func WithCSRFCookie(name string) CSRFOption {
+	return func(c *CSRF) {
+		c.cookieName = name
+	}
+}
+
+// WithCSRFHeader names the request header carrying the token, "X-CSRF-Token"
+// by default. Form posts may use the "csrf_token" field instead.
+func WithCSRFHeader(name string) CSRFOption {
+	return func(c *CSRF) {
+		c.headerName = name
+	}
+}
+
+// WithCSRFSessionCookies names the session cookies tokens are bound to and
+// sets the cookie attributes to theirs. The CSRF cookie is never HttpOnly,
+// since scripts must read it.
+func WithCSRFSessionCookies(cookies SessionCookies) CSRFOption {
+	return func(c *CSRF) {
+		c.cookies = cookies
+	}
+}
+
+// WithTrustedOrigins accepts requests from other origins, such as
+// "https://app.example.com", in addition to the request host.
+func WithTrustedOrigins(origins ...string) CSRFOption {
+	return func(c *CSRF) {
+		for _, origin := range origins {
+			c.trusted[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+}
+
+// WithCSRFExempt skips the checks for the given request paths, e.g. for
+// endpoints called by servers with bearer tokens.
+func WithCSRFExempt(paths ...string) CSRFOption {
+	return func(c *CSRF) {
+		for _, path := range paths {
+			c.exempt[path] = true
+		}
+	}
+}
+
+// NewCSRF returns CSRF protection signing tokens with key, which must be at
+// least 32 bytes and shared by all instances serving the app.
+func NewCSRF(key []byte, opts ...CSRFOption) (*CSRF, error) {
+	if len(key) < minCSRFKeySize {
+		return nil, werr.Wrap(errorz.ErrCSRFKeySize)
+	}
+	c := &CSRF{
+		key:        key,
+		cookieName: defaultCSRFCookie,
+		headerName: defaultCSRFHeader,
+		fieldName:  defaultCSRFField,
+		cookies:    SessionCookies{},
+		trusted:    make(map[string]bool),
+		exempt:     make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.cookies = c.cookies.withDefaults()
+
+	return c, nil
+}
+
+// Middleware answers requests failing Check with 403. Clients get their
+// first token from a page rendered with Token or an endpoint returning it.
+func (c *CSRF) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.Check(r); err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Token returns the CSRF token of r, issuing a new one on w if r has no
+// valid one for its session. Use it to render the token into forms.
+func (c *CSRF) Token(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(c.cookieName); err == nil && c.valid(cookie.Value, c.session(r)) {
+		return cookie.Value
+	}
+
+	return c.Rotate(w, r)
+}
+
+// Rotate issues a new CSRF token on w for the session of r and returns it.
+func (c *CSRF) Rotate(w http.ResponseWriter, r *http.Request) string {
+	return c.issue(w, c.session(r))
+}
+
+// RotateSession issues a new CSRF token on w for the session of tokens, which
+// are being set as session cookies on w, and returns it. Empty tokens stand
+// for no session, e.g. after logout.
+func (c *CSRF) RotateSession(w http.ResponseWriter, tokens authclient.Tokens) string {
+	session := tokens.RefreshToken.Token
+	if session == "" {
+		session = tokens.AccessToken.Token
+	}
+
+	return c.issue(w, session)
+}
+
+// Clear expires the CSRF cookie.
+func (c *CSRF) Clear(w http.ResponseWriter) {
+	cookie := c.cookie("")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+}
+
+// Check returns errorz.ErrCSRFOriginMismatch or errorz.ErrCSRFTokenInvalid
+// for unsafe requests to paths that are not exempt and fail the checks.
+func (c *CSRF) Check(r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+	if c.exempt[r.URL.Path] {
+		return nil
+	}
+	if !c.sameOrigin(r) {
+		return werr.Wrap(errorz.ErrCSRFOriginMismatch)
+	}
+
+	cookie, err := r.Cookie(c.cookieName)
+	if err != nil || !c.valid(cookie.Value, c.session(r)) {
+		return werr.Wrap(errorz.ErrCSRFTokenInvalid)
+	}
+	submitted := r.Header.Get(c.headerName)
+	if submitted == "" {
+		contentType := r.Header.Get("Content-Type")
+		if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") ||
+			strings.HasPrefix(contentType, "multipart/form-data") {
+			submitted = r.PostFormValue(c.fieldName)
+		}
+	}
+	if !hmac.Equal([]byte(submitted), []byte(cookie.Value)) {
+		return werr.Wrap(errorz.ErrCSRFTokenInvalid)
+	}
+
+	return nil
+}
+
+// sameOrigin checks Origin, or Referer without it. Requests with neither,
+// such as those of non-browser clients, pass and rely on the token.
+func (c *CSRF) sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return true
+	}
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	return c.trusted[strings.ToLower(u.Scheme+"://"+u.Host)]
+}
+
+// session returns the token identifying the session of r, or "" for none.
+func (c *CSRF) session(r *http.Request) string {
+	if token := c.cookies.RefreshToken(r); token != "" {
+		return token
+	}
+
+	return c.cookies.AccessToken(r)
+}
+
+func (c *CSRF) issue(w http.ResponseWriter, session string) string {
+	nonce := make([]byte, csrfNonceSize)
+	_, _ = rand.Read(nonce)
+	token := base64.RawURLEncoding.EncodeToString(nonce) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(nonce, session))
+	http.SetCookie(w, c.cookie(token))
+
+	return token
+}
+
+func (c *CSRF) valid(token string, session string) bool {
+	encodedNonce, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(encodedNonce)
+	if err != nil || len(nonce) != csrfNonceSize {
+		return false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(mac, c.sign(nonce, session))
+}
+
+func (c *CSRF) sign(nonce []byte, session string) []byte {
+	sessionHash := sha256.Sum256([]byte(session))
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte("vauth-csrf:"))
+	mac.Write(nonce)
+	mac.Write(sessionHash[:])
+
+	return mac.Sum(nil)
+}
+
+func (c *CSRF) cookie(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.cookieName,
+		Value:    value,
+		Path:     c.cookies.Path,
+		Domain:   c.cookies.Domain,
+		Secure:   !c.cookies.Insecure,
+		HttpOnly: false,
+		SameSite: c.cookies.SameSite,
+	}
+}
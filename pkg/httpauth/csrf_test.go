@@ -0,0 +1,158 @@
+package httpauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRF(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	protected := func(csrf *httpauth.CSRF) http.Handler {
+		return csrf.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+	issue := func(t *testing.T, csrf *httpauth.CSRF) *http.Cookie {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		token := csrf.Token(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, token, cookies[0].Value)
+
+		return cookies[0]
+	}
+
+	t.Run("short key is rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := httpauth.NewCSRF([]byte("short"))
+
+		require.ErrorIs(t, err, errorz.ErrCSRFKeySize)
+	})
+
+	t.Run("token is reused while valid", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key)
+		require.NoError(t, err)
+		cookie := issue(t, csrf)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(cookie)
+		w := httptest.NewRecorder()
+
+		assert.Equal(t, cookie.Value, csrf.Token(w, r))
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("cookie follows session cookie attributes", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key, httpauth.WithCSRFCookie("xsrf"), httpauth.WithCSRFSessionCookies(
+			httpauth.SessionCookies{
+				AccessName:  "",
+				RefreshName: "",
+				Domain:      "example.com",
+				Path:        "/app",
+				SameSite:    http.SameSiteStrictMode,
+				Insecure:    false,
+			}))
+		require.NoError(t, err)
+		cookie := issue(t, csrf)
+
+		assert.Equal(t, "xsrf", cookie.Name)
+		assert.Equal(t, "example.com", cookie.Domain)
+		assert.Equal(t, "/app", cookie.Path)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.True(t, cookie.Secure)
+		assert.False(t, cookie.HttpOnly)
+	})
+
+	t.Run("form field and header are accepted", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key, httpauth.WithCSRFHeader("X-XSRF-Token"))
+		require.NoError(t, err)
+		cookie := issue(t, csrf)
+
+		form := url.Values{"csrf_token": {cookie.Value}}.Encode()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		protected(csrf).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		r = httptest.NewRequest(http.MethodDelete, "/", nil)
+		r.Header.Set("X-XSRF-Token", cookie.Value)
+		r.AddCookie(cookie)
+		w = httptest.NewRecorder()
+		protected(csrf).ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("unsafe requests without token are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		protected(csrf).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", nil))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = httptest.NewRecorder()
+		protected(csrf).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("token is bound to the session", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key)
+		require.NoError(t, err)
+		post := func(refresh, token string) *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.AddCookie(&http.Cookie{Name: "vauth_refresh", Value: refresh})
+			r.AddCookie(&http.Cookie{Name: "vauth_csrf", Value: token})
+			r.Header.Set("X-CSRF-Token", token)
+
+			return r
+		}
+		token := csrf.RotateSession(httptest.NewRecorder(), newTokens("access-a", "session-a"))
+
+		require.NoError(t, csrf.Check(post("session-a", token)))
+		require.ErrorIs(t, csrf.Check(post("session-b", token)), errorz.ErrCSRFTokenInvalid)
+
+		// An anonymous token planted in a logged-in browser is rejected too.
+		anonymous := issue(t, csrf)
+		require.ErrorIs(t, csrf.Check(post("session-a", anonymous.Value)), errorz.ErrCSRFTokenInvalid)
+	})
+
+	t.Run("referer is checked without origin", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF(key)
+		require.NoError(t, err)
+		cookie := issue(t, csrf)
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Referer", "https://evil.example.net/page")
+		r.Header.Set("X-CSRF-Token", cookie.Value)
+		r.AddCookie(cookie)
+
+		require.ErrorIs(t, csrf.Check(r), errorz.ErrCSRFOriginMismatch)
+	})
+}
@@ -0,0 +1,198 @@
+package httpauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/google/uuid"
+)
+
+// Validator is implemented by *authclient.Client.
+type Validator interface {
+	ValidateToken(ctx context.Context, token string) (authclient.Claims, error)
+}
+
+type ValidatorFunc func(ctx context.Context, token string) (authclient.Claims, error)
+
+var _ Validator = ValidatorFunc(nil)
+
+func (f ValidatorFunc) ValidateToken(ctx context.Context, token string) (authclient.Claims, error) {
+	return f(ctx, token)
+}
+
+// Refresher is implemented by *authclient.Client.
+type Refresher interface {
+	Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error)
+}
+
+type config struct {
+	cookieName string
+	realm      string
+	optional   bool
+	session    *session
+	csrf       *CSRF
+}
+
+type Option func(*config)
+
+// WithCookie reads the token from the named cookie when the request has no
+// Authorization header.
+func WithCookie(name string) Option {
+	return func(c *config) {
+		c.cookieName = name
+	}
+}
+
+// WithRealm sets the realm of WWW-Authenticate challenges.
+func WithRealm(realm string) Option {
+	return func(c *config) {
+		c.realm = realm
+	}
+}
+
+// WithSessionCookies reads the token from SessionCookies when the request has
+// no Authorization header. When the access token is missing or expired but
+// the refresh token is not, the tokens are refreshed and the new cookies set
+// on the response before the request continues; a failed refresh clears them.
+func WithSessionCookies(cookies SessionCookies, refresher Refresher) Option {
+	return func(c *config) {
+		c.session = newSession(cookies, refresher)
+	}
+}
+
+// WithCSRF rotates the CSRF token whenever WithSessionCookies refreshes or
+// clears the session cookies, keeping it bound to the session.
+func WithCSRF(csrf *CSRF) Option {
+	return func(c *config) {
+		c.csrf = csrf
+	}
+}
+
+// Optional lets requests without a token through with no claims in their
+// context. Requests with an invalid token are still rejected.
+func Optional() Option {
+	return func(c *config) {
+		c.optional = true
+	}
+}
+
+// Middleware authenticates requests with a bearer token and stores its claims
+// in the request context. Failures are answered as described in RFC 6750.
+func Middleware(validator Validator, opts ...Option) func(http.Handler) http.Handler {
+	cfg := config{cookieName: "", realm: "", optional: false, session: nil, csrf: nil}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, fromHeader, err := cfg.token(r)
+			if err != nil {
+				cfg.challenge(w, http.StatusBadRequest, "invalid_request", "malformed Authorization header")
+
+				return
+			}
+			refreshable := !fromHeader && cfg.session != nil && cfg.session.cookies.RefreshToken(r) != ""
+			if token == "" && !refreshable {
+				if cfg.optional {
+					next.ServeHTTP(w, r)
+
+					return
+				}
+				cfg.challenge(w, http.StatusUnauthorized, "", "")
+
+				return
+			}
+
+			var claims authclient.Claims
+			if token == "" {
+				err = errorz.ErrTokenExpired
+			} else {
+				claims, err = validator.ValidateToken(r.Context(), token)
+			}
+			if refreshable && errors.Is(err, errorz.ErrTokenExpired) {
+				claims, err = cfg.session.refresh(w, r, validator, cfg.csrf)
+			}
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), claims)))
+			case errors.Is(err, errorz.ErrTokenExpired):
+				cfg.challenge(w, http.StatusUnauthorized, "invalid_token", "the access token expired")
+			case errors.Is(err, errorz.ErrTokenRevoked):
+				cfg.challenge(w, http.StatusUnauthorized, "invalid_token", "the access token was revoked")
+			case errors.Is(err, errorz.ErrInvalidToken):
+				cfg.challenge(w, http.StatusUnauthorized, "invalid_token", "the access token is invalid")
+			default:
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		})
+	}
+}
+
+var errMalformedHeader = errors.New("malformed Authorization header")
+
+// token returns the token of r and whether it came from the Authorization header.
+func (c config) token(r *http.Request) (string, bool, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return "", true, errMalformedHeader
+		}
+
+		return token, true, nil
+	}
+	if c.session != nil {
+		if token := c.session.cookies.AccessToken(r); token != "" {
+			return token, false, nil
+		}
+	}
+	if c.cookieName != "" {
+		if cookie, err := r.Cookie(c.cookieName); err == nil {
+			return cookie.Value, false, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func (c config) challenge(w http.ResponseWriter, status int, code, description string) {
+	params := make([]string, 0, 3)
+	if c.realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", c.realm))
+	}
+	if code != "" {
+		params = append(params, fmt.Sprintf("error=%q", code), fmt.Sprintf("error_description=%q", description))
+	}
+	value := "Bearer"
+	if len(params) > 0 {
+		value += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", value)
+	http.Error(w, http.StatusText(status), status)
+}
+
+type claimsKey struct{}
+
+func ContextWithClaims(ctx context.Context, claims authclient.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by Middleware; ok is false for
+// anonymous requests.
+func ClaimsFromContext(ctx context.Context) (authclient.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(authclient.Claims)
+
+	return claims, ok
+}
+
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+
+	return claims.UserID, ok
+}
@@ -0,0 +1,313 @@
+package httpauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpauth"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	validator := httpauth.ValidatorFunc(func(_ context.Context, token string) (authclient.Claims, error) {
+		switch token {
+		case "valid":
+			return authclient.Claims{UserID: userID}, nil
+		case "expired":
+			return authclient.Claims{}, errorz.ErrTokenExpired
+		default:
+			return authclient.Claims{}, errorz.ErrInvalidToken
+		}
+	})
+	handler := func(opts ...httpauth.Option) http.Handler {
+		return httpauth.Middleware(validator, opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id, ok := httpauth.UserIDFromContext(r.Context()); ok {
+				_, _ = w.Write([]byte(id.String()))
+			}
+		}))
+	}
+	serve := func(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer valid")
+		w := serve(handler(), r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, userID.String(), w.Body.String())
+	})
+
+	t.Run("cookie token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(&http.Cookie{Name: "session", Value: "valid"})
+		w := serve(handler(httpauth.WithCookie("session")), r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, userID.String(), w.Body.String())
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(handler(httpauth.WithRealm("api")), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `Bearer realm="api"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer expired")
+		w := serve(handler(httpauth.WithRealm("api")), r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t,
+			`Bearer realm="api", error="invalid_token", error_description="the access token expired"`,
+			w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		w := serve(handler(), r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="invalid_request"`)
+	})
+
+	t.Run("optional lets anonymous requests through", func(t *testing.T) {
+		t.Parallel()
+
+		w := serve(handler(httpauth.Optional()), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("optional still rejects invalid tokens", func(t *testing.T) {
+		t.Parallel()
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer forged")
+		w := serve(handler(httpauth.Optional()), r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+type refresherFunc func(ctx context.Context, refreshToken string) (authclient.Tokens, error)
+
+func (f refresherFunc) Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error) {
+	return f(ctx, refreshToken)
+}
+
+func TestMiddleware_SessionCookies(t *testing.T) {
+	t.Parallel()
+
+	userID := uuid.New()
+	validator := httpauth.ValidatorFunc(func(_ context.Context, token string) (authclient.Claims, error) {
+		switch token {
+		case "valid", "refreshed":
+			return authclient.Claims{UserID: userID}, nil
+		case "expired":
+			return authclient.Claims{}, errorz.ErrTokenExpired
+		default:
+			return authclient.Claims{}, errorz.ErrInvalidToken
+		}
+	})
+	handler := func(refresher httpauth.Refresher) http.Handler {
+		return httpauth.Middleware(validator, httpauth.WithSessionCookies(httpauth.SessionCookies{}, refresher))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if id, ok := httpauth.UserIDFromContext(r.Context()); ok {
+					_, _ = w.Write([]byte(id.String()))
+				}
+			}))
+	}
+	request := func(access, refresh string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if access != "" {
+			r.AddCookie(&http.Cookie{Name: "vauth_access", Value: access})
+		}
+		if refresh != "" {
+			r.AddCookie(&http.Cookie{Name: "vauth_refresh", Value: refresh})
+		}
+
+		return r
+	}
+	noRefresh := refresherFunc(func(context.Context, string) (authclient.Tokens, error) {
+		return authclient.Tokens{}, errors.New("unexpected refresh")
+	})
+
+	t.Run("valid access cookie", func(t *testing.T) {
+		t.Parallel()
+
+		w := httptest.NewRecorder()
+		handler(noRefresh).ServeHTTP(w, request("valid", "refresh"))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, userID.String(), w.Body.String())
+		assert.Empty(t, w.Header().Values("Set-Cookie"))
+	})
+
+	t.Run("expired or missing access cookie is refreshed", func(t *testing.T) {
+		t.Parallel()
+
+		for _, access := range []string{"expired", ""} {
+			var calls atomic.Int32
+			refresher := refresherFunc(func(_ context.Context, refreshToken string) (authclient.Tokens, error) {
+				calls.Add(1)
+				assert.Equal(t, "refresh", refreshToken)
+
+				return newTokens("refreshed", "refresh2"), nil
+			})
+
+			w := httptest.NewRecorder()
+			handler(refresher).ServeHTTP(w, request(access, "refresh"))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, userID.String(), w.Body.String())
+			assert.EqualValues(t, 1, calls.Load())
+			var cookies httpauth.SessionCookies
+			r := requestWith(w)
+			assert.Equal(t, "refreshed", cookies.AccessToken(r))
+			assert.Equal(t, "refresh2", cookies.RefreshToken(r))
+		}
+	})
+
+	t.Run("refresh rotates the CSRF token", func(t *testing.T) {
+		t.Parallel()
+
+		csrf, err := httpauth.NewCSRF([]byte("0123456789abcdef0123456789abcdef"))
+		require.NoError(t, err)
+		h := httpauth.Middleware(validator,
+			httpauth.WithSessionCookies(httpauth.SessionCookies{}, refresherFunc(
+				func(context.Context, string) (authclient.Tokens, error) {
+					return newTokens("refreshed", "refresh2"), nil
+				})),
+			httpauth.WithCSRF(csrf),
+		)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("expired", "refresh"))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		r := requestWith(w)
+		r.Method = http.MethodPost
+		token, err := r.Cookie("vauth_csrf")
+		require.NoError(t, err)
+		r.Header.Set("X-CSRF-Token", token.Value)
+		assert.NoError(t, csrf.Check(r))
+	})
+
+	t.Run("parallel requests share one refresh", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		h := handler(refresherFunc(func(context.Context, string) (authclient.Tokens, error) {
+			calls.Add(1)
+			<-release
+
+			return newTokens("refreshed", "refresh2"), nil
+		}))
+
+		var wg sync.WaitGroup
+		codes := make([]int, 5)
+		for i := range codes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, request("expired", "refresh"))
+				codes[i] = w.Code
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.EqualValues(t, 1, calls.Load())
+		assert.Equal(t, []int{200, 200, 200, 200, 200}, codes)
+
+		// A straggler with the old cookies gets the same tokens.
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("expired", "refresh"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("used refresh token alone is not shared", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		h := handler(refresherFunc(func(context.Context, string) (authclient.Tokens, error) {
+			if calls.Add(1) > 1 {
+				return authclient.Tokens{}, errorz.ErrTokenRevoked
+			}
+
+			return newTokens("refreshed", "refresh2"), nil
+		}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, request("expired", "refresh"))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, request("", "refresh"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("failed refresh clears cookies", func(t *testing.T) {
+		t.Parallel()
+
+		w := httptest.NewRecorder()
+		handler(refresherFunc(func(context.Context, string) (authclient.Tokens, error) {
+			return authclient.Tokens{}, errorz.ErrTokenRevoked
+		})).ServeHTTP(w, request("expired", "refresh"))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		set := w.Result().Cookies()
+		require.Len(t, set, 2)
+		for _, cookie := range set {
+			assert.Negative(t, cookie.MaxAge)
+		}
+	})
+
+	t.Run("bearer header is not refreshed", func(t *testing.T) {
+		t.Parallel()
+
+		r := request("", "refresh")
+		r.Header.Set("Authorization", "Bearer expired")
+		w := httptest.NewRecorder()
+		handler(noRefresh).ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,140 @@
+package httpauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+)
+
+// refreshGrace is how long the result of a refresh is reused for requests
+// still carrying the old cookies. Browsers send parallel requests with the
+// same cookies, and since refresh tokens work once, refreshing each of them
+// would be taken for token theft and revoke the session.
+const refreshGrace = 5 * time.Second
+
+type session struct {
+	cookies   SessionCookies
+	refresher Refresher
+
+	mu    sync.Mutex
+	calls map[string]*refreshCall
+}
+
+type refreshCall struct {
+	done        chan struct{}
+	accessToken string
+	tokens      authclient.Tokens
+	err         error
+	expires     time.Time
+}
+
+func newSession(cookies SessionCookies, refresher Refresher) *session {
+	return &session{
+		cookies:   cookies,
+		refresher: refresher,
+		mu:        sync.Mutex{},
+		calls:     make(map[string]*refreshCall),
+	}
+}
+
+// refresh exchanges the refresh cookie of r for new tokens, sets them as
+// cookies on w and returns the claims of the new access token. csrf, when
+// set, is rotated along with the cookies.
+func (s *session) refresh(
+	w http.ResponseWriter,
+	r *http.Request,
+	validator Validator,
+	csrf *CSRF,
+) (authclient.Claims, error) {
+	tokens, err := s.do(r.Context(), s.cookies.RefreshToken(r), s.cookies.AccessToken(r))
+	if err != nil {
+		if errors.Is(err, errorz.ErrTokenExpired) || errors.Is(err, errorz.ErrTokenRevoked) ||
+			errors.Is(err, errorz.ErrInvalidToken) {
+			s.cookies.Clear(w, r)
+			if csrf != nil {
+				csrf.RotateSession(w, authclient.Tokens{})
+			}
+		}
+
+		return authclient.Claims{}, err
+	}
+
+	claims, err := validator.ValidateToken(r.Context(), tokens.AccessToken.Token)
+	if err != nil {
+		return authclient.Claims{}, err
+	}
+	s.cookies.SetTokens(w, r, tokens)
+	if csrf != nil {
+		csrf.RotateSession(w, tokens)
+	}
+
+	return claims, nil
+}
+
+// do refreshes refreshToken once, sharing the result with callers presenting
+// the same token while the refresh is in flight. For refreshGrace after it,
+// the result is only shared with callers that also present the access token
+// of the request that refreshed; anyone else refreshes the used token, which
+// revokes the session. Only a single process is covered; behind a load
+// balancer, route a session to one instance or expect the occasional parallel
+// refresh to end it.
+func (s *session) do(ctx context.Context, refreshToken string, accessToken string) (authclient.Tokens, error) {
+	s.mu.Lock()
+	now := time.Now()
+	for token, call := range s.calls {
+		if !call.expires.IsZero() && now.After(call.expires) {
+			delete(s.calls, token)
+		}
+	}
+	if call, ok := s.calls[refreshToken]; ok && call.shared(accessToken) {
+		s.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.tokens, call.err
+		case <-ctx.Done():
+			return authclient.Tokens{}, ctx.Err()
+		}
+	}
+	call := &refreshCall{
+		done:        make(chan struct{}),
+		accessToken: accessToken,
+		tokens:      authclient.Tokens{},
+		err:         nil,
+		expires:     time.Time{},
+	}
+	s.calls[refreshToken] = call
+	s.mu.Unlock()
+
+	call.tokens, call.err = s.refresher.Refresh(context.WithoutCancel(ctx), refreshToken)
+
+	s.mu.Lock()
+	if call.err != nil || call.accessToken == "" {
+		if s.calls[refreshToken] == call {
+			delete(s.calls, refreshToken)
+		}
+	} else {
+		call.expires = time.Now().Add(refreshGrace)
+	}
+	s.mu.Unlock()
+	close(call.done)
+
+	return call.tokens, call.err
+}
+
+// shared reports whether a caller presenting accessToken may take the result
+// of call.
+func (call *refreshCall) shared(accessToken string) bool {
+	select {
+	case <-call.done:
+		return call.accessToken != "" &&
+			subtle.ConstantTimeCompare([]byte(call.accessToken), []byte(accessToken)) == 1
+	default:
+		return true
+	}
+}
@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpapi"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	maxResponseBody = 1 << 20
+)
+
+// Client calls the REST API of a remote vauthd, described by httpapi.OpenAPI.
+// It implements httpapi.Client, so code written against the methods of
+// *authclient.Client can switch from the embedded library to a remote server.
+//
+// Errors answered by the server wrap the errorz error of their problem code
+// and can be matched with errors.Is and errors.As as with the embedded client.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+}
+
+var _ httpapi.Client = (*Client)(nil)
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default client, which times out after 10 seconds.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New returns a client of the API served at baseURL, e.g. "https://auth.internal/v1".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, werr.Wrapf(errorz.ErrInvalidBaseURL, "%q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+type credentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type emailRequest struct {
+	Email string `json:"email"`
+}
+
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+type verifyMFARequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+type resetPasswordRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (c *Client) Register(ctx context.Context, dto authclient.RegisterParams) error {
+	return c.post(ctx, "/register", "", credentialsRequest{Email: dto.Email, Password: dto.Password}, nil)
+}
+
+// LoginTokens returns a *errorz.MFARequiredError for users with a second factor.
+func (c *Client) LoginTokens(ctx context.Context, dto authclient.LoginParams) (authclient.Tokens, error) {
+	var resp httpapi.TokenResponse
+	req := credentialsRequest{Email: dto.Email, Password: dto.Password}
+	if err := c.post(ctx, "/login", "", req, &resp); err != nil {
+		return authclient.Tokens{}, err
+	}
+
+	return tokens(resp), nil
+}
+
+// VerifyMFATokens finishes a login with the challenge of a *errorz.MFARequiredError.
+func (c *Client) VerifyMFATokens(ctx context.Context, challenge string, code string) (authclient.Tokens, error) {
+	var resp httpapi.TokenResponse
+	if err := c.post(ctx, "/mfa/verify", "", verifyMFARequest{Challenge: challenge, Code: code}, &resp); err != nil {
+		return authclient.Tokens{}, err
+	}
+
+	return tokens(resp), nil
+}
+
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (authclient.Tokens, error) {
+	var resp httpapi.TokenResponse
+	if err := c.post(ctx, "/refresh", "", refreshRequest{RefreshToken: refreshToken}, &resp); err != nil {
+		return authclient.Tokens{}, err
+	}
+
+	return tokens(resp), nil
+}
+
+func (c *Client) Logout(ctx context.Context, dto authclient.LogoutParams) error {
+	var body any
+	if dto.RefreshToken != "" {
+		body = refreshRequest{RefreshToken: dto.RefreshToken}
+	}
+
+	return c.post(ctx, "/logout", dto.AccessToken, body, nil)
+}
+
+func (c *Client) ConfirmEmail(ctx context.Context, dto authclient.ConfirmEmailParams) error {
+	return c.post(ctx, "/email/confirm", "", codeRequest{Code: dto.Code}, nil)
+}
+
+func (c *Client) SendConfirmationEmail(ctx context.Context, dto authclient.SendConfirmationEmailParams) error {
+	return c.post(ctx, "/email/resend", "", emailRequest{Email: dto.Email}, nil)
+}
+
+func (c *Client) ForgotPassword(ctx context.Context, dto authclient.ForgotPasswordParams) error {
+	return c.post(ctx, "/password/forgot", "", emailRequest{Email: dto.Email}, nil)
+}
+
+func (c *Client) ResetPassword(ctx context.Context, dto authclient.ResetPasswordParams) error {
+	return c.post(ctx, "/password/reset", "", resetPasswordRequest{Code: dto.Code, Password: dto.Password}, nil)
+}
+
+// post sends body as JSON and decodes a successful response into dst unless
+// it is nil. The RequestInfo of ctx is forwarded as User-Agent,
+// Accept-Language and X-Forwarded-For; the server trusts the latter only when
+// configured to.
+func (c *Client) post(ctx context.Context, path, bearer string, body, dst any) error {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return werr.Wrap(err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL.JoinPath(path).String(), reader)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	info := authclient.RequestInfoFromContext(ctx)
+	if info.UserAgent != "" {
+		req.Header.Set("User-Agent", info.UserAgent)
+	}
+	if info.Locale != "" {
+		req.Header.Set("Accept-Language", info.Locale)
+	}
+	if info.IP != "" {
+		req.Header.Set("X-Forwarded-For", info.IP)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	defer resp.Body.Close()
+	respBody := io.LimitReader(resp.Body, maxResponseBody)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return werr.Wrap(responseError(resp, respBody))
+	}
+	if dst == nil {
+		_, _ = io.Copy(io.Discard, respBody)
+
+		return nil
+	}
+	if err := json.NewDecoder(respBody).Decode(dst); err != nil {
+		return werr.Wrapf(errorz.ErrUnexpectedResponse, "decode %s response: %v", path, err)
+	}
+
+	return nil
+}
+
+// responseError turns a problem response into the error the embedded client
+// would have returned.
+func responseError(resp *http.Response, body io.Reader) error {
+	var problem httpapi.Problem
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") ||
+		json.NewDecoder(body).Decode(&problem) != nil {
+		return werr.Wrapf(errorz.ErrUnexpectedResponse, "status %d", resp.StatusCode)
+	}
+
+	retryAfter := time.Duration(0)
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	err := errorz.FromCode(problem.Code)
+	switch {
+	case errors.Is(err, errorz.ErrMFARequired):
+		return &errorz.MFARequiredError{
+			Challenge: problem.Challenge,
+			Factor:    problem.Factor,
+			Factors:   problem.Factors,
+		}
+	case errors.Is(err, errorz.ErrAccountLocked):
+		return &errorz.AccountLockedError{RetryAfter: retryAfter}
+	case errors.Is(err, errorz.ErrRateLimited):
+		return &errorz.RateLimitedError{RetryAfter: retryAfter}
+	case err != nil:
+		return err
+	default:
+		return werr.Wrapf(errorz.ErrUnexpectedResponse, "status %d: %s: %s", resp.StatusCode, problem.Code, problem.Detail)
+	}
+}
+
+func tokens(resp httpapi.TokenResponse) authclient.Tokens {
+	now := time.Now()
+
+	return authclient.Tokens{
+		AccessToken: jwtgen.Token{
+			Token:     resp.AccessToken,
+			ExpiresAt: expiresAt(now, resp.ExpiresIn),
+		},
+		RefreshToken: jwtgen.Token{
+			Token:     resp.RefreshToken,
+			ExpiresAt: expiresAt(now, resp.RefreshExpiresIn),
+		},
+	}
+}
+
+func expiresAt(now time.Time, expiresIn int64) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(time.Duration(expiresIn) * time.Second)
+}
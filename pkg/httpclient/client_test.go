@@ -0,0 +1,218 @@
+package httpclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpapi"
+	httpapimocks "github.com/github.com/VadimOcLock/vauth/pkg/httpapi/mocks"
+	"github.com/github.com/VadimOcLock/vauth/pkg/httpclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/matchsystems/werr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newClient serves the handler under /v1 like a mounted vauthd.
+func newClient(t *testing.T, server httpapi.Client, opts ...httpapi.Option) *httpclient.Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", http.StripPrefix("/v1", httpapi.New(server, opts...)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client, err := httpclient.New(srv.URL+"/v1/", httpclient.WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("login returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("LoginTokens", mock.Anything, authclient.LoginParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		}).Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		tokens, err := newClient(t, server).LoginTokens(context.Background(), authclient.LoginParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", tokens.AccessToken.Token)
+		assert.Equal(t, "refresh", tokens.RefreshToken.Token)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), tokens.AccessToken.ExpiresAt, 2*time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), tokens.RefreshToken.ExpiresAt, 2*time.Second)
+	})
+
+	t.Run("mfa verify returns tokens", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("VerifyMFATokens", mock.Anything, "challenge", "123456").Return(authclient.Tokens{
+			AccessToken:  jwtgen.Token{Token: "access", ExpiresAt: time.Now().Add(15 * time.Minute)},
+			RefreshToken: jwtgen.Token{Token: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}, nil)
+
+		tokens, err := newClient(t, server).VerifyMFATokens(context.Background(), "challenge", "123456")
+
+		require.NoError(t, err)
+		assert.Equal(t, "access", tokens.AccessToken.Token)
+		assert.Equal(t, "refresh", tokens.RefreshToken.Token)
+	})
+
+	t.Run("problems become errorz errors", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("Register", mock.Anything, mock.Anything).Return(werr.Wrap(errorz.ErrLoginAlreadyExists))
+		server.On("ResetPassword", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+		client := newClient(t, server)
+
+		err := client.Register(context.Background(), authclient.RegisterParams{
+			Email:    "test@example.com",
+			Password: "securepassword",
+		})
+		require.ErrorIs(t, err, errorz.ErrLoginAlreadyExists)
+		assert.Equal(t, "login_already_exists", errorz.Code(err))
+
+		err = client.ResetPassword(context.Background(), authclient.ResetPasswordParams{
+			Code:     "123456",
+			Password: "securepassword",
+		})
+		require.ErrorIs(t, err, errorz.ErrUnexpectedResponse)
+		assert.NotContains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("typed errors keep their details", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("LoginTokens", mock.Anything, authclient.LoginParams{Email: "mfa@example.com", Password: "password"}).
+			Return(authclient.Tokens{}, &errorz.MFARequiredError{
+				Challenge: "challenge",
+				Factor:    "totp",
+				Factors:   []string{"totp", "email"},
+			})
+		server.On("LoginTokens", mock.Anything, authclient.LoginParams{Email: "locked@example.com", Password: "password"}).
+			Return(authclient.Tokens{}, &errorz.AccountLockedError{RetryAfter: 90 * time.Second})
+		server.On("ForgotPassword", mock.Anything, mock.Anything).
+			Return(&errorz.RateLimitedError{RetryAfter: 1500 * time.Millisecond})
+		client := newClient(t, server)
+
+		_, err := client.LoginTokens(context.Background(), authclient.LoginParams{
+			Email:    "mfa@example.com",
+			Password: "password",
+		})
+		var mfaRequired *errorz.MFARequiredError
+		require.ErrorAs(t, err, &mfaRequired)
+		assert.Equal(t, &errorz.MFARequiredError{
+			Challenge: "challenge",
+			Factor:    "totp",
+			Factors:   []string{"totp", "email"},
+		}, mfaRequired)
+
+		_, err = client.LoginTokens(context.Background(), authclient.LoginParams{
+			Email:    "locked@example.com",
+			Password: "password",
+		})
+		var locked *errorz.AccountLockedError
+		require.ErrorAs(t, err, &locked)
+		assert.Equal(t, 90*time.Second, locked.RetryAfter)
+
+		err = client.ForgotPassword(context.Background(), authclient.ForgotPasswordParams{Email: "test@example.com"})
+		var rateLimited *errorz.RateLimitedError
+		require.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, 2*time.Second, rateLimited.RetryAfter)
+	})
+
+	t.Run("logout sends bearer and refresh token", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("Logout", mock.Anything, authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+		}).Return(nil)
+		server.On("Logout", mock.Anything, authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "",
+		}).Return(nil)
+		client := newClient(t, server)
+
+		require.NoError(t, client.Logout(context.Background(), authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+		}))
+		require.NoError(t, client.Logout(context.Background(), authclient.LogoutParams{
+			AccessToken:  "access",
+			RefreshToken: "",
+		}))
+	})
+
+	t.Run("request info is forwarded", func(t *testing.T) {
+		t.Parallel()
+
+		server := httpapimocks.NewClient(t)
+		server.On("ConfirmEmail", mock.MatchedBy(func(ctx context.Context) bool {
+			return authclient.RequestInfoFromContext(ctx) == authclient.RequestInfo{
+				IP:        "203.0.113.7",
+				UserAgent: "browser",
+				Locale:    "de-DE",
+			}
+		}), authclient.ConfirmEmailParams{Code: "123456"}).Return(nil)
+		client := newClient(t, server, httpapi.WithClientIP(func(r *http.Request) string {
+			return r.Header.Get("X-Forwarded-For")
+		}))
+
+		ctx := authclient.ContextWithRequestInfo(context.Background(), authclient.RequestInfo{
+			IP:        "203.0.113.7",
+			UserAgent: "browser",
+			Locale:    "de-DE",
+		})
+		require.NoError(t, client.ConfirmEmail(ctx, authclient.ConfirmEmailParams{Code: "123456"}))
+	})
+
+	t.Run("non-problem responses are unexpected", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}))
+		t.Cleanup(srv.Close)
+		client, err := httpclient.New(srv.URL)
+		require.NoError(t, err)
+
+		err = client.SendConfirmationEmail(context.Background(), authclient.SendConfirmationEmailParams{
+			Email: "test@example.com",
+		})
+
+		require.ErrorIs(t, err, errorz.ErrUnexpectedResponse)
+	})
+
+	t.Run("invalid base URL", func(t *testing.T) {
+		t.Parallel()
+
+		for _, baseURL := range []string{"", "auth.internal", "ftp://auth.internal", "http://"} {
+			_, err := httpclient.New(baseURL)
+			require.ErrorIs(t, err, errorz.ErrInvalidBaseURL, baseURL)
+		}
+	})
+}
@@ -1,9 +1,10 @@
 package jwtgen
 
 import (
+	"crypto"
 	"time"
 
-	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/matchsystems/werr"
 )
 
@@ -15,7 +16,9 @@ type Creator interface {
 }
 
 type creatorImpl struct {
-	secretKey       []byte
+	method          jwt.SigningMethod
+	signingKey      any
+	keyID           string
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 	resetTokenTTL   time.Duration
@@ -24,18 +27,28 @@ type creatorImpl struct {
 
 var _ Creator = (*creatorImpl)(nil)
 
+// CreatorConfig signs tokens with HS256 and SecretKey, unless SigningKey is set.
 type CreatorConfig struct {
 	SecretKey []byte
+	// SigningKey is an *rsa.PrivateKey (RS256), *ecdsa.PrivateKey (ES256,
+	// ES384 or ES512 by curve) or ed25519.PrivateKey (EdDSA). Its public key
+	// is published by NewJWKS.
+	SigningKey crypto.Signer
+	// KeyID is the "kid" header of signed tokens and JWKS.
+	KeyID     string
 	TokenOpts []CreatorOption
 }
 
 func NewCreator(cfg CreatorConfig) (Creator, error) {
-	if len(cfg.SecretKey) == 0 {
-		return nil, werr.Wrap(errorz.ErrJWTSecretKeyRequired)
+	method, key, err := cfg.signing()
+	if err != nil {
+		return nil, werr.Wrap(err)
 	}
 
 	creator := &creatorImpl{
-		secretKey:       cfg.SecretKey,
+		method:          method,
+		signingKey:      key,
+		keyID:           cfg.KeyID,
 		accessTokenTTL:  defaultAccessTokenTTL,
 		refreshTokenTTL: defaultRefreshTokenTTL,
 		resetTokenTTL:   defaultResetTokenTTL,
@@ -12,8 +12,11 @@ func (c creatorImpl) createToken(claims jwt.MapClaims, ttl time.Duration) (Token
 	claims["exp"] = expAt.Unix()
 	claims["iat"] = time.Now().Unix()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(c.secretKey)
+	token := jwt.NewWithClaims(c.method, claims)
+	if c.keyID != "" {
+		token.Header["kid"] = c.keyID
+	}
+	signedToken, err := token.SignedString(c.signingKey)
 	if err != nil {
 		return Token{}, werr.Wrap(err)
 	}
@@ -26,6 +29,7 @@ func (c creatorImpl) createToken(claims jwt.MapClaims, ttl time.Duration) (Token
 
 func (c creatorImpl) CreateAccessToken(userID string) (Token, error) {
 	claims := jwt.MapClaims{
+		"typ":     tokenTypeAccess,
 		"user_id": userID,
 	}
 
@@ -34,6 +38,7 @@ func (c creatorImpl) CreateAccessToken(userID string) (Token, error) {
 
 func (c creatorImpl) CreateRefreshToken(userID string) (Token, error) {
 	claims := jwt.MapClaims{
+		"typ":     tokenTypeRefresh,
 		"user_id": userID,
 	}
 
@@ -42,6 +47,7 @@ func (c creatorImpl) CreateRefreshToken(userID string) (Token, error) {
 
 func (c creatorImpl) CreateResetToken(email string) (Token, error) {
 	claims := jwt.MapClaims{
+		"typ":   tokenTypeReset,
 		"email": email,
 	}
 
@@ -50,6 +56,7 @@ func (c creatorImpl) CreateResetToken(email string) (Token, error) {
 
 func (c creatorImpl) CreateVerifyToken(email string) (Token, error) {
 	claims := jwt.MapClaims{
+		"typ":   tokenTypeVerify,
 		"email": email,
 	}
 
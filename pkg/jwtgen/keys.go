@@ -0,0 +1,143 @@
+package jwtgen
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/matchsystems/werr"
+)
+
+// signing returns the method and key tokens are signed with.
+func (cfg CreatorConfig) signing() (jwt.SigningMethod, any, error) {
+	switch key := cfg.SigningKey.(type) {
+	case nil:
+		if len(cfg.SecretKey) == 0 {
+			return nil, nil, errorz.ErrJWTSecretKeyRequired
+		}
+
+		return jwt.SigningMethodHS256, cfg.SecretKey, nil
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, key, nil
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, key, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, key, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, key, nil
+		}
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, key, nil
+	}
+
+	return nil, nil, errorz.ErrUnsupportedSigningKey
+}
+
+// ParsePrivateKeyPEM parses a PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, werr.Wrap(errorz.ErrUnsupportedSigningKey)
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, werr.Wrapf(errorz.ErrUnsupportedSigningKey, "PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, werr.Wrap(errorz.ErrUnsupportedSigningKey)
+	}
+
+	return signer, nil
+}
+
+// JWK is a public JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKS returns the key set that verifies tokens signed with cfg.SigningKey.
+// A SecretKey is never published: it fails with errorz.ErrNoPublicKey.
+func NewJWKS(cfg CreatorConfig) (JWKSet, error) {
+	method, _, err := cfg.signing()
+	if err != nil {
+		return JWKSet{}, werr.Wrap(err)
+	}
+
+	jwk := JWK{
+		KeyType:   "",
+		Use:       "sig",
+		Algorithm: method.Alg(),
+		KeyID:     cfg.KeyID,
+		Curve:     "",
+		N:         "",
+		E:         "",
+		X:         "",
+		Y:         "",
+	}
+	switch key := cfg.SigningKey.(type) {
+	case *rsa.PrivateKey:
+		jwk.KeyType = "RSA"
+		jwk.N = encodeBase64(key.N.Bytes())
+		jwk.E = encodeBase64(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PrivateKey:
+		public, err := key.PublicKey.ECDH()
+		if err != nil {
+			return JWKSet{}, werr.Wrap(err)
+		}
+		// Uncompressed point: 0x04 || X || Y.
+		point := public.Bytes()[1:]
+		jwk.KeyType = "EC"
+		jwk.Curve = key.Curve.Params().Name
+		jwk.X = encodeBase64(point[:len(point)/2])
+		jwk.Y = encodeBase64(point[len(point)/2:])
+	case ed25519.PrivateKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = encodeBase64(key.Public().(ed25519.PublicKey))
+	default:
+		return JWKSet{}, werr.Wrap(errorz.ErrNoPublicKey)
+	}
+
+	return JWKSet{Keys: []JWK{jwk}}, nil
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,99 @@
+package jwtgen_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKeys(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+		kty  string
+	}{
+		{"rsa", rsaKey, "RS256", "RSA"},
+		{"ecdsa", ecKey, "ES256", "EC"},
+		{"ed25519", edKey, "EdDSA", "OKP"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := jwtgen.CreatorConfig{SigningKey: tc.key, KeyID: "key-1"}
+			creator, err := jwtgen.NewCreator(cfg)
+			require.NoError(t, err)
+			verifier, err := jwtgen.NewVerifier(cfg)
+			require.NoError(t, err)
+
+			token, err := creator.CreateAccessToken("user-1")
+			require.NoError(t, err)
+			claims, err := verifier.ParseAccessToken(token.Token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.UserID)
+
+			jwks, err := jwtgen.NewJWKS(cfg)
+			require.NoError(t, err)
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tc.alg, jwks.Keys[0].Algorithm)
+			assert.Equal(t, tc.kty, jwks.Keys[0].KeyType)
+			assert.Equal(t, "key-1", jwks.Keys[0].KeyID)
+		})
+	}
+
+	t.Run("tokens of another key are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		creator, err := jwtgen.NewCreator(jwtgen.CreatorConfig{SigningKey: otherKey})
+		require.NoError(t, err)
+		verifier, err := jwtgen.NewVerifier(jwtgen.CreatorConfig{SigningKey: edKey})
+		require.NoError(t, err)
+
+		token, err := creator.CreateAccessToken("user-1")
+		require.NoError(t, err)
+		_, err = verifier.ParseAccessToken(token.Token)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidToken)
+	})
+
+	t.Run("secret keys are not published", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := jwtgen.NewJWKS(jwtgen.CreatorConfig{SecretKey: []byte("secret")})
+
+		require.ErrorIs(t, err, errorz.ErrNoPublicKey)
+	})
+
+	t.Run("parse PEM", func(t *testing.T) {
+		t.Parallel()
+
+		der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+		require.NoError(t, err)
+		key, err := jwtgen.ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+		require.NoError(t, err)
+		assert.True(t, ecKey.Equal(key))
+	})
+}
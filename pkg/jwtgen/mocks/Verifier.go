@@ -0,0 +1,83 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	jwtgen "github.com/github.com/VadimOcLock/vauth/pkg/jwtgen"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Verifier is an autogenerated mock type for the Verifier type
+type Verifier struct {
+	mock.Mock
+}
+
+// ParseAccessToken provides a mock function with given fields: token
+func (_m *Verifier) ParseAccessToken(token string) (jwtgen.Claims, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseAccessToken")
+	}
+
+	var r0 jwtgen.Claims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (jwtgen.Claims, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) jwtgen.Claims); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(jwtgen.Claims)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ParseRefreshToken provides a mock function with given fields: token
+func (_m *Verifier) ParseRefreshToken(token string) (jwtgen.Claims, error) {
+	ret := _m.Called(token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ParseRefreshToken")
+	}
+
+	var r0 jwtgen.Claims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (jwtgen.Claims, error)); ok {
+		return rf(token)
+	}
+	if rf, ok := ret.Get(0).(func(string) jwtgen.Claims); ok {
+		r0 = rf(token)
+	} else {
+		r0 = ret.Get(0).(jwtgen.Claims)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewVerifier creates a new instance of Verifier. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewVerifier(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Verifier {
+	mock := &Verifier{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
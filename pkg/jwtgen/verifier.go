@@ -0,0 +1,87 @@
+package jwtgen
+
+import (
+	"crypto"
+	"errors"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+	tokenTypeReset   = "reset"
+	tokenTypeVerify  = "verify"
+)
+
+// Verifier checks the signature, expiry and type of tokens issued by a
+// Creator with the same secret key. Revocation is checked by the caller.
+type Verifier interface {
+	ParseAccessToken(token string) (Claims, error)
+	ParseRefreshToken(token string) (Claims, error)
+}
+
+type Claims struct {
+	UserID    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type verifierImpl struct {
+	method jwt.SigningMethod
+	key    any
+}
+
+var _ Verifier = (*verifierImpl)(nil)
+
+func NewVerifier(cfg CreatorConfig) (Verifier, error) {
+	method, key, err := cfg.signing()
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	return verifierImpl{method: method, key: key}, nil
+}
+
+func (v verifierImpl) ParseAccessToken(token string) (Claims, error) {
+	return v.parse(token, tokenTypeAccess)
+}
+
+func (v verifierImpl) ParseRefreshToken(token string) (Claims, error) {
+	return v.parse(token, tokenTypeRefresh)
+}
+
+func (v verifierImpl) parse(token string, tokenType string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return v.key, nil
+	}, jwt.WithValidMethods([]string{v.method.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return Claims{}, werr.Wrap(errorz.ErrTokenExpired)
+		}
+
+		return Claims{}, werr.Wrap(errorz.ErrInvalidToken)
+	}
+
+	typ, _ := claims["typ"].(string)
+	userID, _ := claims["user_id"].(string)
+	if typ != tokenType || userID == "" {
+		return Claims{}, werr.Wrap(errorz.ErrInvalidToken)
+	}
+	result := Claims{UserID: userID, IssuedAt: time.Time{}, ExpiresAt: time.Time{}}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		result.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		result.ExpiresAt = exp.Time
+	}
+
+	return result, nil
+}
@@ -0,0 +1,9 @@
+package mail
+
+func CacheSize(r Renderer) int {
+	impl, _ := r.(*rendererImpl)
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	return len(impl.cache)
+}
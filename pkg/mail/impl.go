@@ -0,0 +1,182 @@
+package mail
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+	"golang.org/x/text/language"
+)
+
+type compiledTemplate struct {
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+func (r *rendererImpl) Render(name string, data TemplateData) (Message, error) {
+	data.Locale = normalizeLocale(data.Locale)
+	tmpl, err := r.compiled(name, data.Locale)
+	if err != nil {
+		return Message{}, werr.Wrap(err)
+	}
+
+	var subject, html, text bytes.Buffer
+	if err = tmpl.subject.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, werr.Wrap(err)
+	}
+	if err = tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, werr.Wrap(err)
+	}
+	if err = tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, werr.Wrap(err)
+	}
+
+	return Message{
+		To:      data.To,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// templateFiles are the files a template is compiled from.
+type templateFiles struct {
+	layout  string
+	subject string
+	html    string
+	text    string
+}
+
+// compiled caches by the files the locale resolves to, so there is at most
+// one entry per combination of existing files whatever locales are asked for.
+func (r *rendererImpl) compiled(name string, locale string) (compiledTemplate, error) {
+	var files templateFiles
+	var err error
+	for _, f := range []struct {
+		dst  *string
+		name string
+		kind string
+	}{
+		{&files.layout, "layout", "html"},
+		{&files.subject, name, "subject"},
+		{&files.html, name, "html"},
+		{&files.text, name, "txt"},
+	} {
+		if *f.dst, err = r.lookup(f.name, f.kind, locale); err != nil {
+			return compiledTemplate{}, werr.Wrap(err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tmpl, ok := r.cache[files]; ok {
+		return tmpl, nil
+	}
+
+	tmpl, err := r.compile(name, files)
+	if err != nil {
+		return compiledTemplate{}, werr.Wrap(err)
+	}
+	r.cache[files] = tmpl
+
+	return tmpl, nil
+}
+
+func (r *rendererImpl) compile(name string, files templateFiles) (compiledTemplate, error) {
+	var layoutSrc, subjectSrc, htmlSrc, textSrc string
+	for _, f := range []struct {
+		dst  *string
+		file string
+	}{
+		{&layoutSrc, files.layout},
+		{&subjectSrc, files.subject},
+		{&htmlSrc, files.html},
+		{&textSrc, files.text},
+	} {
+		content, err := r.read(f.file)
+		if err != nil {
+			return compiledTemplate{}, werr.Wrap(err)
+		}
+		*f.dst = content
+	}
+
+	subject, err := texttemplate.New(name + ".subject").Parse(subjectSrc)
+	if err != nil {
+		return compiledTemplate{}, werr.Wrap(err)
+	}
+	html := htmltemplate.New(name + ".html")
+	for _, src := range []string{layoutSrc, subjectSrc, htmlSrc} {
+		if html, err = html.Parse(src); err != nil {
+			return compiledTemplate{}, werr.Wrap(err)
+		}
+	}
+	text, err := texttemplate.New(name + ".txt").Parse(textSrc)
+	if err != nil {
+		return compiledTemplate{}, werr.Wrap(err)
+	}
+
+	return compiledTemplate{
+		subject: subject,
+		html:    html,
+		text:    text,
+	}, nil
+}
+
+// lookup returns the most specific template file that exists in any source:
+// "name.pt-BR.kind.tmpl", then "name.pt.kind.tmpl", then "name.kind.tmpl".
+func (r *rendererImpl) lookup(name string, kind string, locale string) (string, error) {
+	for _, candidate := range localeCandidates(locale) {
+		file := name + "." + kind + ".tmpl"
+		if candidate != "" {
+			file = name + "." + candidate + "." + kind + ".tmpl"
+		}
+		for _, src := range r.sources {
+			if _, err := fs.Stat(src, file); err == nil {
+				return file, nil
+			}
+		}
+	}
+
+	return "", werr.Wrapf(errorz.ErrMailTemplateNotFound, "%s.%s", name, kind)
+}
+
+// read returns file from the first source that has it.
+func (r *rendererImpl) read(file string) (string, error) {
+	for _, src := range r.sources {
+		content, err := fs.ReadFile(src, file)
+		if err == nil {
+			return string(content), nil
+		}
+	}
+
+	return "", werr.Wrapf(errorz.ErrMailTemplateNotFound, "%s", file)
+}
+
+// normalizeLocale returns the canonical BCP 47 form of locale, such as
+// "pt-BR" for "pt_br", or "" when it is not a valid tag.
+func normalizeLocale(locale string) string {
+	tag, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if err != nil || tag == language.Und {
+		return ""
+	}
+
+	return tag.String()
+}
+
+func localeCandidates(locale string) []string {
+	if locale == "" {
+		return []string{""}
+	}
+	candidates := []string{locale}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, base)
+	}
+
+	return append(candidates, "")
+}
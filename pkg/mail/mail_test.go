@@ -0,0 +1,141 @@
+package mail_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTransport struct {
+	messages []mail.Message
+}
+
+func (t *recordingTransport) Send(_ context.Context, msg mail.Message) error {
+	t.messages = append(t.messages, msg)
+
+	return nil
+}
+
+func TestRenderer_Render(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default templates", func(t *testing.T) {
+		t.Parallel()
+
+		renderer, err := mail.NewRenderer()
+		require.NoError(t, err)
+
+		msg, err := renderer.Render(mail.TemplateReset, mail.TemplateData{
+			To:        "test@example.com",
+			Code:      "654321",
+			Link:      "https://example.com/reset?code=654321&x=<y>",
+			ExpiresAt: time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "test@example.com", msg.To)
+		assert.Equal(t, "Reset your password", msg.Subject)
+		assert.Contains(t, msg.HTML, "<title>Reset your password</title>")
+		assert.Contains(t, msg.HTML, `href="https://example.com/reset?code=654321&amp;x=%3cy%3e"`)
+		assert.Contains(t, msg.Text, "Code: 654321")
+		assert.Contains(t, msg.Text, "https://example.com/reset?code=654321&x=<y>")
+		assert.Contains(t, msg.Text, "2025-01-02 03:04 UTC")
+	})
+
+	t.Run("locale variant with fallback", func(t *testing.T) {
+		t.Parallel()
+
+		renderer, err := mail.NewRenderer(mail.WithTemplates(fstest.MapFS{
+			"confirmation.de.subject.tmpl": {Data: []byte(`{{define "subject"}}E-Mail bestätigen{{end}}`)},
+			"confirmation.de.txt.tmpl":     {Data: []byte(`Code: {{.Code}}`)},
+		}))
+		require.NoError(t, err)
+
+		msg, err := renderer.Render(mail.TemplateConfirmation, mail.TemplateData{
+			To:     "test@example.com",
+			Code:   "123456",
+			Locale: "de-AT",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "E-Mail bestätigen", msg.Subject)
+		assert.Equal(t, "Code: 123456\n", msg.Text)
+		assert.Contains(t, msg.HTML, "Confirm your email address")
+	})
+
+	t.Run("locale is normalized and cached by resolved files", func(t *testing.T) {
+		t.Parallel()
+
+		renderer, err := mail.NewRenderer(mail.WithTemplates(fstest.MapFS{
+			"confirmation.pt-BR.subject.tmpl": {Data: []byte(`{{define "subject"}}Confirme seu e-mail{{end}}`)},
+		}))
+		require.NoError(t, err)
+		cached := mail.CacheSize(renderer)
+
+		msg, err := renderer.Render(mail.TemplateConfirmation, mail.TemplateData{Locale: "pt_br"})
+		require.NoError(t, err)
+		assert.Equal(t, "Confirme seu e-mail", msg.Subject)
+		assert.Equal(t, cached+1, mail.CacheSize(renderer))
+
+		for _, locale := range []string{"en", "en-US", "fr-CA", "xx-invalid-<tag>", strings.Repeat("a", 300)} {
+			msg, err = renderer.Render(mail.TemplateConfirmation, mail.TemplateData{Locale: locale})
+			require.NoError(t, err)
+			assert.Equal(t, "Confirm your email address", msg.Subject)
+		}
+		assert.Equal(t, cached+1, mail.CacheSize(renderer))
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		t.Parallel()
+
+		renderer, err := mail.NewRenderer()
+		require.NoError(t, err)
+
+		_, err = renderer.Render("unknown", mail.TemplateData{})
+
+		require.ErrorIs(t, err, errorz.ErrMailTemplateNotFound)
+	})
+}
+
+func TestSender(t *testing.T) {
+	t.Parallel()
+
+	renderer, err := mail.NewRenderer()
+	require.NoError(t, err)
+	transport := &recordingTransport{}
+	sender := mail.NewSender(renderer, transport)
+	ctx := context.Background()
+
+	err = sender.SendEmail(ctx, authclient.EmailMessage{
+		Purpose: authclient.EmailPurposeLoginLink,
+		To:      "test@example.com",
+		Code:    "login-code",
+		Link:    "https://example.com/login?code=login-code",
+		UserID:  uuid.New(),
+		Data:    map[string]any{"AppName": "Acme"},
+	})
+	require.NoError(t, err)
+
+	err = sender.SendNewLogin(ctx, authclient.SecurityNotification{
+		To:         "test@example.com",
+		IP:         "203.0.113.7",
+		UserAgent:  "test-agent",
+		OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, transport.messages, 2)
+	assert.Equal(t, "Your sign-in link", transport.messages[0].Subject)
+	assert.Contains(t, transport.messages[0].HTML, "Acme")
+	assert.Equal(t, "New sign-in to your account", transport.messages[1].Subject)
+	assert.Contains(t, transport.messages[1].Text, "IP address: 203.0.113.7")
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"embed"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	TemplateConfirmation  = "confirmation"
+	TemplateReset         = "reset"
+	TemplateLoginLink     = "login_link"
+	TemplateMFACode       = "mfa_code"
+	TemplateUnlock        = "unlock"
+	TemplateSignUpAttempt = "signup_attempt"
+	TemplateSecurityAlert = "security_alert"
+)
+
+const (
+	AlertPasswordChanged = "password_changed"
+	AlertNewLogin        = "new_login"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+type TemplateData struct {
+	To         string
+	Code       string
+	Link       string
+	ExpiresAt  time.Time
+	UserID     uuid.UUID
+	Locale     string
+	Alert      string
+	IP         string
+	UserAgent  string
+	OccurredAt time.Time
+	Data       map[string]any
+}
+
+type Renderer interface {
+	Render(name string, data TemplateData) (Message, error)
+}
+
+type rendererImpl struct {
+	sources []fs.FS
+
+	mu    sync.Mutex
+	cache map[templateFiles]compiledTemplate
+}
+
+var _ Renderer = (*rendererImpl)(nil)
+
+type RendererOption func(*rendererImpl)
+
+// WithTemplates puts fsys in front of the default templates. Files in fsys use
+// the same names as the defaults ("reset.html.tmpl", "reset.de.html.tmpl", ...)
+// and replace them one by one.
+func WithTemplates(fsys fs.FS) RendererOption {
+	return func(r *rendererImpl) {
+		r.sources = append([]fs.FS{fsys}, r.sources...)
+	}
+}
+
+func NewRenderer(opts ...RendererOption) (Renderer, error) {
+	defaults, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	r := &rendererImpl{
+		sources: []fs.FS{defaults},
+		mu:      sync.Mutex{},
+		cache:   make(map[templateFiles]compiledTemplate),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, name := range []string{
+		TemplateConfirmation,
+		TemplateReset,
+		TemplateLoginLink,
+		TemplateMFACode,
+		TemplateUnlock,
+		TemplateSignUpAttempt,
+		TemplateSecurityAlert,
+	} {
+		if _, err = r.compiled(name, ""); err != nil {
+			return nil, werr.Wrap(err)
+		}
+	}
+
+	return r, nil
+}
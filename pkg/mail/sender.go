@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/authclient"
+	"github.com/matchsystems/werr"
+)
+
+type Transport interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Sender renders authclient emails with a Renderer and hands the finished
+// messages to a Transport. The template name is the email purpose.
+type Sender struct {
+	renderer  Renderer
+	transport Transport
+}
+
+var _ authclient.EmailSender = (*Sender)(nil)
+
+func NewSender(renderer Renderer, transport Transport) *Sender {
+	return &Sender{
+		renderer:  renderer,
+		transport: transport,
+	}
+}
+
+func (s *Sender) SendEmail(ctx context.Context, msg authclient.EmailMessage) error {
+	return s.send(ctx, string(msg.Purpose), TemplateData{
+		To:         msg.To,
+		Code:       msg.Code,
+		Link:       msg.Link,
+		ExpiresAt:  msg.ExpiresAt,
+		UserID:     msg.UserID,
+		Locale:     msg.Locale,
+		Alert:      "",
+		IP:         "",
+		UserAgent:  "",
+		OccurredAt: time.Time{},
+		Data:       msg.Data,
+	})
+}
+
+func (s *Sender) SendPasswordChanged(ctx context.Context, notification authclient.SecurityNotification) error {
+	return s.send(ctx, TemplateSecurityAlert, alertData(AlertPasswordChanged, notification))
+}
+
+func (s *Sender) SendNewLogin(ctx context.Context, notification authclient.SecurityNotification) error {
+	return s.send(ctx, TemplateSecurityAlert, alertData(AlertNewLogin, notification))
+}
+
+func (s *Sender) send(ctx context.Context, name string, data TemplateData) error {
+	msg, err := s.renderer.Render(name, data)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(s.transport.Send(ctx, msg))
+}
+
+func alertData(alert string, notification authclient.SecurityNotification) TemplateData {
+	return TemplateData{
+		To:         notification.To,
+		Code:       "",
+		Link:       "",
+		ExpiresAt:  time.Time{},
+		UserID:     notification.UserID,
+		Locale:     notification.Locale,
+		Alert:      alert,
+		IP:         notification.IP,
+		UserAgent:  notification.UserAgent,
+		OccurredAt: notification.OccurredAt,
+		Data:       notification.Data,
+	}
+}
@@ -0,0 +1,50 @@
+package smtp
+
+import (
+	"bytes"
+	netsmtp "net/smtp"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+// loginAuth implements the non-standard but widespread LOGIN mechanism.
+// Like net/smtp's PLAIN auth it refuses to send credentials in the clear,
+// except to localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+var _ netsmtp.Auth = (*loginAuth)(nil)
+
+func (a *loginAuth) Start(server *netsmtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, werr.Wrap(errorz.ErrSMTPInsecureAuth)
+	}
+	if server.Name != a.host {
+		return "", nil, werr.Wrapf(errorz.ErrSMTPInsecureAuth, "wrong host name %q", server.Name)
+	}
+
+	return string(AuthLogin), nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch {
+	case bytes.EqualFold(fromServer, []byte("Username:")):
+		return []byte(a.username), nil
+	case bytes.EqualFold(fromServer, []byte("Password:")):
+		return []byte(a.password), nil
+	default:
+		return nil, werr.Wrapf(errorz.ErrSMTPAuthUnsupported, "unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
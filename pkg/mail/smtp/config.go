@@ -0,0 +1,46 @@
+package smtp
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+type AuthMechanism string
+
+const (
+	AuthNone  AuthMechanism = ""
+	AuthPlain AuthMechanism = "PLAIN"
+	AuthLogin AuthMechanism = "LOGIN"
+)
+
+type Security string
+
+const (
+	SecurityStartTLS    Security = "starttls"
+	SecurityImplicitTLS Security = "tls"
+	SecurityNone        Security = "none"
+)
+
+const (
+	defaultPort        = 587
+	defaultTimeout     = 30 * time.Second
+	defaultIdleTimeout = 30 * time.Second
+)
+
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Auth     AuthMechanism
+	// Security defaults to SecurityStartTLS.
+	Security  Security
+	TLSConfig *tls.Config
+	// From is the sender address, optionally with a display name: "Acme <no-reply@acme.io>".
+	From      string
+	LocalName string
+	// Timeout bounds dialing and every send when the context has no earlier deadline.
+	Timeout time.Duration
+	// IdleTimeout is how long an unused connection is kept open for reuse.
+	IdleTimeout time.Duration
+}
@@ -0,0 +1,86 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/matchsystems/werr"
+)
+
+// buildMessage encodes msg as a multipart/alternative MIME message with a
+// quoted-printable text part followed by the preferred HTML part.
+func buildMessage(from string, domain string, msg mail.Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	messageID, err := newMessageID(domain)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	headers := []struct {
+		key   string
+		value string
+	}{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{
+			"boundary": body.Boundary(),
+		})},
+	}
+	var head bytes.Buffer
+	for _, h := range headers {
+		fmt.Fprintf(&head, "%s: %s\r\n", h.key, h.value)
+	}
+	head.WriteString("\r\n")
+
+	if err = writePart(body, "text/plain", msg.Text); err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if err = writePart(body, "text/html", msg.HTML); err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if err = body.Close(); err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+func writePart(w *multipart.Writer, contentType string, content string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"})},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write([]byte(content)); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(qp.Close())
+}
+
+func newMessageID(domain string) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">", nil
+}
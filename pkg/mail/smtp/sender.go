@@ -0,0 +1,282 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	netmail "net/mail"
+	netsmtp "net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/matchsystems/werr"
+)
+
+// Sender delivers rendered messages over SMTP. It keeps one connection open
+// between sends and serializes deliveries over it.
+type Sender struct {
+	cfg          Config
+	from         string
+	envelopeFrom string
+	auth         netsmtp.Auth
+
+	mu       sync.Mutex
+	conn     net.Conn
+	client   *netsmtp.Client
+	lastUsed time.Time
+}
+
+var _ mail.Transport = (*Sender)(nil)
+
+func New(cfg Config) (*Sender, error) {
+	if cfg.Host == "" {
+		return nil, werr.Wrap(errorz.ErrSMTPHostRequired)
+	}
+	from, err := netmail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, werr.Wrap(errors.Join(errorz.ErrSMTPSenderRequired, err))
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultPort
+	}
+	switch cfg.Security {
+	case "":
+		cfg.Security = SecurityStartTLS
+	case SecurityStartTLS, SecurityImplicitTLS, SecurityNone:
+	default:
+		return nil, werr.Wrapf(errorz.ErrSMTPSecurityUnknown, "%q", cfg.Security)
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = defaultIdleTimeout
+	}
+
+	sender := &Sender{
+		cfg:          cfg,
+		from:         from.String(),
+		envelopeFrom: from.Address,
+		auth:         nil,
+		mu:           sync.Mutex{},
+		conn:         nil,
+		client:       nil,
+		lastUsed:     time.Time{},
+	}
+	switch cfg.Auth {
+	case AuthNone:
+	case AuthPlain:
+		sender.auth = netsmtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	case AuthLogin:
+		sender.auth = &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}
+	default:
+		return nil, werr.Wrapf(errorz.ErrSMTPAuthUnsupported, "%q", cfg.Auth)
+	}
+
+	return sender, nil
+}
+
+func (s *Sender) Send(ctx context.Context, msg mail.Message) error {
+	to, err := netmail.ParseAddress(msg.To)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	data, err := buildMessage(s.from, s.domain(), msg, time.Now())
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = ctx.Err(); err != nil {
+		return werr.Wrap(err)
+	}
+	deadline := time.Now().Add(s.cfg.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	if err = s.connect(ctx, deadline); err != nil {
+		return werr.Wrap(s.fail(ctx, err))
+	}
+
+	conn := s.conn
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	if err = s.deliver(to.Address, data); err != nil {
+		return werr.Wrap(s.fail(ctx, err))
+	}
+	s.lastUsed = time.Now()
+
+	return nil
+}
+
+// Close ends the cached connection, if any.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Quit()
+	s.drop()
+
+	return werr.Wrap(err)
+}
+
+func (s *Sender) deliver(to string, data []byte) error {
+	if err := s.client.Mail(s.envelopeFrom); err != nil {
+		return werr.Wrap(err)
+	}
+	if err := s.client.Rcpt(to); err != nil {
+		return werr.Wrap(err)
+	}
+	w, err := s.client.Data()
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if _, err = w.Write(data); err != nil {
+		return werr.Wrap(err)
+	}
+
+	return werr.Wrap(w.Close())
+}
+
+// connect makes sure s.client is a live, authenticated session. A cached
+// connection is reused unless it sat idle too long or fails a RSET probe.
+func (s *Sender) connect(ctx context.Context, deadline time.Time) error {
+	if s.client != nil {
+		if time.Since(s.lastUsed) > s.cfg.IdleTimeout {
+			_ = s.client.Quit()
+			s.drop()
+		} else {
+			_ = s.conn.SetDeadline(deadline)
+			if err := s.client.Reset(); err == nil {
+				return nil
+			}
+			s.drop()
+		}
+	}
+
+	return werr.Wrap(s.dial(ctx, deadline))
+}
+
+func (s *Sender) dial(ctx context.Context, deadline time.Time) error {
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+
+		return werr.Wrap(err)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+
+	if s.cfg.Security == SecurityImplicitTLS {
+		tlsConn := tls.Client(conn, s.tlsConfig())
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+
+			return werr.Wrap(err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := netsmtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+
+		return werr.Wrap(err)
+	}
+	s.conn, s.client = conn, client
+
+	if s.cfg.LocalName != "" {
+		if err = client.Hello(s.cfg.LocalName); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+	if s.cfg.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return werr.Wrap(errorz.ErrSMTPStartTLSUnsupported)
+		}
+		if err = client.StartTLS(s.tlsConfig()); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+	if s.auth != nil {
+		ok, mechanisms := client.Extension("AUTH")
+		if !ok || !containsFold(strings.Fields(mechanisms), string(s.cfg.Auth)) {
+			return werr.Wrap(errorz.ErrSMTPAuthUnsupported)
+		}
+		if err = client.Auth(s.auth); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Sender) fail(ctx context.Context, err error) error {
+	s.drop()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errors.Join(ctxErr, err)
+	}
+
+	return err
+}
+
+func (s *Sender) drop() {
+	if s.client != nil {
+		_ = s.client.Close()
+	}
+	s.conn, s.client = nil, nil
+}
+
+func (s *Sender) tlsConfig() *tls.Config {
+	if s.cfg.TLSConfig != nil {
+		cfg := s.cfg.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = s.cfg.Host
+		}
+
+		return cfg
+	}
+
+	return &tls.Config{
+		ServerName: s.cfg.Host,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+func (s *Sender) domain() string {
+	if _, domain, ok := strings.Cut(s.envelopeFrom, "@"); ok {
+		return domain
+	}
+
+	return s.cfg.Host
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,401 @@
+package smtp_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail"
+	"github.com/github.com/VadimOcLock/vauth/pkg/mail/smtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+type fakeServer struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	username    string
+	password    string
+	greet       bool
+
+	mu          sync.Mutex
+	messages    []receivedMessage
+	connections int
+}
+
+func newFakeServer(t *testing.T, implicitTLS bool, greet bool) (*fakeServer, *x509.CertPool) {
+	t.Helper()
+
+	cert, pool := selfSignedCert(t)
+	srv := &fakeServer{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+		implicitTLS: implicitTLS,
+		username:    "user",
+		password:    "secret",
+		greet:       greet,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if implicitTLS {
+		listener = tls.NewListener(listener, srv.tlsConfig)
+	}
+	srv.listener = listener
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, aErr := listener.Accept()
+			if aErr != nil {
+				return
+			}
+			srv.mu.Lock()
+			srv.connections++
+			srv.mu.Unlock()
+			go srv.serve(conn)
+		}
+	}()
+
+	return srv, pool
+}
+
+func (f *fakeServer) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (f *fakeServer) received() []receivedMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]receivedMessage(nil), f.messages...)
+}
+
+func (f *fakeServer) connectionCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.connections
+}
+
+func (f *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	if !f.greet {
+		_, _ = io.Copy(io.Discard, conn)
+
+		return
+	}
+
+	tp := textproto.NewConn(conn)
+	secured := f.implicitTLS
+	var current receivedMessage
+	reply := func(code int, lines ...string) {
+		for i, line := range lines {
+			sep := "-"
+			if i == len(lines)-1 {
+				sep = " "
+			}
+			_ = tp.PrintfLine("%d%s%s", code, sep, line)
+		}
+	}
+
+	reply(220, "fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			ext := []string{"fake"}
+			if !secured {
+				ext = append(ext, "STARTTLS")
+			}
+			reply(250, append(ext, "AUTH PLAIN LOGIN", "8BITMIME")...)
+		case "STARTTLS":
+			reply(220, "go ahead")
+			tlsConn := tls.Server(conn, f.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secured = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			f.auth(tp, arg, reply)
+		case "MAIL":
+			from, _, _ := strings.Cut(strings.TrimPrefix(arg, "FROM:"), " ")
+			current = receivedMessage{from: strings.Trim(from, "<>")}
+			reply(250, "ok")
+		case "RCPT":
+			current.to = append(current.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply(250, "ok")
+		case "DATA":
+			reply(354, "go ahead")
+			if current.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, current)
+			f.mu.Unlock()
+			reply(250, "queued")
+		case "RSET", "NOOP":
+			reply(250, "ok")
+		case "QUIT":
+			reply(221, "bye")
+
+			return
+		default:
+			reply(502, "unknown command")
+		}
+	}
+}
+
+func (f *fakeServer) auth(tp *textproto.Conn, arg string, reply func(int, ...string)) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	var username, password string
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(initial)
+		parts := strings.Split(string(decoded), "\x00")
+		if len(parts) == 3 {
+			username, password = parts[1], parts[2]
+		}
+	case "LOGIN":
+		reply(334, base64.StdEncoding.EncodeToString([]byte("Username:")))
+		line, _ := tp.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		username = string(decoded)
+		reply(334, base64.StdEncoding.EncodeToString([]byte("Password:")))
+		line, _ = tp.ReadLine()
+		decoded, _ = base64.StdEncoding.DecodeString(line)
+		password = string(decoded)
+	}
+	if username == f.username && password == f.password {
+		reply(235, "authenticated")
+
+		return
+	}
+	reply(535, "bad credentials")
+}
+
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func parseMessage(t *testing.T, data []byte) (string, map[string]string) {
+	t.Helper()
+
+	msg, err := netmail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := make(map[string]string)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, pErr := reader.NextRawPart()
+		if pErr == io.EOF {
+			break
+		}
+		require.NoError(t, pErr)
+		assert.Equal(t, "quoted-printable", part.Header.Get("Content-Transfer-Encoding"))
+		body, rErr := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, rErr)
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[partType] = string(body)
+	}
+
+	return subject, parts
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	msg := mail.Message{
+		To:      "user@example.com",
+		Subject: "Passwort zurücksetzen",
+		HTML:    "<p>" + strings.Repeat("long line ", 20) + "</p>\n<p>Code: 123456</p>\n",
+		Text:    "Code: 123456\n.\nbye\n",
+	}
+
+	t.Run("starttls with plain auth", func(t *testing.T) {
+		t.Parallel()
+
+		srv, pool := newFakeServer(t, false, true)
+		sender, err := smtp.New(smtp.Config{
+			Host:      "127.0.0.1",
+			Port:      srv.port(),
+			Username:  "user",
+			Password:  "secret",
+			Auth:      smtp.AuthPlain,
+			TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			From:      "Acme <no-reply@acme.io>",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sender.Close() })
+
+		require.NoError(t, sender.Send(context.Background(), msg))
+
+		received := srv.received()
+		require.Len(t, received, 1)
+		assert.Equal(t, "no-reply@acme.io", received[0].from)
+		assert.Equal(t, []string{"user@example.com"}, received[0].to)
+		subject, parts := parseMessage(t, received[0].data)
+		assert.Equal(t, msg.Subject, subject)
+		assert.Equal(t, msg.Text, parts["text/plain"])
+		assert.Equal(t, msg.HTML, parts["text/html"])
+	})
+
+	t.Run("implicit tls with login auth", func(t *testing.T) {
+		t.Parallel()
+
+		srv, pool := newFakeServer(t, true, true)
+		sender, err := smtp.New(smtp.Config{
+			Host:      "127.0.0.1",
+			Port:      srv.port(),
+			Username:  "user",
+			Password:  "secret",
+			Auth:      smtp.AuthLogin,
+			Security:  smtp.SecurityImplicitTLS,
+			TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			From:      "no-reply@acme.io",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sender.Close() })
+
+		require.NoError(t, sender.Send(context.Background(), msg))
+		assert.Len(t, srv.received(), 1)
+	})
+
+	t.Run("connection is reused", func(t *testing.T) {
+		t.Parallel()
+
+		srv, pool := newFakeServer(t, false, true)
+		sender, err := smtp.New(smtp.Config{
+			Host:      "127.0.0.1",
+			Port:      srv.port(),
+			TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			From:      "no-reply@acme.io",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sender.Close() })
+
+		for range 3 {
+			require.NoError(t, sender.Send(context.Background(), msg))
+		}
+		assert.Len(t, srv.received(), 3)
+		assert.Equal(t, 1, srv.connectionCount())
+	})
+
+	t.Run("wrong credentials", func(t *testing.T) {
+		t.Parallel()
+
+		srv, pool := newFakeServer(t, false, true)
+		sender, err := smtp.New(smtp.Config{
+			Host:      "127.0.0.1",
+			Port:      srv.port(),
+			Username:  "user",
+			Password:  "wrong",
+			Auth:      smtp.AuthPlain,
+			TLSConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			From:      "no-reply@acme.io",
+		})
+		require.NoError(t, err)
+
+		err = sender.Send(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bad credentials")
+		assert.Empty(t, srv.received())
+	})
+
+	t.Run("context deadline interrupts a stuck server", func(t *testing.T) {
+		t.Parallel()
+
+		srv, _ := newFakeServer(t, false, false)
+		sender, err := smtp.New(smtp.Config{
+			Host: "127.0.0.1",
+			Port: srv.port(),
+			From: "no-reply@acme.io",
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		started := time.Now()
+		err = sender.Send(ctx, msg)
+		require.Error(t, err)
+		assert.Less(t, time.Since(started), 5*time.Second)
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := smtp.New(smtp.Config{From: "no-reply@acme.io"})
+	require.Error(t, err)
+
+	_, err = smtp.New(smtp.Config{Host: "smtp.acme.io", From: "not an address"})
+	require.Error(t, err)
+
+	_, err = smtp.New(smtp.Config{Host: "smtp.acme.io", From: "no-reply@acme.io", Auth: "CRAM-MD5"})
+	require.Error(t, err)
+
+	for _, security := range []smtp.Security{"STARTTLS", "ssl", "starttsl"} {
+		_, err = smtp.New(smtp.Config{Host: "smtp.acme.io", From: "no-reply@acme.io", Security: security})
+		require.ErrorIs(t, err, errorz.ErrSMTPSecurityUnknown, security)
+	}
+
+	_, err = smtp.New(smtp.Config{Host: "smtp.acme.io", Port: 2525, From: "no-reply@acme.io"})
+	require.NoError(t, err)
+}
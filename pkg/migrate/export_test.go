@@ -0,0 +1,46 @@
+package migrate
+
+import "time"
+
+// Plan runs plan for applied versions and returns the versions of its steps,
+// negated for roll-backs.
+func Plan(list []Migration, applied []int64, target int64) ([]int64, error) {
+	steps, err := plan(list, appliedSet(applied), target)
+	if err != nil {
+		return nil, err
+	}
+
+	return stepVersions(steps), nil
+}
+
+// BaselinePlan runs baseline for applied versions and returns the versions it records.
+func BaselinePlan(list []Migration, applied []int64, version int64) ([]int64, error) {
+	steps, err := baseline(list, appliedSet(applied), version)
+	if err != nil {
+		return nil, err
+	}
+
+	return stepVersions(steps), nil
+}
+
+func appliedSet(applied []int64) map[int64]MigrationStatus {
+	set := make(map[int64]MigrationStatus, len(applied))
+	for _, version := range applied {
+		set[version] = MigrationStatus{Version: version, Name: "", AppliedAt: time.Now()}
+	}
+
+	return set
+}
+
+func stepVersions(steps []step) []int64 {
+	versions := make([]int64, 0, len(steps))
+	for _, s := range steps {
+		if s.up {
+			versions = append(versions, s.migration.Version)
+		} else {
+			versions = append(versions, -s.migration.Version)
+		}
+	}
+
+	return versions
+}
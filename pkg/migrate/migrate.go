@@ -0,0 +1,386 @@
+// Package migrate applies the vauth schema migrations to Postgres.
+//
+// Applied versions are recorded in the vauth_schema_migrations table, and a
+// session advisory lock serializes runs, so every replica may call Up on
+// startup. Each migration runs in its own transaction.
+//
+// Databases set up before this package existed, by running the files in
+// database/postgres/migrations by hand, have no recorded versions, so Up
+// would fail on the first migration. Record the last version applied by hand
+// once with Baseline, e.g. Baseline(ctx, db, 3) after 0001-0003; Up then
+// applies only the newer migrations.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/database/postgres/migrations"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matchsystems/werr"
+)
+
+const lockKey = "vauth.migrate"
+
+const (
+	createTableQuery = `CREATE TABLE IF NOT EXISTS vauth_schema_migrations
+(
+    version    BIGINT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at timestamp without time zone default timezone('utc'::text, now()) not null
+)`
+	appliedQuery = `SELECT version, name, applied_at FROM vauth_schema_migrations ORDER BY version`
+	insertQuery  = `INSERT INTO vauth_schema_migrations (version, name) VALUES ($1, $2)`
+	deleteQuery  = `DELETE FROM vauth_schema_migrations WHERE version = $1`
+	lockQuery    = `SELECT pg_advisory_lock(hashtext($1))`
+	unlockQuery  = `SELECT pg_advisory_unlock(hashtext($1))`
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	// AppliedAt is zero for pending migrations.
+	AppliedAt time.Time
+}
+
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+type options struct {
+	source fs.FS
+}
+
+type Option func(*options)
+
+// WithSource reads migrations from fsys instead of the embedded vauth ones.
+func WithSource(fsys fs.FS) Option {
+	return func(o *options) {
+		o.source = fsys
+	}
+}
+
+// Load reads and checks the migrations in the root of fsys, sorted by
+// version. Every version needs a non-empty up and down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "%s: name is not <version>_<name>.up|down.sql",
+				entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "%s: version must be positive", entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "%s: file is empty", entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2], Up: "", Down: ""}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "version %d: names %s and %s",
+				version, m.Name, match[2])
+		}
+		sql := &m.Up
+		if match[3] == "down" {
+			sql = &m.Down
+		}
+		if *sql != "" {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "version %d: duplicate %s file", version, match[3])
+		}
+		*sql = string(data)
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, werr.Wrapf(errorz.ErrInvalidMigration, "version %d: up and down files are required",
+				m.Version)
+		}
+		list = append(list, *m)
+	}
+	slices.SortFunc(list, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+
+	return list, nil
+}
+
+// Up applies every pending migration. Versions applied by a newer release
+// are left alone, so replicas of both releases can start during a rollout.
+func Up(ctx context.Context, db *pgxpool.Pool, opts ...Option) error {
+	return run(ctx, db, opts, func(list []Migration, applied map[int64]MigrationStatus) ([]step, error) {
+		var steps []step
+		for _, m := range list {
+			if _, ok := applied[m.Version]; !ok {
+				steps = append(steps, step{migration: m, up: true, recordOnly: false})
+			}
+		}
+
+		return steps, nil
+	})
+}
+
+// Baseline records every migration up to version as applied without running
+// it. It is meant for a schema created by hand from those files; see the
+// package documentation.
+func Baseline(ctx context.Context, db *pgxpool.Pool, version int64, opts ...Option) error {
+	return run(ctx, db, opts, func(list []Migration, applied map[int64]MigrationStatus) ([]step, error) {
+		return baseline(list, applied, version)
+	})
+}
+
+func baseline(list []Migration, applied map[int64]MigrationStatus, version int64) ([]step, error) {
+	if !slices.ContainsFunc(list, func(m Migration) bool { return m.Version == version }) {
+		return nil, werr.Wrapf(errorz.ErrUnknownMigration, "version %d", version)
+	}
+
+	var steps []step
+	for _, m := range list {
+		if _, ok := applied[m.Version]; !ok && m.Version <= version {
+			steps = append(steps, step{migration: m, up: true, recordOnly: true})
+		}
+	}
+
+	return steps, nil
+}
+
+// Down rolls back the last applied migration.
+func Down(ctx context.Context, db *pgxpool.Pool, opts ...Option) error {
+	return run(ctx, db, opts, func(list []Migration, applied map[int64]MigrationStatus) ([]step, error) {
+		var last int64
+		for version := range applied {
+			last = max(last, version)
+		}
+		if last == 0 {
+			return nil, nil
+		}
+		i := slices.IndexFunc(list, func(m Migration) bool { return m.Version == last })
+		if i < 0 {
+			return nil, werr.Wrapf(errorz.ErrUnknownMigration, "version %d", last)
+		}
+
+		return []step{{migration: list[i], up: false, recordOnly: false}}, nil
+	})
+}
+
+// Goto applies or rolls back migrations until exactly those up to version
+// are applied. Version 0 rolls back everything.
+func Goto(ctx context.Context, db *pgxpool.Pool, version int64, opts ...Option) error {
+	return run(ctx, db, opts, func(list []Migration, applied map[int64]MigrationStatus) ([]step, error) {
+		if version != 0 && !slices.ContainsFunc(list, func(m Migration) bool { return m.Version == version }) {
+			return nil, werr.Wrapf(errorz.ErrUnknownMigration, "version %d", version)
+		}
+
+		return plan(list, applied, version)
+	})
+}
+
+// Status lists the known migrations and any unknown applied versions.
+func Status(ctx context.Context, db *pgxpool.Pool, opts ...Option) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := run(ctx, db, opts, func(list []Migration, applied map[int64]MigrationStatus) ([]step, error) {
+		statuses = status(list, applied)
+
+		return nil, nil
+	})
+
+	return statuses, werr.Wrap(err)
+}
+
+type step struct {
+	migration Migration
+	up        bool
+	// recordOnly marks the migration applied without running it.
+	recordOnly bool
+}
+
+// plan returns the steps leaving exactly the migrations up to target
+// applied: roll-backs newest first, then the rest oldest first.
+func plan(list []Migration, applied map[int64]MigrationStatus, target int64) ([]step, error) {
+	var steps []step
+	known := make(map[int64]bool, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		known[list[i].Version] = true
+		if _, ok := applied[list[i].Version]; ok && list[i].Version > target {
+			steps = append(steps, step{migration: list[i], up: false, recordOnly: false})
+		}
+	}
+	for version := range applied {
+		if version > target && !known[version] {
+			return nil, werr.Wrapf(errorz.ErrUnknownMigration, "version %d is applied", version)
+		}
+	}
+	for _, m := range list {
+		if _, ok := applied[m.Version]; !ok && m.Version <= target {
+			steps = append(steps, step{migration: m, up: true, recordOnly: false})
+		}
+	}
+
+	return steps, nil
+}
+
+func status(list []Migration, applied map[int64]MigrationStatus) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(list))
+	for _, m := range list {
+		statuses = append(statuses, MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			AppliedAt: applied[m.Version].AppliedAt,
+		})
+	}
+	for version, s := range applied {
+		if !slices.ContainsFunc(list, func(m Migration) bool { return m.Version == version }) {
+			statuses = append(statuses, s)
+		}
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return cmp.Compare(a.Version, b.Version) })
+
+	return statuses
+}
+
+// run holds the advisory lock on one connection while it reads the applied
+// versions, plans the steps and applies them.
+func run(
+	ctx context.Context,
+	db *pgxpool.Pool,
+	opts []Option,
+	planSteps func(list []Migration, applied map[int64]MigrationStatus) ([]step, error),
+) (err error) {
+	o := options{source: migrations.FS}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	list, err := Load(o.source)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	defer conn.Release()
+	if _, err = conn.Exec(ctx, lockQuery, lockKey); err != nil {
+		return werr.Wrap(err)
+	}
+	defer func() {
+		if _, unlockErr := conn.Exec(context.WithoutCancel(ctx), unlockQuery, lockKey); unlockErr != nil {
+			// A closed connection releases the lock and is dropped from the pool.
+			_ = conn.Conn().Close(context.WithoutCancel(ctx))
+			if err == nil {
+				err = werr.Wrap(unlockErr)
+			}
+		}
+	}()
+
+	if _, err = conn.Exec(ctx, createTableQuery); err != nil {
+		return werr.Wrap(err)
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	steps, err := planSteps(list, applied)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	for _, s := range steps {
+		if err = apply(ctx, conn, s); err != nil {
+			return werr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.Query(ctx, appliedQuery)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	statuses, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (MigrationStatus, error) {
+		var s MigrationStatus
+		err := row.Scan(&s.Version, &s.Name, &s.AppliedAt)
+
+		return s, err
+	})
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	applied := make(map[int64]MigrationStatus, len(statuses))
+	for _, s := range statuses {
+		applied[s.Version] = s
+	}
+
+	return applied, nil
+}
+
+func apply(ctx context.Context, conn *pgxpool.Conn, s step) error {
+	m := s.migration
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if !s.up {
+			if _, err := tx.Exec(ctx, m.Down); err != nil {
+				return werr.Wrap(err)
+			}
+			_, err := tx.Exec(ctx, deleteQuery, m.Version)
+
+			return werr.Wrap(err)
+		}
+		if !s.recordOnly {
+			if _, err := tx.Exec(ctx, m.Up); err != nil {
+				return werr.Wrap(err)
+			}
+		}
+		_, err := tx.Exec(ctx, insertQuery, m.Version, m.Name)
+
+		return werr.Wrap(err)
+	})
+	if err != nil {
+		direction := "up"
+		switch {
+		case !s.up:
+			direction = "down"
+		case s.recordOnly:
+			direction = "baseline"
+		}
+
+		return werr.Wrapf(err, "migration %d_%s %s", m.Version, m.Name, direction)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package migrate_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/github.com/VadimOcLock/vauth/database/postgres/migrations"
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("embedded migrations are complete", func(t *testing.T) {
+		t.Parallel()
+
+		list, err := migrate.Load(migrations.FS)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, list)
+		for i, m := range list {
+			assert.Equal(t, int64(i+1), m.Version, "versions have no gaps")
+			assert.NotEmpty(t, strings.TrimSpace(m.Down), m.Name)
+		}
+		assert.Equal(t, "create_users_table", list[0].Name)
+	})
+
+	t.Run("files are paired and sorted", func(t *testing.T) {
+		t.Parallel()
+
+		list, err := migrate.Load(fstest.MapFS{
+			"10_b.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+			"10_b.down.sql": {Data: []byte("DROP TABLE b;")},
+			"2_a.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+			"2_a.down.sql":  {Data: []byte("DROP TABLE a;")},
+			"README.md":     {Data: []byte("ignored")},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, migrate.Migration{
+			Version: 2,
+			Name:    "a",
+			Up:      "CREATE TABLE a ();",
+			Down:    "DROP TABLE a;",
+		}, list[0])
+		assert.Equal(t, int64(10), list[1].Version)
+	})
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {"1_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"empty down": {
+			"1_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"1_a.down.sql": {Data: []byte("\n")},
+		},
+		"name mismatch": {
+			"1_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"1_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad file name": {"create_users.sql": {Data: []byte("SELECT 1;")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := migrate.Load(fsys)
+
+			require.ErrorIs(t, err, errorz.ErrInvalidMigration)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	list := []migrate.Migration{
+		{Version: 1, Name: "a", Up: "up", Down: "down"},
+		{Version: 2, Name: "b", Up: "up", Down: "down"},
+		{Version: 3, Name: "c", Up: "up", Down: "down"},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		applied []int64
+		target  int64
+		want    []int64
+	}{
+		{name: "fresh database", applied: nil, target: 3, want: []int64{1, 2, 3}},
+		{name: "partially applied", applied: []int64{1}, target: 2, want: []int64{2}},
+		{name: "roll back newest first", applied: []int64{1, 2, 3}, target: 1, want: []int64{-3, -2}},
+		{name: "roll back everything", applied: []int64{1, 2}, target: 0, want: []int64{-2, -1}},
+		{name: "fill a gap", applied: []int64{1, 3}, target: 3, want: []int64{2}},
+		{name: "up to date", applied: []int64{1, 2, 3}, target: 3, want: []int64{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			steps, err := migrate.Plan(list, tc.applied, tc.target)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, steps)
+		})
+	}
+
+	t.Run("unknown applied version cannot be rolled back", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := migrate.Plan(list, []int64{1, 2, 3, 4}, 2)
+
+		require.ErrorIs(t, err, errorz.ErrUnknownMigration)
+	})
+}
+
+func TestBaseline(t *testing.T) {
+	t.Parallel()
+
+	list := []migrate.Migration{
+		{Version: 1, Name: "a", Up: "up", Down: "down"},
+		{Version: 2, Name: "b", Up: "up", Down: "down"},
+		{Version: 3, Name: "c", Up: "up", Down: "down"},
+	}
+
+	t.Run("records the versions applied by hand", func(t *testing.T) {
+		t.Parallel()
+
+		versions, err := migrate.BaselinePlan(list, nil, 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, versions)
+	})
+
+	t.Run("skips recorded versions", func(t *testing.T) {
+		t.Parallel()
+
+		versions, err := migrate.BaselinePlan(list, []int64{1}, 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 3}, versions)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := migrate.BaselinePlan(list, nil, 4)
+
+		require.ErrorIs(t, err, errorz.ErrUnknownMigration)
+	})
+}
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type memoryLimiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+var _ Limiter = (*memoryLimiter)(nil)
+
+type MemoryOption func(*memoryLimiter)
+
+func WithClock(now func() time.Time) MemoryOption {
+	return func(l *memoryLimiter) {
+		l.now = now
+	}
+}
+
+// NewMemory returns a token bucket limiter for a single process. Each key
+// starts with Limit tokens and regains them evenly over Period.
+func NewMemory(cfg Config, opts ...MemoryOption) Limiter {
+	l := &memoryLimiter{
+		cfg:       cfg,
+		now:       time.Now,
+		mu:        sync.Mutex{},
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Time{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+func (l *memoryLimiter) Allow(_ context.Context, key string) (time.Duration, error) {
+	rule := l.cfg.rule(key)
+	if rule.disabled() {
+		return 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = refill(rule, b, now)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return 0, nil
+	}
+
+	return time.Duration((1 - b.tokens) / rate(rule) * float64(time.Second)), nil
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// would be identical.
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		rule := l.cfg.rule(key)
+		if rule.disabled() || refill(rule, b, now) >= float64(rule.Limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func refill(rule Rule, b *bucket, now time.Time) float64 {
+	return min(float64(rule.Limit), b.tokens+now.Sub(b.updated).Seconds()*rate(rule))
+}
+
+func rate(rule Rule) float64 {
+	return float64(rule.Limit) / rule.Period.Seconds()
+}
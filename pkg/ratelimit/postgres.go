@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/internal/store"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matchsystems/werr"
+)
+
+type postgresLimiter struct {
+	cfg   Config
+	store store.Store
+}
+
+var _ Limiter = (*postgresLimiter)(nil)
+
+// NewPostgres returns a sliding window limiter shared by every process using
+// the same database. Each allowed call is one row in rate_limit_hits.
+func NewPostgres(pgClient *pgxpool.Pool, cfg Config) Limiter {
+	return &postgresLimiter{
+		cfg:   cfg,
+		store: store.New(pgClient),
+	}
+}
+
+func (l *postgresLimiter) Allow(ctx context.Context, key string) (time.Duration, error) {
+	rule := l.cfg.rule(key)
+	if rule.disabled() {
+		return 0, nil
+	}
+
+	retryAfter, err := l.store.HitRateLimit(ctx, store.HitRateLimitDTO{
+		Key:    key,
+		Limit:  rule.Limit,
+		Period: rule.Period,
+	})
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	return retryAfter, nil
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Limiter reports how long the caller has to wait before key may be used
+// again. A zero duration means the call is allowed and has been counted.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (time.Duration, error)
+}
+
+// Rule allows Limit calls per Period. A zero Limit disables limiting.
+type Rule struct {
+	Limit  int64
+	Period time.Duration
+}
+
+type Config struct {
+	Default Rule
+	// Rules overrides Default by operation, the part of the key before the first ':'.
+	Rules map[string]Rule
+}
+
+func (cfg Config) rule(key string) Rule {
+	operation, _, _ := strings.Cut(key, ":")
+	if rule, ok := cfg.Rules[operation]; ok {
+		return rule
+	}
+
+	return cfg.Default
+}
+
+func (r Rule) disabled() bool {
+	return r.Limit <= 0 || r.Period <= 0
+}
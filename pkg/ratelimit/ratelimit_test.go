@@ -0,0 +1,103 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	cfg := ratelimit.Config{
+		Default: ratelimit.Rule{Limit: 3, Period: time.Minute},
+		Rules: map[string]ratelimit.Rule{
+			"forgot_password": {Limit: 1, Period: time.Hour},
+			"unlimited":       {Limit: 0, Period: 0},
+		},
+	}
+
+	t.Run("bucket allows a burst and refills", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(1700000000, 0)}
+		limiter := ratelimit.NewMemory(cfg, ratelimit.WithClock(clock.Now))
+
+		for range 3 {
+			retryAfter, err := limiter.Allow(ctx, "register:ip:203.0.113.7")
+			require.NoError(t, err)
+			assert.Zero(t, retryAfter)
+		}
+		retryAfter, err := limiter.Allow(ctx, "register:ip:203.0.113.7")
+		require.NoError(t, err)
+		assert.Equal(t, 20*time.Second, retryAfter)
+
+		clock.now = clock.now.Add(20 * time.Second)
+		retryAfter, err = limiter.Allow(ctx, "register:ip:203.0.113.7")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(1700000000, 0)}
+		limiter := ratelimit.NewMemory(cfg, ratelimit.WithClock(clock.Now))
+
+		retryAfter, err := limiter.Allow(ctx, "forgot_password:email:a@example.com")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+		retryAfter, err = limiter.Allow(ctx, "forgot_password:email:b@example.com")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+		retryAfter, err = limiter.Allow(ctx, "forgot_password:email:a@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, retryAfter)
+	})
+
+	t.Run("zero limit disables the rule", func(t *testing.T) {
+		t.Parallel()
+
+		limiter := ratelimit.NewMemory(cfg)
+
+		for range 10 {
+			retryAfter, err := limiter.Allow(ctx, "unlimited:ip:203.0.113.7")
+			require.NoError(t, err)
+			assert.Zero(t, retryAfter)
+		}
+	})
+
+	t.Run("refilled buckets are swept", func(t *testing.T) {
+		t.Parallel()
+
+		clock := &fakeClock{now: time.Unix(1700000000, 0)}
+		limiter := ratelimit.NewMemory(cfg, ratelimit.WithClock(clock.Now))
+
+		for range 3 {
+			_, err := limiter.Allow(ctx, "register:ip:203.0.113.7")
+			require.NoError(t, err)
+		}
+		clock.now = clock.now.Add(2 * time.Minute)
+		_, err := limiter.Allow(ctx, "register:email:a@example.com")
+		require.NoError(t, err)
+
+		for range 3 {
+			retryAfter, err := limiter.Allow(ctx, "register:ip:203.0.113.7")
+			require.NoError(t, err)
+			assert.Zero(t, retryAfter)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const KeySize = 32
+
+// Box encrypts small secrets for storage with AES-256-GCM. Sealed values are
+// base64 strings holding the nonce followed by the ciphertext.
+type Box interface {
+	Seal(plaintext []byte) (string, error)
+	Open(sealed string) ([]byte, error)
+}
+
+type boxImpl struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (Box, error) {
+	if len(key) != KeySize {
+		return nil, werr.Wrap(errorz.ErrEncryptionKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return boxImpl{aead: aead}, nil
+}
+
+func (b boxImpl) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (b boxImpl) Open(sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if len(data) < b.aead.NonceSize() {
+		return nil, werr.Wrap(errorz.ErrSealedValueCorrupted)
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, werr.Wrap(errorz.ErrSealedValueCorrupted)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,100 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/matchsystems/werr"
+	"github.com/skip2/go-qrcode"
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (a authenticatorImpl) GenerateSecret() (string, error) {
+	secret := make([]byte, defaultSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+func (a authenticatorImpl) URI(secret string, account string) string {
+	label := account
+	if a.issuer != "" {
+		label = a.issuer + ":" + account
+	}
+	query := url.Values{}
+	query.Set("secret", secret)
+	if a.issuer != "" {
+		query.Set("issuer", a.issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(a.digits))
+	query.Set("period", strconv.Itoa(int(a.period.Seconds())))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func (a authenticatorImpl) QRCode(uri string) ([]byte, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, a.qrCodeSize)
+
+	return png, werr.Wrap(err)
+}
+
+func (a authenticatorImpl) Validate(secret string, code string, usedStep int64) (int64, bool) {
+	if len(code) != a.digits {
+		return 0, false
+	}
+
+	current := a.now().Unix() / int64(a.period.Seconds())
+	for step := current - a.skew; step <= current+a.skew; step++ {
+		if step <= usedStep {
+			continue
+		}
+		expected, err := a.Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func (a authenticatorImpl) Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for range a.digits {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", a.digits, value%modulo), nil
+}
@@ -0,0 +1,156 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// Authenticator is an autogenerated mock type for the Authenticator type
+type Authenticator struct {
+	mock.Mock
+}
+
+// Code provides a mock function with given fields: secret, step
+func (_m *Authenticator) Code(secret string, step int64) (string, error) {
+	ret := _m.Called(secret, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Code")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64) (string, error)); ok {
+		return rf(secret, step)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64) string); ok {
+		r0 = rf(secret, step)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64) error); ok {
+		r1 = rf(secret, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GenerateSecret provides a mock function with given fields:
+func (_m *Authenticator) GenerateSecret() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GenerateSecret")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QRCode provides a mock function with given fields: uri
+func (_m *Authenticator) QRCode(uri string) ([]byte, error) {
+	ret := _m.Called(uri)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QRCode")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]byte, error)); ok {
+		return rf(uri)
+	}
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(uri)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(uri)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// URI provides a mock function with given fields: secret, account
+func (_m *Authenticator) URI(secret string, account string) string {
+	ret := _m.Called(secret, account)
+
+	if len(ret) == 0 {
+		panic("no return value specified for URI")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(secret, account)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// Validate provides a mock function with given fields: secret, code, usedStep
+func (_m *Authenticator) Validate(secret string, code string, usedStep int64) (int64, bool) {
+	ret := _m.Called(secret, code, usedStep)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Validate")
+	}
+
+	var r0 int64
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string, string, int64) (int64, bool)); ok {
+		return rf(secret, code, usedStep)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, int64) int64); ok {
+		r0 = rf(secret, code, usedStep)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, int64) bool); ok {
+		r1 = rf(secret, code, usedStep)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// NewAuthenticator creates a new instance of Authenticator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuthenticator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Authenticator {
+	mock := &Authenticator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
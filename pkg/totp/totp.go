@@ -0,0 +1,86 @@
+package totp
+
+import "time"
+
+const (
+	defaultDigits       = 6
+	defaultPeriod       = 30 * time.Second
+	defaultSkew         = 1
+	defaultSecretLength = 20
+	defaultQRCodeSize   = 256
+)
+
+// Authenticator implements RFC 6238 time-based one-time passwords with
+// HMAC-SHA1, the variant every authenticator app supports.
+type Authenticator interface {
+	GenerateSecret() (string, error)
+	URI(secret string, account string) string
+	QRCode(uri string) ([]byte, error)
+	// Validate reports the time step matching code. Steps at or before
+	// usedStep are rejected, so a code cannot be replayed.
+	Validate(secret string, code string, usedStep int64) (int64, bool)
+	Code(secret string, step int64) (string, error)
+}
+
+type authenticatorImpl struct {
+	issuer     string
+	digits     int
+	period     time.Duration
+	skew       int64
+	qrCodeSize int
+	now        func() time.Time
+}
+
+type Config struct {
+	Issuer string
+}
+
+type Option func(*authenticatorImpl)
+
+func WithDigits(digits int) Option {
+	return func(impl *authenticatorImpl) {
+		impl.digits = digits
+	}
+}
+
+func WithPeriod(period time.Duration) Option {
+	return func(impl *authenticatorImpl) {
+		impl.period = period
+	}
+}
+
+// WithSkew sets how many steps before and after the current one are accepted.
+func WithSkew(steps int64) Option {
+	return func(impl *authenticatorImpl) {
+		impl.skew = steps
+	}
+}
+
+func WithQRCodeSize(size int) Option {
+	return func(impl *authenticatorImpl) {
+		impl.qrCodeSize = size
+	}
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(impl *authenticatorImpl) {
+		impl.now = now
+	}
+}
+
+func NewAuthenticator(cfg Config, opts ...Option) Authenticator {
+	impl := authenticatorImpl{
+		issuer:     cfg.Issuer,
+		digits:     defaultDigits,
+		period:     defaultPeriod,
+		skew:       defaultSkew,
+		qrCodeSize: defaultQRCodeSize,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&impl)
+	}
+
+	return impl
+}
@@ -0,0 +1,95 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 key from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestAuthenticator_Code(t *testing.T) {
+	t.Parallel()
+
+	authenticator := totp.NewAuthenticator(totp.Config{}, totp.WithDigits(8))
+	for unix, want := range map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	} {
+		code, err := authenticator.Code(rfcSecret, unix/30)
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "T=%d", unix)
+	}
+}
+
+func TestAuthenticator_Validate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1234567890, 0)
+	authenticator := totp.NewAuthenticator(totp.Config{}, totp.WithClock(func() time.Time { return now }))
+	current := now.Unix() / 30
+
+	t.Run("accepts current and adjacent steps", func(t *testing.T) {
+		t.Parallel()
+
+		for _, step := range []int64{current - 1, current, current + 1} {
+			code, err := authenticator.Code(rfcSecret, step)
+			require.NoError(t, err)
+
+			got, ok := authenticator.Validate(rfcSecret, code, 0)
+			assert.True(t, ok)
+			assert.Equal(t, step, got)
+		}
+	})
+
+	t.Run("rejects steps outside the window", func(t *testing.T) {
+		t.Parallel()
+
+		code, err := authenticator.Code(rfcSecret, current-2)
+		require.NoError(t, err)
+
+		_, ok := authenticator.Validate(rfcSecret, code, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects replayed steps", func(t *testing.T) {
+		t.Parallel()
+
+		code, err := authenticator.Code(rfcSecret, current)
+		require.NoError(t, err)
+
+		_, ok := authenticator.Validate(rfcSecret, code, current)
+		assert.False(t, ok)
+	})
+}
+
+func TestAuthenticator_Enrollment(t *testing.T) {
+	t.Parallel()
+
+	authenticator := totp.NewAuthenticator(totp.Config{Issuer: "Acme"})
+	secret, err := authenticator.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(authenticator.URI(secret, "user@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Acme:user@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Acme", uri.Query().Get("issuer"))
+
+	png, err := authenticator.QRCode(uri.String())
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\x89PNG"), png[:4])
+}
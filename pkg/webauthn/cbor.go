@@ -0,0 +1,168 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const cborMaxDepth = 16
+
+// decodeCBOR decodes the first CBOR item in data and reports how many bytes it
+// used. It covers the subset CTAP2 authenticators emit: integers, byte and text
+// strings, arrays, maps, booleans and null, all with definite lengths.
+// Integers decode to int64, maps to map[any]any keyed by int64 or string.
+func decodeCBOR(data []byte) (any, int, error) {
+	d := cborDecoder{data: data, pos: 0}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, werr.Wrap(err)
+	}
+
+	return value, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) decode(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: nesting too deep")
+	}
+
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: integer overflow")
+		}
+
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: integer overflow")
+		}
+
+		return -1 - int64(arg), nil
+	case 2:
+		raw, err := d.take(arg)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+
+		return append([]byte(nil), raw...), nil
+	case 3:
+		raw, err := d.take(arg)
+		if err != nil {
+			return nil, werr.Wrap(err)
+		}
+
+		return string(raw), nil
+	case 4:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: array too long")
+		}
+		items := make([]any, 0, arg)
+		for range arg {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, werr.Wrap(err)
+			}
+			items = append(items, item)
+		}
+
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: map too long")
+		}
+		items := make(map[any]any, arg)
+		for range arg {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, werr.Wrap(err)
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: unsupported map key %T", key)
+			}
+			if _, ok := items[key]; ok {
+				return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: duplicate map key %v", key)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, werr.Wrap(err)
+			}
+			items[key] = value
+		}
+
+		return items, nil
+	case 7:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		}
+	}
+
+	return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: unsupported item (major %d)", major)
+}
+
+// head reads an item's initial byte and argument.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	raw, err := d.take(1)
+	if err != nil {
+		return 0, 0, werr.Wrap(err)
+	}
+	major, info := raw[0]>>5, raw[0]&0x1f
+
+	var size uint64
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: indefinite or reserved length")
+	}
+	if major == 7 && info > 24 {
+		return 0, 0, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: floats are not supported")
+	}
+
+	raw, err = d.take(size)
+	if err != nil {
+		return 0, 0, werr.Wrap(err)
+	}
+	var buf [8]byte
+	copy(buf[8-len(raw):], raw)
+
+	return major, binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, werr.Wrapf(errorz.ErrWebAuthnMalformed, "cbor: unexpected end of data")
+	}
+	raw := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return raw, nil
+}
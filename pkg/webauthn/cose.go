@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"math/big"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+// COSE algorithm identifiers from the IANA registry.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1 // EC2 and OKP
+	coseX         = -2 // EC2 and OKP
+	coseY         = -3 // EC2
+	coseModulus   = -1 // RSA
+	coseExponent  = -2 // RSA
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	minRSABits = 2048
+)
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key as it appears in attested credential data.
+func parsePublicKey(coseKey []byte) (publicKey, error) {
+	value, _, err := decodeCBOR(coseKey)
+	if err != nil {
+		return publicKey{}, werr.Wrap(err)
+	}
+	fields, ok := value.(map[any]any)
+	if !ok {
+		return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "COSE key is not a map")
+	}
+	kty, _ := fields[int64(coseKeyType)].(int64)
+	alg, _ := fields[int64(coseAlgorithm)].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		return parseEC2Key(fields, alg)
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		crv, _ := fields[int64(coseCurve)].(int64)
+		x, _ := fields[int64(coseX)].([]byte)
+		if crv != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "invalid Ed25519 key")
+		}
+
+		return publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := fields[int64(coseModulus)].([]byte)
+		e, _ := fields[int64(coseExponent)].([]byte)
+		if len(e) == 0 || len(e) > 4 {
+			return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "invalid RSA exponent")
+		}
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.N.BitLen() < minRSABits {
+			return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnUnsupported, "RSA key shorter than %d bits", minRSABits)
+		}
+
+		return publicKey{alg: alg, key: key}, nil
+	default:
+		return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnUnsupported, "key type %d, algorithm %d", kty, alg)
+	}
+}
+
+func parseEC2Key(fields map[any]any, alg int64) (publicKey, error) {
+	crv, _ := fields[int64(coseCurve)].(int64)
+	x, _ := fields[int64(coseX)].([]byte)
+	y, _ := fields[int64(coseY)].([]byte)
+	if crv != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+		return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "invalid P-256 key")
+	}
+	// crypto/ecdh rejects points that are not on the curve.
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return publicKey{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "invalid P-256 point")
+	}
+
+	return publicKey{alg: alg, key: &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}}, nil
+}
+
+func (k publicKey) verify(data []byte, signature []byte) error {
+	return werr.Wrap(verifySignature(k.alg, k.key, data, signature))
+}
+
+func verifySignature(alg int64, key crypto.PublicKey, data []byte, signature []byte) error {
+	digest := sha256.Sum256(data)
+
+	var ok bool
+	switch alg {
+	case AlgES256:
+		ecKey, isEC := key.(*ecdsa.PublicKey)
+		ok = isEC && ecdsa.VerifyASN1(ecKey, digest[:], signature)
+	case AlgEdDSA:
+		edKey, isEd := key.(ed25519.PublicKey)
+		ok = isEd && ed25519.Verify(edKey, data, signature)
+	case AlgRS256:
+		rsaKey, isRSA := key.(*rsa.PublicKey)
+		ok = isRSA && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return werr.Wrapf(errorz.ErrWebAuthnUnsupported, "algorithm %d", alg)
+	}
+	if !ok {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "bad signature")
+	}
+
+	return nil
+}
+
+// certificateKey returns the key of an attestation certificate.
+func certificateKey(der []byte) (crypto.PublicKey, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, werr.Wrap(errorz.ErrWebAuthnMalformed)
+	}
+
+	return cert.PublicKey, nil
+}
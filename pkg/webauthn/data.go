@@ -0,0 +1,137 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+
+	authDataMinLength = 37
+	aaguidLength      = 16
+)
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (a authenticatorData) has(flag byte) bool {
+	return a.flags&flag != 0
+}
+
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	if len(data) < authDataMinLength {
+		return authenticatorData{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "authenticator data too short")
+	}
+	authData := authenticatorData{
+		rpIDHash:     data[:32],
+		flags:        data[32],
+		signCount:    binary.BigEndian.Uint32(data[33:37]),
+		aaguid:       nil,
+		credentialID: nil,
+		publicKey:    nil,
+	}
+	rest := data[authDataMinLength:]
+
+	if authData.has(flagAttestedData) {
+		if len(rest) < aaguidLength+2 {
+			return authenticatorData{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "attested credential data too short")
+		}
+		authData.aaguid = rest[:aaguidLength]
+		idLength := int(binary.BigEndian.Uint16(rest[aaguidLength:]))
+		rest = rest[aaguidLength+2:]
+		if len(rest) < idLength {
+			return authenticatorData{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "credential id too short")
+		}
+		authData.credentialID, rest = rest[:idLength], rest[idLength:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return authenticatorData{}, werr.Wrap(err)
+		}
+		authData.publicKey, rest = rest[:n], rest[n:]
+	}
+	if authData.has(flagExtensionData) {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return authenticatorData{}, werr.Wrap(err)
+		}
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return authenticatorData{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "trailing authenticator data")
+	}
+
+	return authData, nil
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+func parseClientData(data []byte) (clientData, error) {
+	var parsed clientData
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return clientData{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "client data: %v", err)
+	}
+
+	return parsed, nil
+}
+
+// ChallengeFromClientData extracts the challenge a response was signed for,
+// so the server can look up the ceremony it belongs to.
+func ChallengeFromClientData(clientDataJSON []byte) (string, error) {
+	parsed, err := parseClientData(clientDataJSON)
+	if err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return parsed.Challenge, nil
+}
+
+type attestationObject struct {
+	format    string
+	statement map[any]any
+	authData  []byte
+}
+
+func parseAttestationObject(data []byte) (attestationObject, error) {
+	value, n, err := decodeCBOR(data)
+	if err != nil {
+		return attestationObject{}, werr.Wrap(err)
+	}
+	fields, ok := value.(map[any]any)
+	if !ok || n != len(data) {
+		return attestationObject{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "attestation object is not a map")
+	}
+
+	format, _ := fields["fmt"].(string)
+	statement, okStatement := fields["attStmt"].(map[any]any)
+	authData, okAuthData := fields["authData"].([]byte)
+	if format == "" || !okStatement || !okAuthData {
+		return attestationObject{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "incomplete attestation object")
+	}
+
+	return attestationObject{
+		format:    format,
+		statement: statement,
+		authData:  authData,
+	}, nil
+}
@@ -0,0 +1,231 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"slices"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const publicKeyType = "public-key"
+
+func (rp relyingPartyImpl) NewChallenge() (string, error) {
+	challenge := make([]byte, challengeLength)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(challenge), nil
+}
+
+func (rp relyingPartyImpl) CreationOptions(challenge string, user User, exclude [][]byte) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP: RelyingPartyEntity{
+			ID:   rp.rpID,
+			Name: rp.rpName,
+		},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: publicKeyType, Alg: AlgES256},
+			{Type: publicKeyType, Alg: AlgEdDSA},
+			{Type: publicKeyType, Alg: AlgRS256},
+		},
+		Timeout:            rp.timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(exclude),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: UserVerificationPreferred,
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp relyingPartyImpl) RequestOptions(challenge string, allow [][]byte, verification UserVerification) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.timeout.Milliseconds(),
+		RPID:             rp.rpID,
+		AllowCredentials: descriptors(allow),
+		UserVerification: verification,
+	}
+}
+
+// VerifyRegistration implements the registration steps of WebAuthn Level 2 §7.1.
+// Attestation formats "none" and "packed" are accepted; attestation
+// certificates are checked against the signature but not against a trust store.
+func (rp relyingPartyImpl) VerifyRegistration(challenge string, response RegistrationResponse) (Credential, error) {
+	if err := rp.verifyClientData(response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+
+	attestation, err := parseAttestationObject(response.AttestationObject)
+	if err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+	authData, err := parseAuthenticatorData(attestation.authData)
+	if err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+	if err = rp.verifyAuthenticatorData(authData, false); err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+	if !authData.has(flagAttestedData) {
+		return Credential{}, werr.Wrapf(errorz.ErrWebAuthnMalformed, "no attested credential data")
+	}
+	key, err := parsePublicKey(authData.publicKey)
+	if err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+
+	clientDataHash := sha256.Sum256(response.ClientDataJSON)
+	if err = verifyAttestation(attestation, key, clientDataHash[:]); err != nil {
+		return Credential{}, werr.Wrap(err)
+	}
+
+	return Credential{
+		ID:             bytes.Clone(authData.credentialID),
+		PublicKey:      bytes.Clone(authData.publicKey),
+		Algorithm:      key.alg,
+		SignCount:      authData.signCount,
+		AAGUID:         bytes.Clone(authData.aaguid),
+		UserVerified:   authData.has(flagUserVerified),
+		BackupEligible: authData.has(flagBackupEligible),
+		BackupState:    authData.has(flagBackupState),
+	}, nil
+}
+
+// VerifyAssertion implements the authentication steps of WebAuthn Level 2 §7.2
+// and returns the new signature counter to store.
+func (rp relyingPartyImpl) VerifyAssertion(
+	challenge string,
+	credential Credential,
+	response AssertionResponse,
+	requireUV bool,
+) (uint32, error) {
+	if !bytes.Equal(response.CredentialID, credential.ID) {
+		return 0, werr.Wrapf(errorz.ErrWebAuthnVerification, "credential mismatch")
+	}
+	if err := rp.verifyClientData(response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	authData, err := parseAuthenticatorData(response.AuthenticatorData)
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+	if err = rp.verifyAuthenticatorData(authData, requireUV); err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, werr.Wrap(err)
+	}
+	clientDataHash := sha256.Sum256(response.ClientDataJSON)
+	signed := append(bytes.Clone(response.AuthenticatorData), clientDataHash[:]...)
+	if err = key.verify(signed, response.Signature); err != nil {
+		return 0, werr.Wrap(err)
+	}
+
+	// Authenticators without a counter always report zero.
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, werr.Wrap(errorz.ErrWebAuthnSignCount)
+	}
+
+	return authData.signCount, nil
+}
+
+func (rp relyingPartyImpl) verifyClientData(raw []byte, ceremony string, challenge string) error {
+	data, err := parseClientData(raw)
+	if err != nil {
+		return werr.Wrap(err)
+	}
+	if data.Type != ceremony {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "unexpected type %q", data.Type)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "challenge mismatch")
+	}
+	if !slices.Contains(rp.origins, data.Origin) {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "unexpected origin %q", data.Origin)
+	}
+	if data.CrossOrigin {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "cross-origin request")
+	}
+
+	return nil
+}
+
+func (rp relyingPartyImpl) verifyAuthenticatorData(authData authenticatorData, requireUV bool) error {
+	rpIDHash := sha256.Sum256([]byte(rp.rpID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "RP ID mismatch")
+	}
+	if !authData.has(flagUserPresent) {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "user not present")
+	}
+	if requireUV && !authData.has(flagUserVerified) {
+		return werr.Wrapf(errorz.ErrWebAuthnVerification, "user not verified")
+	}
+
+	return nil
+}
+
+func verifyAttestation(attestation attestationObject, key publicKey, clientDataHash []byte) error {
+	switch attestation.format {
+	case "none":
+		if len(attestation.statement) != 0 {
+			return werr.Wrapf(errorz.ErrWebAuthnMalformed, "none attestation with a statement")
+		}
+
+		return nil
+	case "packed":
+		alg, _ := attestation.statement["alg"].(int64)
+		sig, _ := attestation.statement["sig"].([]byte)
+		signed := append(bytes.Clone(attestation.authData), clientDataHash...)
+
+		x5c, hasX5C := attestation.statement["x5c"].([]any)
+		if !hasX5C {
+			// Self attestation is signed by the credential key itself.
+			if alg != key.alg {
+				return werr.Wrapf(errorz.ErrWebAuthnVerification, "attestation algorithm mismatch")
+			}
+
+			return werr.Wrap(key.verify(signed, sig))
+		}
+		if len(x5c) == 0 {
+			return werr.Wrapf(errorz.ErrWebAuthnMalformed, "empty x5c")
+		}
+		leaf, _ := x5c[0].([]byte)
+		certKey, err := certificateKey(leaf)
+		if err != nil {
+			return werr.Wrap(err)
+		}
+
+		return werr.Wrap(verifySignature(alg, certKey, signed, sig))
+	default:
+		return werr.Wrapf(errorz.ErrWebAuthnUnsupported, "attestation format %q", attestation.format)
+	}
+}
+
+func descriptors(ids [][]byte) []CredentialDescriptor {
+	result := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, CredentialDescriptor{
+			Type: publicKeyType,
+			ID:   base64.RawURLEncoding.EncodeToString(id),
+		})
+	}
+
+	return result
+}
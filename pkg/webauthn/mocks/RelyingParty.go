@@ -0,0 +1,147 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	webauthn "github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RelyingParty is an autogenerated mock type for the RelyingParty type
+type RelyingParty struct {
+	mock.Mock
+}
+
+// CreationOptions provides a mock function with given fields: challenge, user, exclude
+func (_m *RelyingParty) CreationOptions(challenge string, user webauthn.User, exclude [][]byte) webauthn.CreationOptions {
+	ret := _m.Called(challenge, user, exclude)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreationOptions")
+	}
+
+	var r0 webauthn.CreationOptions
+	if rf, ok := ret.Get(0).(func(string, webauthn.User, [][]byte) webauthn.CreationOptions); ok {
+		r0 = rf(challenge, user, exclude)
+	} else {
+		r0 = ret.Get(0).(webauthn.CreationOptions)
+	}
+
+	return r0
+}
+
+// NewChallenge provides a mock function with given fields:
+func (_m *RelyingParty) NewChallenge() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for NewChallenge")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestOptions provides a mock function with given fields: challenge, allow, verification
+func (_m *RelyingParty) RequestOptions(challenge string, allow [][]byte, verification webauthn.UserVerification) webauthn.RequestOptions {
+	ret := _m.Called(challenge, allow, verification)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequestOptions")
+	}
+
+	var r0 webauthn.RequestOptions
+	if rf, ok := ret.Get(0).(func(string, [][]byte, webauthn.UserVerification) webauthn.RequestOptions); ok {
+		r0 = rf(challenge, allow, verification)
+	} else {
+		r0 = ret.Get(0).(webauthn.RequestOptions)
+	}
+
+	return r0
+}
+
+// VerifyAssertion provides a mock function with given fields: challenge, credential, response, requireUV
+func (_m *RelyingParty) VerifyAssertion(challenge string, credential webauthn.Credential, response webauthn.AssertionResponse, requireUV bool) (uint32, error) {
+	ret := _m.Called(challenge, credential, response, requireUV)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyAssertion")
+	}
+
+	var r0 uint32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, webauthn.Credential, webauthn.AssertionResponse, bool) (uint32, error)); ok {
+		return rf(challenge, credential, response, requireUV)
+	}
+	if rf, ok := ret.Get(0).(func(string, webauthn.Credential, webauthn.AssertionResponse, bool) uint32); ok {
+		r0 = rf(challenge, credential, response, requireUV)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, webauthn.Credential, webauthn.AssertionResponse, bool) error); ok {
+		r1 = rf(challenge, credential, response, requireUV)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyRegistration provides a mock function with given fields: challenge, response
+func (_m *RelyingParty) VerifyRegistration(challenge string, response webauthn.RegistrationResponse) (webauthn.Credential, error) {
+	ret := _m.Called(challenge, response)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyRegistration")
+	}
+
+	var r0 webauthn.Credential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, webauthn.RegistrationResponse) (webauthn.Credential, error)); ok {
+		return rf(challenge, response)
+	}
+	if rf, ok := ret.Get(0).(func(string, webauthn.RegistrationResponse) webauthn.Credential); ok {
+		r0 = rf(challenge, response)
+	} else {
+		r0 = ret.Get(0).(webauthn.Credential)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, webauthn.RegistrationResponse) error); ok {
+		r1 = rf(challenge, response)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRelyingParty creates a new instance of RelyingParty. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRelyingParty(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RelyingParty {
+	mock := &RelyingParty{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
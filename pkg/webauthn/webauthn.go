@@ -0,0 +1,143 @@
+package webauthn
+
+import (
+	"time"
+)
+
+const (
+	defaultTimeout  = 5 * time.Minute
+	challengeLength = 32
+)
+
+type UserVerification string
+
+const (
+	UserVerificationRequired    UserVerification = "required"
+	UserVerificationPreferred   UserVerification = "preferred"
+	UserVerificationDiscouraged UserVerification = "discouraged"
+)
+
+type Config struct {
+	// RPID is the relying party ID, usually the site's registrable domain.
+	RPID   string
+	RPName string
+	// Origins lists the exact origins responses may come from, e.g. "https://example.com".
+	Origins []string
+	// Timeout is sent to the browser and defaults to five minutes.
+	Timeout time.Duration
+}
+
+// RelyingParty runs the server side of WebAuthn Level 2 ceremonies. It is
+// stateless: callers keep the challenge between the options and the response.
+type RelyingParty interface {
+	NewChallenge() (string, error)
+	CreationOptions(challenge string, user User, exclude [][]byte) CreationOptions
+	VerifyRegistration(challenge string, response RegistrationResponse) (Credential, error)
+	RequestOptions(challenge string, allow [][]byte, verification UserVerification) RequestOptions
+	VerifyAssertion(challenge string, credential Credential, response AssertionResponse, requireUV bool) (uint32, error)
+}
+
+type relyingPartyImpl struct {
+	rpID    string
+	rpName  string
+	origins []string
+	timeout time.Duration
+}
+
+func NewRelyingParty(cfg Config) RelyingParty {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return relyingPartyImpl{
+		rpID:    cfg.RPID,
+		rpName:  cfg.RPName,
+		origins: cfg.Origins,
+		timeout: timeout,
+	}
+}
+
+type User struct {
+	// ID is the user handle; it must not contain personal data.
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential is what the server stores for a registered authenticator.
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key exactly as the authenticator sent it.
+	PublicKey      []byte
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte
+	UserVerified   bool
+	BackupEligible bool
+	BackupState    bool
+}
+
+// RegistrationResponse holds the decoded fields of an AuthenticatorAttestationResponse.
+type RegistrationResponse struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// AssertionResponse holds the decoded fields of an AuthenticatorAssertionResponse
+// and the id of the credential that produced it.
+type AssertionResponse struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// CreationOptions serializes to PublicKeyCredentialCreationOptionsJSON, with
+// binary values base64url-encoded.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions serializes to PublicKeyCredentialRequestOptionsJSON.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification UserVerification       `json:"userVerification"`
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string           `json:"residentKey"`
+	UserVerification UserVerification `json:"userVerification"`
+}
@@ -0,0 +1,297 @@
+package webauthn_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rpID   = "example.com"
+	origin = "https://example.com"
+)
+
+// cborMap is encoded with its keys in canonical order.
+type cborMap map[any]any
+
+func encodeCBOR(value any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+
+		return out
+	case cborMap:
+		keys := make([][]byte, 0, len(v))
+		values := map[string][]byte{}
+		for key, item := range v {
+			encoded := encodeCBOR(key)
+			keys = append(keys, encoded)
+			values[string(encoded)] = encodeCBOR(item)
+		}
+		sort.Slice(keys, func(i, j int) bool { return string(keys[i]) < string(keys[j]) })
+		out := head(5, uint64(len(v)))
+		for _, key := range keys {
+			out = append(append(out, key...), values[string(key)]...)
+		}
+
+		return out
+	default:
+		panic("unsupported type")
+	}
+}
+
+// authenticator is a software authenticator producing real attestation
+// objects and assertions.
+type authenticator struct {
+	alg          int
+	signer       crypto.Signer
+	coseKey      []byte
+	credentialID []byte
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T, alg int) *authenticator {
+	t.Helper()
+
+	a := &authenticator{alg: alg, credentialID: []byte("credential-" + big.NewInt(int64(-alg)).String())}
+	switch alg {
+	case -7:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		a.signer = key
+		a.coseKey = encodeCBOR(cborMap{1: 2, 3: -7, -1: 1, -2: key.X.FillBytes(make([]byte, 32)), -3: key.Y.FillBytes(make([]byte, 32))})
+	case -8:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		a.signer = key
+		a.coseKey = encodeCBOR(cborMap{1: 1, 3: -8, -1: 6, -2: []byte(pub)})
+	case -257:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		a.signer = key
+		a.coseKey = encodeCBOR(cborMap{1: 3, 3: -257, -1: key.N.Bytes(), -2: big.NewInt(int64(key.E)).Bytes()})
+	}
+
+	return a
+}
+
+func (a *authenticator) sign(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var (
+		sig []byte
+		err error
+	)
+	if a.alg == -8 {
+		sig, err = a.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(data)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	require.NoError(t, err)
+
+	return sig
+}
+
+func (a *authenticator) authData(flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(append(data, a.credentialID...), a.coseKey...)
+	}
+
+	return data
+}
+
+func clientDataJSON(t *testing.T, ceremony, challenge, from string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"type": ceremony, "challenge": challenge, "origin": from})
+	require.NoError(t, err)
+
+	return data
+}
+
+func (a *authenticator) register(t *testing.T, challenge string, format string) webauthn.RegistrationResponse {
+	t.Helper()
+
+	clientData := clientDataJSON(t, "webauthn.create", challenge, origin)
+	authData := a.authData(0x45, true)
+	statement := cborMap{}
+	if format == "packed" {
+		hash := sha256.Sum256(clientData)
+		statement = cborMap{"alg": a.alg, "sig": a.sign(t, append(append([]byte{}, authData...), hash[:]...))}
+	}
+
+	return webauthn.RegistrationResponse{
+		ClientDataJSON:    clientData,
+		AttestationObject: encodeCBOR(cborMap{"fmt": format, "attStmt": statement, "authData": authData}),
+	}
+}
+
+func (a *authenticator) assert(t *testing.T, challenge string, flags byte) webauthn.AssertionResponse {
+	t.Helper()
+
+	a.signCount++
+	clientData := clientDataJSON(t, "webauthn.get", challenge, origin)
+	authData := a.authData(flags, false)
+	hash := sha256.Sum256(clientData)
+
+	return webauthn.AssertionResponse{
+		CredentialID:      a.credentialID,
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         a.sign(t, append(append([]byte{}, authData...), hash[:]...)),
+		UserHandle:        []byte("user"),
+	}
+}
+
+func newRelyingParty() webauthn.RelyingParty {
+	return webauthn.NewRelyingParty(webauthn.Config{
+		RPID:    rpID,
+		RPName:  "Example",
+		Origins: []string{origin},
+	})
+}
+
+func TestRelyingParty_Ceremonies(t *testing.T) {
+	t.Parallel()
+
+	for name, alg := range map[string]int{"ES256": -7, "EdDSA": -8, "RS256": -257} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rp := newRelyingParty()
+			a := newAuthenticator(t, alg)
+
+			challenge, err := rp.NewChallenge()
+			require.NoError(t, err)
+			credential, err := rp.VerifyRegistration(challenge, a.register(t, challenge, "packed"))
+			require.NoError(t, err)
+			assert.Equal(t, a.credentialID, credential.ID)
+			assert.Equal(t, int64(alg), credential.Algorithm)
+			assert.True(t, credential.UserVerified)
+
+			challenge, err = rp.NewChallenge()
+			require.NoError(t, err)
+			signCount, err := rp.VerifyAssertion(challenge, credential, a.assert(t, challenge, 0x05), true)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), signCount)
+		})
+	}
+}
+
+func TestRelyingParty_Rejections(t *testing.T) {
+	t.Parallel()
+
+	rp := newRelyingParty()
+	a := newAuthenticator(t, -7)
+	credential, err := rp.VerifyRegistration("c1", a.register(t, "c1", "none"))
+	require.NoError(t, err)
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := rp.VerifyRegistration("other", a.register(t, "c1", "none"))
+		require.ErrorIs(t, err, errorz.ErrWebAuthnVerification)
+	})
+
+	t.Run("wrong origin", func(t *testing.T) {
+		t.Parallel()
+
+		response := a.register(t, "c1", "none")
+		response.ClientDataJSON = clientDataJSON(t, "webauthn.create", "c1", "https://evil.example")
+		_, err := rp.VerifyRegistration("c1", response)
+		require.ErrorIs(t, err, errorz.ErrWebAuthnVerification)
+	})
+
+	t.Run("unsupported attestation", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := rp.VerifyRegistration("c1", a.register(t, "c1", "fido-u2f"))
+		require.ErrorIs(t, err, errorz.ErrWebAuthnUnsupported)
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		t.Parallel()
+
+		other := newAuthenticator(t, -7)
+		response := other.assert(t, "c2", 0x05)
+		response.CredentialID = credential.ID
+		_, err := rp.VerifyAssertion("c2", credential, response, false)
+		require.ErrorIs(t, err, errorz.ErrWebAuthnVerification)
+	})
+
+	t.Run("user verification required", func(t *testing.T) {
+		t.Parallel()
+
+		b := newAuthenticator(t, -7)
+		cred, err := rp.VerifyRegistration("c1", b.register(t, "c1", "none"))
+		require.NoError(t, err)
+		_, err = rp.VerifyAssertion("c3", cred, b.assert(t, "c3", 0x01), true)
+		require.ErrorIs(t, err, errorz.ErrWebAuthnVerification)
+	})
+
+	t.Run("sign counter must increase", func(t *testing.T) {
+		t.Parallel()
+
+		b := newAuthenticator(t, -7)
+		cred, err := rp.VerifyRegistration("c1", b.register(t, "c1", "none"))
+		require.NoError(t, err)
+		cred.SignCount = 5
+		_, err = rp.VerifyAssertion("c4", cred, b.assert(t, "c4", 0x05), false)
+		require.ErrorIs(t, err, errorz.ErrWebAuthnSignCount)
+	})
+
+	t.Run("truncated attestation object", func(t *testing.T) {
+		t.Parallel()
+
+		response := a.register(t, "c1", "none")
+		response.AttestationObject = response.AttestationObject[:len(response.AttestationObject)-3]
+		_, err := rp.VerifyRegistration("c1", response)
+		require.ErrorIs(t, err, errorz.ErrWebAuthnMalformed)
+	})
+}
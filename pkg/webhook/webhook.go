@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/matchsystems/werr"
+)
+
+const (
+	HeaderEvent     = "Vauth-Event"
+	HeaderDelivery  = "Vauth-Delivery"
+	HeaderTimestamp = "Vauth-Timestamp"
+	HeaderSignature = "Vauth-Signature"
+
+	// DefaultTolerance is how old a delivery may be before Verify rejects it as a replay.
+	DefaultTolerance = 5 * time.Minute
+
+	signatureVersion = "v1="
+	secretSize       = 32
+)
+
+// NewSecret returns a random hex-encoded signing secret.
+func NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", werr.Wrap(err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign returns the signature header value for body sent at timestamp: the
+// hex HMAC-SHA256 of "<unix timestamp>.<body>" keyed with secret.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeaders sets the timestamp and signature headers of a delivery.
+func SetHeaders(header http.Header, secret []byte, timestamp time.Time, body []byte) {
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	header.Set(HeaderSignature, Sign(secret, timestamp, body))
+}
+
+// Verify checks the signature headers against body. Deliveries whose
+// timestamp is further than tolerance from now are rejected.
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	unix, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return werr.Wrap(errorz.ErrInvalidWebhookSignature)
+	}
+	timestamp := time.Unix(unix, 0)
+	if now.Sub(timestamp).Abs() > tolerance {
+		return werr.Wrap(errorz.ErrWebhookTimestampExpired)
+	}
+
+	expected := []byte(Sign(secret, timestamp, body))
+	for _, signature := range strings.Split(header.Get(HeaderSignature), ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), expected) {
+			return nil
+		}
+	}
+
+	return werr.Wrap(errorz.ErrInvalidWebhookSignature)
+}
+
+// VerifyRequest reads and verifies the body of a delivery with DefaultTolerance.
+func VerifyRequest(r *http.Request, secret []byte) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, werr.Wrap(err)
+	}
+	if err = Verify(secret, r.Header, body, DefaultTolerance, time.Now()); err != nil {
+		return nil, werr.Wrap(err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,64 @@
+package webhook_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/github.com/VadimOcLock/vauth/pkg/errorz"
+	"github.com/github.com/VadimOcLock/vauth/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	body := []byte(`{"type":"user.logged_in"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := http.Header{}
+	webhook.SetHeaders(header, secret, sentAt, body)
+
+	t.Run("valid signature", func(t *testing.T) {
+		t.Parallel()
+
+		require.NoError(t, webhook.Verify(secret, header, body, time.Minute, sentAt.Add(30*time.Second)))
+		assert.Equal(t, "1700000000", header.Get(webhook.HeaderTimestamp))
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		t.Parallel()
+
+		err := webhook.Verify(secret, header, []byte(`{"type":"user.registered"}`), time.Minute, sentAt)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidWebhookSignature)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		t.Parallel()
+
+		err := webhook.Verify([]byte("other"), header, body, time.Minute, sentAt)
+
+		require.ErrorIs(t, err, errorz.ErrInvalidWebhookSignature)
+	})
+
+	t.Run("replayed delivery", func(t *testing.T) {
+		t.Parallel()
+
+		err := webhook.Verify(secret, header, body, time.Minute, sentAt.Add(2*time.Minute))
+
+		require.ErrorIs(t, err, errorz.ErrWebhookTimestampExpired)
+	})
+
+	t.Run("rotated secrets", func(t *testing.T) {
+		t.Parallel()
+
+		rotated := http.Header{}
+		rotated.Set(webhook.HeaderTimestamp, header.Get(webhook.HeaderTimestamp))
+		rotated.Set(webhook.HeaderSignature,
+			webhook.Sign([]byte("old"), sentAt, body)+", "+webhook.Sign(secret, sentAt, body))
+
+		require.NoError(t, webhook.Verify(secret, rotated, body, time.Minute, sentAt))
+	})
+}